@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/models"
+	"github.com/iyashjayesh/monigo/timeseries"
+)
+
+// DeleteMetric deletes the series of a metric from storage.
+// POST /monigo/api/v1/admin/delete-metric {"metric": "goroutines", "labels": {"host": "a"}}
+func DeleteMetric(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	var req models.DeleteMetricRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Failed to decode request", err.Error())
+		return
+	}
+	if req.Metric == "" {
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Field 'metric' is required")
+		return
+	}
+
+	// Within an isolated instance, function series are only deleted for its service.
+	if namespace := core.NamespaceFromContext(r.Context()); namespace != "" && timeseries.NamespacedMetric(req.Metric) {
+		if req.Labels == nil {
+			req.Labels = make(map[string]string)
+		}
+		req.Labels[timeseries.ServiceLabelName] = namespace
+	}
+
+	labels := make([]timeseries.Label, 0, len(req.Labels))
+	for name, value := range req.Labels {
+		labels = append(labels, timeseries.Label{Name: name, Value: value})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+	if err := timeseries.DeleteMetric(req.Metric, labels); err != nil {
+		if errors.Is(err, timeseries.ErrDeleteNotSupported) {
+			writeError(w, http.StatusNotImplemented, ErrCodeNotSupported, "Storage backend cannot delete metrics", err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to delete metric", err.Error())
+		return
+	}
+
+	writeJSON(w, r, map[string]string{"deleted": req.Metric})
+}
+
+// SyncControl reports or changes whether the metric sync loop is paused. The
+// loop is shared by every instance, so an isolated instance can only report it.
+// GET  /monigo/api/v1/admin/sync
+// POST /monigo/api/v1/admin/sync {"paused": true}
+func SyncControl(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if namespace := core.NamespaceFromContext(r.Context()); namespace != "" {
+			writeError(w, http.StatusConflict, ErrCodeNotSupported, "The sync loop is shared by all instances and can't be paused by an isolated one", namespace)
+			return
+		}
+		var req models.SyncState
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Failed to decode request", err.Error())
+			return
+		}
+		if req.Paused == nil {
+			writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Field 'paused' is required")
+			return
+		}
+		if *req.Paused {
+			timeseries.PauseSync()
+		} else {
+			timeseries.ResumeSync()
+		}
+	default:
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	paused := timeseries.IsSyncPaused()
+	writeJSON(w, r, models.SyncState{Paused: &paused})
+}
+
+// ResetFunctions clears the metrics of the functions traced in the request's namespace.
+// POST /monigo/api/v1/admin/reset-functions
+func ResetFunctions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	core.ResetFunctionMetricsFor(core.NamespaceFromContext(r.Context()))
+	writeJSON(w, r, map[string]bool{"reset": true})
+}
+
+// Thresholds reports or updates the thresholds used for health scoring in the
+// request's namespace. Fields left out of the update keep their current value.
+// GET  /monigo/api/v1/admin/thresholds
+// POST /monigo/api/v1/admin/thresholds {"max_cpu_usage": 60}
+func Thresholds(w http.ResponseWriter, r *http.Request) {
+	namespace := core.NamespaceFromContext(r.Context())
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req models.ServiceHealthThresholds
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Failed to decode request", err.Error())
+			return
+		}
+		if err := core.UpdateServiceThresholdsFor(namespace, &req); err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Invalid thresholds", err.Error())
+			return
+		}
+	default:
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	writeJSON(w, r, core.ServiceThresholdsFor(namespace))
+}
+
+// SamplingRate reports or changes the function tracing sampling rate, e.g. to
+// profile every call while chasing a rare slow path.
+// GET  /monigo/api/v1/admin/sampling-rate
+// POST /monigo/api/v1/admin/sampling-rate {"rate": 1}
+func SamplingRate(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req models.SamplingRateState
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Failed to decode request", err.Error())
+			return
+		}
+		if req.Rate == nil {
+			writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Field 'rate' is required")
+			return
+		}
+		if *req.Rate < 1 {
+			writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Field 'rate' must be >= 1")
+			return
+		}
+		core.SetSamplingRate(*req.Rate)
+	default:
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	rate := core.SamplingRate()
+	writeJSON(w, r, models.SamplingRateState{Rate: &rate})
+}
+
+// DumpStorage returns every row held by storage with its labels, for debugging.
+// Within a namespace only the rows it reads are returned: its own and the
+// shared ones without a service label.
+// GET /monigo/api/v1/debug/dump
+func DumpStorage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	rows, err := timeseries.DumpStorage()
+	if err != nil {
+		if errors.Is(err, timeseries.ErrDumpNotSupported) {
+			writeError(w, http.StatusNotImplemented, ErrCodeNotSupported, "Storage backend cannot dump rows", err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to dump storage", err.Error())
+		return
+	}
+	if namespace := core.NamespaceFromContext(r.Context()); namespace != "" {
+		rows = slices.DeleteFunc(rows, func(row timeseries.Row) bool {
+			for _, l := range row.Labels {
+				if l.Name == timeseries.ServiceLabelName {
+					return l.Value != namespace
+				}
+			}
+			return false
+		})
+	}
+	if rows == nil {
+		rows = []timeseries.Row{}
+	}
+
+	writeJSON(w, r, rows)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"sort"
 	"sync"
@@ -27,37 +28,218 @@ func init() {
 // GetServiceInfoAPI returns the service information
 func GetServiceInfoAPI(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(common.GetServiceInfo()); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	info := common.GetServiceInfo()
+	if ns := core.NamespaceFromContext(r.Context()); ns != "" {
+		info.ServiceName = ns
+		info.DashboardTitle, info.ServiceDescription = ns, ""
+		if source, ok := configSources.Load(ns); ok {
+			cfg := source.(func() models.MonigoConfig)()
+			info.DashboardTitle, info.ServiceDescription = cfg.DashboardTitle, cfg.ServiceDescription
+		}
 	}
+	writeJSON(w, r, info)
 }
 
-// GetServiceStatistics returns the service metrics detailed information
+// GetServiceStatistics returns the service metrics detailed information.
+// The optional "unit" query parameter (auto, bytes, KB, MB, GB or TB) sets the
+// unit of byte-valued fields.
+// GET /monigo/api/v1/metrics?unit=MB
 func GetServiceStatistics(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(core.GetServiceStats(r.Context())); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	ctx := r.Context()
+	if unitParam := r.URL.Query().Get("unit"); unitParam != "" {
+		unit, err := common.ParseByteUnit(unitParam)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Invalid unit", err.Error())
+			return
+		}
+		ctx = core.WithByteUnit(ctx, unit)
 	}
+	writeJSON(w, r, core.GetServiceStats(ctx))
 }
 
 // GetGoRoutinesStats returns the goroutine statistics
 func GetGoRoutinesStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
+		return
+	}
+	writeJSON(w, r, core.CollectGoRoutinesInfo())
+}
+
+// GetStorageStats returns the size of the metrics store and the range of stored data.
+// GET /monigo/api/v1/storage-stats
+func GetStorageStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	stats, err := timeseries.StorageStats()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to read storage stats", err.Error())
+		return
+	}
+	writeJSON(w, r, stats)
+}
+
+// GetLatest returns the newest stored point of a metric, optionally with
+// equality label matchers as in query_range.
+// GET /monigo/api/v1/latest?metric=cpu_core_usage{core="0"}
+func GetLatest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	metric, matchers, err := parseSelector(r.URL.Query().Get("metric"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Invalid metric", err.Error())
+		return
+	}
+
+	labels := selectorLabels(core.NamespaceFromContext(r.Context()), metric, matchers)
+	latest, ok, err := timeseries.Latest(metric, labels)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to read the latest value", err.Error())
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "No stored value for metric", metric)
+		return
+	}
+	writeJSON(w, r, models.LatestValue{Metric: metric, Timestamp: latest.Timestamp, Value: latest.Value})
+}
+
+// GetMetricsDelta returns the change in cumulative metrics since the given time.
+// GET /monigo/api/v1/metrics-delta?since=2024-01-01T00:00:00Z
+func GetMetricsDelta(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	sinceParam := r.URL.Query().Get("since")
+	if sinceParam == "" {
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Query parameter 'since' is required")
+		return
+	}
+
+	since, err := time.Parse(time.RFC3339, sinceParam)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidTimeRange, "Invalid since time", err.Error())
+		return
+	}
+
+	now := time.Now()
+	if since.After(now) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidTimeRange, "since must not be in the future", sinceParam)
+		return
+	}
+
+	delta, err := timeseries.MetricsDeltaFor(core.NamespaceFromContext(r.Context()), since, now)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to compute metrics delta", err.Error())
+		return
+	}
+	writeJSON(w, r, delta)
+}
+
+const (
+	// defaultHealthHistoryRange is the range of GetHealthHistory without a range.
+	defaultHealthHistoryRange = "last-24h"
+	// maxHealthHistoryPoints bounds the samples GetHealthHistory returns when
+	// no step is given.
+	maxHealthHistoryPoints = 300
+)
+
+// GetHealthHistory returns the service and system health scores over a
+// relative range (default last-24h), downsampled to at most 300 points unless
+// a step is given.
+// GET /monigo/api/v1/health-history?range=last-24h&step=5m
+func GetHealthHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(core.CollectGoRoutinesInfo()); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+
+	relRange := r.URL.Query().Get("range")
+	if relRange == "" {
+		relRange = defaultHealthHistoryRange
+	}
+	startTime, endTime, ok := parseTimeRange(w, r, relRange, "", "")
+	if !ok {
+		return
 	}
+	if serviceStartTime := common.GetServiceStartTime(); startTime.Before(serviceStartTime) {
+		startTime = serviceStartTime
+	}
+
+	step, err := parseQueryStep(r.URL.Query().Get("step"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Invalid step", err.Error())
+		return
+	}
+	if step == 0 {
+		step = max(time.Second, (endTime.Sub(startTime) / maxHealthHistoryPoints).Truncate(time.Second))
+	}
+
+	namespace := core.NamespaceFromContext(r.Context())
+	byTimestamp := make(map[int64]*models.HealthHistoryPoint)
+	for _, fieldName := range []string{"service_health_percent", "system_health_percent"} {
+		datapoints, err := storedPoints(fieldName, timeseries.MetricLabels(namespace, fieldName), startTime.Unix(), endTime.Unix())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to get data points", err.Error())
+			return
+		}
+		for _, dp := range samplePoints(datapoints, startTime.Unix(), endTime.Unix(), int64(step/time.Second)) {
+			point, exists := byTimestamp[dp.Timestamp]
+			if !exists {
+				point = &models.HealthHistoryPoint{Time: time.Unix(dp.Timestamp, 0).UTC()}
+				byTimestamp[dp.Timestamp] = point
+			}
+			value := dp.Value
+			if fieldName == "service_health_percent" {
+				point.ServiceHealth = &value
+			} else {
+				point.SystemHealth = &value
+			}
+		}
+	}
+
+	history := models.HealthHistory{
+		Start:  startTime.UTC(),
+		End:    endTime.UTC(),
+		Step:   step.String(),
+		Points: make([]models.HealthHistoryPoint, 0, len(byTimestamp)),
+	}
+	for _, point := range byTimestamp {
+		history.Points = append(history.Points, *point)
+	}
+	sort.Slice(history.Points, func(i, j int) bool { return history.Points[i].Time.Before(history.Points[j].Time) })
+
+	writeJSON(w, r, history)
+}
+
+// storedPoints returns the points of metric in [start, end] across the series
+// matching labels, in timestamp order. An empty range is not an error.
+func storedPoints(metric string, labels []timeseries.Label, start, end int64) ([]timeseries.DataPoint, error) {
+	series, err := timeseries.GetSeries(metric, labels, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var points []timeseries.DataPoint
+	for _, s := range series {
+		points = append(points, s.Points...)
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Timestamp < points[j].Timestamp })
+	return points, nil
 }
 
 var NameMap = map[string]string{
@@ -80,28 +262,52 @@ var NameMap = map[string]string{
 	"other_sys":       "OtherSys",
 }
 
+// parseTimeRange resolves the requested time range. A relative range, from
+// the body or the "range" query parameter, takes precedence over the absolute
+// RFC3339 start and end times. It writes the error response and returns false
+// when the range is invalid.
+func parseTimeRange(w http.ResponseWriter, r *http.Request, relRange, start, end string) (startTime, endTime time.Time, ok bool) {
+	if relRange == "" {
+		relRange = r.URL.Query().Get("range")
+	}
+	if relRange != "" {
+		startUnix, endUnix, err := common.ParseRelativeRange(relRange)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidTimeRange, "Invalid range", err.Error())
+			return time.Time{}, time.Time{}, false
+		}
+		return time.Unix(startUnix, 0), time.Unix(endUnix, 0), true
+	}
+
+	startTime, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidTimeRange, "Invalid start time", err.Error())
+		return time.Time{}, time.Time{}, false
+	}
+
+	endTime, err = time.Parse(time.RFC3339, end)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidTimeRange, "Invalid end time", err.Error())
+		return time.Time{}, time.Time{}, false
+	}
+	return startTime, endTime, true
+}
+
 // GetServiceMetricsFromStorage returns the service metrics from the storage
 func GetServiceMetricsFromStorage(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 
 	var req models.FetchDataPoints
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Failed to decode request", http.StatusBadRequest)
-		return
-	}
-
-	startTime, err := time.Parse(time.RFC3339, req.StartTime)
-	if err != nil {
-		http.Error(w, "Invalid start time", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Failed to decode request", err.Error())
 		return
 	}
 
-	endTime, err := time.Parse(time.RFC3339, req.EndTime)
-	if err != nil {
-		http.Error(w, "Invalid end time", http.StatusBadRequest)
+	startTime, endTime, ok := parseTimeRange(w, r, req.Range, req.StartTime, req.EndTime)
+	if !ok {
 		return
 	}
 
@@ -111,17 +317,35 @@ func GetServiceMetricsFromStorage(w http.ResponseWriter, r *http.Request) {
 		startTime = serviceStartTime
 	}
 
-	hostLabel := timeseries.GetHostLabel()
-
-	dataByTimestamp := make(map[int64]map[string]float64)
+	namespace := core.NamespaceFromContext(r.Context())
 
+	pointsByField := make(map[string][]timeseries.DataPoint, len(req.FieldName))
+	timestamps := make(map[int64]struct{})
 	for _, fieldName := range req.FieldName {
-		datapoints, err := timeseries.GetDataPoints(fieldName, []timeseries.Label{hostLabel}, startTime.Unix(), endTime.Unix())
+		datapoints, err := timeseries.GetDataPoints(fieldName, timeseries.MetricLabels(namespace, fieldName), startTime.Unix(), endTime.Unix())
 		if err != nil {
-			http.Error(w, "Failed to get data points", http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to get data points", err.Error())
 			return
 		}
+		pointsByField[fieldName] = datapoints
+		for _, dp := range datapoints {
+			timestamps[dp.Timestamp] = struct{}{}
+		}
+	}
 
+	var resp models.ServiceMetricsResponse
+	if limit := MaxResponsePoints(); len(timestamps) > limit {
+		step := downsampleStep(startTime.Unix(), endTime.Unix(), limit)
+		for fieldName, datapoints := range pointsByField {
+			sort.SliceStable(datapoints, func(i, j int) bool { return datapoints[i].Timestamp < datapoints[j].Timestamp })
+			pointsByField[fieldName] = samplePoints(datapoints, startTime.Unix(), endTime.Unix(), step)
+		}
+		resp.Downsampled = true
+		resp.Step = (time.Duration(step) * time.Second).String()
+	}
+
+	dataByTimestamp := make(map[int64]map[string]float64)
+	for fieldName, datapoints := range pointsByField {
 		for _, dp := range datapoints {
 			if _, exists := dataByTimestamp[dp.Timestamp]; !exists {
 				dataByTimestamp[dp.Timestamp] = make(map[string]float64)
@@ -134,46 +358,85 @@ func GetServiceMetricsFromStorage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	var result []map[string]interface{}
+	resp.Points = make([]map[string]interface{}, 0, len(dataByTimestamp))
 	for timestamp, values := range dataByTimestamp {
-		result = append(result, map[string]interface{}{
+		resp.Points = append(resp.Points, map[string]interface{}{
 			"time":  time.Unix(timestamp, 0).UTC().Format(time.RFC3339Nano),
 			"value": values,
 		})
 	}
 
-	sort.Slice(result, func(i, j int) bool {
-		return result[i]["time"].(string) < result[j]["time"].(string)
+	sort.Slice(resp.Points, func(i, j int) bool {
+		return resp.Points[i]["time"].(string) < resp.Points[j]["time"].(string)
 	})
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		http.Error(w, "Failed to encode data points", http.StatusInternalServerError)
+	writeJSON(w, r, resp)
+}
+
+// downsampleStep returns the smallest step, in whole seconds, at which
+// samplePoints yields at most limit samples between start and end.
+func downsampleStep(start, end int64, limit int) int64 {
+	span := end - start
+	if limit <= 1 {
+		return span + 1
 	}
+	return max(1, (span+int64(limit)-2)/int64(limit-1))
+}
+
+// reportTopics are the topics of GetReportData and the metrics each returns.
+var reportTopics = []models.ReportTopic{
+	{Name: "LoadStatistics", Metrics: []string{"overall_load_of_service", "service_cpu_load", "service_memory_load", "system_cpu_load", "system_memory_load"}},
+	{Name: "CPUStatistics", Metrics: []string{"total_cores", "cores_used_by_service", "cores_used_by_system"}},
+	{Name: "MemoryStatistics", Metrics: []string{"total_system_memory", "memory_used_by_system", "memory_used_by_service", "available_memory", "gc_pause_duration", "stack_memory_usage"}},
+	{Name: "MemoryProfile", Metrics: []string{"heap_alloc_by_service", "heap_alloc_by_system", "total_alloc_by_service", "total_memory_by_os"}},
+	{Name: "NetworkIO", Metrics: []string{"bytes_sent", "bytes_received"}},
+	{Name: "OverallHealth", Metrics: []string{"service_health_percent", "system_health_percent"}},
+}
+
+// reportParameters describes the fields of a GetReportData request.
+var reportParameters = []models.ReportParameter{
+	{Name: "topic", Description: "One of the listed topics", Required: true},
+	{Name: "range", Description: "Relative range such as last-1h or last-7d; overrides start_time and end_time. Also accepted as the ?range= query parameter"},
+	{Name: "start_time", Description: "RFC3339 start of the report, required without range"},
+	{Name: "end_time", Description: "RFC3339 end of the report, required without range"},
+}
+
+// reportTopicMetrics returns the metrics of topic and whether it exists.
+func reportTopicMetrics(topic string) ([]string, bool) {
+	for _, t := range reportTopics {
+		if t.Name == topic {
+			return t.Metrics, true
+		}
+	}
+	return nil, false
+}
+
+// GetReportTopics lists the topics GetReportData accepts, the metrics each
+// returns and the request parameters, for building report forms.
+// GET /monigo/api/v1/reports/topics
+func GetReportTopics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	writeJSON(w, r, models.ReportTopics{Topics: reportTopics, Parameters: reportParameters})
 }
 
 // GetReportData returns the report data
 func GetReportData(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 
 	var reqObj models.ReportsRequest
 	if err := json.NewDecoder(r.Body).Decode(&reqObj); err != nil {
-		http.Error(w, "Failed to decode request", http.StatusBadRequest)
-		return
-	}
-
-	startTime, err := time.Parse(time.RFC3339, reqObj.StartTime)
-	if err != nil {
-		http.Error(w, "Invalid start time", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Failed to decode request", err.Error())
 		return
 	}
 
-	endTime, err := time.Parse(time.RFC3339, reqObj.EndTime)
-	if err != nil {
-		http.Error(w, "Invalid end time", http.StatusBadRequest)
+	startTime, endTime, ok := parseTimeRange(w, r, reqObj.Range, reqObj.StartTime, reqObj.EndTime)
+	if !ok {
 		return
 	}
 
@@ -183,32 +446,19 @@ func GetReportData(w http.ResponseWriter, r *http.Request) {
 		startTime = serviceStartTime
 	}
 
-	var fieldNameList []string
-	switch reqObj.Topic {
-	case "LoadStatistics":
-		fieldNameList = []string{"overall_load_of_service", "service_cpu_load", "service_memory_load", "system_cpu_load", "system_memory_load"}
-	case "CPUStatistics":
-		fieldNameList = []string{"total_cores", "cores_used_by_service", "cores_used_by_system"}
-	case "MemoryStatistics":
-		fieldNameList = []string{"total_system_memory", "memory_used_by_system", "memory_used_by_service", "available_memory", "gc_pause_duration", "stack_memory_usage"}
-	case "MemoryProfile":
-		fieldNameList = []string{"heap_alloc_by_service", "heap_alloc_by_system", "total_alloc_by_service", "total_memory_by_os"}
-	case "NetworkIO":
-		fieldNameList = []string{"bytes_sent", "bytes_received"}
-	case "OverallHealth":
-		fieldNameList = []string{"service_health_percent", "system_health_percent"}
-	default:
-		http.Error(w, "Unknown topic", http.StatusBadRequest)
+	fieldNameList, ok := reportTopicMetrics(reqObj.Topic)
+	if !ok {
+		writeError(w, http.StatusBadRequest, ErrCodeUnknownTopic, "Unknown topic", reqObj.Topic)
 		return
 	}
 
-	hostLabel := timeseries.GetHostLabel()
+	namespace := core.NamespaceFromContext(r.Context())
 
 	dataByTimestamp := make(map[int64]map[string]float64)
 	for _, fieldName := range fieldNameList {
-		datapoints, err := timeseries.GetDataPoints(fieldName, []timeseries.Label{hostLabel}, startTime.Unix(), endTime.Unix())
+		datapoints, err := timeseries.GetDataPoints(fieldName, timeseries.MetricLabels(namespace, fieldName), startTime.Unix(), endTime.Unix())
 		if err != nil {
-			http.Error(w, "Failed to get data points", http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to get data points", err.Error())
 			return
 		}
 
@@ -233,29 +483,34 @@ func GetReportData(w http.ResponseWriter, r *http.Request) {
 		return result[i]["time"].(string) < result[j]["time"].(string)
 	})
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		http.Error(w, "Failed to encode report data", http.StatusInternalServerError)
-	}
+	writeJSON(w, r, result)
 }
 
-// GetFunctionTraceDetails returns the function trace details
+// GetFunctionTraceDetails returns the function trace details as a list sorted
+// by name, or by calls, execution_time, memory or panics (largest first)
+// GET /monigo/api/v1/function?sort=calls
 func GetFunctionTraceDetails(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(core.FunctionTraceDetails()); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = core.SortByName
 	}
+	if !core.IsFunctionSortKey(sortBy) {
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Unknown sort key, expected name, calls, execution_time, memory or panics", sortBy)
+		return
+	}
+	writeJSON(w, r, core.FunctionTraceDetailsSortedFor(core.NamespaceFromContext(r.Context()), sortBy))
 }
 
 // ViewFunctionMetrics returns detailed function metrics for a specific function
 // GET /monigo/api/v1/function-details?name=FunctionName&reportType=text
 func ViewFunctionMetrics(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 
@@ -263,7 +518,7 @@ func ViewFunctionMetrics(w http.ResponseWriter, r *http.Request) {
 	reportType := r.URL.Query().Get("reportType")
 
 	if name == "" {
-		http.Error(w, "Function name is required to get metrics", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Function name is required to get metrics")
 		return
 	}
 
@@ -271,14 +526,78 @@ func ViewFunctionMetrics(w http.ResponseWriter, r *http.Request) {
 		reportType = "text"
 	}
 
-	metrics := core.FunctionTraceDetails()[name]
+	if !core.IsAllowedReportType(reportType) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidReportType, "Report type is not allowed", reportType)
+		return
+	}
+
+	metrics := core.FunctionTraceDetailsFor(core.NamespaceFromContext(r.Context()))[name]
 	if metrics == nil {
-		http.Error(w, "Function not found", http.StatusNotFound)
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Function not found", name)
+		return
+	}
+
+	writeJSON(w, r, core.ViewFunctionMetrics(name, reportType, metrics))
+}
+
+// GetFunctionHistory returns the recent executions of a traced function, oldest first
+// GET /monigo/api/v1/function-history?name=FunctionName
+func GetFunctionHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(core.ViewFunctionMetrics(name, reportType, metrics)); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Function name is required to get its history")
+		return
+	}
+
+	history := core.FunctionHistoryFor(core.NamespaceFromContext(r.Context()), name)
+	if history == nil {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Function not found", name)
+		return
 	}
+	writeJSON(w, r, history)
+}
+
+// GetFunctionFlamegraph returns the CPU profile of a traced function rendered as SVG
+// GET /monigo/api/v1/function-flamegraph?name=FunctionName
+func GetFunctionFlamegraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Function name is required to render a flamegraph")
+		return
+	}
+
+	metrics := core.FunctionTraceDetailsFor(core.NamespaceFromContext(r.Context()))[name]
+	if metrics == nil {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Function not found", name)
+		return
+	}
+
+	svg, err := core.FunctionFlamegraph(metrics)
+	switch {
+	case errors.Is(err, core.ErrProfilingUnavailable), errors.Is(err, core.ErrGraphvizUnavailable):
+		writeError(w, http.StatusServiceUnavailable, ErrCodeProfilingUnavailable, "Profiling is unavailable", err.Error())
+		return
+	case errors.Is(err, core.ErrNoProfile):
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "No profile captured for function", name)
+		return
+	case errors.Is(err, core.ErrProfileTooOld):
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Profile is older than the maximum profile age", name)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to render flamegraph", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(svg)
 }
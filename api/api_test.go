@@ -2,16 +2,22 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os/exec"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/iyashjayesh/monigo/common"
 	"github.com/iyashjayesh/monigo/core"
 	"github.com/iyashjayesh/monigo/models"
+	"github.com/iyashjayesh/monigo/timeseries"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func init() {
@@ -21,6 +27,8 @@ func init() {
 		MaxMemoryUsage: 95,
 		MaxGoRoutines:  1000,
 	})
+	// Keep handler tests off the on-disk store under the package directory.
+	timeseries.SetStorageType("memory")
 }
 
 func TestGetServiceInfoAPI(t *testing.T) {
@@ -44,6 +52,23 @@ func TestGetServiceInfoAPI(t *testing.T) {
 	}
 }
 
+func TestGetServiceInfoAPI_Branding(t *testing.T) {
+	common.SetServiceBranding("Test Dashboard", "A service under test")
+	defer common.SetServiceBranding("", "")
+
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/service-info", nil)
+	w := httptest.NewRecorder()
+	GetServiceInfoAPI(w, req)
+
+	var info models.ServiceInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if info.DashboardTitle != "Test Dashboard" || info.ServiceDescription != "A service under test" {
+		t.Errorf("expected the configured title and description, got %q and %q", info.DashboardTitle, info.ServiceDescription)
+	}
+}
+
 func TestGetServiceInfoAPI_WrongMethod(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/service-info", nil)
 	w := httptest.NewRecorder()
@@ -72,6 +97,42 @@ func TestGetServiceStatistics(t *testing.T) {
 	}
 }
 
+func TestGetServiceStatistics_Unit(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/metrics?unit=GB", nil)
+	w := httptest.NewRecorder()
+	GetServiceStatistics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var stats models.ServiceStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for name, value := range map[string]string{
+		"heap_alloc_by_service":  stats.HeapAllocByService,
+		"total_memory_by_os":     stats.TotalMemoryByOS,
+		"memory_used_by_service": stats.MemoryStatistics.MemoryUsedByService,
+		"stack_memory_usage":     stats.MemoryStatistics.StackMemoryUsage,
+		"total_system_memory":    stats.MemoryStatistics.TotalSystemMemory,
+	} {
+		if !strings.HasSuffix(value, " GB") {
+			t.Errorf("expected %s in GB, got %q", name, value)
+		}
+	}
+}
+
+func TestGetServiceStatistics_InvalidUnit(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/metrics?unit=parsecs", nil)
+	w := httptest.NewRecorder()
+	GetServiceStatistics(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
 func TestGetServiceStatistics_WrongMethod(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/metrics", nil)
 	w := httptest.NewRecorder()
@@ -110,6 +171,54 @@ func TestGetFunctionTraceDetails(t *testing.T) {
 	}
 }
 
+func TestGetFunctionTraceDetails_Sorted(t *testing.T) {
+	ctx := core.WithNamespace(context.Background(), "api-sorting")
+	for _, f := range []func(){func() {}, func() {}, func() {}} {
+		core.TraceFunction(ctx, f)
+	}
+
+	var previous []string
+	for range 5 {
+		req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/function?sort=name", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		GetFunctionTraceDetails(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+
+		var details []models.NamedFunctionMetrics
+		if err := json.NewDecoder(w.Body).Decode(&details); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		var names []string
+		for _, d := range details {
+			names = append(names, d.Name)
+		}
+		if len(names) != 3 {
+			t.Fatalf("expected 3 functions, got %v", names)
+		}
+		for i := 1; i < len(names); i++ {
+			if names[i-1] >= names[i] {
+				t.Errorf("expected functions sorted by name, got %v", names)
+			}
+		}
+		if previous != nil && strings.Join(previous, ",") != strings.Join(names, ",") {
+			t.Errorf("expected the same order on every call, got %v then %v", previous, names)
+		}
+		previous = names
+	}
+}
+
+func TestGetFunctionTraceDetails_UnknownSort(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/function?sort=random", nil)
+	w := httptest.NewRecorder()
+	GetFunctionTraceDetails(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
 func TestGetFunctionTraceDetails_WrongMethod(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/function", nil)
 	w := httptest.NewRecorder()
@@ -140,6 +249,194 @@ func TestViewFunctionMetrics_NotFound(t *testing.T) {
 	}
 }
 
+func TestViewFunctionMetrics_DisallowedReportType(t *testing.T) {
+	for _, rt := range []string{"output=/etc/passwd", "-output=/tmp/x", "web", "top -http=:0"} {
+		req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/function-details?name=nonexistent&reportType="+url.QueryEscape(rt), nil)
+		w := httptest.NewRecorder()
+		ViewFunctionMetrics(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("reportType %q: expected 400, got %d", rt, w.Code)
+			continue
+		}
+		if body := decodeErrorResponse(t, w); body.Error.Code != ErrCodeInvalidReportType {
+			t.Errorf("reportType %q: expected code %q, got %q", rt, ErrCodeInvalidReportType, body.Error.Code)
+		}
+	}
+}
+
+func TestViewFunctionMetrics_AllowedReportType(t *testing.T) {
+	core.SetSamplingRate(1)
+	core.TraceFunction(context.Background(), func() {})
+
+	var name string
+	for n := range core.FunctionTraceDetails() {
+		name = n
+		break
+	}
+	if name == "" {
+		t.Fatal("expected a traced function")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/function-details?name="+url.QueryEscape(name)+"&reportType=top", nil)
+	w := httptest.NewRecorder()
+	ViewFunctionMetrics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetMetricsDelta_MissingSince(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/metrics-delta", nil)
+	w := httptest.NewRecorder()
+	GetMetricsDelta(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetMetricsDelta_InvalidSince(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/metrics-delta?since=yesterday", nil)
+	w := httptest.NewRecorder()
+	GetMetricsDelta(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if body := decodeErrorResponse(t, w); body.Error.Code != ErrCodeInvalidTimeRange {
+		t.Errorf("expected code %q, got %q", ErrCodeInvalidTimeRange, body.Error.Code)
+	}
+}
+
+func TestGetStorageStats_WrongMethod(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/storage-stats", nil)
+	w := httptest.NewRecorder()
+	GetStorageStats(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestGetLatest(t *testing.T) {
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []timeseries.Row
+	for i, v := range []float64{1.5, 2.5, 3.5} {
+		for _, core := range []string{"0", "1"} {
+			rows = append(rows, timeseries.Row{
+				Metric:    "latest_core_usage",
+				Labels:    append(timeseries.SeriesLabels(), timeseries.Label{Name: "core", Value: core}),
+				DataPoint: timeseries.DataPoint{Timestamp: time.Now().Unix() - 30 + int64(10*i), Value: v},
+			})
+		}
+	}
+	rows[len(rows)-1].DataPoint.Value = 9
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	latest := func(metric string) (int, models.LatestValue) {
+		req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/latest?"+url.Values{"metric": {metric}}.Encode(), nil)
+		w := httptest.NewRecorder()
+		GetLatest(w, req)
+		var v models.LatestValue
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&v); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return w.Code, v
+	}
+
+	code, v := latest(`latest_core_usage{core="0"}`)
+	if code != http.StatusOK || v.Metric != "latest_core_usage" || v.Value != 3.5 {
+		t.Errorf("expected the newest core 0 value 3.5, got %d: %+v", code, v)
+	}
+	if _, v := latest(`latest_core_usage{core="1"}`); v.Value != 9 {
+		t.Errorf("expected the newest core 1 value 9, got %+v", v)
+	}
+	if code, _ := latest("latest_never_stored"); code != http.StatusNotFound {
+		t.Errorf("expected 404 for a metric without points, got %d", code)
+	}
+	if code, _ := latest(""); code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a metric, got %d", code)
+	}
+}
+
+func TestDeleteMetric_WrongMethod(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/admin/delete-metric", nil)
+	w := httptest.NewRecorder()
+	DeleteMetric(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestDeleteMetric_MissingMetric(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/delete-metric", strings.NewReader(`{"labels": {"host": "a"}}`))
+	w := httptest.NewRecorder()
+	DeleteMetric(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestDeleteMetric_Success(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/delete-metric", strings.NewReader(`{"metric": "goroutines"}`))
+	w := httptest.NewRecorder()
+	DeleteMetric(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDeleteMetric_Namespace(t *testing.T) {
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Unix()
+	fn := timeseries.Label{Name: "function", Value: "delete-namespace-test"}
+	rows := []timeseries.Row{
+		{Metric: timeseries.FunctionExecutionMetric, Labels: append(timeseries.SeriesLabels(), fn), DataPoint: timeseries.DataPoint{Timestamp: now - 1, Value: 1}},
+		{Metric: timeseries.FunctionExecutionMetric, Labels: append(timeseries.NamespaceLabels("orders"), fn), DataPoint: timeseries.DataPoint{Timestamp: now - 1, Value: 2}},
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatalf("InsertRows error: %v", err)
+	}
+
+	body := `{"metric": "function_execution_ms", "labels": {"function": "delete-namespace-test"}}`
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/delete-metric", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	DeleteMetric(w, req.WithContext(core.WithNamespace(req.Context(), "orders")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	count := func(namespace string) int {
+		labels := append(timeseries.MetricLabels(namespace, timeseries.FunctionExecutionMetric), fn)
+		points, err := timeseries.GetDataPoints(timeseries.FunctionExecutionMetric, labels, now-10, now+1)
+		if err != nil {
+			t.Fatalf("GetDataPoints error: %v", err)
+		}
+		return len(points)
+	}
+	if n := count("orders"); n != 0 {
+		t.Errorf("expected orders' series to be deleted, got %d points", n)
+	}
+	if n := count(""); n != 1 {
+		t.Errorf("expected the default namespace's series to be kept, got %d points", n)
+	}
+}
+
 func TestGetServiceMetricsFromStorage_WrongMethod(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/service-metrics", nil)
 	w := httptest.NewRecorder()
@@ -181,6 +478,168 @@ func TestGetReportData_UnknownTopic(t *testing.T) {
 	}
 }
 
+func TestGetReportData_RelativeRange(t *testing.T) {
+	body := `{"topic":"LoadStatistics","range":"last-24h"}`
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/reports", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	GetReportData(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a relative range, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetServiceMetricsFromStorage_RelativeRangeQuery(t *testing.T) {
+	body := `{"field_name":["service_cpu_load"]}`
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/service-metrics?range=last-1h", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	GetServiceMetricsFromStorage(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a relative range, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetServiceMetricsFromStorage_Downsamples(t *testing.T) {
+	common.SetServiceInfo("test-service", time.Now().Add(-time.Hour), runtime.Version(), 1234, "7d")
+	t.Cleanup(func() { common.SetServiceInfo("test-service", time.Now(), runtime.Version(), 1234, "7d") })
+
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := time.Now().Unix()
+	start := end - 600
+	var rows []timeseries.Row
+	for ts := start; ts < end; ts++ {
+		rows = append(rows, timeseries.Row{
+			Metric:    "downsample_test_metric",
+			Labels:    timeseries.SeriesLabels(),
+			DataPoint: timeseries.DataPoint{Timestamp: ts, Value: float64(ts - start)},
+		})
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	fetch := func() models.ServiceMetricsResponse {
+		t.Helper()
+		body, _ := json.Marshal(models.FetchDataPoints{
+			FieldName: []string{"downsample_test_metric"},
+			StartTime: time.Unix(start, 0).Format(time.RFC3339),
+			EndTime:   time.Unix(end, 0).Format(time.RFC3339),
+		})
+		req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/service-metrics", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		GetServiceMetricsFromStorage(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp models.ServiceMetricsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	// Under the default cap every point is returned.
+	if resp := fetch(); resp.Downsampled || len(resp.Points) != 600 {
+		t.Errorf("expected all 600 points, got %d (downsampled=%v)", len(resp.Points), resp.Downsampled)
+	}
+
+	SetMaxResponsePoints(50)
+	defer SetMaxResponsePoints(0)
+	resp := fetch()
+	if !resp.Downsampled {
+		t.Error("expected the response to be flagged as downsampled")
+	}
+	if resp.Step != "13s" {
+		t.Errorf("expected a 13s step, got %q", resp.Step)
+	}
+	if len(resp.Points) == 0 || len(resp.Points) > 50 {
+		t.Errorf("expected at most 50 points, got %d", len(resp.Points))
+	}
+	for i := 1; i < len(resp.Points); i++ {
+		if resp.Points[i-1]["time"].(string) >= resp.Points[i]["time"].(string) {
+			t.Fatalf("expected points in time order, got %v", resp.Points)
+		}
+	}
+}
+
+func TestDownsampleStep(t *testing.T) {
+	for _, tt := range []struct {
+		span  int64
+		limit int
+	}{{600, 50}, {3600, 5000}, {86400, 5000}, {100, 1}, {99, 100}} {
+		step := downsampleStep(0, tt.span, tt.limit)
+		if samples := tt.span/step + 1; samples > int64(tt.limit) {
+			t.Errorf("downsampleStep(0, %d, %d) = %d yields %d samples", tt.span, tt.limit, step, samples)
+		}
+	}
+}
+
+func TestGetReportTopics(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/reports/topics", nil)
+	w := httptest.NewRecorder()
+	GetReportTopics(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var topics models.ReportTopics
+	if err := json.NewDecoder(w.Body).Decode(&topics); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	var names []string
+	for _, topic := range topics.Topics {
+		names = append(names, topic.Name)
+		if len(topic.Metrics) == 0 {
+			t.Errorf("%s: expected its metric names", topic.Name)
+		}
+	}
+	want := []string{"LoadStatistics", "CPUStatistics", "MemoryStatistics", "MemoryProfile", "NetworkIO", "OverallHealth"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("expected topics %v, got %v", want, names)
+	}
+	if len(topics.Parameters) == 0 || topics.Parameters[0].Name != "topic" || !topics.Parameters[0].Required {
+		t.Errorf("expected the required topic parameter first, got %+v", topics.Parameters)
+	}
+
+	// Every listed topic is accepted by the reports endpoint, and nothing else.
+	for _, name := range append(names, "DiskIO") {
+		body := `{"topic":"` + name + `","range":"last-1h"}`
+		req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/reports", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		GetReportData(w, req)
+
+		want := http.StatusOK
+		if name == "DiskIO" {
+			want = http.StatusBadRequest
+		}
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d: %s", name, want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetReportData_InvalidRelativeRange(t *testing.T) {
+	body := `{"topic":"LoadStatistics","range":"yesterday"}`
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/reports", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	GetReportData(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid range, got %d", w.Code)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding error response: %v", err)
+	}
+	if resp.Error.Code != ErrCodeInvalidTimeRange {
+		t.Errorf("expected code %q, got %q", ErrCodeInvalidTimeRange, resp.Error.Code)
+	}
+}
+
 func TestGetReportData_InvalidBody(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/reports", bytes.NewBufferString("not json"))
 	w := httptest.NewRecorder()
@@ -190,3 +649,849 @@ func TestGetReportData_InvalidBody(t *testing.T) {
 		t.Errorf("expected 400, got %d", w.Code)
 	}
 }
+
+func decodeErrorResponse(t *testing.T, w *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %q", ct)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+	return resp
+}
+
+func TestErrorResponse_BadRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/reports", bytes.NewBufferString("not json"))
+	w := httptest.NewRecorder()
+	GetReportData(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	resp := decodeErrorResponse(t, w)
+	if resp.Error.Code != ErrCodeBadRequest {
+		t.Errorf("expected code %q, got %q", ErrCodeBadRequest, resp.Error.Code)
+	}
+	if resp.Error.Message == "" {
+		t.Error("expected non-empty error message")
+	}
+	if resp.Error.Details == "" {
+		t.Error("expected decode error details")
+	}
+}
+
+func TestErrorResponse_UnknownTopic(t *testing.T) {
+	body := `{"topic":"UnknownTopic","start_time":"2026-01-01T00:00:00Z","end_time":"2026-01-02T00:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/reports", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	GetReportData(w, req)
+
+	resp := decodeErrorResponse(t, w)
+	if resp.Error.Code != ErrCodeUnknownTopic {
+		t.Errorf("expected code %q, got %q", ErrCodeUnknownTopic, resp.Error.Code)
+	}
+	if resp.Error.Details != "UnknownTopic" {
+		t.Errorf("expected details 'UnknownTopic', got %q", resp.Error.Details)
+	}
+}
+
+func TestErrorResponse_MethodNotAllowed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/monigo/api/v1/go-routines-stats", nil)
+	w := httptest.NewRecorder()
+	GetGoRoutinesStats(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+	resp := decodeErrorResponse(t, w)
+	if resp.Error.Code != ErrCodeMethodNotAllowed {
+		t.Errorf("expected code %q, got %q", ErrCodeMethodNotAllowed, resp.Error.Code)
+	}
+}
+
+func TestPrettyJSON_QueryParam(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/service-info?pretty=true", nil)
+	w := httptest.NewRecorder()
+	GetServiceInfoAPI(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "\n  \"service_name\"") {
+		t.Errorf("expected indented output, got %q", w.Body.String())
+	}
+}
+
+func TestPrettyJSON_DefaultCompact(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/service-info", nil)
+	w := httptest.NewRecorder()
+	GetServiceInfoAPI(w, req)
+
+	body := strings.TrimSpace(w.Body.String())
+	if strings.Contains(body, "\n") {
+		t.Errorf("expected compact output, got %q", body)
+	}
+}
+
+func TestPrettyJSON_ServerDefault(t *testing.T) {
+	SetPrettyJSON(true)
+	defer SetPrettyJSON(false)
+
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/service-info", nil)
+	w := httptest.NewRecorder()
+	GetServiceInfoAPI(w, req)
+	if !strings.Contains(w.Body.String(), "\n  ") {
+		t.Errorf("expected indented output with server default, got %q", w.Body.String())
+	}
+
+	// An explicit ?pretty=false overrides the server default.
+	req = httptest.NewRequest(http.MethodGet, "/monigo/api/v1/service-info?pretty=false", nil)
+	w = httptest.NewRecorder()
+	GetServiceInfoAPI(w, req)
+	if strings.Contains(strings.TrimSpace(w.Body.String()), "\n") {
+		t.Errorf("expected compact output with pretty=false, got %q", w.Body.String())
+	}
+}
+
+func TestGetFunctionFlamegraph_MissingName(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/function-flamegraph", nil)
+	w := httptest.NewRecorder()
+	GetFunctionFlamegraph(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetFunctionFlamegraph_NotFound(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/function-flamegraph?name=nonexistent", nil)
+	w := httptest.NewRecorder()
+	GetFunctionFlamegraph(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestGetFunctionFlamegraph_SVG(t *testing.T) {
+	if _, err := exec.LookPath("dot"); err != nil {
+		t.Skip("graphviz 'dot' is required to render SVG profiles")
+	}
+
+	core.SetSamplingRate(1)
+	core.TraceFunction(context.Background(), func() {
+		var sum int
+		for i := 0; i < 1e6; i++ {
+			sum += i
+		}
+		_ = sum
+	})
+
+	var name string
+	for n, m := range core.FunctionTraceDetails() {
+		if m.CPUProfileFilePath != "" {
+			name = n
+			break
+		}
+	}
+	if name == "" {
+		t.Fatal("expected a traced function with a CPU profile")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/function-flamegraph?name="+url.QueryEscape(name), nil)
+	w := httptest.NewRecorder()
+	GetFunctionFlamegraph(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("expected image/svg+xml, got %q", ct)
+	}
+}
+
+func TestGetFunctionFlamegraph_GraphvizMissing(t *testing.T) {
+	if _, err := exec.LookPath("dot"); err == nil {
+		t.Skip("graphviz 'dot' is installed")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("'go' command not available")
+	}
+
+	core.SetSamplingRate(1)
+	core.TraceFunction(context.Background(), func() {})
+
+	var name string
+	for n := range core.FunctionTraceDetails() {
+		name = n
+		break
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/function-flamegraph?name="+url.QueryEscape(name), nil)
+	w := httptest.NewRecorder()
+	GetFunctionFlamegraph(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := decodeErrorResponse(t, w); body.Error.Code != ErrCodeProfilingUnavailable {
+		t.Errorf("expected code %q, got %q", ErrCodeProfilingUnavailable, body.Error.Code)
+	}
+}
+
+func TestDumpStorage(t *testing.T) {
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := []timeseries.Label{{Name: "host", Value: "dump-test"}, {Name: "env", Value: "prod"}}
+	if err := sto.InsertRows([]timeseries.Row{{Metric: "dump_test_metric", Labels: labels, DataPoint: timeseries.DataPoint{Timestamp: 1, Value: 3}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/debug/dump", nil)
+	w := httptest.NewRecorder()
+	DumpStorage(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var rows []timeseries.Row
+	if err := json.Unmarshal(w.Body.Bytes(), &rows); err != nil {
+		t.Fatalf("decoding dump: %v", err)
+	}
+	for _, row := range rows {
+		if row.Metric == "dump_test_metric" {
+			if len(row.Labels) != 2 || row.Labels[1] != labels[1] {
+				t.Errorf("expected labels %v, got %v", labels, row.Labels)
+			}
+			return
+		}
+	}
+	t.Errorf("expected dump to contain dump_test_metric, got %+v", rows)
+}
+
+func TestDumpStorage_Namespace(t *testing.T) {
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := timeseries.Label{Name: "function", Value: "dump-namespace-test"}
+	if err := sto.InsertRows([]timeseries.Row{
+		{Metric: timeseries.FunctionExecutionMetric, Labels: append(timeseries.NamespaceLabels("orders"), fn), DataPoint: timeseries.DataPoint{Timestamp: 1, Value: 1}},
+		{Metric: timeseries.FunctionExecutionMetric, Labels: append(timeseries.NamespaceLabels("billing"), fn), DataPoint: timeseries.DataPoint{Timestamp: 1, Value: 2}},
+		{Metric: "dump_namespace_shared", Labels: timeseries.SeriesLabels(), DataPoint: timeseries.DataPoint{Timestamp: 1, Value: 3}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/debug/dump", nil)
+	w := httptest.NewRecorder()
+	DumpStorage(w, req.WithContext(core.WithNamespace(req.Context(), "orders")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var rows []timeseries.Row
+	if err := json.Unmarshal(w.Body.Bytes(), &rows); err != nil {
+		t.Fatalf("decoding dump: %v", err)
+	}
+	var shared bool
+	for _, row := range rows {
+		for _, l := range row.Labels {
+			if l.Name == timeseries.ServiceLabelName && l.Value != "orders" {
+				t.Errorf("expected no rows of other namespaces, got %v", row)
+			}
+		}
+		shared = shared || row.Metric == "dump_namespace_shared"
+	}
+	if !shared {
+		t.Error("expected the shared rows in the dump")
+	}
+}
+
+func TestDumpStorage_WrongMethod(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/debug/dump", nil)
+	w := httptest.NewRecorder()
+	DumpStorage(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestEnablePrometheus_Twice(t *testing.T) {
+	EnablePrometheus()
+	EnablePrometheus()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	PrometheusMetricsHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "monigo_goroutines_count") {
+		t.Error("expected MoniGo metrics on /metrics")
+	}
+}
+
+func TestPrometheusHandlerFor(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	appRequests := prometheus.NewCounter(prometheus.CounterOpts{Name: "app_requests_total", Help: "Requests served."})
+	reg.MustRegister(appRequests)
+	appRequests.Inc()
+
+	handler := PrometheusHandlerFor(reg)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	for _, want := range []string{"app_requests_total 1", "monigo_goroutines_count"} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("expected scrape to contain %q", want)
+		}
+	}
+}
+
+func TestSyncControl(t *testing.T) {
+	defer timeseries.ResumeSync()
+
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/sync", strings.NewReader(`{"paused": true}`))
+	w := httptest.NewRecorder()
+	SyncControl(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !timeseries.IsSyncPaused() {
+		t.Error("expected the sync to be paused")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/sync", strings.NewReader(`{"paused": false}`))
+	w = httptest.NewRecorder()
+	SyncControl(w, req)
+	if timeseries.IsSyncPaused() {
+		t.Error("expected the sync to be resumed")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/monigo/api/v1/admin/sync", nil)
+	w = httptest.NewRecorder()
+	SyncControl(w, req)
+	if body := strings.TrimSpace(w.Body.String()); body != `{"paused":false}` {
+		t.Errorf("unexpected state %s", body)
+	}
+}
+
+func TestSyncControl_Namespace(t *testing.T) {
+	defer timeseries.ResumeSync()
+
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/sync", strings.NewReader(`{"paused": true}`))
+	w := httptest.NewRecorder()
+	SyncControl(w, req.WithContext(core.WithNamespace(req.Context(), "orders")))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 from an isolated instance, got %d: %s", w.Code, w.Body.String())
+	}
+	if timeseries.IsSyncPaused() {
+		t.Error("expected the shared sync loop to keep running")
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	orig := core.ServiceThresholds()
+	defer core.ConfigureServiceThresholds(&orig)
+
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/thresholds", strings.NewReader(`{"max_cpu_usage": 42}`))
+	w := httptest.NewRecorder()
+	Thresholds(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var got models.ServiceHealthThresholds
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if got.MaxCPUUsage != 42 || got.MaxMemoryUsage != orig.MaxMemoryUsage || got.MaxGoRoutines != orig.MaxGoRoutines {
+		t.Errorf("unexpected thresholds %+v", got)
+	}
+
+	if core.ServiceThresholds().MaxCPUUsage != 42 {
+		t.Error("expected health scoring to use the new MaxCPUUsage")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/thresholds", strings.NewReader(`{"max_go_routines": -5}`))
+	w = httptest.NewRecorder()
+	Thresholds(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative threshold, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/monigo/api/v1/admin/thresholds", nil)
+	w = httptest.NewRecorder()
+	Thresholds(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestSamplingRate(t *testing.T) {
+	orig := core.SamplingRate()
+	defer core.SetSamplingRate(orig)
+
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/sampling-rate", strings.NewReader(`{"rate": 1}`))
+	w := httptest.NewRecorder()
+	SamplingRate(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := core.SamplingRate(); got != 1 {
+		t.Errorf("expected tracing to use sampling rate 1, got %d", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/sampling-rate", strings.NewReader(`{"rate": 25}`))
+	w = httptest.NewRecorder()
+	SamplingRate(w, req)
+	if body := strings.TrimSpace(w.Body.String()); body != `{"rate":25}` {
+		t.Errorf("unexpected response %s", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/monigo/api/v1/admin/sampling-rate", nil)
+	w = httptest.NewRecorder()
+	SamplingRate(w, req)
+	if body := strings.TrimSpace(w.Body.String()); body != `{"rate":25}` {
+		t.Errorf("unexpected rate %s", body)
+	}
+
+	for _, body := range []string{`{"rate": 0}`, `{"rate": -3}`, `{}`} {
+		req = httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/sampling-rate", strings.NewReader(body))
+		w = httptest.NewRecorder()
+		SamplingRate(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+	if got := core.SamplingRate(); got != 25 {
+		t.Errorf("expected rejected updates to keep rate 25, got %d", got)
+	}
+}
+
+func TestSyncControl_MissingField(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/sync", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	SyncControl(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func historyHandlerForTest() {}
+
+func TestGetFunctionHistory(t *testing.T) {
+	core.TraceFunction(context.Background(), historyHandlerForTest)
+	var name string
+	for n := range core.FunctionTraceDetails() {
+		if strings.HasSuffix(n, "historyHandlerForTest") {
+			name = n
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/function-history?name="+url.QueryEscape(name), nil)
+	w := httptest.NewRecorder()
+	GetFunctionHistory(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var history []models.FunctionSample
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil {
+		t.Fatalf("decoding history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Errorf("expected 1 sample, got %d", len(history))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/monigo/api/v1/function-history?name=unknown", nil)
+	w = httptest.NewRecorder()
+	GetFunctionHistory(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown function, got %d", w.Code)
+	}
+}
+
+func TestResetFunctions(t *testing.T) {
+	core.SetSamplingRate(1)
+	core.TraceFunction(context.Background(), func() {})
+
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/admin/reset-functions", nil)
+	w := httptest.NewRecorder()
+	ResetFunctions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if details := core.FunctionTraceDetails(); len(details) != 0 {
+		t.Errorf("expected no function metrics after reset, got %d", len(details))
+	}
+}
+
+func TestResetFunctions_WrongMethod(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/admin/reset-functions", nil)
+	w := httptest.NewRecorder()
+	ResetFunctions(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+// queryRange calls QueryRange with params and decodes the response.
+func queryRange(t *testing.T, params url.Values) (int, models.QueryRangeResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/query_range?"+params.Encode(), nil)
+	w := httptest.NewRecorder()
+	QueryRange(w, req)
+
+	var resp models.QueryRangeResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return w.Code, resp
+}
+
+func TestQueryRange_LabeledSelector(t *testing.T) {
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []timeseries.Row
+	for _, core := range []string{"0", "1"} {
+		labels := append(timeseries.SeriesLabels(), timeseries.Label{Name: "core", Value: core})
+		for i, ts := range []int64{1000, 1010} {
+			rows = append(rows, timeseries.Row{
+				Metric:    "query_range_core_usage",
+				Labels:    labels,
+				DataPoint: timeseries.DataPoint{Timestamp: ts, Value: float64(10*i) + 0.5},
+			})
+		}
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	code, resp := queryRange(t, url.Values{
+		"query": {`query_range_core_usage{core="1"}`},
+		"start": {"900"},
+		"end":   {"2000"},
+	})
+	if code != http.StatusOK || resp.Status != "success" {
+		t.Fatalf("expected success, got %d: %+v", code, resp)
+	}
+	if resp.Data.ResultType != "matrix" || len(resp.Data.Result) != 1 {
+		t.Fatalf("expected one matrix series, got %+v", resp.Data)
+	}
+	series := resp.Data.Result[0]
+	if series.Metric["__name__"] != "query_range_core_usage" || series.Metric["core"] != "1" {
+		t.Errorf("unexpected series labels %v", series.Metric)
+	}
+	want := [][2]interface{}{{float64(1000), "0.5"}, {float64(1010), "10.5"}}
+	if len(series.Values) != len(want) {
+		t.Fatalf("expected %v, got %v", want, series.Values)
+	}
+	for i := range want {
+		if series.Values[i] != want[i] {
+			t.Errorf("value %d: expected %v, got %v", i, want[i], series.Values[i])
+		}
+	}
+
+	// Without the core matcher both series are returned separately.
+	_, resp = queryRange(t, url.Values{"query": {"query_range_core_usage"}, "start": {"900"}, "end": {"2000"}})
+	if len(resp.Data.Result) != 2 {
+		t.Errorf("expected a series per core, got %d", len(resp.Data.Result))
+	}
+}
+
+func TestQueryRange_NamespaceServiceMatcher(t *testing.T) {
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := timeseries.Label{Name: "function", Value: "query-range-namespace-test"}
+	if err := sto.InsertRows([]timeseries.Row{
+		{Metric: timeseries.FunctionExecutionMetric, Labels: append(timeseries.NamespaceLabels("orders"), fn), DataPoint: timeseries.DataPoint{Timestamp: 1000, Value: 1}},
+		{Metric: timeseries.FunctionExecutionMetric, Labels: append(timeseries.NamespaceLabels("billing"), fn), DataPoint: timeseries.DataPoint{Timestamp: 1000, Value: 2}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	params := url.Values{
+		"query": {`function_execution_ms{function="query-range-namespace-test", service="billing"}`},
+		"start": {"900"},
+		"end":   {"2000"},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/query_range?"+params.Encode(), nil)
+	w := httptest.NewRecorder()
+	QueryRange(w, req.WithContext(core.WithNamespace(req.Context(), "orders")))
+
+	var resp models.QueryRangeResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Data == nil || len(resp.Data.Result) != 1 || resp.Data.Result[0].Metric["service"] != "orders" {
+		t.Fatalf("expected only the namespace's own series, got %+v", resp)
+	}
+}
+
+func TestQueryRange_Step(t *testing.T) {
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []timeseries.Row
+	for i, ts := range []int64{1000, 1010, 1020, 1030} {
+		rows = append(rows, timeseries.Row{
+			Metric:    "query_range_step",
+			Labels:    timeseries.SeriesLabels(),
+			DataPoint: timeseries.DataPoint{Timestamp: ts, Value: float64(i + 1)},
+		})
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	code, resp := queryRange(t, url.Values{
+		"query": {"query_range_step"},
+		"start": {"1000"},
+		"end":   {"1060"},
+		"step":  {"20s"},
+	})
+	if code != http.StatusOK || len(resp.Data.Result) != 1 {
+		t.Fatalf("expected one series, got %d: %+v", code, resp)
+	}
+	// Samples take the latest point of the step ending at them; 1060 has none.
+	want := [][2]interface{}{{float64(1000), "1"}, {float64(1020), "3"}, {float64(1040), "4"}}
+	got := resp.Data.Result[0].Values
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sample %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestQueryRange_BadRequests(t *testing.T) {
+	for name, params := range map[string]url.Values{
+		"missing query":    {},
+		"regex matcher":    {"query": {`goroutines{host=~"a.*"}`}},
+		"unquoted value":   {"query": {`goroutines{host=a}`}},
+		"unclosed":         {"query": {`goroutines{host="a"`}},
+		"invalid step":     {"query": {"goroutines"}, "step": {"soon"}},
+		"end before start": {"query": {"goroutines"}, "start": {"2000"}, "end": {"1000"}},
+		"too many points":  {"query": {"goroutines"}, "start": {"0"}, "end": {"100000"}, "step": {"1"}},
+	} {
+		code, resp := queryRange(t, params)
+		if code != http.StatusBadRequest || resp.Status != "error" || resp.ErrorType != "bad_data" {
+			t.Errorf("%s: expected a bad_data error, got %d: %+v", name, code, resp)
+		}
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	metric, labels, err := parseSelector(`cpu_core_usage{ core="1", host="a \"b\"" }`)
+	if err != nil {
+		t.Fatalf("parseSelector error: %v", err)
+	}
+	if metric != "cpu_core_usage" {
+		t.Errorf("expected metric cpu_core_usage, got %q", metric)
+	}
+	want := []timeseries.Label{{Name: "core", Value: "1"}, {Name: "host", Value: `a "b"`}}
+	if len(labels) != len(want) || labels[0] != want[0] || labels[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, labels)
+	}
+}
+
+func getHealthHistory(t *testing.T, query string) (int, models.HealthHistory) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/health-history"+query, nil)
+	w := httptest.NewRecorder()
+	GetHealthHistory(w, req)
+
+	var history models.HealthHistory
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return w.Code, history
+}
+
+func TestGetHealthHistory(t *testing.T) {
+	// Stored points before the service started are clamped away.
+	common.SetServiceInfo("test-service", time.Now().Add(-time.Hour), runtime.Version(), 1234, "7d")
+	t.Cleanup(func() { common.SetServiceInfo("test-service", time.Now(), runtime.Version(), 1234, "7d") })
+
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Unix()
+	timestamps := []int64{now - 30, now - 20, now - 10}
+	var rows []timeseries.Row
+	for i, ts := range timestamps {
+		rows = append(rows,
+			timeseries.Row{Metric: "service_health_percent", Labels: timeseries.SeriesLabels(), DataPoint: timeseries.DataPoint{Timestamp: ts, Value: float64(90 + i)}},
+			timeseries.Row{Metric: "system_health_percent", Labels: timeseries.SeriesLabels(), DataPoint: timeseries.DataPoint{Timestamp: ts, Value: float64(80 + i)}},
+		)
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	code, history := getHealthHistory(t, "?range=last-1h&step=1s")
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if history.Step != "1s" {
+		t.Errorf("expected step 1s, got %q", history.Step)
+	}
+	if len(history.Points) != len(timestamps) {
+		t.Fatalf("expected %d points, got %+v", len(timestamps), history.Points)
+	}
+	for i, p := range history.Points {
+		if p.Time.Unix() != timestamps[i] {
+			t.Errorf("point %d: expected time %d, got %d", i, timestamps[i], p.Time.Unix())
+		}
+		if p.ServiceHealth == nil || *p.ServiceHealth != float64(90+i) {
+			t.Errorf("point %d: expected service health %d, got %v", i, 90+i, p.ServiceHealth)
+		}
+		if p.SystemHealth == nil || *p.SystemHealth != float64(80+i) {
+			t.Errorf("point %d: expected system health %d, got %v", i, 80+i, p.SystemHealth)
+		}
+	}
+
+	// Without a range or step, the last 24h (clamped to the service start an
+	// hour ago) is downsampled to at most maxHealthHistoryPoints.
+	code, history = getHealthHistory(t, "")
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if step, err := time.ParseDuration(history.Step); err != nil || step < 11*time.Second {
+		t.Errorf("expected a step of about 12s, got %q", history.Step)
+	}
+	if len(history.Points) == 0 {
+		t.Error("expected the stored points to be sampled")
+	}
+	if len(history.Points) > maxHealthHistoryPoints {
+		t.Errorf("expected at most %d points, got %d", maxHealthHistoryPoints, len(history.Points))
+	}
+	for i := 1; i < len(history.Points); i++ {
+		if !history.Points[i-1].Time.Before(history.Points[i].Time) {
+			t.Errorf("points out of order at %d: %v", i, history.Points)
+		}
+	}
+}
+
+func TestGetHealthHistory_BadRequests(t *testing.T) {
+	for _, query := range []string{"?range=yesterday", "?step=soon", "?step=100ms"} {
+		if code, _ := getHealthHistory(t, query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, code)
+		}
+	}
+}
+
+func TestCompareReports(t *testing.T) {
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := func(metric string, ts int64, v float64) timeseries.Row {
+		return timeseries.Row{Metric: metric, Labels: timeseries.SeriesLabels(), DataPoint: timeseries.DataPoint{Timestamp: ts, Value: v}}
+	}
+	if err := sto.InsertRows([]timeseries.Row{
+		// Baseline window.
+		row("bytes_sent", 1000, 10), row("bytes_sent", 1010, 20), row("bytes_sent", 1020, 30),
+		row("bytes_received", 1000, 0), row("bytes_received", 1010, 0),
+		// Comparison window.
+		row("bytes_sent", 2000, 40), row("bytes_sent", 2010, 50),
+		row("bytes_received", 2000, 5),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	window := func(start, end int64) models.ReportWindow {
+		return models.ReportWindow{StartTime: time.Unix(start, 0).Format(time.RFC3339), EndTime: time.Unix(end, 0).Format(time.RFC3339)}
+	}
+	body, _ := json.Marshal(models.ReportCompareRequest{Topic: "NetworkIO", Baseline: window(990, 1100), Comparison: window(1990, 2100)})
+	req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/reports/compare", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	CompareReports(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp models.ReportComparison
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Baseline.Points) != 3 || len(resp.Comparison.Points) != 2 {
+		t.Errorf("expected 3 baseline and 2 comparison timestamps, got %d and %d", len(resp.Baseline.Points), len(resp.Comparison.Points))
+	}
+	if len(resp.Metrics) != 2 || resp.Metrics[0].Name != "bytes_sent" || resp.Metrics[1].Name != "bytes_received" {
+		t.Fatalf("expected bytes_sent and bytes_received in topic order, got %+v", resp.Metrics)
+	}
+
+	sent := resp.Metrics[0]
+	if want := (models.MetricSummary{Count: 3, Avg: 20, Min: 10, Max: 30}); sent.Baseline != want {
+		t.Errorf("expected baseline %+v, got %+v", want, sent.Baseline)
+	}
+	if want := (models.MetricSummary{Count: 2, Avg: 45, Min: 40, Max: 50}); sent.Comparison != want {
+		t.Errorf("expected comparison %+v, got %+v", want, sent.Comparison)
+	}
+	if sent.PercentChange == nil || *sent.PercentChange != 125 {
+		t.Errorf("expected a 125%% change, got %v", sent.PercentChange)
+	}
+
+	// A zero baseline average has no percent change.
+	if received := resp.Metrics[1]; received.PercentChange != nil {
+		t.Errorf("expected no percent change from a zero baseline, got %v", *received.PercentChange)
+	}
+}
+
+func TestCompareReports_BadRequests(t *testing.T) {
+	for name, body := range map[string]string{
+		"unknown topic":      `{"topic":"DiskIO","baseline":{"range":"last-1h"},"comparison":{"range":"last-1h"}}`,
+		"missing baseline":   `{"topic":"NetworkIO","comparison":{"range":"last-1h"}}`,
+		"invalid comparison": `{"topic":"NetworkIO","baseline":{"range":"last-1h"},"comparison":{"range":"yesterday"}}`,
+		"reversed window":    `{"topic":"NetworkIO","baseline":{"start_time":"2024-01-02T00:00:00Z","end_time":"2024-01-01T00:00:00Z"},"comparison":{"range":"last-1h"}}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/monigo/api/v1/reports/compare", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		CompareReports(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/monigo/api/v1/config", nil)
+	w := httptest.NewRecorder()
+	GetConfig(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without a configured instance, got %d", w.Code)
+	}
+
+	SetConfigSource("", func() models.MonigoConfig { return models.MonigoConfig{ServiceName: "orders"} })
+	defer SetConfigSource("", nil)
+	w = httptest.NewRecorder()
+	GetConfig(w, req)
+	var cfg models.MonigoConfig
+	if err := json.NewDecoder(w.Body).Decode(&cfg); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if w.Code != http.StatusOK || cfg.ServiceName != "orders" {
+		t.Errorf("expected the configured instance, got %d %+v", w.Code, cfg)
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/iyashjayesh/monigo/common"
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/models"
+	"github.com/iyashjayesh/monigo/timeseries"
+)
+
+// CompareReports returns the metrics of a report topic over a baseline and a
+// comparison window, with the avg/min/max of each metric per window and the
+// percent change of its average. Windows are not clamped to the service
+// start, so a baseline can cover the run before a deploy.
+// POST /monigo/api/v1/reports/compare
+func CompareReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	var req models.ReportCompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeBadRequest, "Failed to decode request", err.Error())
+		return
+	}
+
+	metrics, ok := reportTopicMetrics(req.Topic)
+	if !ok {
+		writeError(w, http.StatusBadRequest, ErrCodeUnknownTopic, "Unknown topic", req.Topic)
+		return
+	}
+	baseStart, baseEnd, err := resolveReportWindow(req.Baseline)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidTimeRange, "Invalid baseline window", err.Error())
+		return
+	}
+	cmpStart, cmpEnd, err := resolveReportWindow(req.Comparison)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidTimeRange, "Invalid comparison window", err.Error())
+		return
+	}
+
+	namespace := core.NamespaceFromContext(r.Context())
+	basePoints := make(map[string][]timeseries.DataPoint, len(metrics))
+	cmpPoints := make(map[string][]timeseries.DataPoint, len(metrics))
+	result := models.ReportComparison{Topic: req.Topic, Metrics: make([]models.MetricComparison, 0, len(metrics))}
+	for _, metric := range metrics {
+		labels := timeseries.MetricLabels(namespace, metric)
+		if basePoints[metric], err = storedPoints(metric, labels, baseStart.Unix(), baseEnd.Unix()); err == nil {
+			cmpPoints[metric], err = storedPoints(metric, labels, cmpStart.Unix(), cmpEnd.Unix())
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to get data points", err.Error())
+			return
+		}
+
+		baseline, comparison := summarizePoints(basePoints[metric]), summarizePoints(cmpPoints[metric])
+		result.Metrics = append(result.Metrics, models.MetricComparison{
+			Name:          metric,
+			Baseline:      baseline,
+			Comparison:    comparison,
+			PercentChange: percentChange(baseline, comparison),
+		})
+	}
+	result.Baseline = models.ReportWindowData{Start: baseStart.UTC(), End: baseEnd.UTC(), Points: reportRows(basePoints)}
+	result.Comparison = models.ReportWindowData{Start: cmpStart.UTC(), End: cmpEnd.UTC(), Points: reportRows(cmpPoints)}
+
+	writeJSON(w, r, result)
+}
+
+// resolveReportWindow resolves a window's relative range or, without one, its
+// RFC3339 start and end times.
+func resolveReportWindow(win models.ReportWindow) (start, end time.Time, err error) {
+	if win.Range != "" {
+		startUnix, endUnix, err := common.ParseRelativeRange(win.Range)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return time.Unix(startUnix, 0), time.Unix(endUnix, 0), nil
+	}
+	if win.StartTime == "" || win.EndTime == "" {
+		return time.Time{}, time.Time{}, errors.New("range or start_time and end_time are required")
+	}
+	if start, err = time.Parse(time.RFC3339, win.StartTime); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start_time: %w", err)
+	}
+	if end, err = time.Parse(time.RFC3339, win.EndTime); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end_time: %w", err)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, errors.New("end_time must not be before start_time")
+	}
+	return start, end, nil
+}
+
+// summarizePoints returns the count, average, minimum and maximum of points.
+func summarizePoints(points []timeseries.DataPoint) models.MetricSummary {
+	if len(points) == 0 {
+		return models.MetricSummary{}
+	}
+	summary := models.MetricSummary{Count: len(points), Min: math.Inf(1), Max: math.Inf(-1)}
+	var sum float64
+	for _, p := range points {
+		sum += p.Value
+		summary.Min = math.Min(summary.Min, p.Value)
+		summary.Max = math.Max(summary.Max, p.Value)
+	}
+	summary.Avg = sum / float64(len(points))
+	return summary
+}
+
+// percentChange returns the change from the baseline to the comparison
+// average in percent, or nil when it is undefined.
+func percentChange(baseline, comparison models.MetricSummary) *float64 {
+	if baseline.Count == 0 || comparison.Count == 0 || baseline.Avg == 0 {
+		return nil
+	}
+	change := (comparison.Avg - baseline.Avg) / math.Abs(baseline.Avg) * 100
+	return &change
+}
+
+// reportRows groups the points of each metric by timestamp, in the
+// {"time", "value"} shape of GetReportData, oldest first.
+func reportRows(pointsByMetric map[string][]timeseries.DataPoint) []map[string]interface{} {
+	dataByTimestamp := make(map[int64]map[string]float64)
+	for metric, points := range pointsByMetric {
+		for _, dp := range points {
+			if _, exists := dataByTimestamp[dp.Timestamp]; !exists {
+				dataByTimestamp[dp.Timestamp] = make(map[string]float64)
+			}
+			dataByTimestamp[dp.Timestamp][metric] = dp.Value
+		}
+	}
+
+	timestamps := make([]int64, 0, len(dataByTimestamp))
+	for timestamp := range dataByTimestamp {
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	rows := make([]map[string]interface{}, 0, len(timestamps))
+	for _, timestamp := range timestamps {
+		rows = append(rows, map[string]interface{}{
+			"time":  time.Unix(timestamp, 0).UTC().Format(time.RFC3339Nano),
+			"value": dataByTimestamp[timestamp],
+		})
+	}
+	return rows
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/models"
+)
+
+// configSources maps a namespace to the function returning its instance's configuration.
+var configSources sync.Map // map[string]func() models.MonigoConfig
+
+// SetConfigSource sets the function GetConfig serves the configuration of
+// namespace from. Passing nil removes it.
+func SetConfigSource(namespace string, fn func() models.MonigoConfig) {
+	if fn == nil {
+		configSources.Delete(namespace)
+		return
+	}
+	configSources.Store(namespace, fn)
+}
+
+// GetConfig returns the effective configuration of the MoniGo instance, with
+// secrets redacted.
+// GET /monigo/api/v1/config
+func GetConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	source, ok := configSources.Load(core.NamespaceFromContext(r.Context()))
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "No MoniGo instance is configured")
+		return
+	}
+	writeJSON(w, r, source.(func() models.MonigoConfig)())
+}
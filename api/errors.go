@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes returned in the "code" field of API error responses.
+const (
+	ErrCodeMethodNotAllowed     = "method_not_allowed"
+	ErrCodeBadRequest           = "bad_request"
+	ErrCodeInvalidTimeRange     = "invalid_time_range"
+	ErrCodeUnknownTopic         = "unknown_topic"
+	ErrCodeNotFound             = "not_found"
+	ErrCodeInternal             = "internal_error"
+	ErrCodeProfilingUnavailable = "profiling_unavailable"
+	ErrCodeInvalidReportType    = "invalid_report_type"
+	ErrCodeNotSupported         = "not_supported"
+	ErrCodeUnauthorized         = "unauthorized"
+)
+
+// ErrorResponse is the JSON envelope returned by every API handler on failure.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes a single API error.
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+}
+
+// NotFoundResponse is the JSON body returned for an unknown API path. It
+// lists the endpoints that do exist.
+type NotFoundResponse struct {
+	Error              ErrorBody `json:"error"`
+	AvailableEndpoints []string  `json:"available_endpoints"`
+}
+
+// NewNotFoundResponse returns the body of a 404 for an unknown API path.
+func NewNotFoundResponse(endpoints []string) NotFoundResponse {
+	return NotFoundResponse{
+		Error:              ErrorBody{Code: ErrCodeNotFound, Message: "API endpoint not found"},
+		AvailableEndpoints: endpoints,
+	}
+}
+
+// WriteNotFound writes a 404 for an unknown API path listing endpoints.
+func WriteNotFound(w http.ResponseWriter, endpoints []string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	_ = json.NewEncoder(w).Encode(NewNotFoundResponse(endpoints))
+}
+
+// writeError writes a structured JSON error response with the given status code.
+// An optional details string can be supplied to give more context (e.g. a parse error).
+func writeError(w http.ResponseWriter, status int, code, message string, details ...string) {
+	body := ErrorBody{Code: code, Message: message}
+	if len(details) > 0 {
+		body.Details = details[0]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: body})
+}
+
+// WriteError writes a structured JSON error response. It lets the router and
+// middleware outside this package use the same error envelope as the handlers.
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	writeError(w, status, code, message)
+}
+
+// writeMethodNotAllowed writes a 405 error response.
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Method not allowed")
+}
@@ -2,21 +2,44 @@ package api
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/iyashjayesh/monigo/exporters"
+	"github.com/iyashjayesh/monigo/internal/logger"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func init() {
-	prometheus.MustRegister(exporters.NewMonigoCollector())
+var enablePrometheusOnce sync.Once
+
+// EnablePrometheus registers the MoniGo collectors with the default Prometheus
+// registry. It is safe to call more than once; later calls are no-ops. MoniGo
+// calls it during startup, after the configured tags were applied.
+func EnablePrometheus() {
+	enablePrometheusOnce.Do(func() {
+		if err := exporters.RegisterWith(prometheus.DefaultRegisterer); err != nil {
+			logger.Log.Warn("failed to register MoniGo Prometheus collectors", "error", err)
+		}
+	})
 }
 
 func GetPrometheusHandler() http.Handler {
+	EnablePrometheus()
 	return promhttp.Handler()
 }
 
 // PrometheusMetricsHandler handles the /metrics endpoint.
 func PrometheusMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	EnablePrometheus()
 	promhttp.Handler().ServeHTTP(w, r)
 }
+
+// PrometheusHandlerFor registers the MoniGo collectors with reg and returns a
+// handler serving everything in reg, so MoniGo metrics can be scraped
+// alongside the application's own.
+func PrometheusHandlerFor(reg *prometheus.Registry) http.Handler {
+	if err := exporters.RegisterWith(reg); err != nil {
+		logger.Log.Warn("failed to register MoniGo Prometheus collectors", "error", err)
+	}
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
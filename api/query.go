@@ -0,0 +1,280 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/models"
+	"github.com/iyashjayesh/monigo/timeseries"
+)
+
+const (
+	// defaultQueryRange is the range queried when start is omitted.
+	defaultQueryRange = time.Hour
+	// maxQueryPoints caps the points per series, like Prometheus' 11,000.
+	maxQueryPoints = 11000
+)
+
+// QueryRange returns the series matching a selector, in the shape of the
+// Prometheus HTTP API. Only plain selectors with equality matchers are
+// supported, e.g. cpu_core_usage{core="0"}. start and end (unix seconds or
+// RFC3339) default to the last hour; with step (seconds or a duration like
+// 30s), each series is sampled at start, start+step, ... up to end, taking
+// the latest point within the preceding step.
+// GET /monigo/api/v1/query_range?query=goroutines&start=1700000000&end=1700003600&step=60
+func QueryRange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	metric, matchers, err := parseSelector(r.FormValue("query"))
+	if err != nil {
+		writeQueryError(w, http.StatusBadRequest, "bad_data", err.Error())
+		return
+	}
+	end, err := parseQueryTime(r.FormValue("end"), time.Now())
+	if err != nil {
+		writeQueryError(w, http.StatusBadRequest, "bad_data", "invalid end: "+err.Error())
+		return
+	}
+	start, err := parseQueryTime(r.FormValue("start"), end.Add(-defaultQueryRange))
+	if err != nil {
+		writeQueryError(w, http.StatusBadRequest, "bad_data", "invalid start: "+err.Error())
+		return
+	}
+	if end.Before(start) {
+		writeQueryError(w, http.StatusBadRequest, "bad_data", "end must not be before start")
+		return
+	}
+	step, err := parseQueryStep(r.FormValue("step"))
+	if err != nil {
+		writeQueryError(w, http.StatusBadRequest, "bad_data", "invalid step: "+err.Error())
+		return
+	}
+	if step > 0 && end.Sub(start)/step >= maxQueryPoints {
+		writeQueryError(w, http.StatusBadRequest, "bad_data", fmt.Sprintf("exceeded maximum resolution of %d points per series; increase step", maxQueryPoints))
+		return
+	}
+
+	labels := selectorLabels(core.NamespaceFromContext(r.Context()), metric, matchers)
+	series, err := timeseries.GetSeries(metric, labels, start.Unix(), end.Unix())
+	if err != nil {
+		writeQueryError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+
+	result := make([]models.QueryRangeSeries, 0, len(series))
+	for _, s := range series {
+		points := s.Points
+		if step > 0 {
+			points = samplePoints(points, start.Unix(), end.Unix(), int64(step/time.Second))
+		}
+		if len(points) == 0 {
+			continue
+		}
+		result = append(result, newQueryRangeSeries(metric, s.Labels, points))
+	}
+
+	writeJSON(w, r, models.QueryRangeResponse{
+		Status: "success",
+		Data:   &models.QueryRangeData{ResultType: "matrix", Result: result},
+	})
+}
+
+// writeQueryError writes a Prometheus HTTP API style error.
+func writeQueryError(w http.ResponseWriter, status int, errorType, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.QueryRangeResponse{Status: "error", ErrorType: errorType, Error: msg})
+}
+
+// parseSelector parses a selector of the form metric or
+// metric{label="value", ...}.
+func parseSelector(query string) (string, []timeseries.Label, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", nil, errors.New("query is required")
+	}
+
+	metric, rest, hasMatchers := strings.Cut(query, "{")
+	metric = strings.TrimSpace(metric)
+	if !isMetricName(metric) {
+		return "", nil, fmt.Errorf("invalid metric name %q", metric)
+	}
+	if !hasMatchers {
+		return metric, nil, nil
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasSuffix(rest, "}") {
+		return "", nil, errors.New("unclosed label matchers, expected '}'")
+	}
+	rest = strings.TrimSuffix(rest, "}")
+
+	var labels []timeseries.Label
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		if rest == "" {
+			return metric, labels, nil
+		}
+		name, value, remaining, err := parseMatcher(rest)
+		if err != nil {
+			return "", nil, err
+		}
+		labels = append(labels, timeseries.Label{Name: name, Value: value})
+		rest = strings.TrimSpace(remaining)
+		if rest != "" && rest[0] != ',' {
+			return "", nil, fmt.Errorf("expected ',' between label matchers, got %q", rest)
+		}
+	}
+}
+
+// parseMatcher parses one label="value" matcher at the start of s and returns
+// the rest of s.
+func parseMatcher(s string) (name, value, rest string, err error) {
+	name, rest, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if strings.HasSuffix(name, "!") || strings.HasPrefix(rest, "~") {
+		return "", "", "", fmt.Errorf("only equality matchers are supported, got %q", s)
+	}
+	if !ok || !isMetricName(name) || strings.Contains(name, ":") {
+		return "", "", "", fmt.Errorf("invalid label matcher %q", s)
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, `"`) {
+		return "", "", "", fmt.Errorf("label %s: expected a quoted value", name)
+	}
+	for i := 1; i < len(rest); i++ {
+		switch rest[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err := strconv.Unquote(rest[:i+1])
+			if err != nil {
+				return "", "", "", fmt.Errorf("label %s: invalid value: %v", name, err)
+			}
+			return name, value, rest[i+1:], nil
+		}
+	}
+	return "", "", "", fmt.Errorf("label %s: unterminated value", name)
+}
+
+// isMetricName reports whether s is a valid Prometheus metric or label name.
+func isMetricName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_' || c == ':' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case i > 0 && c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseQueryTime parses unix seconds or an RFC3339 time, returning def for "".
+func parseQueryTime(s string, def time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		whole, frac := math.Modf(secs)
+		return time.Unix(int64(whole), int64(frac*1e9)), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// parseQueryStep parses seconds or a duration like 30s, returning 0 for "".
+// Steps are whole seconds, the resolution of stored points.
+func parseQueryStep(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	step, err := time.ParseDuration(s)
+	if err != nil {
+		secs, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return 0, fmt.Errorf("%q is neither seconds nor a duration", s)
+		}
+		step = time.Duration(secs * float64(time.Second))
+	}
+	if step < time.Second {
+		return 0, errors.New("step must be at least 1s")
+	}
+	return step.Truncate(time.Second), nil
+}
+
+// selectorLabels returns the labels to read metric with in namespace,
+// narrowed by matchers. Within a namespace, the service label of its series
+// can't be matched away to read another instance's series.
+func selectorLabels(namespace, metric string, matchers []timeseries.Label) []timeseries.Label {
+	labels := mergeLabels(timeseries.MetricLabels(namespace, metric), matchers)
+	if namespace == "" || !timeseries.NamespacedMetric(metric) {
+		return labels
+	}
+	return mergeLabels(labels, []timeseries.Label{{Name: timeseries.ServiceLabelName, Value: namespace}})
+}
+
+// mergeLabels returns base with the labels of override added or replacing
+// those of the same name.
+func mergeLabels(base, override []timeseries.Label) []timeseries.Label {
+	merged := make([]timeseries.Label, 0, len(base)+len(override))
+	for _, l := range base {
+		replaced := false
+		for _, o := range override {
+			if o.Name == l.Name {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, l)
+		}
+	}
+	return append(merged, override...)
+}
+
+// samplePoints samples points, in timestamp order, at start, start+step, ...
+// up to end. Each sample is the latest point in the step ending at it;
+// samples without such a point are left out.
+func samplePoints(points []timeseries.DataPoint, start, end, step int64) []timeseries.DataPoint {
+	var sampled []timeseries.DataPoint
+	i := 0
+	for t := start; t <= end; t += step {
+		var latest *timeseries.DataPoint
+		for ; i < len(points) && points[i].Timestamp <= t; i++ {
+			if points[i].Timestamp > t-step {
+				latest = &points[i]
+			}
+		}
+		if latest != nil {
+			sampled = append(sampled, timeseries.DataPoint{Timestamp: t, Value: latest.Value})
+		}
+	}
+	return sampled
+}
+
+// newQueryRangeSeries builds a matrix series, naming the metric in __name__.
+func newQueryRangeSeries(metric string, labels []timeseries.Label, points []timeseries.DataPoint) models.QueryRangeSeries {
+	series := models.QueryRangeSeries{
+		Metric: map[string]string{"__name__": metric},
+		Values: make([][2]interface{}, len(points)),
+	}
+	for _, l := range labels {
+		series.Metric[l.Name] = l.Value
+	}
+	for i, p := range points {
+		series.Values[i] = [2]interface{}{p.Timestamp, strconv.FormatFloat(p.Value, 'f', -1, 64)}
+	}
+	return series
+}
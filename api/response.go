@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+)
+
+// prettyJSON is the server-wide default for indented JSON responses.
+var prettyJSON atomic.Bool
+
+// SetPrettyJSON sets whether JSON responses are indented by default.
+// Individual requests can still opt in with the ?pretty=true query parameter.
+func SetPrettyJSON(enabled bool) {
+	prettyJSON.Store(enabled)
+}
+
+// DefaultMaxResponsePoints is the default cap on the points returned by
+// GetServiceMetricsFromStorage.
+const DefaultMaxResponsePoints = 5000
+
+// maxResponsePoints caps the points returned by GetServiceMetricsFromStorage;
+// wider ranges are downsampled.
+var maxResponsePoints atomic.Int64
+
+func init() {
+	maxResponsePoints.Store(DefaultMaxResponsePoints)
+}
+
+// SetMaxResponsePoints sets the number of points GetServiceMetricsFromStorage
+// returns before it downsamples. Values <= 0 restore the default.
+func SetMaxResponsePoints(n int) {
+	if n <= 0 {
+		n = DefaultMaxResponsePoints
+	}
+	maxResponsePoints.Store(int64(n))
+}
+
+// MaxResponsePoints returns the cap set by SetMaxResponsePoints.
+func MaxResponsePoints() int {
+	return int(maxResponsePoints.Load())
+}
+
+// wantsPrettyJSON reports whether the response to r should be indented.
+func wantsPrettyJSON(r *http.Request) bool {
+	if r != nil {
+		switch r.URL.Query().Get("pretty") {
+		case "true", "1":
+			return true
+		case "false", "0":
+			return false
+		}
+	}
+	return prettyJSON.Load()
+}
+
+// writeJSON marshals v and writes it with a 200 status, indenting the output
+// when requested. Marshal failures are reported as a structured 500 error.
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	var (
+		data []byte
+		err  error
+	)
+	if wantsPrettyJSON(r) {
+		data, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to encode response", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
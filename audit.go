@@ -0,0 +1,78 @@
+package monigo
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// APIKeyPrincipal is the principal recorded for requests authenticated by
+// APIKeyMiddleware; the key itself is never recorded.
+const APIKeyPrincipal = "api-key"
+
+// AuditEvent records one access to the dashboard or its API.
+type AuditEvent struct {
+	Time      time.Time `json:"time"`
+	Principal string    `json:"principal,omitempty"` // Empty when unauthenticated
+	IP        string    `json:"ip"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+}
+
+type principalKey struct{}
+
+// principalSlot holds the principal of a request. It is shared through the
+// context so AuditMiddleware sees a principal set by auth middleware it wraps.
+type principalSlot struct {
+	name string
+}
+
+// WithPrincipal records principal, e.g. the user name, as the authenticated
+// identity of r for AuditMiddleware. BasicAuthMiddleware and APIKeyMiddleware
+// call it; custom auth middleware should pass the returned request on.
+func WithPrincipal(r *http.Request, principal string) *http.Request {
+	if slot, ok := r.Context().Value(principalKey{}).(*principalSlot); ok {
+		slot.name = principal
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, &principalSlot{name: principal}))
+}
+
+// PrincipalFromContext returns the principal set by WithPrincipal, or "".
+func PrincipalFromContext(ctx context.Context) string {
+	if slot, ok := ctx.Value(principalKey{}).(*principalSlot); ok {
+		return slot.name
+	}
+	return ""
+}
+
+// AuditMiddleware passes an AuditEvent to sink for every request once it was
+// served, including rejected ones. Static assets are not audited. The
+// principal set by auth middleware is included wherever that middleware sits
+// in the chain. sink is called on the request goroutine, so it should hand
+// slow writes (file, database) off.
+func AuditMiddleware(sink func(AuditEvent)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isStaticFile(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			if _, ok := r.Context().Value(principalKey{}).(*principalSlot); !ok {
+				r = r.WithContext(context.WithValue(r.Context(), principalKey{}, &principalSlot{}))
+			}
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+			sink(AuditEvent{
+				Time:      start,
+				Principal: PrincipalFromContext(r.Context()),
+				IP:        getClientIP(r),
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Status:    wrapped.statusCode,
+			})
+		})
+	}
+}
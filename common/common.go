@@ -12,6 +12,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/iyashjayesh/monigo/models"
@@ -71,6 +72,13 @@ func SetServiceInfo(serviceName string, serviceStartTime time.Time, goVersion st
 	retentionPeriod = retention
 }
 
+// SetServiceBranding sets the dashboard title and service description
+// returned with the service information.
+func SetServiceBranding(dashboardTitle, serviceDescription string) {
+	serviceInfo.DashboardTitle = dashboardTitle
+	serviceInfo.ServiceDescription = serviceDescription
+}
+
 // GetServiceInfo returns the service info.
 func GetServiceInfo() models.ServiceInfo {
 	return serviceInfo
@@ -149,6 +157,7 @@ func ConstructJsonFieldDescription() map[string]string {
 		"memory_used_by_service": "Memory[RAM] Used by Service is the memory the service is using",
 		"available_memory": "Available Memory[RAM] is the memory available on the system",
 		"uptime": "Uptime is the time the service has been running",
+		"uptime_seconds": "Uptime in seconds, for charting",
 		"timestamp": "Timestamp is the time the data was collected"
 	}`
 
@@ -169,6 +178,32 @@ func RoundFloat64(value float64, precision int) float64 {
 	return ParseStringToFloat64(fmt.Sprintf("%."+fmt.Sprintf("%d", precision)+"f", value))
 }
 
+// DefaultFormatPrecision is the number of decimals the formatting helpers use
+// unless SetFormatPrecision changes it.
+const DefaultFormatPrecision = 2
+
+// maxFormatPrecision bounds SetFormatPrecision; float64 carries no more
+// significant decimals.
+const maxFormatPrecision = 15
+
+var formatPrecision atomic.Int32
+
+func init() {
+	formatPrecision.Store(DefaultFormatPrecision)
+}
+
+// SetFormatPrecision sets the number of decimals used by ParseFloat64ToString,
+// ConvertToReadableUnit, BytesToUnit and FormatBytes, clamped to 0..15.
+// It only affects display strings; raw and stored values keep full precision.
+func SetFormatPrecision(n int) {
+	formatPrecision.Store(int32(min(max(n, 0), maxFormatPrecision)))
+}
+
+// FormatPrecision returns the number of decimals used by the formatting helpers.
+func FormatPrecision() int {
+	return int(formatPrecision.Load())
+}
+
 // ConvertToReadableUnit converts the input value to a more human-readable unit.
 func ConvertToReadableUnit(value interface{}) string {
 	var num float64
@@ -208,7 +243,7 @@ func ConvertToReadableUnit(value interface{}) string {
 		num = num / math.Pow(1024, 5)
 	}
 
-	return fmt.Sprintf("%.2f %s", num, unit)
+	return fmt.Sprintf("%.*f %s", FormatPrecision(), num, unit)
 }
 
 // BytesToUnit converts a float64 value (representing bytes) to a human-readable unit (KB, MB, GB, TB) based on its magnitude
@@ -232,7 +267,37 @@ func BytesToUnit(value uint64) string {
 		num = num / math.Pow(1024, 4)
 	}
 
-	return fmt.Sprintf("%.2f %s", num, unit)
+	return fmt.Sprintf("%.*f %s", FormatPrecision(), num, unit)
+}
+
+// ByteUnitAuto is the byte unit that picks KB, MB, GB or TB per value, like BytesToUnit.
+const ByteUnitAuto = "auto"
+
+// ParseByteUnit normalizes a byte unit: "auto" (or empty), "bytes", "KB",
+// "MB", "GB" or "TB", case-insensitively.
+func ParseByteUnit(unit string) (string, error) {
+	switch u := strings.ToUpper(strings.TrimSpace(unit)); u {
+	case "", "AUTO":
+		return ByteUnitAuto, nil
+	case "B", "BYTES":
+		return "bytes", nil
+	case "KB", "MB", "GB", "TB":
+		return u, nil
+	default:
+		return "", fmt.Errorf("unknown unit %q, expected auto, bytes, KB, MB, GB or TB", unit)
+	}
+}
+
+// FormatBytes formats value in a unit returned by ParseByteUnit.
+func FormatBytes(value uint64, unit string) string {
+	switch unit {
+	case ByteUnitAuto:
+		return BytesToUnit(value)
+	case "bytes":
+		return fmt.Sprintf("%d B", value)
+	default:
+		return fmt.Sprintf("%.*f %s", FormatPrecision(), ConvertBytesToUnit(float64(value), unit), unit)
+	}
 }
 
 // ConvertBytesToUnit converts bytes to the specified unit (base-1024) and returns the result as a float64.
@@ -259,28 +324,6 @@ func GetServiceStartTime() time.Time {
 	return serviceInfo.ServiceStartTime
 }
 
-// parseDuration parses the duration string.
-func parseDuration(input string) (time.Duration, error) {
-	if strings.HasSuffix(input, "d") {
-		daysStr := strings.TrimSuffix(input, "d")
-		days, err := strconv.Atoi(daysStr)
-		if err != nil {
-			return 0, err
-		}
-		// Convert days to hours
-		return time.Duration(days*24) * time.Hour, nil
-	} else if strings.HasSuffix(input, "month") {
-		monthsStr := strings.TrimSuffix(input, "month")
-		months, err := strconv.Atoi(monthsStr)
-		if err != nil {
-			return 0, err
-		}
-		return time.Duration(months*30) * 24 * time.Hour, nil
-	}
-
-	return time.ParseDuration(input)
-}
-
 // GetDataRetentionPeriod returns the retention period.
 func GetDataRetentionPeriod() time.Duration {
 	period := retentionPeriod
@@ -288,7 +331,7 @@ func GetDataRetentionPeriod() time.Duration {
 		period = "7d"
 	}
 
-	duration, err := parseDuration(period)
+	duration, err := ParseRetention(period)
 	if err != nil {
 		logger.Log.Error("parsing retention period, using default retention period (7d)", "error", err)
 		duration = 7 * 24 * time.Hour
@@ -3,6 +3,7 @@ package common
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -164,6 +165,44 @@ func TestGetDataRetentionPeriod(t *testing.T) {
 	}
 }
 
+func TestParseRetention(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"30s", 30 * time.Second},
+		{"5m", 5 * time.Minute},
+		{"12h", 12 * time.Hour},
+		{"7d", 7 * day},
+		{"2w", 14 * day},
+		{"1mo", 30 * day},
+		{"3month", 90 * day},
+		{"1y", 365 * day},
+		{"1.5h", 90 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{" 7D ", 7 * day},
+	}
+	for _, tt := range tests {
+		got, err := ParseRetention(tt.input)
+		if err != nil {
+			t.Errorf("ParseRetention(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRetention(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetention_Invalid(t *testing.T) {
+	for _, input := range []string{"", "abc", "7x", "d", "-1d", "0h", "1.2.3d"} {
+		if _, err := ParseRetention(input); err == nil {
+			t.Errorf("ParseRetention(%q) expected error", input)
+		}
+	}
+}
+
 func TestConvertToReadableUnit(t *testing.T) {
 	result := ConvertToReadableUnit(uint64(1048576))
 	if result != "1.00 MB" {
@@ -178,9 +217,137 @@ func TestParseFloat64ToString(t *testing.T) {
 	}
 }
 
+func TestSetFormatPrecision(t *testing.T) {
+	t.Cleanup(func() { SetFormatPrecision(DefaultFormatPrecision) })
+
+	// A GC CPU fraction rounds away at the default precision.
+	if got := ParseFloat64ToString(0.001234); got != "0.00" {
+		t.Errorf("expected '0.00' at the default precision, got %q", got)
+	}
+
+	SetFormatPrecision(6)
+	if got := ParseFloat64ToString(0.001234); got != "0.001234" {
+		t.Errorf("expected '0.001234', got %q", got)
+	}
+	if got := FormatBytes(1024, "MB"); got != "0.000977 MB" {
+		t.Errorf("expected '0.000977 MB', got %q", got)
+	}
+	if got := BytesToUnit(1536); got != "1.500000 KB" {
+		t.Errorf("expected '1.500000 KB', got %q", got)
+	}
+	if got := ConvertToReadableUnit(uint64(1536)); got != "1.500000 KB" {
+		t.Errorf("expected '1.500000 KB', got %q", got)
+	}
+	// Raw values are not display formatting and keep their own precision.
+	if got := RoundFloat64(0.001234, 2); got != 0 {
+		t.Errorf("expected RoundFloat64 to ignore the format precision, got %v", got)
+	}
+
+	SetFormatPrecision(-1)
+	if got := FormatPrecision(); got != 0 {
+		t.Errorf("expected a negative precision to clamp to 0, got %d", got)
+	}
+	SetFormatPrecision(100)
+	if got := FormatPrecision(); got != maxFormatPrecision {
+		t.Errorf("expected precision to clamp to %d, got %d", maxFormatPrecision, got)
+	}
+}
+
 func TestGetProcessId(t *testing.T) {
 	pid := GetProcessId()
 	if pid <= 0 {
 		t.Errorf("expected positive PID, got %d", pid)
 	}
 }
+
+func TestParseRelativeRange(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"last-1h", time.Hour},
+		{"last-24h", 24 * time.Hour},
+		{"last-7d", 7 * 24 * time.Hour},
+		{"last-30m", 30 * time.Minute},
+		{" LAST-2w ", 14 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		before := time.Now().Unix()
+		start, end, err := ParseRelativeRange(tt.input)
+		after := time.Now().Unix()
+		if err != nil {
+			t.Errorf("ParseRelativeRange(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if end < before || end > after {
+			t.Errorf("ParseRelativeRange(%q) end = %d, want between %d and %d", tt.input, end, before, after)
+		}
+		if got := time.Duration(end-start) * time.Second; got != tt.want {
+			t.Errorf("ParseRelativeRange(%q) spans %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseRelativeRange_Invalid(t *testing.T) {
+	for _, input := range []string{"", "1h", "last-", "last-abc", "last--1h", "next-1h"} {
+		if _, _, err := ParseRelativeRange(input); err == nil {
+			t.Errorf("ParseRelativeRange(%q) expected error", input)
+		}
+	}
+}
+
+func TestGetPerCoreCPU(t *testing.T) {
+	perCore := GetPerCoreCPU()
+	if len(perCore) != runtime.NumCPU() {
+		t.Fatalf("expected %d per-core values, got %d", runtime.NumCPU(), len(perCore))
+	}
+	for i, v := range perCore {
+		if v < 0 || v > 100 {
+			t.Errorf("core %d: usage %v out of [0,100]", i, v)
+		}
+	}
+}
+
+func TestGetCPUSensors(t *testing.T) {
+	sensors := GetCPUSensors()
+	if sensors == nil {
+		t.Fatal("expected a non-nil slice even where sensors are unsupported")
+	}
+	for _, s := range sensors {
+		if s.Name == "" {
+			t.Errorf("expected every sensor to be named, got %+v", s)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input uint64
+		unit  string
+		want  string
+	}{
+		{1048576, "auto", "1.00 MB"},
+		{1048576, "bytes", "1048576 B"},
+		{1048576, "KB", "1024.00 KB"},
+		{536870912, "GB", "0.50 GB"},
+		{1024, "MB", "0.00 MB"},
+	}
+	for _, tt := range tests {
+		got := FormatBytes(tt.input, tt.unit)
+		if got != tt.want {
+			t.Errorf("FormatBytes(%d, %q) = %q, want %q", tt.input, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestParseByteUnit(t *testing.T) {
+	for in, want := range map[string]string{"": "auto", "Auto": "auto", "bytes": "bytes", "b": "bytes", "mb": "MB", "GB": "GB"} {
+		got, err := ParseByteUnit(in)
+		if err != nil || got != want {
+			t.Errorf("ParseByteUnit(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseByteUnit("PB"); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
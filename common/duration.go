@@ -0,0 +1,77 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var retentionPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)([a-z]+)$`)
+
+// retentionUnits maps the supported unit suffixes to their length.
+// Months and years are approximated as 30 and 365 days.
+var retentionUnits = map[string]time.Duration{
+	"s":     time.Second,
+	"m":     time.Minute,
+	"h":     time.Hour,
+	"d":     24 * time.Hour,
+	"w":     7 * 24 * time.Hour,
+	"mo":    30 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour, // kept for backward compatibility
+	"y":     365 * 24 * time.Hour,
+}
+
+// ParseRetention parses durations such as "30s", "5m", "12h", "7d", "2w", "1mo" or "1y".
+// Anything else accepted by time.ParseDuration (e.g. "1h30m", "500ms") is also allowed.
+func ParseRetention(s string) (time.Duration, error) {
+	input := strings.ToLower(strings.TrimSpace(s))
+	if input == "" {
+		return 0, fmt.Errorf("invalid duration %q: value is empty", s)
+	}
+
+	if m := retentionPattern.FindStringSubmatch(input); m != nil {
+		if unit, ok := retentionUnits[m[2]]; ok {
+			n, err := strconv.ParseFloat(m[1], 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+			}
+			return checkPositive(s, time.Duration(n*float64(unit)))
+		}
+	}
+
+	d, err := time.ParseDuration(input)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: expected a number followed by one of s, m, h, d, w, mo, y", s)
+	}
+	return checkPositive(s, d)
+}
+
+func checkPositive(s string, d time.Duration) (time.Duration, error) {
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be greater than zero", s)
+	}
+	return d, nil
+}
+
+// relativeRangePrefix starts every relative range accepted by ParseRelativeRange.
+const relativeRangePrefix = "last-"
+
+// ParseRelativeRange resolves a relative range such as "last-1h", "last-24h"
+// or "last-7d" against the current time. It returns the start and end of the
+// range as Unix seconds. The duration accepts the same units as ParseRetention.
+func ParseRelativeRange(s string) (start, end int64, err error) {
+	input := strings.ToLower(strings.TrimSpace(s))
+	if !strings.HasPrefix(input, relativeRangePrefix) {
+		return 0, 0, fmt.Errorf("invalid range %q: expected last-<duration>, e.g. last-1h", s)
+	}
+
+	d, err := ParseRetention(strings.TrimPrefix(input, relativeRangePrefix))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q: %w", s, err)
+	}
+
+	now := time.Now()
+	return now.Add(-d).Unix(), now.Unix(), nil
+}
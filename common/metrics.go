@@ -1,51 +1,92 @@
 package common
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/iyashjayesh/monigo/internal/logger"
+	"github.com/iyashjayesh/monigo/models"
 	"strconv"
 	"time"
 
 	"github.com/shirou/gopsutil/cpu"
-	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/host"
 	"github.com/shirou/gopsutil/mem"
 	"github.com/shirou/gopsutil/process"
 )
 
 // GetCPULoad calculates the CPU load for the service, system, and total.
 func GetCPULoad() (serviceCPU, systemCPU, totalCPU string, serviceCPUF, systemCPUF, totalCPUF float64) {
+	probe := getSystemProbe()
 
-	proc := GetProcessObject()            // Getting process details
-	serviceCPUF, err := proc.CPUPercent() // 	Measure CPU percent for the current process
+	serviceCPUF, err := probe.ProcessCPUPercent() // Measure CPU percent for the current process
 	if err != nil {
 		logger.Log.Error("fetching CPU load for the service", "error", err)
 		serviceCPUF = 0
 	}
 	serviceCPU = ParseFloat64ToString(serviceCPUF) + "%" // Service CPU usage percentage
 
-	cpuPercents, err := cpu.Percent(time.Second, false) // Get total system CPU percentage
+	totalCPUF, err = probe.SystemCPUPercent() // Get total system CPU percentage
 	if err != nil {
 		logger.Log.Error("fetching CPU load for the system", "error", err)
 		return serviceCPU, "0%", "0%", serviceCPUF, 0, 0
 	}
-	if len(cpuPercents) > 0 {
-		systemCPUF = cpuPercents[0] - serviceCPUF
-		if systemCPUF < 0 {
-			systemCPUF = 0
-		}
-		systemCPU = ParseFloat64ToString(systemCPUF) + "%" // System CPU usage percentage
-		totalCPUF = cpuPercents[0]
+	systemCPUF = totalCPUF - serviceCPUF
+	if systemCPUF < 0 {
+		systemCPUF = 0
 	}
-
-	totalCPU = ParseFloat64ToString(totalCPUF) + "%" // Total CPU usage percentage
+	systemCPU = ParseFloat64ToString(systemCPUF) + "%" // System CPU usage percentage
+	totalCPU = ParseFloat64ToString(totalCPUF) + "%"   // Total CPU usage percentage
 	return serviceCPU, systemCPU, totalCPU, serviceCPUF, systemCPUF, totalCPUF
 }
 
+// GetPerCoreCPU returns the utilization percentage of each logical CPU core,
+// indexed by core number. It returns nil if the usage can't be read.
+func GetPerCoreCPU() []float64 {
+	perCore, err := cpu.Percent(time.Second, true)
+	if err != nil {
+		logger.Log.Error("fetching per-core CPU load", "error", err)
+		return nil
+	}
+	for i, v := range perCore {
+		perCore[i] = RoundFloat64(v, 2)
+	}
+	return perCore
+}
+
+// GetCPUSensors returns the temperature sensors and per-CPU frequencies
+// reported by the host. Most containers and Windows expose neither, in which
+// case the result is empty rather than an error.
+func GetCPUSensors() []models.CPUSensor {
+	sensors := []models.CPUSensor{}
+
+	temps, err := host.SensorsTemperatures()
+	if err != nil {
+		// Partial results come with a warnings error, so keep whatever was read.
+		logger.Log.Debug("fetching temperature sensors", "error", err)
+	}
+	for _, t := range temps {
+		sensors = append(sensors, models.CPUSensor{Name: t.SensorKey, TemperatureCelsius: t.Temperature})
+	}
+
+	infos, err := cpu.Info()
+	if err != nil {
+		logger.Log.Debug("fetching CPU frequency", "error", err)
+	}
+	for _, info := range infos {
+		if info.Mhz > 0 {
+			sensors = append(sensors, models.CPUSensor{Name: fmt.Sprintf("cpu%d", info.CPU), FrequencyMHz: info.Mhz})
+		}
+	}
+	return sensors
+}
+
 // GetMemoryLoad calculates the memory load for the service, system, and total.
 func GetMemoryLoad() (serviceMem, systemMem, totalMem string, serviceMemF, systemMemF, totalMemF float64) {
+	probe := getSystemProbe()
+
 	// Get system memory statistics
-	vmStat, err := mem.VirtualMemory()
+	vmStat, err := probe.VirtualMemory()
 	if err != nil {
 		logger.Log.Error("fetching memory load for the system", "error", err)
 		return "0%", "0%", "0%", 0, 0, 0
@@ -55,14 +96,13 @@ func GetMemoryLoad() (serviceMem, systemMem, totalMem string, serviceMemF, syste
 	totalMemF = float64(vmStat.Total)
 	totalMem = ParseFloat64ToString(totalMemF) // Total memory in bytes Total amount of RAM on this system
 
-	proc := GetProcessObject()
-	memInfo, err := proc.MemoryInfo()
+	rss, err := probe.ProcessMemoryRSS()
 	if err != nil {
 		logger.Log.Error("fetching memory load for the service", "error", err)
 		return "0%", systemMem, totalMem, 0, systemMemF, totalMemF
 	}
 
-	serviceMemF = (float64(memInfo.RSS) / float64(vmStat.Total)) * 100
+	serviceMemF = (float64(rss) / float64(vmStat.Total)) * 100
 	serviceMem = ParseFloat64ToString(serviceMemF) + "%" // Calculate service memory as a percentage of total memory
 
 	return serviceMem, systemMem, totalMem, serviceMemF, systemMemF, totalMemF
@@ -74,7 +114,7 @@ func GetDiskLoad() (serviceDisk, systemDisk, totalDisk string, systemDiskF, tota
 	// However, gathering "Disk Usage by Process" is complex and often requires root or specific tracking.
 	// For now, we will track System Disk Usage (Root Partition).
 
-	diskUsage, err := disk.Usage("/")
+	diskUsage, err := getSystemProbe().DiskUsage("/")
 	if err != nil {
 		logger.Log.Error("fetching disk usage", "error", err)
 		return "0%", "0%", "0%", 0, 0
@@ -122,9 +162,9 @@ func ParseUint64ToFloat64(value uint64) float64 {
 	return float64(value)
 }
 
-// ParseFloat64ToString converts float64 to string.
+// ParseFloat64ToString converts float64 to string with FormatPrecision decimals.
 func ParseFloat64ToString(value float64) string {
-	return strconv.FormatFloat(value, 'f', 2, 64)
+	return strconv.FormatFloat(value, 'f', FormatPrecision(), 64)
 }
 
 // GetVirtualMemory returns the virtual memory statistics.
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/mem"
+)
+
+// SystemProbe reads the CPU, memory and disk figures behind GetCPULoad,
+// GetMemoryLoad and GetDiskLoad. The default implementation uses gopsutil;
+// SetSystemProbe replaces it, e.g. with fixed values in tests.
+type SystemProbe interface {
+	// ProcessCPUPercent returns the CPU usage of this process.
+	ProcessCPUPercent() (float64, error)
+	// SystemCPUPercent returns the CPU usage of the whole system.
+	SystemCPUPercent() (float64, error)
+	// ProcessMemoryRSS returns the resident memory of this process in bytes.
+	ProcessMemoryRSS() (uint64, error)
+	// VirtualMemory returns the system memory statistics.
+	VirtualMemory() (*mem.VirtualMemoryStat, error)
+	// DiskUsage returns the usage of the filesystem holding path.
+	DiskUsage(path string) (*disk.UsageStat, error)
+}
+
+// errNoProcess is returned when the process of this service can't be opened.
+var errNoProcess = errors.New("process details unavailable")
+
+// gopsutilProbe is the default SystemProbe.
+type gopsutilProbe struct{}
+
+func (gopsutilProbe) ProcessCPUPercent() (float64, error) {
+	proc := GetProcessObject()
+	if proc == nil {
+		return 0, errNoProcess
+	}
+	return proc.CPUPercent()
+}
+
+// SystemCPUPercent samples the system CPU usage over one second.
+func (gopsutilProbe) SystemCPUPercent() (float64, error) {
+	percents, err := cpu.Percent(time.Second, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(percents) == 0 {
+		return 0, errors.New("no CPU usage reported")
+	}
+	return percents[0], nil
+}
+
+func (gopsutilProbe) ProcessMemoryRSS() (uint64, error) {
+	proc := GetProcessObject()
+	if proc == nil {
+		return 0, errNoProcess
+	}
+	info, err := proc.MemoryInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.RSS, nil
+}
+
+func (gopsutilProbe) VirtualMemory() (*mem.VirtualMemoryStat, error) {
+	return mem.VirtualMemory()
+}
+
+func (gopsutilProbe) DiskUsage(path string) (*disk.UsageStat, error) {
+	return disk.Usage(path)
+}
+
+var systemProbe = struct {
+	mu    sync.RWMutex
+	probe SystemProbe
+}{probe: gopsutilProbe{}}
+
+// SetSystemProbe replaces the source of the load figures. A nil probe
+// restores the default gopsutil implementation.
+func SetSystemProbe(p SystemProbe) {
+	if p == nil {
+		p = gopsutilProbe{}
+	}
+	systemProbe.mu.Lock()
+	defer systemProbe.mu.Unlock()
+	systemProbe.probe = p
+}
+
+// getSystemProbe returns the current SystemProbe.
+func getSystemProbe() SystemProbe {
+	systemProbe.mu.RLock()
+	defer systemProbe.mu.RUnlock()
+	return systemProbe.probe
+}
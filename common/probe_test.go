@@ -0,0 +1,108 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/mem"
+)
+
+// fakeProbe is a SystemProbe returning fixed values and errors.
+type fakeProbe struct {
+	processCPU, systemCPU float64
+	rss                   uint64
+	vm                    *mem.VirtualMemoryStat
+	disk                  *disk.UsageStat
+
+	processCPUErr, systemCPUErr, rssErr, vmErr, diskErr error
+}
+
+func (p *fakeProbe) ProcessCPUPercent() (float64, error) { return p.processCPU, p.processCPUErr }
+func (p *fakeProbe) SystemCPUPercent() (float64, error)  { return p.systemCPU, p.systemCPUErr }
+func (p *fakeProbe) ProcessMemoryRSS() (uint64, error)   { return p.rss, p.rssErr }
+func (p *fakeProbe) VirtualMemory() (*mem.VirtualMemoryStat, error) {
+	return p.vm, p.vmErr
+}
+func (p *fakeProbe) DiskUsage(string) (*disk.UsageStat, error) { return p.disk, p.diskErr }
+
+var errProbe = errors.New("permission denied")
+
+func TestGetCPULoad_Probe(t *testing.T) {
+	t.Cleanup(func() { SetSystemProbe(nil) })
+
+	SetSystemProbe(&fakeProbe{processCPU: 12.5, systemCPU: 40})
+	service, system, total, serviceF, systemF, totalF := GetCPULoad()
+	if service != "12.50%" || system != "27.50%" || total != "40.00%" {
+		t.Errorf("unexpected CPU load %q %q %q", service, system, total)
+	}
+	if serviceF != 12.5 || systemF != 27.5 || totalF != 40 {
+		t.Errorf("unexpected CPU load values %v %v %v", serviceF, systemF, totalF)
+	}
+
+	// The system share can't go below zero between the two readings.
+	SetSystemProbe(&fakeProbe{processCPU: 50, systemCPU: 40})
+	if _, system, _, _, systemF, _ := GetCPULoad(); system != "0.00%" || systemF != 0 {
+		t.Errorf("expected the system CPU to clamp to 0, got %q", system)
+	}
+
+	SetSystemProbe(&fakeProbe{processCPUErr: errProbe, systemCPU: 40})
+	if service, _, total, _, _, _ := GetCPULoad(); service != "0.00%" || total != "40.00%" {
+		t.Errorf("expected the service CPU to fall back to 0, got %q %q", service, total)
+	}
+
+	SetSystemProbe(&fakeProbe{processCPU: 12.5, systemCPUErr: errProbe})
+	if service, system, total, _, _, _ := GetCPULoad(); service != "12.50%" || system != "0%" || total != "0%" {
+		t.Errorf("expected the system CPU to fall back to 0%%, got %q %q %q", service, system, total)
+	}
+}
+
+func TestGetMemoryLoad_Probe(t *testing.T) {
+	t.Cleanup(func() { SetSystemProbe(nil) })
+
+	vm := &mem.VirtualMemoryStat{Total: 1000, UsedPercent: 60}
+	SetSystemProbe(&fakeProbe{rss: 250, vm: vm})
+	service, system, total, serviceF, systemF, totalF := GetMemoryLoad()
+	if service != "25.00%" || system != "60.00%" || total != "1000.00" {
+		t.Errorf("unexpected memory load %q %q %q", service, system, total)
+	}
+	if serviceF != 25 || systemF != 60 || totalF != 1000 {
+		t.Errorf("unexpected memory load values %v %v %v", serviceF, systemF, totalF)
+	}
+
+	SetSystemProbe(&fakeProbe{rssErr: errProbe, vm: vm})
+	if service, system, _, serviceF, _, _ := GetMemoryLoad(); service != "0%" || serviceF != 0 || system != "60.00%" {
+		t.Errorf("expected the service memory to fall back to 0%%, got %q %q", service, system)
+	}
+
+	SetSystemProbe(&fakeProbe{vmErr: errProbe})
+	if service, system, total, _, _, _ := GetMemoryLoad(); service != "0%" || system != "0%" || total != "0%" {
+		t.Errorf("expected all memory loads to fall back to 0%%, got %q %q %q", service, system, total)
+	}
+}
+
+func TestGetDiskLoad_Probe(t *testing.T) {
+	t.Cleanup(func() { SetSystemProbe(nil) })
+
+	SetSystemProbe(&fakeProbe{disk: &disk.UsageStat{Total: 2048, UsedPercent: 75.5}})
+	service, system, total, systemF, totalF := GetDiskLoad()
+	if service != "0%" || system != "75.50%" || total != "2048.00" {
+		t.Errorf("unexpected disk load %q %q %q", service, system, total)
+	}
+	if systemF != 75.5 || totalF != 2048 {
+		t.Errorf("unexpected disk load values %v %v", systemF, totalF)
+	}
+
+	SetSystemProbe(&fakeProbe{diskErr: errProbe})
+	if service, system, total, systemF, totalF := GetDiskLoad(); service != "0%" || system != "0%" || total != "0%" || systemF != 0 || totalF != 0 {
+		t.Errorf("expected the disk load to fall back to 0%%, got %q %q %q", service, system, total)
+	}
+}
+
+func TestSetSystemProbe_NilRestoresDefault(t *testing.T) {
+	SetSystemProbe(&fakeProbe{})
+	SetSystemProbe(nil)
+	if _, ok := getSystemProbe().(gopsutilProbe); !ok {
+		t.Errorf("expected the gopsutil probe, got %T", getSystemProbe())
+	}
+}
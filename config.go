@@ -0,0 +1,116 @@
+package monigo
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+
+	"github.com/iyashjayesh/monigo/api"
+	"github.com/iyashjayesh/monigo/common"
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/exporters"
+	"github.com/iyashjayesh/monigo/models"
+)
+
+// Defaults applied to unset configuration fields.
+const (
+	defaultSyncFrequency   = "5m"
+	defaultRetentionPeriod = "7d"
+	defaultMaxUsage        = 95
+	defaultMaxGoRoutines   = 100
+	defaultSamplingRate    = 100
+)
+
+// redacted replaces secret configuration values.
+const redacted = "***"
+
+// MonigoConfig is a snapshot of an instance's effective configuration.
+type MonigoConfig = models.MonigoConfig
+
+// Config returns the effective configuration of m, with defaults applied and
+// secrets such as OTel header values replaced by "***", e.g. for support logs.
+func (m *Monigo) Config() MonigoConfig {
+	cfg := MonigoConfig{
+		ServiceName:             m.ServiceName,
+		DashboardTitle:          common.DefaultIfEmpty(m.DashboardTitle, m.ServiceName),
+		ServiceDescription:      m.ServiceDescription,
+		DashboardPort:           m.DashboardPort,
+		AutoPort:                m.AutoPort,
+		Headless:                m.Headless,
+		APIPath:                 common.DefaultIfEmpty(m.CustomBaseAPIPath, baseAPIPath),
+		DataPointsSyncFrequency: common.DefaultIfEmpty(m.DataPointsSyncFrequency, defaultSyncFrequency),
+		DataRetentionPeriod:     common.DefaultIfEmpty(m.DataRetentionPeriod, defaultRetentionPeriod),
+		TimeZone:                common.DefaultIfEmpty(m.TimeZone, "Local"),
+		MaxCPUUsage:             common.DefaultFloatIfZero(m.MaxCPUUsage, defaultMaxUsage),
+		MaxMemoryUsage:          common.DefaultFloatIfZero(m.MaxMemoryUsage, defaultMaxUsage),
+		MaxGoRoutines:           common.DefaultIntIfZero(m.MaxGoRoutines, defaultMaxGoRoutines),
+		SamplingRate:            common.DefaultIntIfZero(m.SamplingRate, defaultSamplingRate),
+		StorageType:             common.DefaultIfEmpty(m.StorageType, "disk"),
+		StorageWAL:              m.StorageWAL,
+		PrettyJSON:              m.PrettyJSON,
+		ProfileReportTypes:      slices.Clone(m.ProfileReportTypes),
+		HostLabel:               m.HostLabel,
+		Tags:                    maps.Clone(m.Tags),
+		PrometheusMetrics:       slices.Clone(m.PrometheusMetrics),
+		LoadWindowSize:          m.LoadWindowSize,
+		FunctionHistorySize:     m.FunctionHistorySize,
+		ByteUnit:                common.DefaultIfEmpty(m.ByteUnit, "auto"),
+		MaxResponsePoints:       common.DefaultIntIfZero(m.MaxResponsePoints, api.DefaultMaxResponsePoints),
+		EnableSignalDump:        m.EnableSignalDump,
+		Isolated:                m.Isolated,
+		CounterStorage:          common.DefaultIfEmpty(m.CounterStorage, "total"),
+		ClockSkewPolicy:         common.DefaultIfEmpty(m.ClockSkewPolicy, "clamp"),
+		Telemetry:               m.Telemetry,
+		TelemetryEndpoint:       redactURL(m.TelemetryEndpoint),
+		OTelLogsEndpoint:        m.OTelLogsEndpoint,
+		AuthConfigured:          m.AuthFunction != nil,
+		AdminConfigured:         m.hasAdminGuard(),
+	}
+
+	// Once set up, the thresholds and sampling rate in use may have been
+	// changed through the admin endpoints.
+	if m.live {
+		thresholds := core.ServiceThresholdsFor(m.namespace())
+		cfg.MaxCPUUsage = thresholds.MaxCPUUsage
+		cfg.MaxMemoryUsage = thresholds.MaxMemoryUsage
+		cfg.MaxGoRoutines = thresholds.MaxGoRoutines
+		cfg.SamplingRate = core.SamplingRate()
+	}
+	if m.HealthWarmup > 0 {
+		cfg.HealthWarmup = m.HealthWarmup.String()
+	}
+	if m.MaxProfileAge > 0 {
+		cfg.MaxProfileAge = m.MaxProfileAge.String()
+	}
+	if m.StatsCacheTTL > 0 {
+		cfg.StatsCacheTTL = m.StatsCacheTTL.String()
+	}
+	if m.OTelEndpoint != "" {
+		cfg.OTelEndpoint = m.OTelEndpoint
+		cfg.OTelProtocol = common.DefaultIfEmpty(m.OTelProtocol, exporters.ProtocolGRPC)
+		cfg.OTelResourceAttributes = maps.Clone(m.OTelResourceAttributes)
+		interval := m.OTelExportInterval
+		if interval == 0 {
+			interval = exporters.DefaultExportInterval
+		}
+		cfg.OTelExportInterval = interval.String()
+		cfg.OTelMetricsAllow = slices.Clone(m.OTelMetricsAllow)
+		cfg.OTelMetricsDeny = slices.Clone(m.OTelMetricsDeny)
+	}
+	if len(m.OTelHeaders) > 0 {
+		cfg.OTelHeaders = make(map[string]string, len(m.OTelHeaders))
+		for k := range m.OTelHeaders {
+			cfg.OTelHeaders[k] = redacted
+		}
+	}
+	return cfg
+}
+
+// redactURL hides the password of a URL's user info.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
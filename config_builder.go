@@ -3,8 +3,14 @@ package monigo
 import (
 	"log/slog"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/iyashjayesh/monigo/common"
+	"github.com/iyashjayesh/monigo/internal/exporter"
 	"github.com/iyashjayesh/monigo/internal/logger"
+	"github.com/iyashjayesh/monigo/timeseries"
+	"google.golang.org/grpc"
 )
 
 // MonigoBuilder is the builder for the Monigo struct
@@ -25,13 +31,25 @@ func (b *MonigoBuilder) WithServiceName(serviceName string) *MonigoBuilder {
 	return b
 }
 
+// WithDashboardTitle sets the title the dashboard displays (default: the service name)
+func (b *MonigoBuilder) WithDashboardTitle(title string) *MonigoBuilder {
+	b.config.DashboardTitle = title
+	return b
+}
+
+// WithServiceDescription sets a description of the service for the dashboard to display
+func (b *MonigoBuilder) WithServiceDescription(description string) *MonigoBuilder {
+	b.config.ServiceDescription = description
+	return b
+}
+
 // WithPort sets the dashboard port
 func (b *MonigoBuilder) WithPort(port int) *MonigoBuilder {
 	b.config.DashboardPort = port
 	return b
 }
 
-// WithRetentionPeriod sets the data retention period
+// WithRetentionPeriod sets the data retention period (units: s, m, h, d, w, mo, y; e.g. "2w")
 func (b *MonigoBuilder) WithRetentionPeriod(period string) *MonigoBuilder {
 	b.config.DataRetentionPeriod = period
 	return b
@@ -73,6 +91,12 @@ func (b *MonigoBuilder) WithMaxGoRoutines(routines int) *MonigoBuilder {
 	return b
 }
 
+// WithHealthWarmup reports health as initializing instead of scoring it for the given period after startup
+func (b *MonigoBuilder) WithHealthWarmup(period time.Duration) *MonigoBuilder {
+	b.config.HealthWarmup = period
+	return b
+}
+
 // WithDashboardMiddleware sets the dashboard middleware
 func (b *MonigoBuilder) WithDashboardMiddleware(middleware ...func(http.Handler) http.Handler) *MonigoBuilder {
 	b.config.DashboardMiddleware = middleware
@@ -85,42 +109,226 @@ func (b *MonigoBuilder) WithAPIMiddleware(middleware ...func(http.Handler) http.
 	return b
 }
 
+// WithAdminMiddleware sets the middleware that guards the admin endpoints.
+// Admin endpoints are only served when this or an auth function is set.
+func (b *MonigoBuilder) WithAdminMiddleware(middleware ...func(http.Handler) http.Handler) *MonigoBuilder {
+	b.config.AdminMiddleware = middleware
+	return b
+}
+
 // WithAuthFunction sets the custom authentication function
 func (b *MonigoBuilder) WithAuthFunction(authFunc func(*http.Request) bool) *MonigoBuilder {
 	b.config.AuthFunction = authFunc
 	return b
 }
 
+// WithHostLabel overrides the host label on stored metrics (defaults to the hostname)
+func (b *MonigoBuilder) WithHostLabel(host string) *MonigoBuilder {
+	b.config.HostLabel = host
+	return b
+}
+
+// WithTags sets extra labels (e.g. "env": "prod") attached to every stored and exported metric
+func (b *MonigoBuilder) WithTags(tags map[string]string) *MonigoBuilder {
+	b.config.Tags = tags
+	return b
+}
+
+// WithPrometheusMetrics exposes the latest stored value of each metric (e.g. "heap_alloc_by_service") via Prometheus
+func (b *MonigoBuilder) WithPrometheusMetrics(metrics ...string) *MonigoBuilder {
+	b.config.PrometheusMetrics = metrics
+	return b
+}
+
 // WithSamplingRate sets the sampling rate for function tracing
 func (b *MonigoBuilder) WithSamplingRate(rate int) *MonigoBuilder {
 	b.config.SamplingRate = rate
 	return b
 }
 
+// WithLoadWindowSize sets how many recent samples are averaged for the overall load
+func (b *MonigoBuilder) WithLoadWindowSize(n int) *MonigoBuilder {
+	b.config.LoadWindowSize = n
+	return b
+}
+
+// WithFunctionHistorySize sets how many recent executions are kept per traced function
+func (b *MonigoBuilder) WithFunctionHistorySize(n int) *MonigoBuilder {
+	b.config.FunctionHistorySize = n
+	return b
+}
+
+// WithByteUnit sets the unit memory fields of the metrics API are formatted in:
+// "auto" (default), "bytes", "KB", "MB", "GB" or "TB"
+func (b *MonigoBuilder) WithByteUnit(unit string) *MonigoBuilder {
+	b.config.ByteUnit = unit
+	return b
+}
+
+// WithMaxResponsePoints sets how many points the service-metrics API returns
+// before it downsamples (default: 5000)
+func (b *MonigoBuilder) WithMaxResponsePoints(n int) *MonigoBuilder {
+	b.config.MaxResponsePoints = n
+	return b
+}
+
+// WithSignalDump sets whether SIGUSR1 logs a stats snapshot (unix only)
+func (b *MonigoBuilder) WithSignalDump(enabled bool) *MonigoBuilder {
+	b.config.EnableSignalDump = enabled
+	return b
+}
+
+// WithMaxProfileAge refuses to render function profiles captured longer ago
+// than maxAge, since they may not match the current metrics (default: 0, any age)
+func (b *MonigoBuilder) WithMaxProfileAge(maxAge time.Duration) *MonigoBuilder {
+	b.config.MaxProfileAge = maxAge
+	return b
+}
+
+// WithStatsCacheTTL serves a collected service stats snapshot to callers for
+// the given period instead of collecting again on every request
+func (b *MonigoBuilder) WithStatsCacheTTL(ttl time.Duration) *MonigoBuilder {
+	b.config.StatsCacheTTL = ttl
+	return b
+}
+
 // WithStorageType sets the storage type ("disk" or "memory")
 func (b *MonigoBuilder) WithStorageType(storageType string) *MonigoBuilder {
 	b.config.StorageType = storageType
 	return b
 }
 
+// WithStorageWAL sets whether rows are written to a write-ahead log before
+// being stored, so rows a crash kept from storage are stored on the next start
+func (b *MonigoBuilder) WithStorageWAL(enabled bool) *MonigoBuilder {
+	b.config.StorageWAL = enabled
+	return b
+}
+
+// WithCounterStorage sets how network and disk counters are stored: "total"
+// (default), per-second "rate" (e.g. bytes_sent_per_second) or "both"
+func (b *MonigoBuilder) WithCounterStorage(mode string) *MonigoBuilder {
+	b.config.CounterStorage = mode
+	return b
+}
+
+// WithClockSkewPolicy sets how samples are stored when the clock jumps
+// backwards: "clamp" (default) stores them just after the last one, "skip" drops them
+func (b *MonigoBuilder) WithClockSkewPolicy(policy string) *MonigoBuilder {
+	b.config.ClockSkewPolicy = policy
+	return b
+}
+
+// WithIsolation scopes the instance's stored metrics and traced functions to
+// its service name, for running several instances in one process
+func (b *MonigoBuilder) WithIsolation(isolated bool) *MonigoBuilder {
+	b.config.Isolated = isolated
+	return b
+}
+
+// WithAutoPort sets whether the dashboard scans for the next free port
+// instead of failing when the configured one is taken
+func (b *MonigoBuilder) WithAutoPort(autoPort bool) *MonigoBuilder {
+	b.config.AutoPort = autoPort
+	return b
+}
+
+// WithOnReady sets the callback invoked with the bound port once the dashboard is listening
+func (b *MonigoBuilder) WithOnReady(fn func(port int)) *MonigoBuilder {
+	b.config.OnReady = fn
+	return b
+}
+
 // WithHeadless sets whether the dashboard should be started
 func (b *MonigoBuilder) WithHeadless(headless bool) *MonigoBuilder {
 	b.config.Headless = headless
 	return b
 }
 
+// WithPrettyJSON sets whether API responses are indented by default
+func (b *MonigoBuilder) WithPrettyJSON(pretty bool) *MonigoBuilder {
+	b.config.PrettyJSON = pretty
+	return b
+}
+
+// WithProfileReportTypes sets the pprof report types the function-details endpoint accepts
+func (b *MonigoBuilder) WithProfileReportTypes(types ...string) *MonigoBuilder {
+	b.config.ProfileReportTypes = types
+	return b
+}
+
+// WithTelemetry opts in to a one-time anonymous usage report sent to endpoint
+// at startup. An empty endpoint disables it.
+func (b *MonigoBuilder) WithTelemetry(endpoint string) *MonigoBuilder {
+	b.config.Telemetry = endpoint != ""
+	b.config.TelemetryEndpoint = endpoint
+	return b
+}
+
 // WithOTelEndpoint sets the OTLP gRPC endpoint for OpenTelemetry export (e.g. "localhost:4317")
 func (b *MonigoBuilder) WithOTelEndpoint(endpoint string) *MonigoBuilder {
 	b.config.OTelEndpoint = endpoint
 	return b
 }
 
+// WithOTelProtocol sets the OTLP transport protocol: "grpc" (default) or "http"
+func (b *MonigoBuilder) WithOTelProtocol(protocol string) *MonigoBuilder {
+	b.config.OTelProtocol = protocol
+	return b
+}
+
+// WithOTelResourceAttributes sets extra OTel resource attributes (e.g. "deployment.environment")
+func (b *MonigoBuilder) WithOTelResourceAttributes(attrs map[string]string) *MonigoBuilder {
+	b.config.OTelResourceAttributes = attrs
+	return b
+}
+
+// WithOTelExportInterval sets how often metrics are pushed to the OTel collector (default 30s, minimum 1s)
+func (b *MonigoBuilder) WithOTelExportInterval(interval time.Duration) *MonigoBuilder {
+	b.config.OTelExportInterval = interval
+	return b
+}
+
+// WithOTelMetricsAllow only exports the registry metrics matching the names or globs (e.g. "monigo_goroutines", "monigo_*_health_percent") via OTel
+func (b *MonigoBuilder) WithOTelMetricsAllow(patterns ...string) *MonigoBuilder {
+	b.config.OTelMetricsAllow = patterns
+	return b
+}
+
+// WithOTelMetricsDeny never exports the registry metrics matching the names or globs via OTel
+func (b *MonigoBuilder) WithOTelMetricsDeny(patterns ...string) *MonigoBuilder {
+	b.config.OTelMetricsDeny = patterns
+	return b
+}
+
+// WithOTelLogsEndpoint also sends monigo's own logs to the OTLP/HTTP endpoint (e.g. "localhost:4318")
+func (b *MonigoBuilder) WithOTelLogsEndpoint(endpoint string) *MonigoBuilder {
+	b.config.OTelLogsEndpoint = endpoint
+	return b
+}
+
 // WithOTelHeaders sets optional headers for the OTel exporter
 func (b *MonigoBuilder) WithOTelHeaders(headers map[string]string) *MonigoBuilder {
 	b.config.OTelHeaders = headers
 	return b
 }
 
+// WithOTelHTTPClient sets the client sending OTLP/HTTP metrics and logs, e.g. for
+// a proxy, timeouts or a custom CA bundle (https is used when its *http.Transport
+// has a TLSClientConfig)
+func (b *MonigoBuilder) WithOTelHTTPClient(client *http.Client) *MonigoBuilder {
+	b.config.OTelHTTPClient = client
+	return b
+}
+
+// WithOTelDialOptions sets gRPC dial options for the OTLP/gRPC connection, e.g.
+// grpc.WithTransportCredentials or grpc.WithContextDialer; they take precedence
+// over MoniGo's own
+func (b *MonigoBuilder) WithOTelDialOptions(opts ...grpc.DialOption) *MonigoBuilder {
+	b.config.OTelDialOptions = opts
+	return b
+}
+
 // WithLogLevel sets the log level for monigo's structured logger
 func (b *MonigoBuilder) WithLogLevel(level slog.Level) *MonigoBuilder {
 	logger.Init(level)
@@ -145,8 +353,57 @@ func (b *MonigoBuilder) Build() *Monigo {
 	if b.config.SamplingRate < 0 {
 		panic("[MoniGo] Build() failed: SamplingRate must be >= 0")
 	}
+	if b.config.HealthWarmup < 0 {
+		panic("[MoniGo] Build() failed: HealthWarmup must be >= 0")
+	}
+	if b.config.MaxProfileAge < 0 {
+		panic("[MoniGo] Build() failed: MaxProfileAge must be >= 0")
+	}
+	if b.config.StatsCacheTTL < 0 {
+		panic("[MoniGo] Build() failed: StatsCacheTTL must be >= 0")
+	}
+	if b.config.LoadWindowSize < 0 {
+		panic("[MoniGo] Build() failed: LoadWindowSize must be >= 0")
+	}
+	if b.config.FunctionHistorySize < 0 {
+		panic("[MoniGo] Build() failed: FunctionHistorySize must be >= 0")
+	}
+	if b.config.MaxResponsePoints < 0 {
+		panic("[MoniGo] Build() failed: MaxResponsePoints must be >= 0")
+	}
+	if _, err := common.ParseByteUnit(b.config.ByteUnit); err != nil {
+		panic("[MoniGo] Build() failed: ByteUnit " + err.Error())
+	}
 	if b.config.StorageType != "" && b.config.StorageType != "disk" && b.config.StorageType != "memory" {
 		panic("[MoniGo] Build() failed: StorageType must be 'disk' or 'memory'")
 	}
+	if _, err := timeseries.ParseCounterStorage(b.config.CounterStorage); err != nil {
+		panic("[MoniGo] Build() failed: CounterStorage " + err.Error())
+	}
+	if _, err := timeseries.ParseClockSkewPolicy(b.config.ClockSkewPolicy); err != nil {
+		panic("[MoniGo] Build() failed: ClockSkewPolicy " + err.Error())
+	}
+	if b.config.DataRetentionPeriod != "" {
+		if _, err := common.ParseRetention(b.config.DataRetentionPeriod); err != nil {
+			panic("[MoniGo] Build() failed: DataRetentionPeriod " + err.Error())
+		}
+	}
+	if _, ok := b.config.Tags["host"]; ok {
+		panic("[MoniGo] Build() failed: Tags must not contain the reserved 'host' key. Use WithHostLabel()")
+	}
+	if b.config.TelemetryEndpoint != "" {
+		if u, err := url.Parse(b.config.TelemetryEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			panic("[MoniGo] Build() failed: TelemetryEndpoint must be an http(s) URL")
+		}
+	}
+	if b.config.OTelProtocol != "" && b.config.OTelProtocol != "grpc" && b.config.OTelProtocol != "http" {
+		panic("[MoniGo] Build() failed: OTelProtocol must be 'grpc' or 'http'")
+	}
+	if b.config.OTelExportInterval < 0 || (b.config.OTelExportInterval > 0 && b.config.OTelExportInterval < time.Second) {
+		panic("[MoniGo] Build() failed: OTelExportInterval must be at least 1s")
+	}
+	if err := (exporter.MetricFilter{Allow: b.config.OTelMetricsAllow, Deny: b.config.OTelMetricsDeny}).Validate(); err != nil {
+		panic("[MoniGo] Build() failed: OTel metrics filter has an " + err.Error())
+	}
 	return b.config
 }
@@ -2,6 +2,7 @@ package monigo
 
 import (
 	"testing"
+	"time"
 )
 
 func TestBuilderValidBuild(t *testing.T) {
@@ -56,6 +57,96 @@ func TestBuilderInvalidStorageType(t *testing.T) {
 	NewBuilder().WithServiceName("test").WithStorageType("redis").Build()
 }
 
+func TestBuilderInvalidOTelProtocol(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for invalid OTel protocol")
+		}
+	}()
+
+	NewBuilder().WithServiceName("test").WithOTelProtocol("udp").Build()
+}
+
+func TestBuilderInvalidOTelExportInterval(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for OTel export interval below 1s")
+		}
+	}()
+
+	NewBuilder().WithServiceName("test").WithOTelExportInterval(100 * time.Millisecond).Build()
+}
+
+func TestBuilderInvalidOTelMetricsPattern(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for a malformed OTel metrics pattern")
+		}
+	}()
+
+	NewBuilder().WithServiceName("test").WithOTelMetricsDeny("monigo_[").Build()
+}
+
+func TestBuilderNegativeHealthWarmup(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for a negative health warmup")
+		}
+	}()
+
+	NewBuilder().WithServiceName("test").WithHealthWarmup(-time.Second).Build()
+}
+
+func TestBuilderInvalidRetentionPeriod(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for invalid retention period")
+		}
+	}()
+
+	NewBuilder().WithServiceName("test").WithRetentionPeriod("7x").Build()
+}
+
+func TestBuilderReservedHostTag(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for reserved 'host' tag")
+		}
+	}()
+
+	NewBuilder().WithServiceName("test").WithTags(map[string]string{"host": "x"}).Build()
+}
+
+func TestBuilderInvalidLoadWindowSize(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for negative LoadWindowSize")
+		}
+	}()
+
+	NewBuilder().WithServiceName("test").WithLoadWindowSize(-1).Build()
+}
+
+func TestBuilderInvalidByteUnit(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for unknown ByteUnit")
+		}
+	}()
+
+	NewBuilder().WithServiceName("test").WithByteUnit("PB").Build()
+}
+
+func TestBuilderNegativeMaxResponsePoints(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for negative MaxResponsePoints")
+		}
+	}()
+
+	NewBuilder().WithServiceName("test").WithMaxResponsePoints(-1).Build()
+}
+
 func TestBuilderDefaultStorageType(t *testing.T) {
 	// Empty storage type should be allowed (defaults at runtime)
 	m := NewBuilder().WithServiceName("test").Build()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"runtime"
 	"testing"
+	"time"
 )
 
 func BenchmarkGetServiceStats(b *testing.B) {
@@ -48,6 +49,17 @@ func BenchmarkTraceFunction(b *testing.B) {
 	}
 }
 
+func BenchmarkTraceFunction_Lightweight(b *testing.B) {
+	SetSamplingRate(1000)
+	SetLightweightTracing(true)
+	defer SetLightweightTracing(false)
+	f := func() {}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TraceFunction(context.Background(), f)
+	}
+}
+
 func BenchmarkTraceFunctionWithArgs(b *testing.B) {
 	SetSamplingRate(1000)
 	f := func(a int, s string) {}
@@ -56,3 +68,39 @@ func BenchmarkTraceFunctionWithArgs(b *testing.B) {
 		TraceFunctionWithArgs(context.Background(), f, 42, "test")
 	}
 }
+
+func BenchmarkReadMemStats(b *testing.B) {
+	defer SetMemStatsInterval(defaultMemStatsInterval)
+
+	b.Run("uncached", func(b *testing.B) {
+		SetMemStatsInterval(0)
+		for i := 0; i < b.N; i++ {
+			ReadMemStats()
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		SetMemStatsInterval(time.Hour)
+		for i := 0; i < b.N; i++ {
+			ReadMemStats()
+		}
+	})
+}
+
+func BenchmarkTraceFunctionWithReturns(b *testing.B) {
+	SetSamplingRate(1000)
+	defer SetSignatureCaching(true)
+	f := func(a int, s string) (int, error) { return a + len(s), nil }
+
+	b.Run("uncached", func(b *testing.B) {
+		SetSignatureCaching(false)
+		for i := 0; i < b.N; i++ {
+			TraceFunctionWithReturns(context.Background(), f, 42, "test")
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		SetSignatureCaching(true)
+		for i := 0; i < b.N; i++ {
+			TraceFunctionWithReturns(context.Background(), f, 42, "test")
+		}
+	})
+}
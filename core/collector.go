@@ -0,0 +1,59 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/iyashjayesh/monigo/internal/logger"
+	"github.com/iyashjayesh/monigo/models"
+)
+
+// Collector supplies app-specific metrics, such as queue depth or cache hit
+// rate, that are stored alongside the built-in ones every sync cycle.
+// models.Row is the same type as timeseries.Row.
+//
+// Rows without labels get the service's series labels, and rows without a
+// timestamp get the time of the sync cycle.
+type Collector interface {
+	Collect() []models.Row
+}
+
+var (
+	collectorsMu sync.RWMutex
+	collectors   []Collector
+)
+
+// RegisterCollector adds c to the collectors run each sync cycle. A nil
+// collector is ignored.
+func RegisterCollector(c Collector) {
+	if c == nil {
+		return
+	}
+	collectorsMu.Lock()
+	defer collectorsMu.Unlock()
+	collectors = append(collectors, c)
+}
+
+// CollectRows runs every registered collector and returns their rows. A
+// collector that panics is logged and skipped.
+func CollectRows() []models.Row {
+	collectorsMu.RLock()
+	registered := append([]Collector(nil), collectors...)
+	collectorsMu.RUnlock()
+
+	var rows []models.Row
+	for _, c := range registered {
+		rows = append(rows, collectSafely(c)...)
+	}
+	return rows
+}
+
+func collectSafely(c Collector) (rows []models.Row) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Log.Error("collector panicked", "collector", fmt.Sprintf("%T", c), "panic", r)
+			rows = nil
+		}
+	}()
+	return c.Collect()
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/iyashjayesh/monigo/models"
+)
+
+type collectorFunc func() []models.Row
+
+func (f collectorFunc) Collect() []models.Row { return f() }
+
+func TestCollectRows_SkipsPanickingCollector(t *testing.T) {
+	RegisterCollector(nil)
+	RegisterCollector(collectorFunc(func() []models.Row { panic("boom") }))
+	RegisterCollector(collectorFunc(func() []models.Row {
+		return []models.Row{{Metric: "cache_hit_rate", DataPoint: models.DataPoint{Value: 0.9}}}
+	}))
+
+	rows := CollectRows()
+	if len(rows) != 1 || rows[0].Metric != "cache_hit_rate" {
+		t.Errorf("expected only the healthy collector's row, got %+v", rows)
+	}
+}
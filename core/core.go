@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
@@ -13,64 +14,130 @@ import (
 	"github.com/iyashjayesh/monigo/models"
 	"github.com/shirou/gopsutil/cpu"
 	"github.com/shirou/gopsutil/mem"
-	"github.com/shirou/gopsutil/net"
 )
 
+// CollectOptions selects the sections of ServiceStats that
+// GetServiceStatsSelective collects. CoreStatistics are always collected.
+type CollectOptions struct {
+	CPU     bool // CPUStatistics; samples the CPU for a second
+	Memory  bool // MemoryStatistics and the heap allocation fields
+	Load    bool // LoadStatistics
+	Disk    bool // DiskIO
+	Network bool // NetworkIO, which also feeds the network throughput
+	Health  bool // Health; implies CPU and Memory, which it is scored from
+}
+
+// CollectAll collects every section, as GetServiceStats does.
+var CollectAll = CollectOptions{CPU: true, Memory: true, Load: true, Disk: true, Network: true, Health: true}
+
 // GetServiceStats collects statistics related to service and system performance.
-func GetServiceStats(_ context.Context) models.ServiceStats {
+// Byte-valued fields are formatted in the unit set by WithByteUnit or SetByteUnit.
+func GetServiceStats(ctx context.Context) models.ServiceStats {
+	return GetServiceStatsSelective(ctx, CollectAll)
+}
+
+// GetServiceStatsSelective collects the sections of ServiceStats selected by
+// opts, leaving the others zero, e.g. to skip the CPU sampling and health
+// scoring when only memory is needed. Within the TTL set by SetStatsCacheTTL
+// the previous snapshot for the same sections and byte unit is returned.
+// Health is scored against the thresholds of ctx's namespace.
+func GetServiceStatsSelective(ctx context.Context, opts CollectOptions) models.ServiceStats {
+	if opts.Health {
+		opts.CPU, opts.Memory = true, true
+	}
+	unit := byteUnitFromContext(ctx)
+	stats := cachedServiceStats(statsCacheKey{unit: unit, opts: opts}, func() models.ServiceStats {
+		return collectStats(unit, opts)
+	})
+	if opts.Health {
+		// The snapshot is scored against the default thresholds.
+		serviceHealthThresholds.mu.RLock()
+		thresholds, ok := namespaceThresholds(NamespaceFromContext(ctx))
+		serviceHealthThresholds.mu.RUnlock()
+		if ok {
+			stats.Health = serviceHealth(&stats, thresholds)
+		}
+	}
+	return stats
+}
+
+// collectStats collects the sections of ServiceStats selected by opts;
+// replaceable in tests.
+var collectStats = collectServiceStats
+
+// collectServiceStats collects the sections selected by opts, formatting
+// byte-valued fields in unit.
+func collectServiceStats(unit string, opts CollectOptions) models.ServiceStats {
 	var stats models.ServiceStats
 	stats.CoreStatistics = GetCoreStatistics()
 
 	var wg sync.WaitGroup
-	wg.Add(6)
+	collect := func(enabled bool, fn func()) {
+		if !enabled {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
 
-	// Goroutine to fetch load statistics
-	go func() {
-		defer wg.Done()
+	// Load statistics
+	collect(opts.Load, func() {
 		stats.LoadStatistics = GetLoadStatistics()
-	}()
+	})
 
-	// Goroutine to fetch memory statistics
-	go func() {
-		defer wg.Done()
-		stats.MemoryStatistics = GetMemoryStatistics()
-	}()
+	// Memory statistics
+	collect(opts.Memory, func() {
+		stats.MemoryStatistics = memoryStatistics(unit)
+	})
 
-	// Goroutine to fetch CPU statistics
-	go func() {
-		defer wg.Done()
+	// CPU statistics
+	collect(opts.CPU, func() {
 		stats.CPUStatistics = GetCPUStatistics()
-	}()
+	})
 
-	// Goroutine to fetch memory allocation statistics
-	go func() {
-		defer wg.Done()
+	// Memory allocation statistics
+	collect(opts.Memory, func() {
 		memStats := ReadMemStats()
-		stats.HeapAllocByService = common.BytesToUnit(memStats.HeapAlloc)
-		stats.HeapAllocBySystem = common.BytesToUnit(memStats.HeapSys)
-		stats.TotalAllocByService = common.BytesToUnit(memStats.TotalAlloc)
-		stats.TotalMemoryByOS = common.BytesToUnit(memStats.Sys)
+		stats.HeapAllocByService = common.FormatBytes(memStats.HeapAlloc, unit)
+		stats.HeapAllocBySystem = common.FormatBytes(memStats.HeapSys, unit)
+		stats.TotalAllocByService = common.FormatBytes(memStats.TotalAlloc, unit)
+		stats.TotalMemoryByOS = common.FormatBytes(memStats.Sys, unit)
 		stats.HeapAllocByServiceRaw = memStats.HeapAlloc
 		stats.HeapAllocBySystemRaw = memStats.HeapSys
 		stats.TotalAllocByServiceRaw = memStats.TotalAlloc
 		stats.TotalMemoryByOSRaw = memStats.Sys
-	}()
-
-	// Goroutine to fetch network I/O statistics
-	go func() {
-		defer wg.Done()
-		stats.NetworkIO.BytesReceived, stats.NetworkIO.BytesSent = GetNetworkIO()
-	}()
+	})
+
+	// Network I/O statistics
+	collect(opts.Network, func() {
+		var err error
+		stats.NetworkIO.BytesReceived, stats.NetworkIO.BytesSent, err = networkIO()
+		stats.NetworkIOUnavailable = err != nil
+		if err == nil {
+			stats.NetworkThroughput.BytesReceivedPerSecond, stats.NetworkThroughput.BytesSentPerSecond = serviceNetworkThroughput.add(
+				stats.NetworkIO.BytesReceived, stats.NetworkIO.BytesSent, now())
+		}
+	})
 
-	// Goroutine to fetch disk I/O statistics
-	go func() {
-		defer wg.Done()
-		stats.DiskIO.ReadBytes, stats.DiskIO.WriteBytes = GetDiskIO()
-	}()
+	// Disk I/O statistics
+	collect(opts.Disk, func() {
+		var err error
+		stats.DiskIO.ReadBytes, stats.DiskIO.WriteBytes, err = diskIO()
+		stats.DiskIOUnavailable = err != nil
+	})
 
 	wg.Wait()
 
-	stats.Health = GetServiceHealth(&stats)
+	if opts.Load {
+		// The load is derived from the same CPU and memory readings.
+		stats.LoadStatistics.Unavailable = stats.CPUStatistics.Unavailable || stats.MemoryStatistics.Unavailable
+	}
+	if opts.Health {
+		stats.Health = GetServiceHealth(&stats)
+	}
 
 	return stats
 }
@@ -99,12 +166,15 @@ func formatUptime(uptime time.Duration) string {
 func GetCoreStatistics() models.CoreStatistics {
 
 	serviceInfo := common.GetServiceInfo()
-	uptime := time.Since(serviceInfo.ServiceStartTime)
-	uptimeFormatted := formatUptime(uptime)
+	return coreStatistics(runtime.NumGoroutine(), time.Since(serviceInfo.ServiceStartTime))
+}
 
+// coreStatistics builds the core statistics, deriving both uptime fields from uptime.
+func coreStatistics(goroutines int, uptime time.Duration) models.CoreStatistics {
 	return models.CoreStatistics{
-		Goroutines: runtime.NumGoroutine(),
-		Uptime:     uptimeFormatted,
+		Goroutines:    goroutines,
+		Uptime:        formatUptime(uptime),
+		UptimeSeconds: uptime.Seconds(),
 	}
 }
 
@@ -120,7 +190,7 @@ func GetLoadStatistics() models.LoadStatistics {
 	// Fetch disk load statistics
 	serviceDisk, systemDisk, totalDisk, systemDiskF, totalDiskF := common.GetDiskLoad()
 
-	overallLoadF, overallLoadStr := CalculateOverallLoad(serviceCPUF, serviceMemF)
+	overallLoadF, overallLoadStr := calculateLoad(serviceCPUF, serviceMemF, systemDiskF)
 
 	return models.LoadStatistics{
 		ServiceCPULoad:          serviceCPULoad,
@@ -161,10 +231,15 @@ func CalculateOverallLoad(serviceCPUF, serviceMemF float64) (float64, string) {
 func GetCPUStatistics() models.CPUStatistics {
 	var cpuStats models.CPUStatistics
 
-	sysCPUPercent, err := GetCPUPrecent()
+	// Per-core usage samples for a second as well, so read it concurrently.
+	perCore := make(chan []float64, 1)
+	go func() { perCore <- common.GetPerCoreCPU() }()
+
+	sysCPUPercent, err := systemCPUPercent()
 	if err != nil {
 		logger.Log.Error("Error fetching system CPU percent", "error", err)
 		sysCPUPercent = 0
+		cpuStats.Unavailable = true
 	}
 	memInfo, err := GetVirtualMemoryStats()
 	if err != nil {
@@ -172,10 +247,11 @@ func GetCPUStatistics() models.CPUStatistics {
 		memInfo = mem.VirtualMemoryStat{}
 	}
 
-	procCPUPercent, _, err := getProcessUsage(common.GetProcessObject(), &memInfo)
+	procCPUPercent, _, err := getProcessUsage(&memInfo)
 	if err != nil {
 		logger.Log.Error("Error fetching process usage", "error", err)
 		procCPUPercent = 0
+		cpuStats.Unavailable = true
 	}
 
 	totalLogicalCores, _ := cpu.Counts(true)
@@ -191,20 +267,29 @@ func GetCPUStatistics() models.CPUStatistics {
 	// Converting CPU usage to percentage strings
 	cpuStats.CoresUsedBySystemInPercent = strconv.FormatFloat(cpuStats.CoresUsedBySystem, 'f', 2, 64) + "%"
 	cpuStats.CoresUsedByServiceInPercent = strconv.FormatFloat(cpuStats.CoresUsedByService, 'f', 2, 64) + "%"
+	cpuStats.Sensors = common.GetCPUSensors()
+	cpuStats.PerCore = <-perCore
 
 	return cpuStats
 }
 
 // GetMemoryStatistics retrieves memory statistics.
 func GetMemoryStatistics() models.MemoryStatistics {
+	return memoryStatistics(defaultByteUnit.Load().(string))
+}
 
-	memInfo, err := mem.VirtualMemory() // Fetcing system memory statistics
+// memoryStatistics retrieves memory statistics with byte-valued fields formatted in unit.
+func memoryStatistics(unit string) models.MemoryStatistics {
+
+	unavailable := false
+	memInfo, err := virtualMemory() // Fetcing system memory statistics
 	if err != nil {
 		logger.Log.Error("Error fetching virtual memory info", "error", err)
-		return models.MemoryStatistics{}
+		memInfo = &mem.VirtualMemoryStat{}
+		unavailable = true
 	}
 
-	swapInfo, err := mem.SwapMemory() // Fetching swap memory statistics
+	swapInfo, err := swapMemory() // Fetching swap memory statistics
 	if err != nil {
 		logger.Log.Error("Error fetching swap memory info", "error", err)
 		// valid SwapMemory struct to prevent nil pointer later if used, or continue with zeroed swapInfo
@@ -213,13 +298,13 @@ func GetMemoryStatistics() models.MemoryStatistics {
 
 	m := ReadMemStats() // Get the memory statistics for the service
 	return models.MemoryStatistics{
-		TotalSystemMemory:      common.BytesToUnit(memInfo.Total),
-		MemoryUsedBySystem:     common.BytesToUnit(memInfo.Used),
-		AvailableMemory:        common.BytesToUnit(memInfo.Available),
-		TotalSwapMemory:        common.BytesToUnit(swapInfo.Total),
-		FreeSwapMemory:         common.BytesToUnit(swapInfo.Free),
-		MemoryUsedByService:    common.BytesToUnit(m.Alloc), // Example metric
-		StackMemoryUsage:       common.BytesToUnit(m.StackInuse),
+		TotalSystemMemory:      common.FormatBytes(memInfo.Total, unit),
+		MemoryUsedBySystem:     common.FormatBytes(memInfo.Used, unit),
+		AvailableMemory:        common.FormatBytes(memInfo.Available, unit),
+		TotalSwapMemory:        common.FormatBytes(swapInfo.Total, unit),
+		FreeSwapMemory:         common.FormatBytes(swapInfo.Free, unit),
+		MemoryUsedByService:    common.FormatBytes(m.Alloc, unit), // Example metric
+		StackMemoryUsage:       common.FormatBytes(m.StackInuse, unit),
 		GCPauseDuration:        fmt.Sprintf("%.2f ms", float64(m.PauseTotalNs)/float64(time.Millisecond)), // Convert nanoseconds to milliseconds
 		MemStatsRecords:        ConstructMemStats(m),
 		RawMemStatsRecords:     ConstructRawMemStats(m),
@@ -229,6 +314,7 @@ func GetMemoryStatistics() models.MemoryStatistics {
 		AvailableMemoryRaw:     float64(memInfo.Available),
 		GCPauseDurationRaw:     float64(m.PauseTotalNs) / float64(time.Millisecond),
 		StackMemoryUsageRaw:    float64(m.StackInuse),
+		Unavailable:            unavailable,
 	}
 }
 
@@ -264,16 +350,22 @@ func ConstructMemStats(memStats *runtime.MemStats) []models.Record {
 		newRecord("GCCPUFraction", "Fraction of this program's available CPU time used by the GC.", memStats.GCCPUFraction),
 	}
 
-	return r
+	return dedupeRecords(r, func(rec models.Record) string { return rec.Name })
 }
 
 // GetNetworkIO retrieves network I/O statistics.
 func GetNetworkIO() (float64, float64) {
+	received, sent, _ := networkIO()
+	return received, sent
+}
+
+// networkIO is GetNetworkIO, also reporting whether the counters could be read.
+func networkIO() (float64, float64, error) {
 	// Fetch network I/O statistics
-	netIO, err := net.IOCounters(true)
+	netIO, err := netIOCounters(true)
 	if err != nil {
 		logger.Log.Error("Error fetching network I/O statistics", "error", err)
-		return 0, 0
+		return 0, 0, err
 	}
 
 	var totalBytesReceived, totalBytesSent float64
@@ -282,7 +374,7 @@ func GetNetworkIO() (float64, float64) {
 		totalBytesSent += float64(iface.BytesSent)
 	}
 
-	return totalBytesReceived, totalBytesSent
+	return totalBytesReceived, totalBytesSent, nil
 }
 
 // getStatusMessage returns a status message based on the health score.
@@ -309,7 +401,23 @@ func getStatusMessage(healthScore float64) string {
 
 // GetServiceHealth retrieves the service health statistics.
 func GetServiceHealth(serviceStats *models.ServiceStats) models.ServiceHealth {
-	healthInPercent, err := CalculateHealthScore(serviceStats)
+	return serviceHealth(serviceStats, ServiceThresholds())
+}
+
+// serviceHealth is GetServiceHealth scored against thresholds.
+func serviceHealth(serviceStats *models.ServiceStats, thresholds models.ServiceHealthThresholds) models.ServiceHealth {
+	if inHealthWarmup() {
+		return initializingHealth()
+	}
+	if missing := unavailableHealthInputs(serviceStats); missing != "" {
+		msg := "[Unknown] Health cannot be scored: " + missing + " could not be read. Check the process permissions."
+		return models.ServiceHealth{
+			SystemHealth:  models.Health{Percent: 0, Healthy: false, Message: msg},
+			ServiceHealth: models.Health{Percent: 0, Healthy: false, Message: msg},
+		}
+	}
+
+	healthInPercent, err := calculateHealthScore(serviceStats, thresholds)
 	if err != nil {
 		return models.ServiceHealth{
 			SystemHealth:  models.Health{Percent: 0, Healthy: false, Message: "Error: Unable to calculate health score. Please check system configuration."},
@@ -336,6 +444,20 @@ func GetServiceHealth(serviceStats *models.ServiceStats) models.ServiceHealth {
 	return healthData
 }
 
+// unavailableHealthInputs names the statistics the health score depends on
+// that could not be read, or returns "" if all were read.
+func unavailableHealthInputs(stats *models.ServiceStats) string {
+	switch {
+	case stats.CPUStatistics.Unavailable && stats.MemoryStatistics.Unavailable:
+		return "CPU and memory statistics"
+	case stats.CPUStatistics.Unavailable:
+		return "CPU statistics"
+	case stats.MemoryStatistics.Unavailable:
+		return "memory statistics"
+	}
+	return ""
+}
+
 // ConstructRawMemStats constructs a list of raw memory statistics records.
 func ConstructRawMemStats(memStats *runtime.MemStats) []models.RawMemStatsRecords {
 	r := []models.RawMemStatsRecords{
@@ -368,7 +490,37 @@ func ConstructRawMemStats(memStats *runtime.MemStats) []models.RawMemStatsRecord
 		newRawRecord("gc_cpu_fraction", float64(memStats.GCCPUFraction)),
 	}
 
-	return r
+	return dedupeRecords(r, func(rec models.RawMemStatsRecords) string { return rec.RecordName })
+}
+
+// dedupeRecords keeps the first record of each name, in order, and logs the
+// rest: records are stored by name, so a duplicate would silently overwrite
+// the earlier value.
+func dedupeRecords[T any](records []T, name func(T) string) []T {
+	unique, duplicates := splitDuplicateRecords(records, name)
+	if len(duplicates) > 0 {
+		logger.Log.Error("Duplicate memory statistic records dropped", "names", duplicates)
+	}
+	return unique
+}
+
+// splitDuplicateRecords returns the first record of each name, in order, and
+// the names that occurred more than once.
+func splitDuplicateRecords[T any](records []T, name func(T) string) (unique []T, duplicates []string) {
+	seen := make(map[string]bool, len(records))
+	unique = make([]T, 0, len(records))
+	for _, rec := range records {
+		n := name(rec)
+		if seen[n] {
+			if !slices.Contains(duplicates, n) {
+				duplicates = append(duplicates, n)
+			}
+			continue
+		}
+		seen[n] = true
+		unique = append(unique, rec)
+	}
+	return unique, duplicates
 }
 
 // nonByteMetrics are metrics that represent counts or ratios, not byte values
@@ -2,6 +2,8 @@ package core
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"runtime"
 	"strings"
 	"testing"
@@ -9,6 +11,9 @@ import (
 
 	"github.com/iyashjayesh/monigo/common"
 	"github.com/iyashjayesh/monigo/models"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/shirou/gopsutil/net"
 )
 
 func init() {
@@ -137,6 +142,79 @@ func TestConstructRawMemStats(t *testing.T) {
 	}
 }
 
+func TestConstructMemStats_UniqueNames(t *testing.T) {
+	m := ReadMemStats()
+	if _, dups := splitDuplicateRecords(ConstructMemStats(m), func(r models.Record) string { return r.Name }); len(dups) > 0 {
+		t.Errorf("expected unique record names, got duplicates %v", dups)
+	}
+	if _, dups := splitDuplicateRecords(ConstructRawMemStats(m), func(r models.RawMemStatsRecords) string { return r.RecordName }); len(dups) > 0 {
+		t.Errorf("expected unique raw record names, got duplicates %v", dups)
+	}
+}
+
+func TestDedupeRecords_DropsDuplicates(t *testing.T) {
+	records := []models.RawMemStatsRecords{
+		newRawRecord("heap_alloc", 2048),
+		newRawRecord("num_gc", 3),
+		newRawRecord("heap_alloc", 4096), // would overwrite the first heap_alloc when stored
+		newRawRecord("frees", 7),
+		newRawRecord("num_gc", 5),
+	}
+	name := func(r models.RawMemStatsRecords) string { return r.RecordName }
+
+	unique, dups := splitDuplicateRecords(records, name)
+	if len(dups) != 2 || dups[0] != "heap_alloc" || dups[1] != "num_gc" {
+		t.Errorf("expected duplicates [heap_alloc num_gc], got %v", dups)
+	}
+
+	got := dedupeRecords(records, name)
+	if len(got) != len(unique) {
+		t.Fatalf("expected dedupeRecords to match splitDuplicateRecords, got %v and %v", got, unique)
+	}
+	want := []models.RawMemStatsRecords{{RecordName: "heap_alloc", RecordValue: 2}, {RecordName: "num_gc", RecordValue: 3}, {RecordName: "frees", RecordValue: 7}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestReadMemStats_CachedWithinInterval(t *testing.T) {
+	defer SetMemStatsInterval(defaultMemStatsInterval)
+
+	SetMemStatsInterval(time.Hour)
+	first := ReadMemStats()
+	_ = allocateForTest()
+	if second := ReadMemStats(); second.Mallocs != first.Mallocs {
+		t.Errorf("expected cached stats within the interval, Mallocs went from %d to %d", first.Mallocs, second.Mallocs)
+	}
+
+	// The cache hands out copies.
+	first.Mallocs = 0
+	if ReadMemStats().Mallocs == 0 {
+		t.Error("expected mutating a result not to affect the cache")
+	}
+
+	SetMemStatsInterval(0)
+	before := ReadMemStats()
+	_ = allocateForTest()
+	if after := ReadMemStats(); after.Mallocs <= before.Mallocs {
+		t.Errorf("expected fresh stats with caching disabled, Mallocs went from %d to %d", before.Mallocs, after.Mallocs)
+	}
+}
+
+//go:noinline
+func allocateForTest() [][]byte {
+	out := make([][]byte, 100)
+	for i := range out {
+		out[i] = make([]byte, 64)
+	}
+	return out
+}
+
 func TestGetDiskIO(t *testing.T) {
 	read, write := GetDiskIO()
 	// Just verify no panic and values are reasonable
@@ -156,6 +234,253 @@ func TestGetServiceHealth(t *testing.T) {
 	}
 }
 
+func TestGetServiceHealth_Warmup(t *testing.T) {
+	start := time.Now()
+	clockAt := start
+	now = func() time.Time { return clockAt }
+	defer func() {
+		now = time.Now
+		ConfigureHealthWarmup(0)
+	}()
+
+	ConfigureHealthWarmup(30 * time.Second)
+	stats := GetServiceStats(context.Background())
+
+	clockAt = start.Add(10 * time.Second)
+	health := GetServiceHealth(&stats)
+	for _, h := range []models.Health{health.ServiceHealth, health.SystemHealth} {
+		if !h.Initializing || h.Healthy || h.Percent != 0 {
+			t.Errorf("expected neutral initializing health within the warmup, got %+v", h)
+		}
+		if !strings.HasPrefix(h.Message, "[Initializing]") {
+			t.Errorf("expected an initializing message, got %q", h.Message)
+		}
+	}
+
+	clockAt = start.Add(30 * time.Second)
+	health = GetServiceHealth(&stats)
+	if health.ServiceHealth.Initializing || health.SystemHealth.Initializing {
+		t.Error("expected health to be scored after the warmup")
+	}
+	if strings.HasPrefix(health.ServiceHealth.Message, "[Initializing]") {
+		t.Errorf("expected a scored health message, got %q", health.ServiceHealth.Message)
+	}
+}
+
+func TestUpdateServiceThresholds(t *testing.T) {
+	orig := ServiceThresholds()
+	origSystem, origProcess := systemCPUPercent, processCPUPercent
+	defer func() {
+		ConfigureServiceThresholds(&orig)
+		systemCPUPercent, processCPUPercent = origSystem, origProcess
+	}()
+	systemCPUPercent = func() (float64, error) { return 40, nil }
+	processCPUPercent = func() (float64, error) { return 40, nil }
+
+	stats := &models.ServiceStats{}
+	stats.CPUStatistics.TotalCores = 100 // so the service uses 40% of the CPU
+	stats.MemoryStatistics.TotalSystemMemory = "1000 MB"
+	stats.MemoryStatistics.MemoryUsedBySystem = "400 MB"
+	stats.MemoryStatistics.MemoryUsedByService = "400 MB"
+
+	ConfigureServiceThresholds(&models.ServiceHealthThresholds{MaxCPUUsage: 80, MaxMemoryUsage: 80, MaxGoRoutines: 1000})
+	before, err := CalculateHealthScore(stats)
+	if err != nil {
+		t.Fatalf("CalculateHealthScore error: %v", err)
+	}
+	if before.SystemHealth.Percentage != 50 {
+		t.Errorf("expected system health 50, got %v", before.SystemHealth.Percentage)
+	}
+
+	// Tighten MaxCPUUsage and MaxMemoryUsage while health is being calculated.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if _, err := CalculateHealthScore(stats); err != nil {
+				t.Errorf("CalculateHealthScore error: %v", err)
+				return
+			}
+		}
+	}()
+	if err := UpdateServiceThresholds(&models.ServiceHealthThresholds{MaxCPUUsage: 50, MaxMemoryUsage: 50}); err != nil {
+		t.Fatalf("UpdateServiceThresholds error: %v", err)
+	}
+	<-done
+
+	if got := ServiceThresholds(); got.MaxGoRoutines != 1000 {
+		t.Errorf("expected a zero field to keep its value, got MaxGoRoutines %d", got.MaxGoRoutines)
+	}
+	after, err := CalculateHealthScore(stats)
+	if err != nil {
+		t.Fatalf("CalculateHealthScore error: %v", err)
+	}
+	if after.SystemHealth.Percentage != 20 {
+		t.Errorf("expected system health 20 with the new thresholds, got %v", after.SystemHealth.Percentage)
+	}
+	if after.SystemHealth.AllowedByUser != 50 {
+		t.Errorf("expected AllowedByUser 50, got %v", after.SystemHealth.AllowedByUser)
+	}
+	if after.ServiceHealth.Percentage >= before.ServiceHealth.Percentage {
+		t.Errorf("expected lower service health with tighter thresholds, got %v then %v",
+			before.ServiceHealth.Percentage, after.ServiceHealth.Percentage)
+	}
+}
+
+func TestUpdateServiceThresholds_Invalid(t *testing.T) {
+	orig := ServiceThresholds()
+	if err := UpdateServiceThresholds(nil); err == nil {
+		t.Error("expected an error for nil thresholds")
+	}
+	if err := UpdateServiceThresholds(&models.ServiceHealthThresholds{MaxCPUUsage: -1}); err == nil {
+		t.Error("expected an error for a negative threshold")
+	}
+	if got := ServiceThresholds(); got != orig {
+		t.Errorf("expected thresholds unchanged, got %+v", got)
+	}
+}
+
+func TestServiceThresholdsFor(t *testing.T) {
+	orig := ServiceThresholds()
+	origCollect, origSystem, origProcess := collectStats, systemCPUPercent, processCPUPercent
+	defer func() {
+		ConfigureServiceThresholds(&orig)
+		collectStats, systemCPUPercent, processCPUPercent = origCollect, origSystem, origProcess
+	}()
+	systemCPUPercent = func() (float64, error) { return 40, nil }
+	processCPUPercent = func() (float64, error) { return 40, nil }
+
+	ConfigureServiceThresholds(&models.ServiceHealthThresholds{MaxCPUUsage: 80, MaxMemoryUsage: 80, MaxGoRoutines: 1000})
+	if err := UpdateServiceThresholdsFor("strict-thresholds", &models.ServiceHealthThresholds{MaxCPUUsage: 50}); err != nil {
+		t.Fatalf("UpdateServiceThresholdsFor error: %v", err)
+	}
+
+	want := models.ServiceHealthThresholds{MaxCPUUsage: 50, MaxMemoryUsage: 80, MaxGoRoutines: 1000}
+	if got := ServiceThresholdsFor("strict-thresholds"); got != want {
+		t.Errorf("expected the namespace's thresholds %+v, got %+v", want, got)
+	}
+	if got := ServiceThresholds(); got.MaxCPUUsage != 80 {
+		t.Errorf("expected the default thresholds unchanged, got %+v", got)
+	}
+	if got := ServiceThresholdsFor("other-thresholds"); got != ServiceThresholds() {
+		t.Errorf("expected a namespace without thresholds to use the default ones, got %+v", got)
+	}
+
+	collectStats = func(string, CollectOptions) models.ServiceStats {
+		var stats models.ServiceStats
+		stats.CPUStatistics.TotalCores = 100
+		stats.MemoryStatistics.TotalSystemMemory = "1000 MB"
+		stats.MemoryStatistics.MemoryUsedBySystem = "400 MB"
+		stats.MemoryStatistics.MemoryUsedByService = "400 MB"
+		stats.Health = GetServiceHealth(&stats)
+		return stats
+	}
+	def := GetServiceStats(context.Background()).Health.SystemHealth.Percent
+	strict := GetServiceStats(WithNamespace(context.Background(), "strict-thresholds")).Health.SystemHealth.Percent
+	if strict >= def {
+		t.Errorf("expected the namespace's health scored against its tighter thresholds, got %v and default %v", strict, def)
+	}
+}
+
+func TestGetServiceStats_Unavailable(t *testing.T) {
+	errDenied := errors.New("permission denied")
+	origSystem, origProcess, origVM := systemCPUPercent, processCPUPercent, virtualMemory
+	origNet, origDisk := netIOCounters, diskIOCounters
+	defer func() {
+		systemCPUPercent, processCPUPercent, virtualMemory = origSystem, origProcess, origVM
+		netIOCounters, diskIOCounters = origNet, origDisk
+	}()
+	systemCPUPercent = func() (float64, error) { return 0, errDenied }
+	processCPUPercent = func() (float64, error) { return 0, errDenied }
+	virtualMemory = func() (*mem.VirtualMemoryStat, error) { return nil, errDenied }
+	netIOCounters = func(bool) ([]net.IOCountersStat, error) { return nil, errDenied }
+	diskIOCounters = func(...string) (map[string]disk.IOCountersStat, error) { return nil, errDenied }
+
+	stats := GetServiceStats(context.Background())
+
+	if !stats.CPUStatistics.Unavailable {
+		t.Error("expected CPU statistics to be unavailable")
+	}
+	if !stats.MemoryStatistics.Unavailable {
+		t.Error("expected memory statistics to be unavailable")
+	}
+	if stats.MemoryStatistics.StackMemoryUsageRaw == 0 {
+		t.Error("expected runtime memory fields to be set")
+	}
+	if !stats.LoadStatistics.Unavailable {
+		t.Error("expected load statistics to be unavailable")
+	}
+	if !stats.NetworkIOUnavailable || !stats.DiskIOUnavailable {
+		t.Error("expected network and disk I/O to be unavailable")
+	}
+	if stats.Health.ServiceHealth.Healthy || stats.Health.SystemHealth.Healthy {
+		t.Error("expected health not to be reported healthy without CPU and memory data")
+	}
+	if !strings.Contains(stats.Health.ServiceHealth.Message, "CPU and memory statistics") {
+		t.Errorf("expected the health message to name the missing data, got %q", stats.Health.ServiceHealth.Message)
+	}
+}
+
+func TestGetServiceStats_Available(t *testing.T) {
+	stats := GetServiceStats(context.Background())
+	if stats.CPUStatistics.Unavailable || stats.MemoryStatistics.Unavailable || stats.LoadStatistics.Unavailable {
+		t.Skip("system statistics are not readable on this host")
+	}
+	if strings.HasPrefix(stats.Health.ServiceHealth.Message, "[Unknown]") {
+		t.Errorf("expected a scored health, got %q", stats.Health.ServiceHealth.Message)
+	}
+}
+
+func TestGetServiceStatsSelective(t *testing.T) {
+	origNet, origDisk, origThroughput := netIOCounters, diskIOCounters, serviceNetworkThroughput
+	defer func() { netIOCounters, diskIOCounters, serviceNetworkThroughput = origNet, origDisk, origThroughput }()
+	serviceNetworkThroughput = &networkThroughput{}
+	netIOCounters = func(bool) ([]net.IOCountersStat, error) {
+		return []net.IOCountersStat{{BytesRecv: 4096, BytesSent: 1024}}, nil
+	}
+	diskIOCounters = func(...string) (map[string]disk.IOCountersStat, error) {
+		return map[string]disk.IOCountersStat{"sda": {ReadBytes: 512, WriteBytes: 256}}, nil
+	}
+
+	stats := GetServiceStatsSelective(context.Background(), CollectOptions{Network: true, Disk: true})
+	if stats.NetworkIO.BytesReceived != 4096 || stats.DiskIO.ReadBytes != 512 {
+		t.Errorf("expected network and disk I/O to be collected, got %+v and %+v", stats.NetworkIO, stats.DiskIO)
+	}
+	if stats.CoreStatistics.Goroutines <= 0 {
+		t.Error("expected core statistics to always be collected")
+	}
+	if stats.CPUStatistics.TotalCores != 0 || stats.CPUStatistics.PerCore != nil {
+		t.Errorf("expected CPU statistics to be skipped, got %+v", stats.CPUStatistics)
+	}
+	if stats.MemoryStatistics.TotalSystemMemory != "" || stats.HeapAllocByServiceRaw != 0 {
+		t.Error("expected memory statistics to be skipped")
+	}
+	if stats.LoadStatistics.OverallLoadOfService != "" {
+		t.Errorf("expected load statistics to be skipped, got %+v", stats.LoadStatistics)
+	}
+	if stats.Health.ServiceHealth.Message != "" || stats.Health.SystemHealth.Message != "" {
+		t.Errorf("expected health to be skipped, got %+v", stats.Health)
+	}
+
+	stats = GetServiceStatsSelective(context.Background(), CollectOptions{Memory: true})
+	if stats.MemoryStatistics.TotalSystemMemory == "" || stats.HeapAllocByServiceRaw == 0 {
+		t.Error("expected memory statistics to be collected")
+	}
+	if stats.NetworkIO.BytesReceived != 0 || stats.DiskIO.ReadBytes != 0 {
+		t.Error("expected network and disk I/O to be skipped")
+	}
+}
+
+func TestGetServiceStatsSelective_HealthImpliesCPUAndMemory(t *testing.T) {
+	stats := GetServiceStatsSelective(context.Background(), CollectOptions{Health: true})
+	if stats.CPUStatistics.TotalCores == 0 || stats.MemoryStatistics.TotalSystemMemory == "" {
+		t.Error("expected health to collect the CPU and memory statistics it is scored from")
+	}
+	if stats.Health.ServiceHealth.Message == "" {
+		t.Error("expected health to be collected")
+	}
+}
+
 func TestGetStatusMessage(t *testing.T) {
 	tests := []struct {
 		score    float64
@@ -194,6 +519,37 @@ func TestFormatUptime(t *testing.T) {
 	}
 }
 
+func TestCoreStatistics_UptimeSeconds(t *testing.T) {
+	tests := []struct {
+		d       time.Duration
+		unit    string
+		seconds float64 // seconds per unit of the formatted string
+	}{
+		{30 * time.Second, "s", 1},
+		{90 * time.Second, "m", 60},
+		{3 * time.Hour, "h", 3600},
+		{48 * time.Hour, "d", 86400},
+	}
+	for _, tt := range tests {
+		cs := coreStatistics(1, tt.d)
+		if cs.UptimeSeconds != tt.d.Seconds() {
+			t.Errorf("UptimeSeconds = %v, want %v", cs.UptimeSeconds, tt.d.Seconds())
+		}
+
+		var value float64
+		var unit string
+		if _, err := fmt.Sscanf(cs.Uptime, "%f %s", &value, &unit); err != nil {
+			t.Fatalf("failed to parse uptime %q: %v", cs.Uptime, err)
+		}
+		if unit != tt.unit {
+			t.Errorf("uptime %q: expected unit %q", cs.Uptime, tt.unit)
+		}
+		if diff := value*tt.seconds - cs.UptimeSeconds; diff < -0.01*tt.seconds || diff > 0.01*tt.seconds {
+			t.Errorf("uptime %q does not match %v seconds", cs.Uptime, cs.UptimeSeconds)
+		}
+	}
+}
+
 func TestCollectGoRoutinesInfo(t *testing.T) {
 	info := CollectGoRoutinesInfo()
 	if info.NumberOfGoroutines <= 0 {
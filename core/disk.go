@@ -2,16 +2,21 @@ package core
 
 import (
 	"github.com/iyashjayesh/monigo/internal/logger"
-	"github.com/shirou/gopsutil/disk"
 )
 
 // GetDiskIO retrieves the disk I/O statistics (Read/Write bytes).
 func GetDiskIO() (uint64, uint64) {
+	read, write, _ := diskIO()
+	return read, write
+}
+
+// diskIO is GetDiskIO, also reporting whether the counters could be read.
+func diskIO() (uint64, uint64, error) {
 	// fetching IO counters for all disks
-	ioCounters, err := disk.IOCounters()
+	ioCounters, err := diskIOCounters()
 	if err != nil {
 		logger.Log.Warn("Error fetching disk I/O statistics", "error", err)
-		return 0, 0
+		return 0, 0, err
 	}
 
 	var totalReadBytes, totalWriteBytes uint64
@@ -20,5 +25,5 @@ func GetDiskIO() (uint64, uint64) {
 		totalWriteBytes += io.WriteBytes
 	}
 
-	return totalReadBytes, totalWriteBytes
+	return totalReadBytes, totalWriteBytes, nil
 }
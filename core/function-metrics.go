@@ -1,13 +1,18 @@
 package core
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -18,19 +23,66 @@ import (
 	"github.com/iyashjayesh/monigo/models"
 )
 
-const maxTrackedFunctions = 10000
+const (
+	defaultMaxTrackedFunctions = 10000
+
+	defaultPprofTimeout = 15 * time.Second
+	maxConcurrentPprof  = 4
+	// pprofWaitDelay bounds how long output pipes are drained after a timeout kill.
+	pprofWaitDelay = time.Second
+)
+
+var (
+	// ErrProfilingUnavailable is returned when the Go SDK needed to render pprof reports is missing.
+	ErrProfilingUnavailable = errors.New("pprof reports require the Go SDK: 'go' command not found in PATH")
+	// ErrGraphvizUnavailable is returned when Graphviz, needed to render SVG flamegraphs, is missing.
+	ErrGraphvizUnavailable = errors.New("flamegraphs require Graphviz: 'dot' command not found in PATH")
+	// ErrNoProfile is returned when no profile has been captured for a function yet.
+	ErrNoProfile = errors.New("no profile has been captured for this function yet")
+	// ErrProfileTooOld is returned when a function's profile is older than the
+	// maximum profile age.
+	ErrProfileTooOld = errors.New("the function's profile is older than the maximum profile age")
+)
 
 var (
 	functionMetrics = make(map[string]*models.FunctionMetrics)
+	metricsLRU      = newLRUIndex() // guarded by mu
 	basePath        = common.GetBasePath()
 
-	samplingRate atomic.Int64
-	callCounters = make(map[string]uint64)
-	countersMu   sync.Mutex
+	// lookPath is exec.LookPath, replaceable in tests.
+	lookPath = exec.LookPath
+
+	// startCPUProfile is StartCPUProfile, replaceable in tests.
+	startCPUProfile = StartCPUProfile
+
+	// profilingGoroutines holds the IDs of the goroutines inside a profiled call.
+	profilingGoroutines sync.Map
+
+	samplingRate        atomic.Int64
+	maxTrackedFunctions atomic.Int64
+	callCounters        = make(map[string]uint64)
+	countersLRU         = newLRUIndex() // guarded by countersMu
+	countersMu          sync.Mutex
+
+	swallowPanics atomic.Bool
+
+	slowFunctionThreshold atomic.Int64 // time.Duration; 0 disables the log
+
+	maxProfileAge atomic.Int64 // time.Duration; 0 renders profiles of any age
+
+	pprofTimeout   atomic.Int64
+	pprofSemaphore = make(chan struct{}, maxConcurrentPprof)
+
+	reportTypesMu      sync.RWMutex
+	allowedReportTypes = map[string]bool{
+		"top": true, "list": true, "tree": true, "text": true, "traces": true, "svg": true, "flamegraph": true,
+	}
 )
 
 func init() {
 	samplingRate.Store(100)
+	maxTrackedFunctions.Store(defaultMaxTrackedFunctions)
+	pprofTimeout.Store(int64(defaultPprofTimeout))
 }
 
 // SetSamplingRate sets the sampling rate for function tracing
@@ -41,57 +93,235 @@ func SetSamplingRate(rate int) {
 	samplingRate.Store(int64(rate))
 }
 
+// SamplingRate returns the current sampling rate for function tracing.
+func SamplingRate() int {
+	return int(samplingRate.Load())
+}
+
+// SetSwallowPanics controls what happens after a panic in a traced function
+// was recovered and recorded. By default it is re-panicked so callers see no
+// difference; with swallow set, the traced call returns normally instead.
+func SetSwallowPanics(swallow bool) {
+	swallowPanics.Store(swallow)
+}
+
+// SetSlowFunctionThreshold makes traced calls taking longer than d log a
+// warning with the function name and duration. Zero (the default) disables it.
+// Calls skipped by lightweight tracing aren't timed, so they never log.
+func SetSlowFunctionThreshold(d time.Duration) {
+	slowFunctionThreshold.Store(int64(max(d, 0)))
+}
+
+// SetMaxProfileAge makes ViewFunctionMetrics and FunctionFlamegraph refuse to
+// render profiles captured more than d ago, which would no longer match the
+// function's current metrics. Zero (the default) renders profiles of any age.
+func SetMaxProfileAge(d time.Duration) {
+	maxProfileAge.Store(int64(max(d, 0)))
+}
+
+// profileAge returns how long ago the profile of metrics was captured, and
+// whether that is beyond the maximum profile age.
+func profileAge(metrics *models.FunctionMetrics) (time.Duration, bool) {
+	if metrics.ProfiledAt.IsZero() {
+		return 0, false
+	}
+	age := now().Sub(metrics.ProfiledAt)
+	limit := time.Duration(maxProfileAge.Load())
+	return age, limit > 0 && age > limit
+}
+
+// SetMaxTrackedFunctions sets how many distinct functions are tracked at once.
+// When the cap is exceeded the least recently traced function is evicted.
+func SetMaxTrackedFunctions(n int) error {
+	if n < 1 {
+		return fmt.Errorf("max tracked functions must be >= 1, got %d", n)
+	}
+	maxTrackedFunctions.Store(int64(n))
+
+	countersMu.Lock()
+	evictLRU(countersLRU, n, func(k string) { delete(callCounters, k) })
+	countersMu.Unlock()
+
+	mu.Lock()
+	evictLRU(metricsLRU, n, forgetFunction)
+	mu.Unlock()
+	return nil
+}
+
+// ResetFunctionMetrics clears the metrics, history and call counters of all
+// traced functions, e.g. after a load test.
+func ResetFunctionMetrics() {
+	countersMu.Lock()
+	callCounters = make(map[string]uint64)
+	countersLRU = newLRUIndex()
+	countersMu.Unlock()
+
+	mu.Lock()
+	functionMetrics = make(map[string]*models.FunctionMetrics)
+	functionHistory = make(map[string]*sampleRing)
+	metricsLRU = newLRUIndex()
+	lightCounters.Clear()
+	mu.Unlock()
+}
+
+// ResetFunctionMetricsFor is ResetFunctionMetrics for the functions traced in
+// namespace; the functions of other namespaces keep their metrics.
+func ResetFunctionMetricsFor(namespace string) {
+	inNamespace := func(key string) bool {
+		ns, _ := splitFunctionKey(key)
+		return ns == namespace
+	}
+
+	countersMu.Lock()
+	for key := range callCounters {
+		if inNamespace(key) {
+			delete(callCounters, key)
+			countersLRU.remove(key)
+		}
+	}
+	countersMu.Unlock()
+
+	mu.Lock()
+	for key := range functionMetrics {
+		if inNamespace(key) {
+			forgetFunction(key)
+			metricsLRU.remove(key)
+		}
+	}
+	for key := range functionHistory {
+		if inNamespace(key) {
+			forgetFunction(key)
+		}
+	}
+	lightCounters.Range(func(key, _ any) bool {
+		if inNamespace(key.(string)) {
+			lightCounters.Delete(key)
+		}
+		return true
+	})
+	mu.Unlock()
+}
+
+// forgetFunction drops the metrics and history of a traced function. Callers hold mu.
+func forgetFunction(key string) {
+	delete(functionMetrics, key)
+	delete(functionHistory, key)
+	lightCounters.Delete(key)
+}
+
+// evictLRU evicts least recently used keys until at most limit remain.
+func evictLRU(idx *lruIndex, limit int, evict func(string)) {
+	for idx.len() > limit {
+		key, ok := idx.oldest()
+		if !ok {
+			return
+		}
+		evict(key)
+	}
+}
+
 // TraceFunction traces the function and captures the metrics
-func TraceFunction(_ context.Context, f func()) {
+func TraceFunction(ctx context.Context, f func()) {
 	name := strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), "/", "-")
-	executeFunctionWithProfiling(name, f)
+	executeFunctionWithProfiling(ctx, name, func(context.Context) { f() })
+}
+
+// TraceContextFunc traces f, which receives ctx (carrying the function's span
+// when tracing is enabled), and captures the metrics.
+func TraceContextFunc(ctx context.Context, f func(context.Context)) {
+	name := strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), "/", "-")
+	executeFunctionWithProfiling(ctx, name, f)
 }
 
 // FunctionTraceDetails returns a snapshot copy of the function trace details (thread-safe)
+// of the default namespace.
 func FunctionTraceDetails() map[string]*models.FunctionMetrics {
+	return FunctionTraceDetailsFor("")
+}
+
+// FunctionTraceDetailsFor returns a snapshot copy of the trace details of the
+// functions traced in namespace (see WithNamespace), keyed by function name.
+func FunctionTraceDetailsFor(namespace string) map[string]*models.FunctionMetrics {
 	mu.Lock()
 	defer mu.Unlock()
 
-	result := make(map[string]*models.FunctionMetrics, len(functionMetrics))
+	result := make(map[string]*models.FunctionMetrics)
 	for k, v := range functionMetrics {
+		ns, name := splitFunctionKey(k)
+		if ns != namespace {
+			continue
+		}
 		copied := *v
-		result[k] = &copied
+		copied.CallCount += skippedCalls(k)
+		_, copied.Labels = SplitFunctionLabels(name)
+		result[name] = &copied
 	}
 	return result
 }
 
-// TraceFunctionWithArgs traces a function with parameters and captures the metrics
-func TraceFunctionWithArgs(_ context.Context, f interface{}, args ...interface{}) {
-	fnValue := reflect.ValueOf(f)
-	if fnValue.Kind() != reflect.Func {
-		logger.Log.Error("first argument must be a function", "type", fmt.Sprintf("%T", f))
-		return
-	}
+// Keys FunctionTraceDetailsSortedFor orders functions by. Name sorts
+// ascending; the others sort largest first, ties broken by name.
+const (
+	SortByName          = "name"
+	SortByCalls         = "calls"
+	SortByExecutionTime = "execution_time"
+	SortByMemory        = "memory"
+	SortByPanics        = "panics"
+)
 
-	fnType := fnValue.Type()
+// functionSortKeys maps each sort key to a comparison of two functions'
+// metrics, negative when a sorts before b.
+var functionSortKeys = map[string]func(a, b *models.FunctionMetrics) int{
+	SortByName:          func(a, b *models.FunctionMetrics) int { return 0 },
+	SortByCalls:         func(a, b *models.FunctionMetrics) int { return cmp.Compare(b.CallCount, a.CallCount) },
+	SortByExecutionTime: func(a, b *models.FunctionMetrics) int { return cmp.Compare(b.ExecutionTime, a.ExecutionTime) },
+	SortByMemory:        func(a, b *models.FunctionMetrics) int { return cmp.Compare(b.MemoryUsage, a.MemoryUsage) },
+	SortByPanics:        func(a, b *models.FunctionMetrics) int { return cmp.Compare(b.PanicCount, a.PanicCount) },
+}
 
-	if len(args) != fnType.NumIn() {
-		logger.Log.Error("function argument count mismatch", "expected", fnType.NumIn(), "got", len(args))
-		return
-	}
+// IsFunctionSortKey reports whether key may be passed to FunctionTraceDetailsSortedFor.
+func IsFunctionSortKey(key string) bool {
+	_, ok := functionSortKeys[key]
+	return ok
+}
 
-	argValues := make([]reflect.Value, len(args))
-	for i, arg := range args {
-		argValue := reflect.ValueOf(arg)
-		expectedType := fnType.In(i)
+// FunctionTraceDetailsSorted returns a snapshot copy of the function trace
+// details of the default namespace, sorted by function name.
+func FunctionTraceDetailsSorted() []models.NamedFunctionMetrics {
+	return FunctionTraceDetailsSortedFor("", SortByName)
+}
 
-		if !argValue.Type().AssignableTo(expectedType) {
-			logger.Log.Error("argument type mismatch", "index", i, "expected", expectedType, "got", argValue.Type())
-			return
-		}
-		argValues[i] = argValue
+// FunctionTraceDetailsSortedFor returns a snapshot copy of the function trace
+// details of namespace ordered by key, one of the SortBy constants. Unknown
+// keys sort by name.
+func FunctionTraceDetailsSortedFor(namespace, key string) []models.NamedFunctionMetrics {
+	compare, ok := functionSortKeys[key]
+	if !ok {
+		compare = functionSortKeys[SortByName]
 	}
 
-	name := generateFunctionName(fnValue, fnType)
-
-	executeFunctionWithProfiling(name, func() {
-		fnValue.Call(argValues)
+	details := FunctionTraceDetailsFor(namespace)
+	sorted := make([]models.NamedFunctionMetrics, 0, len(details))
+	for name, metrics := range details {
+		sorted = append(sorted, models.NamedFunctionMetrics{Name: name, FunctionMetrics: *metrics})
+	}
+	slices.SortFunc(sorted, func(a, b models.NamedFunctionMetrics) int {
+		if c := compare(&a.FunctionMetrics, &b.FunctionMetrics); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
 	})
+	return sorted
+}
+
+// TraceFunctionWithArgs traces a function with parameters and captures the metrics
+func TraceFunctionWithArgs(ctx context.Context, f interface{}, args ...interface{}) {
+	fnValue, sig, argValues, ok := prepareCall(f, args)
+	if !ok {
+		return
+	}
+
+	callTraced(ctx, fnValue, sig, argValues)
 }
 
 // TraceFunctionWithReturn traces a function and returns the first result.
@@ -104,44 +334,111 @@ func TraceFunctionWithReturn(ctx context.Context, f interface{}, args ...interfa
 }
 
 // TraceFunctionWithReturns traces a function and returns all results.
-func TraceFunctionWithReturns(_ context.Context, f interface{}, args ...interface{}) []interface{} {
+func TraceFunctionWithReturns(ctx context.Context, f interface{}, args ...interface{}) []interface{} {
+	fnValue, sig, argValues, ok := prepareCall(f, args)
+	if !ok {
+		return nil
+	}
+
+	reflectResults, ok := callTraced(ctx, fnValue, sig, argValues)
+	if !ok {
+		return nil
+	}
+	results := make([]interface{}, len(reflectResults))
+	for i, result := range reflectResults {
+		results[i] = result.Interface()
+	}
+	return results
+}
+
+// callTraced calls fnValue with argValues as a traced function. A method
+// expression given a nil receiver, or a method value bound to one, that
+// panics with a nil pointer dereference is recorded like any panic, then
+// logged instead of re-panicked. ok is false when fn did not return, e.g.
+// after a swallowed panic.
+func callTraced(ctx context.Context, fnValue reflect.Value, sig *funcSignature, argValues []reflect.Value) (results []reflect.Value, ok bool) {
+	nilReceiver := sig.methodExpr && isNilPointer(argValues[0]) ||
+		sig.methodValue && boundReceiverIsNil(fnValue)
+	if nilReceiver {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if err, isRuntime := r.(runtime.Error); isRuntime && strings.Contains(err.Error(), "nil pointer dereference") {
+				logger.Log.Error("traced method called on a nil receiver", "function", sig.name, "error", err)
+				results, ok = nil, false
+				return
+			}
+			panic(r)
+		}()
+	}
+
+	executeFunctionWithProfiling(ctx, sig.name, func(context.Context) {
+		results = fnValue.Call(argValues)
+		ok = true
+	})
+	return results, ok
+}
+
+// isNillable reports whether nil is a valid value of t.
+func isNillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+// isNilPointer reports whether v is a nil pointer.
+func isNilPointer(v reflect.Value) bool {
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// prepareCall checks that f is a function accepting args and returns its
+// value, signature and the argument values to call it with. Mismatches are
+// logged and reported as not ok.
+func prepareCall(f interface{}, args []interface{}) (reflect.Value, *funcSignature, []reflect.Value, bool) {
 	fnValue := reflect.ValueOf(f)
 	if fnValue.Kind() != reflect.Func {
 		logger.Log.Error("first argument must be a function", "type", fmt.Sprintf("%T", f))
-		return nil
+		return reflect.Value{}, nil, nil, false
 	}
 
-	fnType := fnValue.Type()
-
-	if len(args) != fnType.NumIn() {
-		logger.Log.Error("function argument count mismatch", "expected", fnType.NumIn(), "got", len(args))
-		return nil
+	sig := signatureOf(fnValue)
+	if !sig.acceptsArgs(len(args)) {
+		if sig.variadic {
+			logger.Log.Error("function argument count mismatch", "expected_at_least", len(sig.in)-1, "got", len(args))
+		} else {
+			logger.Log.Error("function argument count mismatch", "expected", len(sig.in), "got", len(args))
+		}
+		return reflect.Value{}, nil, nil, false
 	}
 
+	// Trailing arguments of a variadic function are passed one by one, as
+	// in a direct call; Call packs them into the slice parameter.
 	argValues := make([]reflect.Value, len(args))
 	for i, arg := range args {
 		argValue := reflect.ValueOf(arg)
-		expectedType := fnType.In(i)
-
-		if !argValue.Type().AssignableTo(expectedType) {
-			logger.Log.Error("argument type mismatch", "index", i, "expected", expectedType, "got", argValue.Type())
-			return nil
+		expectedType := sig.argType(i)
+
+		// An untyped nil is the zero value of a pointer, interface, map,
+		// slice, func or channel parameter, e.g. a nil receiver.
+		if arg == nil {
+			if !isNillable(expectedType) {
+				logger.Log.Error("argument type mismatch", "index", i, "expected", expectedType, "got", "nil")
+				return reflect.Value{}, nil, nil, false
+			}
+			argValues[i] = reflect.Zero(expectedType)
+			continue
+		}
+		if argType := argValue.Type(); argType != expectedType && !argType.AssignableTo(expectedType) {
+			logger.Log.Error("argument type mismatch", "index", i, "expected", expectedType, "got", argType)
+			return reflect.Value{}, nil, nil, false
 		}
 		argValues[i] = argValue
 	}
-
-	name := generateFunctionName(fnValue, fnType)
-
-	var results []interface{}
-	executeFunctionWithProfiling(name, func() {
-		reflectResults := fnValue.Call(argValues)
-		results = make([]interface{}, len(reflectResults))
-		for i, result := range reflectResults {
-			results[i] = result.Interface()
-		}
-	})
-
-	return results
+	return fnValue, sig, argValues, true
 }
 
 func generateFunctionName(fnValue reflect.Value, fnType reflect.Type) string {
@@ -152,6 +449,10 @@ func generateFunctionName(fnValue reflect.Value, fnType reflect.Type) string {
 		for i := 0; i < fnType.NumIn(); i++ {
 			paramTypes[i] = fnType.In(i).String()
 		}
+		if fnType.IsVariadic() {
+			last := len(paramTypes) - 1
+			paramTypes[last] = "..." + fnType.In(last).Elem().String()
+		}
 		baseName = fmt.Sprintf("%s(%s)", baseName, strings.Join(paramTypes, ","))
 	}
 
@@ -174,32 +475,56 @@ func sanitizeFileName(name string) string {
 		":", "_", "*", "_",
 		"?", "_", "\"", "_",
 		"|", "_", " ", "_",
+		"/", "-", namespaceSep, "__",
 	)
 	return replacer.Replace(name)
 }
 
-func executeFunctionWithProfiling(name string, fn func()) {
-	countersMu.Lock()
-	if len(callCounters) > maxTrackedFunctions {
-		// Evict oldest entries to prevent unbounded growth.
-		for k := range callCounters {
-			delete(callCounters, k)
-			break
+func executeFunctionWithProfiling(ctx context.Context, name string, fn func(context.Context)) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, endSpan := startFunctionSpan(ctx, name)
+	defer endSpan()
+
+	limit := int(maxTrackedFunctions.Load())
+	key := functionKey(NamespaceFromContext(ctx), name)
+
+	var count uint64
+	if lightweightTracing.Load() {
+		counter := lightCallCounterFor(key)
+		count = counter.calls.Add(1)
+		if count > 1 && count%uint64(samplingRate.Load()) != 0 {
+			traceLightweight(ctx, key, name, counter, fn)
+			return
 		}
+	} else {
+		countersMu.Lock()
+		countersLRU.touch(key)
+		evictLRU(countersLRU, limit, func(k string) { delete(callCounters, k) })
+		callCounters[key]++
+		count = callCounters[key]
+		countersMu.Unlock()
 	}
-	callCounters[name]++
-	count := callCounters[name]
-	countersMu.Unlock()
 
 	shouldProfile := count%uint64(samplingRate.Load()) == 0
 
-	initialGoroutines := runtime.NumGoroutine()
-	var memStatsBefore runtime.MemStats
+	// A traced call made from within a profiled call on the same goroutine,
+	// e.g. by a recursive traced function, is recorded without profiling: a
+	// nested CPU profile can't start and stopping it would end the outer one.
 	if shouldProfile {
-		runtime.ReadMemStats(&memStatsBefore)
+		gid := goroutineID()
+		if _, nested := profilingGoroutines.LoadOrStore(gid, struct{}{}); nested {
+			shouldProfile = false
+		} else {
+			defer profilingGoroutines.Delete(gid)
+		}
 	}
 
-	var cpuProfFilePath, memProfFilePath string
+	initialGoroutines := runtime.NumGoroutine()
+
+	var cpuProfFilePath, memProfFilePath, blockProfFilePath, mutexProfFilePath string
+	var profiledAt time.Time
 	var cpuProfileFile *os.File
 
 	if shouldProfile {
@@ -208,26 +533,45 @@ func executeFunctionWithProfiling(name string, fn func()) {
 			logger.Log.Warn("failed to create profiles directory", "error", err)
 		}
 
-		safeName := sanitizeFileName(name)
-		cpuProfFilePath = filepath.Join(folderPath, fmt.Sprintf("%s_cpu.prof", safeName))
-		memProfFilePath = filepath.Join(folderPath, fmt.Sprintf("%s_mem.prof", safeName))
+		safeName := sanitizeFileName(key)
+		cpuProfFilePath = filepath.Join(folderPath, safeName+"_cpu"+profileFileExt)
+		memProfFilePath = filepath.Join(folderPath, safeName+"_mem"+profileFileExt)
+		if blockProfiling.Load() {
+			blockProfFilePath = filepath.Join(folderPath, safeName+"_block"+profileFileExt)
+		}
+		if mutexProfiling.Load() {
+			mutexProfFilePath = filepath.Join(folderPath, safeName+"_mutex"+profileFileExt)
+		}
 
 		var err error
-		cpuProfileFile, err = StartCPUProfile(cpuProfFilePath)
+		cpuProfileFile, err = startCPUProfile(cpuProfFilePath)
 		if err != nil {
 			logger.Log.Warn("failed to start CPU profile", "error", err)
 		}
 	}
 
+	// Memory stats are read right around fn so profiling overhead isn't counted.
+	var memStatsBefore, memStatsAfter runtime.MemStats
+	if shouldProfile {
+		runtime.ReadMemStats(&memStatsBefore)
+	}
+
 	start := time.Now()
-	fn()
+	recovered, stack := callRecovering(ctx, fn)
 	elapsed := time.Since(start)
+	if threshold := time.Duration(slowFunctionThreshold.Load()); threshold > 0 && elapsed > threshold {
+		logger.Log.Warn("slow traced function", "function", name, "duration", elapsed, "threshold", threshold)
+	}
 
 	if shouldProfile {
+		runtime.ReadMemStats(&memStatsAfter)
 		StopCPUProfile(cpuProfileFile)
 		if err := WriteHeapProfile(memProfFilePath); err != nil {
 			logger.Log.Warn("failed to write heap profile", "error", err)
 		}
+		blockProfFilePath = writeOptionalProfile("block", blockProfFilePath)
+		mutexProfFilePath = writeOptionalProfile("mutex", mutexProfFilePath)
+		profiledAt = now()
 	}
 
 	finalGoroutines := runtime.NumGoroutine() - initialGoroutines
@@ -235,68 +579,206 @@ func executeFunctionWithProfiling(name string, fn func()) {
 		finalGoroutines = 0
 	}
 
-	var memoryUsage uint64
+	var memoryUsage, allocsCount, freesCount uint64
 	if shouldProfile {
-		var memStatsAfter runtime.MemStats
-		runtime.ReadMemStats(&memStatsAfter)
 		if memStatsAfter.Alloc >= memStatsBefore.Alloc {
 			memoryUsage = memStatsAfter.Alloc - memStatsBefore.Alloc
 		}
+		// Mallocs and Frees are cumulative, so the deltas are the heap objects
+		// allocated and freed during the call.
+		allocsCount = memStatsAfter.Mallocs - memStatsBefore.Mallocs
+		freesCount = memStatsAfter.Frees - memStatsBefore.Frees
 	}
 
-	mu.Lock()
-	defer mu.Unlock()
-
-	if len(functionMetrics) > maxTrackedFunctions {
-		// Evict one arbitrary entry to cap memory.
-		for k := range functionMetrics {
-			delete(functionMetrics, k)
-			break
-		}
+	var lastPanic *models.FunctionPanic
+	if recovered != nil {
+		lastPanic = newFunctionPanic(name, start, recovered, stack)
 	}
 
-	if m, exists := functionMetrics[name]; exists {
+	mu.Lock()
+	metricsLRU.touch(key)
+	evictLRU(metricsLRU, limit, forgetFunction)
+	recordFunctionSample(key, models.FunctionSample{Timestamp: start, ExecutionTime: elapsed, MemoryUsage: memoryUsage})
+
+	if m, exists := functionMetrics[key]; exists {
 		m.FunctionLastRanAt = start
 		m.ExecutionTime = elapsed
 		m.GoroutineCount = finalGoroutines
+		m.CallCount++
 		if shouldProfile {
 			m.MemoryUsage = memoryUsage
+			m.AllocsCount = allocsCount
+			m.FreesCount = freesCount
 			m.CPUProfileFilePath = cpuProfFilePath
 			m.MemProfileFilePath = memProfFilePath
+			m.BlockProfileFilePath = blockProfFilePath
+			m.MutexProfileFilePath = mutexProfFilePath
+			m.ProfiledAt = profiledAt
+		}
+		if lastPanic != nil {
+			m.PanicCount++
+			m.LastPanic = lastPanic
 		}
 	} else {
-		functionMetrics[name] = &models.FunctionMetrics{
-			FunctionLastRanAt:  start,
-			ExecutionTime:      elapsed,
-			GoroutineCount:     finalGoroutines,
-			MemoryUsage:        memoryUsage,
-			CPUProfileFilePath: cpuProfFilePath,
-			MemProfileFilePath: memProfFilePath,
+		functionMetrics[key] = &models.FunctionMetrics{
+			FunctionLastRanAt:    start,
+			ExecutionTime:        elapsed,
+			GoroutineCount:       finalGoroutines,
+			CallCount:            1,
+			MemoryUsage:          memoryUsage,
+			AllocsCount:          allocsCount,
+			FreesCount:           freesCount,
+			CPUProfileFilePath:   cpuProfFilePath,
+			MemProfileFilePath:   memProfFilePath,
+			BlockProfileFilePath: blockProfFilePath,
+			MutexProfileFilePath: mutexProfFilePath,
+			ProfiledAt:           profiledAt,
+		}
+		if lastPanic != nil {
+			functionMetrics[key].PanicCount = 1
+			functionMetrics[key].LastPanic = lastPanic
 		}
 	}
+	mu.Unlock()
+
+	if recovered != nil && !swallowPanics.Load() {
+		panic(recovered)
+	}
+}
+
+// writeOptionalProfile writes the named profile to path unless path is empty,
+// returning the path written or "" on failure.
+func writeOptionalProfile(name, path string) string {
+	if path == "" {
+		return ""
+	}
+	if err := WriteLookupProfile(name, path); err != nil {
+		logger.Log.Warn("failed to write "+name+" profile", "error", err)
+		return ""
+	}
+	return path
+}
+
+// goroutineID returns the ID of the calling goroutine, parsed from the
+// "goroutine 18 [running]:" header of its stack.
+func goroutineID() uint64 {
+	var buf [64]byte
+	header := strings.TrimPrefix(string(buf[:runtime.Stack(buf[:], false)]), "goroutine ")
+	id, _, _ := strings.Cut(header, " ")
+	n, _ := strconv.ParseUint(id, 10, 64)
+	return n
+}
+
+// newFunctionPanic describes and logs a panic recovered from function name.
+func newFunctionPanic(name string, at time.Time, recovered any, stack []byte) *models.FunctionPanic {
+	p := &models.FunctionPanic{Time: at, Message: fmt.Sprint(recovered), Stack: string(stack)}
+	logger.Log.Error("traced function panicked", "function", name, "panic", p.Message)
+	return p
+}
+
+// callRecovering calls fn, recovering a panic and the stack it was raised at.
+func callRecovering(ctx context.Context, fn func(context.Context)) (recovered any, stack []byte) {
+	defer func() {
+		if recovered = recover(); recovered != nil {
+			stack = debug.Stack()
+		}
+	}()
+	fn(ctx)
+	return nil, nil
+}
+
+// SetAllowedReportTypes replaces the set of pprof report types that may be requested.
+// Values must be plain identifiers; anything containing flag syntax is ignored.
+func SetAllowedReportTypes(types []string) {
+	allowed := make(map[string]bool, len(types))
+	for _, t := range types {
+		if isValidReportTypeName(t) {
+			allowed[t] = true
+		}
+	}
+	reportTypesMu.Lock()
+	allowedReportTypes = allowed
+	reportTypesMu.Unlock()
+}
+
+// IsAllowedReportType reports whether reportType may be passed to pprof.
+func IsAllowedReportType(reportType string) bool {
+	reportTypesMu.RLock()
+	defer reportTypesMu.RUnlock()
+	return allowedReportTypes[reportType]
+}
+
+// isValidReportTypeName guards against values that would be interpreted as extra pprof flags.
+func isValidReportTypeName(t string) bool {
+	if t == "" {
+		return false
+	}
+	for _, r := range t {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
 }
 
 // ViewFunctionMetrics generates the function metrics
 func ViewFunctionMetrics(name, reportType string, metrics *models.FunctionMetrics) models.FunctionTraceDetails {
-	_, err := exec.LookPath("go")
-	if err != nil {
+	if !IsAllowedReportType(reportType) {
+		return models.FunctionTraceDetails{
+			FunctionName:       name,
+			ProfilingAvailable: false,
+			Message:            fmt.Sprintf("Report type %q is not allowed.", reportType),
+		}
+	}
+
+	if _, err := lookPath("go"); err != nil {
 		logger.Log.Warn("'go' command not found in PATH, pprof reports will be unavailable")
 		return models.FunctionTraceDetails{
-			FunctionName: name,
-			CoreProfile: models.Profiles{
-				CPU: "Error: 'go' command not found. pprof reports require the Go SDK.",
-				Mem: "Error: 'go' command not found. pprof reports require the Go SDK.",
-			},
-			FunctionCodeTrace: "Error: 'go' command not found.",
+			FunctionName:       name,
+			ProfilingAvailable: false,
+			Message:            "pprof reports require the Go SDK, but the 'go' command was not found in PATH.",
+		}
+	}
+
+	if reportType == "svg" || reportType == "flamegraph" {
+		if _, err := lookPath("dot"); err != nil {
+			logger.Log.Warn("'dot' command not found in PATH, svg and flamegraph reports will be unavailable")
+			return models.FunctionTraceDetails{
+				FunctionName:       name,
+				ProfilingAvailable: false,
+				Message:            "svg and flamegraph reports require Graphviz, but the 'dot' command was not found in PATH.",
+			}
+		}
+	}
+
+	if metrics.CPUProfileFilePath == "" && metrics.MemProfileFilePath == "" {
+		return models.FunctionTraceDetails{
+			FunctionName:       name,
+			ProfilingAvailable: false,
+			Message:            "No profile has been captured for this function yet. Profiles are written when a call is sampled.",
+		}
+	}
+
+	age, tooOld := profileAge(metrics)
+	if tooOld {
+		return models.FunctionTraceDetails{
+			FunctionName:       name,
+			ProfilingAvailable: false,
+			ProfileAge:         age,
+			Message: fmt.Sprintf("The last profile was captured %s ago, beyond the maximum profile age of %s, so it may not match the current metrics. A new profile is written when a call is sampled.",
+				age.Round(time.Second), time.Duration(maxProfileAge.Load())),
 		}
 	}
 
+	if reportType == "flamegraph" {
+		reportType = "svg"
+	}
+
 	executePprof := func(profileFilePath, reportType string) string {
 		if profileFilePath == "" {
 			return "Error: Profile file path is empty"
 		}
-		cmd := exec.Command("go", "tool", "pprof", "-"+reportType, profileFilePath)
-		output, err := cmd.CombinedOutput()
+		output, err := runPprof("-"+reportType, profileFilePath)
 		if err != nil {
 			return fmt.Sprintf("Error executing pprof: %v\nOutput: %s", err, string(output))
 		}
@@ -305,8 +787,7 @@ func ViewFunctionMetrics(name, reportType string, metrics *models.FunctionMetric
 
 	var codeStack string
 	if metrics.CPUProfileFilePath != "" {
-		codeStackView := exec.Command("go", "tool", "pprof", "-list", name, metrics.CPUProfileFilePath)
-		output, err := codeStackView.CombinedOutput()
+		output, err := runPprof("-list", name, metrics.CPUProfileFilePath)
 		if err != nil {
 			codeStack = fmt.Sprintf("Error generating code trace: %v\nOutput: %s", err, string(output))
 		} else {
@@ -314,12 +795,87 @@ func ViewFunctionMetrics(name, reportType string, metrics *models.FunctionMetric
 		}
 	}
 
+	profiles := models.Profiles{
+		CPU: executePprof(metrics.CPUProfileFilePath, reportType),
+		Mem: executePprof(metrics.MemProfileFilePath, reportType),
+	}
+	if metrics.BlockProfileFilePath != "" {
+		profiles.Block = executePprof(metrics.BlockProfileFilePath, reportType)
+	}
+	if metrics.MutexProfileFilePath != "" {
+		profiles.Mutex = executePprof(metrics.MutexProfileFilePath, reportType)
+	}
+
 	return models.FunctionTraceDetails{
-		FunctionName: name,
-		CoreProfile: models.Profiles{
-			CPU: executePprof(metrics.CPUProfileFilePath, reportType),
-			Mem: executePprof(metrics.MemProfileFilePath, reportType),
-		},
-		FunctionCodeTrace: codeStack,
+		FunctionName:       name,
+		CoreProfile:        profiles,
+		FunctionCodeTrace:  codeStack,
+		ProfilingAvailable: true,
+		ProfileAge:         age,
+	}
+}
+
+// FunctionFlamegraph renders the CPU profile of a traced function as an SVG call graph.
+// It returns ErrProfilingUnavailable when the Go SDK is missing, ErrNoProfile when
+// the function has not been sampled yet and ErrProfileTooOld when its profile is
+// older than the maximum profile age.
+func FunctionFlamegraph(metrics *models.FunctionMetrics) ([]byte, error) {
+	if _, err := lookPath("go"); err != nil {
+		return nil, ErrProfilingUnavailable
+	}
+	if _, err := lookPath("dot"); err != nil {
+		return nil, ErrGraphvizUnavailable
+	}
+	if metrics == nil || metrics.CPUProfileFilePath == "" {
+		return nil, ErrNoProfile
+	}
+	if _, tooOld := profileAge(metrics); tooOld {
+		return nil, ErrProfileTooOld
+	}
+
+	output, err := runPprof("-svg", metrics.CPUProfileFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error executing pprof: %w: %s", err, string(output))
+	}
+	return output, nil
+}
+
+// SetPprofTimeout sets the maximum time a single `go tool pprof` invocation may run.
+func SetPprofTimeout(d time.Duration) {
+	if d <= 0 {
+		d = defaultPprofTimeout
+	}
+	pprofTimeout.Store(int64(d))
+}
+
+// runPprof runs `go tool pprof` with the given arguments and returns its combined output.
+// It is a variable so tests can substitute the subprocess.
+var runPprof = runPprofCommand
+
+// pprofCommand builds the pprof subprocess; replaceable in tests.
+var pprofCommand = func(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "go", append([]string{"tool", "pprof"}, args...)...)
+}
+
+// runPprofCommand runs pprof under a timeout, limiting how many invocations run at once.
+func runPprofCommand(args ...string) ([]byte, error) {
+	timeout := time.Duration(pprofTimeout.Load())
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	select {
+	case pprofSemaphore <- struct{}{}:
+		defer func() { <-pprofSemaphore }()
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out after %s waiting for a free pprof slot", timeout)
+	}
+
+	cmd := pprofCommand(ctx, args...)
+	killProcessGroup(cmd)
+	cmd.WaitDelay = pprofWaitDelay
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return output, fmt.Errorf("pprof timed out after %s", timeout)
 	}
+	return output, err
 }
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"time"
+
+	"github.com/iyashjayesh/monigo/models"
+)
+
+const defaultFunctionHistorySize = 50
+
+var (
+	// functionHistory holds the recent samples of each traced function, keyed
+	// like functionMetrics. Guarded by mu.
+	functionHistory    = make(map[string]*sampleRing)
+	functionHistoryCap = defaultFunctionHistorySize // guarded by mu
+)
+
+// sampleRing is a fixed-size ring of samples, oldest first.
+type sampleRing struct {
+	samples []models.FunctionSample
+	next    int
+	full    bool
+}
+
+func newSampleRing(size int) *sampleRing {
+	return &sampleRing{samples: make([]models.FunctionSample, size)}
+}
+
+func (r *sampleRing) add(s models.FunctionSample) {
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the samples in the order they were added.
+func (r *sampleRing) list() []models.FunctionSample {
+	if !r.full {
+		return append([]models.FunctionSample(nil), r.samples[:r.next]...)
+	}
+	out := make([]models.FunctionSample, 0, len(r.samples))
+	out = append(out, r.samples[r.next:]...)
+	return append(out, r.samples[:r.next]...)
+}
+
+// SetFunctionHistorySize sets how many recent executions are kept per traced
+// function. Existing histories keep their newest samples. 0 disables history.
+func SetFunctionHistorySize(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	functionHistoryCap = n
+	for key, ring := range functionHistory {
+		if n == 0 {
+			delete(functionHistory, key)
+			continue
+		}
+		resized := newSampleRing(n)
+		samples := ring.list()
+		if len(samples) > n {
+			samples = samples[len(samples)-n:]
+		}
+		for _, s := range samples {
+			resized.add(s)
+		}
+		functionHistory[key] = resized
+	}
+}
+
+// recordFunctionSample appends a sample to the history of key. Callers hold mu.
+func recordFunctionSample(key string, s models.FunctionSample) {
+	if functionHistoryCap == 0 {
+		return
+	}
+	ring, ok := functionHistory[key]
+	if !ok {
+		ring = newSampleRing(functionHistoryCap)
+		functionHistory[key] = ring
+	}
+	ring.add(s)
+}
+
+// FunctionHistory returns the recent executions of a function of the default
+// namespace, oldest first, or nil if it has no history.
+func FunctionHistory(name string) []models.FunctionSample {
+	return FunctionHistoryFor("", name)
+}
+
+// FunctionHistoryFor is FunctionHistory for a function of namespace.
+func FunctionHistoryFor(namespace, name string) []models.FunctionSample {
+	mu.Lock()
+	defer mu.Unlock()
+	ring, ok := functionHistory[functionKey(namespace, name)]
+	if !ok {
+		return nil
+	}
+	return ring.list()
+}
+
+// FunctionSamplesSince returns, for every namespace and function, the
+// samples taken after since, oldest first.
+func FunctionSamplesSince(since time.Time) map[string]map[string][]models.FunctionSample {
+	mu.Lock()
+	defer mu.Unlock()
+
+	result := make(map[string]map[string][]models.FunctionSample)
+	for key, ring := range functionHistory {
+		var recent []models.FunctionSample
+		for _, s := range ring.list() {
+			if s.Timestamp.After(since) {
+				recent = append(recent, s)
+			}
+		}
+		if len(recent) == 0 {
+			continue
+		}
+		ns, name := splitFunctionKey(key)
+		if result[ns] == nil {
+			result[ns] = make(map[string][]models.FunctionSample)
+		}
+		result[ns][name] = recent
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func historyFunctionForTest() { time.Sleep(time.Millisecond) }
+
+func historyNameForTest(t *testing.T, namespace string) string {
+	t.Helper()
+	for name := range FunctionTraceDetailsFor(namespace) {
+		if strings.HasSuffix(name, "historyFunctionForTest") {
+			return name
+		}
+	}
+	t.Fatal("traced function not found")
+	return ""
+}
+
+func TestFunctionHistory_CapsAndKeepsOrder(t *testing.T) {
+	SetFunctionHistorySize(3)
+	defer SetFunctionHistorySize(defaultFunctionHistorySize)
+
+	ctx := WithNamespace(context.Background(), "history-test")
+	for i := 0; i < 5; i++ {
+		TraceFunction(ctx, historyFunctionForTest)
+	}
+	name := historyNameForTest(t, "history-test")
+
+	history := FunctionHistoryFor("history-test", name)
+	if len(history) != 3 {
+		t.Fatalf("expected the history to be capped at 3, got %d", len(history))
+	}
+	for i := 1; i < len(history); i++ {
+		if history[i].Timestamp.Before(history[i-1].Timestamp) {
+			t.Errorf("expected samples oldest first, got %v before %v", history[i-1].Timestamp, history[i].Timestamp)
+		}
+	}
+	if last := FunctionTraceDetailsFor("history-test")[name].FunctionLastRanAt; !history[2].Timestamp.Equal(last) {
+		t.Errorf("expected the newest sample to be the last execution at %v, got %v", last, history[2].Timestamp)
+	}
+	for _, s := range history {
+		if s.ExecutionTime < time.Millisecond {
+			t.Errorf("expected execution time >= 1ms, got %v", s.ExecutionTime)
+		}
+	}
+
+	// Shrinking keeps the newest samples.
+	SetFunctionHistorySize(2)
+	if shrunk := FunctionHistoryFor("history-test", name); len(shrunk) != 2 || !shrunk[1].Timestamp.Equal(history[2].Timestamp) {
+		t.Errorf("expected the 2 newest samples after shrinking, got %v", shrunk)
+	}
+
+	if FunctionHistory(name) != nil {
+		t.Error("expected no history for the function in the default namespace")
+	}
+}
+
+func TestFunctionSamplesSince(t *testing.T) {
+	ctx := WithNamespace(context.Background(), "samples-test")
+	TraceFunction(ctx, historyFunctionForTest)
+	mark := time.Now()
+	TraceFunction(ctx, historyFunctionForTest)
+	name := historyNameForTest(t, "samples-test")
+
+	if got := FunctionSamplesSince(mark)["samples-test"][name]; len(got) != 1 {
+		t.Errorf("expected 1 sample after the mark, got %d", len(got))
+	}
+}
@@ -1,8 +1,27 @@
 package core
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/iyashjayesh/monigo/internal/logger"
+	"github.com/iyashjayesh/monigo/models"
 )
 
 func TestTraceFunction(t *testing.T) {
@@ -20,6 +39,53 @@ func TestTraceFunction(t *testing.T) {
 	}
 }
 
+var allocSink [][]byte
+
+func allocateSlicesForTest() {
+	for i := 0; i < 100; i++ {
+		allocSink = append(allocSink, make([]byte, 64))
+	}
+}
+
+func emptyFunctionForTest() {}
+
+func TestTraceFunction_AllocsCount(t *testing.T) {
+	SetSamplingRate(1)
+
+	tracedAllocs := func(f func()) uint64 {
+		TraceFunction(context.Background(), f)
+		name := strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), "/", "-")
+		m, ok := FunctionTraceDetails()[name]
+		if !ok {
+			t.Fatalf("expected trace entry for %s", name)
+		}
+		return m.AllocsCount
+	}
+
+	baseline := tracedAllocs(emptyFunctionForTest)
+	if baseline > 10 {
+		t.Errorf("expected profiling overhead to be excluded, got %d allocations for an empty function", baseline)
+	}
+	if got := tracedAllocs(allocateSlicesForTest); got < baseline+100 {
+		t.Errorf("expected at least %d allocations, got %d", baseline+100, got)
+	}
+}
+
+func TestTraceFunction_FreesCount(t *testing.T) {
+	SetSamplingRate(1)
+	TraceFunction(context.Background(), runtime.GC)
+
+	name := strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(runtime.GC).Pointer()).Name(), "/", "-")
+	m, ok := FunctionTraceDetails()[name]
+	if !ok {
+		t.Fatalf("expected trace entry for %s", name)
+	}
+	// Earlier tests left garbage behind, so a forced GC frees some of it.
+	if m.FreesCount == 0 {
+		t.Error("expected frees to be recorded for a call that runs the GC")
+	}
+}
+
 func TestTraceFunctionWithArgs(t *testing.T) {
 	SetSamplingRate(1)
 	var got string
@@ -44,6 +110,49 @@ func TestTraceFunctionWithArgs_NotAFunction(t *testing.T) {
 	TraceFunctionWithArgs(context.Background(), "not-a-function")
 }
 
+func joinForTest(sep string, parts ...string) string { return strings.Join(parts, sep) }
+
+func TestTraceFunctionWithArgs_Variadic(t *testing.T) {
+	SetSamplingRate(1)
+
+	for _, tc := range []struct {
+		args []interface{}
+		want string
+	}{
+		{[]interface{}{","}, ""},
+		{[]interface{}{",", "a"}, "a"},
+		{[]interface{}{",", "a", "b", "c"}, "a,b,c"},
+	} {
+		if got := TraceFunctionWithReturn(context.Background(), joinForTest, tc.args...); got != tc.want {
+			t.Errorf("args %v: expected %q, got %v", tc.args, tc.want, got)
+		}
+	}
+
+	var got []int
+	TraceFunctionWithArgs(context.Background(), func(first int, rest ...int) { got = append([]int{first}, rest...) }, 1, 2, 3)
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("expected [1 2 3], got %v", got)
+	}
+
+	name := strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(joinForTest).Pointer()).Name(), "/", "-") + "(string,...string)->(string)"
+	if m, ok := FunctionTraceDetails()[name]; !ok || m.CallCount != 3 {
+		t.Errorf("expected %s traced 3 times, got %+v", name, m)
+	}
+}
+
+func TestTraceFunctionWithArgs_VariadicMismatch(t *testing.T) {
+	SetSamplingRate(1)
+
+	// The fixed parameters are still required.
+	if got := TraceFunctionWithReturns(context.Background(), joinForTest); got != nil {
+		t.Errorf("expected a count mismatch without the separator, got %v", got)
+	}
+	// Trailing arguments must match the element type.
+	if got := TraceFunctionWithReturns(context.Background(), joinForTest, ",", "a", 3); got != nil {
+		t.Errorf("expected a type mismatch for a trailing int, got %v", got)
+	}
+}
+
 func TestTraceFunctionWithReturn(t *testing.T) {
 	SetSamplingRate(1)
 	fn := func(a, b int) int { return a + b }
@@ -70,6 +179,59 @@ func TestTraceFunctionWithReturns(t *testing.T) {
 	}
 }
 
+func describeForTest(v any, d fmt.Stringer) string { return fmt.Sprintf("%v/%s", v, d) }
+
+func TestTraceFunctionWithReturns_SignatureCaching(t *testing.T) {
+	SetSamplingRate(1)
+	defer SetSignatureCaching(true)
+
+	adders := make([]func(int) int, 3)
+	for i := range adders {
+		n := i
+		adders[i] = func(v int) int { return v + n }
+	}
+
+	for _, enabled := range []bool{false, true} {
+		SetSignatureCaching(enabled)
+
+		// The same function called with different dynamic argument types.
+		for _, tc := range []struct {
+			v    any
+			want string
+		}{
+			{42, "42/1s"},
+			{"text", "text/1s"},
+			{[]int{1, 2}, "[1 2]/1s"},
+		} {
+			got := TraceFunctionWithReturn(context.Background(), describeForTest, tc.v, time.Second)
+			if got != tc.want {
+				t.Errorf("caching %v: expected %q, got %v", enabled, tc.want, got)
+			}
+		}
+
+		// A cached signature still rejects mismatched arguments.
+		if got := TraceFunctionWithReturns(context.Background(), describeForTest, 42, "not a Stringer"); got != nil {
+			t.Errorf("caching %v: expected a type mismatch, got %v", enabled, got)
+		}
+		if got := TraceFunctionWithReturns(context.Background(), describeForTest, 42); got != nil {
+			t.Errorf("caching %v: expected a count mismatch, got %v", enabled, got)
+		}
+
+		// Closures sharing a signature keep their own captured state.
+		for i, add := range adders {
+			if got := TraceFunctionWithReturn(context.Background(), add, 10); got != 10+i {
+				t.Errorf("caching %v: expected %d from closure %d, got %v", enabled, 10+i, i, got)
+			}
+		}
+	}
+
+	name := strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(describeForTest).Pointer()).Name(), "/", "-") +
+		"(interface {},fmt.Stringer)->(string)"
+	if m, ok := FunctionTraceDetails()[name]; !ok || m.CallCount != 6 {
+		t.Errorf("expected %s traced 6 times under one name, got %+v", name, m)
+	}
+}
+
 func TestSetSamplingRate(t *testing.T) {
 	SetSamplingRate(1)
 	if samplingRate.Load() != 1 {
@@ -109,3 +271,967 @@ func TestFunctionTraceDetailsReturnsCopy(t *testing.T) {
 		t.Error("expected FunctionTraceDetails to return independent copies")
 	}
 }
+
+func TestViewFunctionMetrics_GoMissing(t *testing.T) {
+	orig := lookPath
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	defer func() { lookPath = orig }()
+
+	details := ViewFunctionMetrics("fn", "text", &models.FunctionMetrics{CPUProfileFilePath: "cpu.prof"})
+	if details.ProfilingAvailable {
+		t.Error("expected ProfilingAvailable=false when go is missing")
+	}
+	if details.Message == "" {
+		t.Error("expected an explanatory message")
+	}
+	if details.CoreProfile.CPU != "" || details.CoreProfile.Mem != "" {
+		t.Error("expected no profile text when go is missing")
+	}
+}
+
+func TestViewFunctionMetrics_GraphvizMissing(t *testing.T) {
+	origLookPath, origRun := lookPath, runPprof
+	defer func() { lookPath, runPprof = origLookPath, origRun }()
+	lookPath = func(file string) (string, error) {
+		if file == "dot" {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/bin/" + file, nil
+	}
+	called := false
+	runPprof = func(args ...string) ([]byte, error) { called = true; return nil, nil }
+
+	for _, rt := range []string{"svg", "flamegraph"} {
+		details := ViewFunctionMetrics("fn", rt, &models.FunctionMetrics{CPUProfileFilePath: "cpu.prof"})
+		if details.ProfilingAvailable {
+			t.Errorf("%s: expected ProfilingAvailable=false when dot is missing", rt)
+		}
+		if !strings.Contains(details.Message, "Graphviz") {
+			t.Errorf("%s: expected a message naming Graphviz, got %q", rt, details.Message)
+		}
+	}
+	if called {
+		t.Error("pprof must not run when dot is missing")
+	}
+
+	// Text reports don't need Graphviz.
+	ViewFunctionMetrics("fn", "text", &models.FunctionMetrics{CPUProfileFilePath: "cpu.prof"})
+	if !called {
+		t.Error("expected text reports to run without dot")
+	}
+}
+
+func TestIsAllowedReportType(t *testing.T) {
+	for _, rt := range []string{"top", "list", "tree", "text", "traces", "svg"} {
+		if !IsAllowedReportType(rt) {
+			t.Errorf("expected %q to be allowed", rt)
+		}
+	}
+	for _, rt := range []string{"", "web", "output=/etc/passwd", "-output=/tmp/x", "top -http=:0"} {
+		if IsAllowedReportType(rt) {
+			t.Errorf("expected %q to be rejected", rt)
+		}
+	}
+}
+
+func TestSetAllowedReportTypes(t *testing.T) {
+	reportTypesMu.RLock()
+	orig := allowedReportTypes
+	reportTypesMu.RUnlock()
+	defer func() {
+		reportTypesMu.Lock()
+		allowedReportTypes = orig
+		reportTypesMu.Unlock()
+	}()
+
+	SetAllowedReportTypes([]string{"top", "output=/etc/passwd"})
+	if !IsAllowedReportType("top") {
+		t.Error("expected top to be allowed")
+	}
+	if IsAllowedReportType("text") {
+		t.Error("expected text to be rejected after narrowing the allowlist")
+	}
+	if IsAllowedReportType("output=/etc/passwd") {
+		t.Error("expected values with flag syntax to be ignored")
+	}
+}
+
+func TestViewFunctionMetrics_DisallowedReportType(t *testing.T) {
+	called := false
+	orig := runPprof
+	runPprof = func(args ...string) ([]byte, error) { called = true; return nil, nil }
+	defer func() { runPprof = orig }()
+
+	details := ViewFunctionMetrics("fn", "output=/etc/passwd", &models.FunctionMetrics{CPUProfileFilePath: "cpu.prof"})
+	if details.ProfilingAvailable {
+		t.Error("expected ProfilingAvailable=false for a disallowed report type")
+	}
+	if called {
+		t.Error("pprof must not run for a disallowed report type")
+	}
+}
+
+func TestViewFunctionMetrics_StaleProfile(t *testing.T) {
+	origLookPath, origRun := lookPath, runPprof
+	defer func() { lookPath, runPprof = origLookPath, origRun }()
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	var pprofRuns int
+	runPprof = func(args ...string) ([]byte, error) { pprofRuns++; return []byte("report"), nil }
+
+	current := time.Unix(1_700_000_000, 0)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+	defer SetMaxProfileAge(0)
+
+	metrics := &models.FunctionMetrics{
+		CPUProfileFilePath: "cpu.prof",
+		MemProfileFilePath: "mem.prof",
+		ProfiledAt:         current.Add(-time.Hour),
+	}
+
+	details := ViewFunctionMetrics("fn", "text", metrics)
+	if !details.ProfilingAvailable || details.ProfileAge != time.Hour {
+		t.Errorf("expected the stale profile rendered with age 1h, got available=%v age=%s", details.ProfilingAvailable, details.ProfileAge)
+	}
+
+	SetMaxProfileAge(10 * time.Minute)
+	pprofRuns = 0
+	details = ViewFunctionMetrics("fn", "text", metrics)
+	if details.ProfilingAvailable {
+		t.Error("expected a profile older than the maximum age to be refused")
+	}
+	if details.ProfileAge != time.Hour {
+		t.Errorf("expected the refused profile's age 1h, got %s", details.ProfileAge)
+	}
+	if want := "captured 1h0m0s ago, beyond the maximum profile age of 10m0s"; !strings.Contains(details.Message, want) {
+		t.Errorf("expected message to contain %q, got %q", want, details.Message)
+	}
+	if pprofRuns != 0 {
+		t.Errorf("pprof must not run for a refused profile, ran %d times", pprofRuns)
+	}
+	if _, err := FunctionFlamegraph(metrics); !errors.Is(err, ErrProfileTooOld) {
+		t.Errorf("expected ErrProfileTooOld from the flamegraph, got %v", err)
+	}
+
+	metrics.ProfiledAt = current.Add(-time.Minute)
+	if details := ViewFunctionMetrics("fn", "text", metrics); !details.ProfilingAvailable || details.ProfileAge != time.Minute {
+		t.Errorf("expected a recent profile rendered with age 1m, got available=%v age=%s", details.ProfilingAvailable, details.ProfileAge)
+	}
+}
+
+func profiledFunctionForTest() {
+	for i := 0; i < 1000; i++ {
+		allocSink = append(allocSink, make([]byte, 64))
+	}
+	allocSink = nil
+}
+
+func TestTraceFunction_GzippedProfiles(t *testing.T) {
+	SetSamplingRate(1)
+	TraceFunction(context.Background(), profiledFunctionForTest)
+
+	name := strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(profiledFunctionForTest).Pointer()).Name(), "/", "-")
+	m, ok := FunctionTraceDetails()[name]
+	if !ok {
+		t.Fatalf("expected trace entry for %s", name)
+	}
+	if m.ProfiledAt.IsZero() {
+		t.Error("expected the profile capture time to be recorded")
+	}
+
+	for _, path := range []string{m.CPUProfileFilePath, m.MemProfileFilePath} {
+		if !strings.HasSuffix(path, ".prof.gz") {
+			t.Errorf("expected a .prof.gz profile, got %q", path)
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("open profile: %v", err)
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Errorf("expected %s to be gzipped: %v", path, err)
+		} else if _, err := io.Copy(io.Discard, zr); err != nil {
+			t.Errorf("expected %s to decompress: %v", path, err)
+		}
+		f.Close()
+	}
+
+	if _, err := lookPath("go"); err != nil {
+		t.Skip("go command not available to render the profiles")
+	}
+	details := ViewFunctionMetrics(name, "top", m)
+	if !details.ProfilingAvailable {
+		t.Fatalf("expected profiling to be available: %s", details.Message)
+	}
+	for kind, report := range map[string]string{"CPU": details.CoreProfile.CPU, "memory": details.CoreProfile.Mem} {
+		if strings.HasPrefix(report, "Error") {
+			t.Errorf("expected pprof to read the gzipped %s profile, got %s", kind, report)
+		}
+	}
+}
+
+// contendedFunctionForTest blocks on a channel and a contended mutex.
+func contendedFunctionForTest() {
+	var mu sync.Mutex
+	mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		mu.Lock()
+		mu.Unlock()
+		close(done)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	mu.Unlock()
+	<-done
+}
+
+func TestTraceFunction_BlockAndMutexProfiles(t *testing.T) {
+	SetSamplingRate(1)
+	ResetFunctionMetrics()
+	defer ResetFunctionMetrics()
+	name := strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(contendedFunctionForTest).Pointer()).Name(), "/", "-")
+
+	TraceFunction(context.Background(), contendedFunctionForTest)
+	if m := FunctionTraceDetails()[name]; m.BlockProfileFilePath != "" || m.MutexProfileFilePath != "" {
+		t.Fatalf("expected no block or mutex profile while disabled, got %q and %q", m.BlockProfileFilePath, m.MutexProfileFilePath)
+	}
+
+	EnableBlockProfiling()
+	EnableMutexProfiling()
+	defer DisableBlockProfiling()
+	defer DisableMutexProfiling()
+
+	TraceFunction(context.Background(), contendedFunctionForTest)
+	m := FunctionTraceDetails()[name]
+	for kind, path := range map[string]string{"block": m.BlockProfileFilePath, "mutex": m.MutexProfileFilePath} {
+		if !strings.HasSuffix(path, "_"+kind+".prof.gz") {
+			t.Errorf("expected a %s profile, got %q", kind, path)
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("open %s profile: %v", kind, err)
+		}
+		if _, err := gzip.NewReader(f); err != nil {
+			t.Errorf("expected the %s profile to be gzipped: %v", kind, err)
+		}
+		f.Close()
+	}
+
+	origLookPath, origRunPprof := lookPath, runPprof
+	lookPath = func(string) (string, error) { return "/usr/bin/go", nil }
+	runPprof = func(args ...string) ([]byte, error) { return []byte("report " + args[len(args)-1]), nil }
+	defer func() { lookPath, runPprof = origLookPath, origRunPprof }()
+
+	details := ViewFunctionMetrics(name, "top", m)
+	if details.CoreProfile.Block != "report "+m.BlockProfileFilePath || details.CoreProfile.Mutex != "report "+m.MutexProfileFilePath {
+		t.Errorf("expected block and mutex reports, got %q and %q", details.CoreProfile.Block, details.CoreProfile.Mutex)
+	}
+}
+
+func TestViewFunctionMetrics_EmptyProfilePath(t *testing.T) {
+	orig := lookPath
+	lookPath = func(string) (string, error) { return "/usr/bin/go", nil }
+	defer func() { lookPath = orig }()
+
+	details := ViewFunctionMetrics("fn", "text", &models.FunctionMetrics{})
+	if details.ProfilingAvailable {
+		t.Error("expected ProfilingAvailable=false when no profile was captured")
+	}
+	if details.Message == "" {
+		t.Error("expected an explanatory message")
+	}
+}
+
+func TestFunctionFlamegraph(t *testing.T) {
+	origLookPath, origRun := lookPath, runPprof
+	defer func() { lookPath, runPprof = origLookPath, origRun }()
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+
+	const profilePath = "fixture_cpu.prof"
+	var gotArgs []string
+	runPprof = func(args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte("<svg></svg>"), nil
+	}
+
+	svg, err := FunctionFlamegraph(&models.FunctionMetrics{CPUProfileFilePath: profilePath})
+	if err != nil {
+		t.Fatalf("FunctionFlamegraph error: %v", err)
+	}
+	if !strings.HasPrefix(string(svg), "<svg") {
+		t.Errorf("expected SVG output, got %q", svg)
+	}
+	if len(gotArgs) != 2 || gotArgs[0] != "-svg" || gotArgs[1] != profilePath {
+		t.Errorf("unexpected pprof args: %v", gotArgs)
+	}
+}
+
+func TestFunctionFlamegraph_Unavailable(t *testing.T) {
+	orig := lookPath
+	defer func() { lookPath = orig }()
+
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	if _, err := FunctionFlamegraph(&models.FunctionMetrics{CPUProfileFilePath: "cpu.prof"}); !errors.Is(err, ErrProfilingUnavailable) {
+		t.Errorf("expected ErrProfilingUnavailable, got %v", err)
+	}
+
+	lookPath = func(file string) (string, error) {
+		if file == "dot" {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/bin/" + file, nil
+	}
+	if _, err := FunctionFlamegraph(&models.FunctionMetrics{CPUProfileFilePath: "cpu.prof"}); !errors.Is(err, ErrGraphvizUnavailable) {
+		t.Errorf("expected ErrGraphvizUnavailable, got %v", err)
+	}
+
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	if _, err := FunctionFlamegraph(&models.FunctionMetrics{}); !errors.Is(err, ErrNoProfile) {
+		t.Errorf("expected ErrNoProfile, got %v", err)
+	}
+}
+
+func TestRunPprofCommand_Timeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("'sleep' command not available")
+	}
+	origCmd := pprofCommand
+	defer func() {
+		pprofCommand = origCmd
+		SetPprofTimeout(defaultPprofTimeout)
+	}()
+
+	pprofCommand = func(ctx context.Context, _ ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sleep", "10")
+	}
+	SetPprofTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	_, err := runPprofCommand("-text", "cpu.prof")
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected hung pprof to be killed quickly, took %s", elapsed)
+	}
+}
+
+func TestRunPprofCommand_TimeoutKillsChildren(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("'sh' command not available")
+	}
+	origCmd := pprofCommand
+	defer func() {
+		pprofCommand = origCmd
+		SetPprofTimeout(defaultPprofTimeout)
+	}()
+
+	// Like `go tool pprof`, sh starts the long-running process as a child that
+	// inherits the output pipe.
+	pprofCommand = func(ctx context.Context, _ ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", "sleep 10; echo done")
+	}
+	SetPprofTimeout(200 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := runPprofCommand("-text", "cpu.prof"); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected pprof and its children to be killed quickly, took %s", elapsed)
+	}
+}
+
+func TestRunPprofCommand_ConcurrencyLimit(t *testing.T) {
+	origCmd := pprofCommand
+	defer func() {
+		pprofCommand = origCmd
+		SetPprofTimeout(defaultPprofTimeout)
+	}()
+
+	// Occupy every slot so the next invocation has to wait for one.
+	for i := 0; i < cap(pprofSemaphore); i++ {
+		pprofSemaphore <- struct{}{}
+	}
+	defer func() {
+		for i := 0; i < cap(pprofSemaphore); i++ {
+			<-pprofSemaphore
+		}
+	}()
+
+	called := false
+	pprofCommand = func(ctx context.Context, _ ...string) *exec.Cmd {
+		called = true
+		return exec.CommandContext(ctx, "true")
+	}
+	SetPprofTimeout(50 * time.Millisecond)
+
+	if _, err := runPprofCommand("-text", "cpu.prof"); err == nil {
+		t.Fatal("expected an error while all pprof slots are busy")
+	}
+	if called {
+		t.Error("expected pprof not to run while the semaphore is full")
+	}
+}
+
+func TestSetMaxTrackedFunctions_Invalid(t *testing.T) {
+	if err := SetMaxTrackedFunctions(0); err == nil {
+		t.Error("expected error for a cap of 0")
+	}
+	if got := maxTrackedFunctions.Load(); got != defaultMaxTrackedFunctions {
+		t.Errorf("expected cap to stay at %d, got %d", defaultMaxTrackedFunctions, got)
+	}
+}
+
+func TestSetMaxTrackedFunctions_EvictsLeastRecentlyUsed(t *testing.T) {
+	SetSamplingRate(1)
+	if err := SetMaxTrackedFunctions(2); err != nil {
+		t.Fatalf("SetMaxTrackedFunctions: %v", err)
+	}
+	defer SetMaxTrackedFunctions(defaultMaxTrackedFunctions)
+
+	first := func() {}
+	second := func() {}
+	third := func() {}
+	nameOf := func(f func()) string {
+		return strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), "/", "-")
+	}
+
+	TraceFunction(context.Background(), first)
+	TraceFunction(context.Background(), second)
+	TraceFunction(context.Background(), first) // first is now the most recent
+	TraceFunction(context.Background(), third)
+
+	details := FunctionTraceDetails()
+	if len(details) != 2 {
+		t.Fatalf("expected 2 tracked functions, got %d", len(details))
+	}
+	if _, ok := details[nameOf(second)]; ok {
+		t.Error("expected least recently used function to be evicted")
+	}
+	for _, f := range []func(){first, third} {
+		if _, ok := details[nameOf(f)]; !ok {
+			t.Errorf("expected %s to still be tracked", nameOf(f))
+		}
+	}
+
+	countersMu.Lock()
+	counters := len(callCounters)
+	countersMu.Unlock()
+	if counters != 2 {
+		t.Errorf("expected 2 call counters, got %d", counters)
+	}
+}
+
+func namespacedFunctionForTest() {}
+
+func TestFunctionTraceDetailsFor_Namespaces(t *testing.T) {
+	SetSamplingRate(1)
+	ctx := WithNamespace(context.Background(), "orders")
+	TraceFunction(ctx, namespacedFunctionForTest)
+
+	var name string
+	for n := range FunctionTraceDetailsFor("orders") {
+		if strings.HasSuffix(n, "namespacedFunctionForTest") {
+			name = n
+		}
+	}
+	if name == "" {
+		t.Fatal("expected the function in its namespace")
+	}
+	if _, ok := FunctionTraceDetails()[name]; ok {
+		t.Error("expected the function not to be listed in the default namespace")
+	}
+	if _, ok := FunctionTraceDetailsFor("billing")[name]; ok {
+		t.Error("expected the function not to be listed in another namespace")
+	}
+	if m := FunctionTraceDetailsFor("orders")[name]; m.CPUProfileFilePath != "" && strings.Contains(m.CPUProfileFilePath, namespaceSep) {
+		t.Errorf("expected a sanitized profile path, got %q", m.CPUProfileFilePath)
+	}
+}
+
+func TestTraceFunction_NilContext(t *testing.T) {
+	called := false
+	//nolint:staticcheck // a nil context must not panic
+	TraceFunction(nil, func() { called = true })
+	if !called {
+		t.Error("expected function to be called")
+	}
+}
+
+func panickingFunctionForTest() { panic("boom") }
+
+func panickingMetricsForTest(t *testing.T) *models.FunctionMetrics {
+	t.Helper()
+	for name, m := range FunctionTraceDetails() {
+		if strings.HasSuffix(name, "panickingFunctionForTest") {
+			return m
+		}
+	}
+	t.Fatal("expected metrics for the panicking function")
+	return nil
+}
+
+type receiverForTest struct {
+	n    int
+	next *receiverForTest
+}
+
+func (r *receiverForTest) Get() int          { return r.n }
+func (r *receiverForTest) NextN() int        { return r.next.n }
+func (r *receiverForTest) Add(delta int) int { return r.n + delta }
+func (r *receiverForTest) NilSafe() string {
+	if r == nil {
+		return "nil"
+	}
+	return "set"
+}
+func (r receiverForTest) Value() int { return r.n }
+
+// captureLogsForTest returns the buffer the logger writes to until the test ends.
+func captureLogsForTest(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	orig := logger.Get()
+	logger.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { logger.SetLogger(orig) })
+	return &buf
+}
+
+func TestTraceFunctionWithArgs_NilReceiver(t *testing.T) {
+	SetSamplingRate(1)
+	SetSwallowPanics(false)
+	logs := captureLogsForTest(t)
+
+	var nilReceiver *receiverForTest
+
+	// A method expression given a nil receiver, typed or untyped, and a
+	// method value bound to one.
+	TraceFunctionWithArgs(context.Background(), (*receiverForTest).Add, nilReceiver, 1)
+	if got := TraceFunctionWithReturn(context.Background(), (*receiverForTest).Add, nil, 1); got != nil {
+		t.Errorf("expected no result from a nil receiver, got %v", got)
+	}
+	if got := TraceFunctionWithReturns(context.Background(), nilReceiver.Get); got != nil {
+		t.Errorf("expected no results from a method value on a nil receiver, got %v", got)
+	}
+
+	if n := strings.Count(logs.String(), "traced method called on a nil receiver"); n != 3 {
+		t.Errorf("expected 3 nil receiver errors, got %d in %q", n, logs.String())
+	}
+
+	// Methods handling a nil receiver still work, and valid receivers are unaffected.
+	if got := TraceFunctionWithReturn(context.Background(), (*receiverForTest).NilSafe, nil); got != "nil" {
+		t.Errorf("expected a nil-safe method to run, got %v", got)
+	}
+	if got := TraceFunctionWithReturn(context.Background(), (*receiverForTest).Add, &receiverForTest{n: 2}, 3); got != 5 {
+		t.Errorf("expected 5 from a valid receiver, got %v", got)
+	}
+}
+
+func TestTraceFunctionWithArgs_InvalidReceiver(t *testing.T) {
+	SetSamplingRate(1)
+	logs := captureLogsForTest(t)
+
+	// A value receiver can't be nil.
+	if got := TraceFunctionWithReturns(context.Background(), receiverForTest.Value, nil); got != nil {
+		t.Errorf("expected no results for a nil value receiver, got %v", got)
+	}
+	if !strings.Contains(logs.String(), "argument type mismatch") {
+		t.Errorf("expected an argument type mismatch to be logged, got %q", logs.String())
+	}
+}
+
+func TestTraceFunctionWithArgs_NilDereferenceStillPanics(t *testing.T) {
+	SetSamplingRate(1)
+	SetSwallowPanics(false)
+
+	// Only nil receivers are handled; a nil dereference in a plain function
+	// is re-panicked as before.
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected the nil dereference to be re-panicked")
+		}
+	}()
+	TraceFunctionWithArgs(context.Background(), func(p *receiverForTest) { _ = p.n }, nil)
+}
+
+func TestTraceFunctionWithArgs_MethodNilDereferenceStillPanics(t *testing.T) {
+	SetSamplingRate(1)
+	SetSwallowPanics(false)
+
+	// The receivers are valid, so a nil dereference is a bug in the method
+	// and is re-panicked.
+	for name, call := range map[string]func(){
+		"method value":      func() { TraceFunctionWithReturns(context.Background(), (&receiverForTest{}).NextN) },
+		"method expression": func() { TraceFunctionWithReturns(context.Background(), (*receiverForTest).NextN, &receiverForTest{}) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("%s: expected the nil dereference to be re-panicked", name)
+				}
+			}()
+			call()
+		}()
+	}
+}
+
+func slowFunctionForTest() { time.Sleep(20 * time.Millisecond) }
+func fastFunctionForTest() {}
+
+func TestSetSlowFunctionThreshold(t *testing.T) {
+	logs := captureLogsForTest(t)
+	SetSlowFunctionThreshold(10 * time.Millisecond)
+	defer SetSlowFunctionThreshold(0)
+
+	TraceFunction(context.Background(), slowFunctionForTest)
+	TraceFunction(context.Background(), fastFunctionForTest)
+
+	out := logs.String()
+	if strings.Count(out, "slow traced function") != 1 {
+		t.Fatalf("expected one slow function warning, got %q", out)
+	}
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "slowFunctionForTest") || !strings.Contains(out, "duration=") {
+		t.Errorf("expected a warning with the function name and duration, got %q", out)
+	}
+
+	// Disabled, nothing is logged.
+	logs.Reset()
+	SetSlowFunctionThreshold(0)
+	TraceFunction(context.Background(), slowFunctionForTest)
+	if strings.Contains(logs.String(), "slow traced function") {
+		t.Errorf("expected no warning when disabled, got %q", logs.String())
+	}
+}
+
+// recurseTracedForTest traces itself depth levels deep, recording at each
+// level whether the goroutine was already inside a profiled call.
+func recurseTracedForTest(ctx context.Context, depth int, nested *[]bool) {
+	TraceFunctionWithArgs(ctx, func(level int) {
+		_, profiling := profilingGoroutines.Load(goroutineID())
+		*nested = append(*nested, profiling)
+		if level > 1 {
+			recurseTracedForTest(ctx, level-1, nested)
+		}
+	}, depth)
+}
+
+func TestTraceFunction_RecursiveProfilesOnce(t *testing.T) {
+	SetSamplingRate(1)
+	ResetFunctionMetrics()
+	defer ResetFunctionMetrics()
+
+	var started atomic.Int32
+	orig := startCPUProfile
+	startCPUProfile = func(path string) (*os.File, error) {
+		started.Add(1)
+		return orig(path)
+	}
+	defer func() { startCPUProfile = orig }()
+
+	const depth = 5
+	var nested []bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		recurseTracedForTest(context.Background(), depth, &nested)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("recursive traced function deadlocked")
+	}
+
+	if n := started.Load(); n != 1 {
+		t.Errorf("expected one CPU profile for the outermost call, got %d", n)
+	}
+	if len(nested) != depth || !slices.Equal(nested, []bool{true, true, true, true, true}) {
+		t.Errorf("expected every level to run inside the outer profile, got %v", nested)
+	}
+	var calls uint64
+	for _, m := range FunctionTraceDetails() {
+		calls += m.CallCount
+	}
+	if calls != depth {
+		t.Errorf("expected %d recorded calls, got %d", depth, calls)
+	}
+	if _, profiling := profilingGoroutines.Load(goroutineID()); profiling {
+		t.Error("expected the test goroutine not to be marked as profiling")
+	}
+
+	// Once the outer call returned, the next call is profiled again.
+	TraceFunction(context.Background(), emptyFunctionForTest)
+	if n := started.Load(); n != 2 {
+		t.Errorf("expected the next call to be profiled, got %d profiles", n)
+	}
+}
+
+func TestTraceFunction_RePanics(t *testing.T) {
+	SetSamplingRate(1)
+	SetSwallowPanics(false)
+
+	var before uint64
+	for name, m := range FunctionTraceDetails() {
+		if strings.HasSuffix(name, "panickingFunctionForTest") {
+			before = m.PanicCount
+		}
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected the original panic value, got %v", r)
+			}
+		}()
+		TraceFunction(context.Background(), panickingFunctionForTest)
+		t.Error("expected TraceFunction to re-panic")
+	}()
+
+	m := panickingMetricsForTest(t)
+	if m.PanicCount != before+1 {
+		t.Errorf("expected panic count %d, got %d", before+1, m.PanicCount)
+	}
+	if m.LastPanic == nil || m.LastPanic.Message != "boom" {
+		t.Fatalf("expected last panic to be recorded, got %+v", m.LastPanic)
+	}
+	if !strings.Contains(m.LastPanic.Stack, "panickingFunctionForTest") {
+		t.Error("expected the stack to include the panicking function")
+	}
+}
+
+func TestTraceFunction_SwallowPanics(t *testing.T) {
+	SetSamplingRate(1)
+	SetSwallowPanics(true)
+	defer SetSwallowPanics(false)
+
+	TraceFunction(context.Background(), panickingFunctionForTest)
+
+	m := panickingMetricsForTest(t)
+	if m.PanicCount == 0 || m.LastPanic == nil {
+		t.Errorf("expected the panic to be recorded, got %+v", m)
+	}
+}
+
+func TestResetFunctionMetrics(t *testing.T) {
+	SetSamplingRate(1)
+	TraceFunction(context.Background(), emptyFunctionForTest)
+	TraceFunction(WithNamespace(context.Background(), "orders"), emptyFunctionForTest)
+	if len(FunctionTraceDetails()) == 0 {
+		t.Fatal("expected traced functions before reset")
+	}
+
+	ResetFunctionMetrics()
+
+	if details := FunctionTraceDetails(); len(details) != 0 {
+		t.Errorf("expected no function metrics after reset, got %d", len(details))
+	}
+	if details := FunctionTraceDetailsFor("orders"); len(details) != 0 {
+		t.Errorf("expected no namespaced function metrics after reset, got %d", len(details))
+	}
+
+	TraceFunction(context.Background(), emptyFunctionForTest)
+	for _, m := range FunctionTraceDetails() {
+		if m.CallCount != 1 {
+			t.Errorf("expected call count to restart at 1, got %d", m.CallCount)
+		}
+	}
+}
+
+func TestResetFunctionMetricsFor(t *testing.T) {
+	SetSamplingRate(1)
+	ResetFunctionMetrics()
+	TraceFunction(context.Background(), emptyFunctionForTest)
+	TraceFunction(WithNamespace(context.Background(), "orders"), emptyFunctionForTest)
+
+	ResetFunctionMetricsFor("orders")
+
+	if details := FunctionTraceDetailsFor("orders"); len(details) != 0 {
+		t.Errorf("expected no orders function metrics after reset, got %d", len(details))
+	}
+	if details := FunctionTraceDetails(); len(details) != 1 {
+		t.Errorf("expected the default namespace's functions to be kept, got %d", len(details))
+	}
+
+	TraceFunction(WithNamespace(context.Background(), "orders"), emptyFunctionForTest)
+	for _, m := range FunctionTraceDetailsFor("orders") {
+		if m.CallCount != 1 {
+			t.Errorf("expected call count to restart at 1, got %d", m.CallCount)
+		}
+	}
+}
+
+func sortedFunctionAForTest() {}
+func sortedFunctionBForTest() {}
+func sortedFunctionCForTest() {}
+
+func TestFunctionTraceDetailsSortedFor(t *testing.T) {
+	SetSamplingRate(1)
+	ctx := WithNamespace(context.Background(), "sorting")
+	TraceFunction(ctx, sortedFunctionCForTest)
+	TraceFunction(ctx, sortedFunctionAForTest)
+	for range 3 {
+		TraceFunction(ctx, sortedFunctionBForTest)
+	}
+
+	names := func(details []models.NamedFunctionMetrics) []string {
+		var out []string
+		for _, d := range details {
+			out = append(out, d.Name[strings.LastIndex(d.Name, ".")+1:])
+		}
+		return out
+	}
+
+	byName := FunctionTraceDetailsSortedFor("sorting", SortByName)
+	want := []string{"sortedFunctionAForTest", "sortedFunctionBForTest", "sortedFunctionCForTest"}
+	if got := names(byName); !slices.Equal(got, want) {
+		t.Fatalf("expected %v sorted by name, got %v", want, got)
+	}
+	// Map iteration order must not leak into the result.
+	for range 10 {
+		if got := names(FunctionTraceDetailsSortedFor("sorting", SortByName)); !slices.Equal(got, want) {
+			t.Fatalf("expected a deterministic order %v, got %v", want, got)
+		}
+	}
+
+	byCalls := FunctionTraceDetailsSortedFor("sorting", SortByCalls)
+	want = []string{"sortedFunctionBForTest", "sortedFunctionAForTest", "sortedFunctionCForTest"}
+	if got := names(byCalls); !slices.Equal(got, want) {
+		t.Errorf("expected %v sorted by calls, ties by name, got %v", want, got)
+	}
+	if byCalls[0].CallCount != 3 {
+		t.Errorf("expected the sorted copy to carry the metrics, got %d calls", byCalls[0].CallCount)
+	}
+
+	if got := names(FunctionTraceDetailsSortedFor("sorting", "unknown")); !slices.Equal(got, names(byName)) {
+		t.Errorf("expected an unknown key to sort by name, got %v", got)
+	}
+	if IsFunctionSortKey("unknown") || !IsFunctionSortKey(SortByMemory) {
+		t.Error("IsFunctionSortKey accepted an unknown key or rejected a known one")
+	}
+}
+
+func lightweightFunctionForTest()          {}
+func lightweightPanickingFunctionForTest() { panic("light boom") }
+
+func TestLightweightTracing(t *testing.T) {
+	SetSamplingRate(10)
+	defer SetSamplingRate(1)
+	SetLightweightTracing(true)
+	defer SetLightweightTracing(false)
+
+	ctx := WithNamespace(context.Background(), "lightweight")
+	for range 25 {
+		TraceFunction(ctx, lightweightFunctionForTest)
+	}
+
+	var name string
+	var m *models.FunctionMetrics
+	for n, details := range FunctionTraceDetailsFor("lightweight") {
+		if strings.HasSuffix(n, "lightweightFunctionForTest") {
+			name, m = n, details
+		}
+	}
+	if m == nil {
+		t.Fatal("expected the first lightweight call to be recorded")
+	}
+	if m.CallCount != 25 {
+		t.Errorf("expected every call to be counted, got %d", m.CallCount)
+	}
+	// Only the first call and the sampled 10th and 20th calls were timed.
+	if history := FunctionHistoryFor("lightweight", name); len(history) != 3 {
+		t.Errorf("expected 3 recorded executions, got %d", len(history))
+	}
+}
+
+func TestLightweightTracing_RecordsPanics(t *testing.T) {
+	SetSamplingRate(100)
+	defer SetSamplingRate(1)
+	SetLightweightTracing(true)
+	defer SetLightweightTracing(false)
+	SetSwallowPanics(true)
+	defer SetSwallowPanics(false)
+
+	ctx := WithNamespace(context.Background(), "lightweight-panics")
+	for range 3 {
+		TraceFunction(ctx, lightweightPanickingFunctionForTest)
+	}
+
+	for n, m := range FunctionTraceDetailsFor("lightweight-panics") {
+		if !strings.HasSuffix(n, "lightweightPanickingFunctionForTest") {
+			continue
+		}
+		if m.CallCount != 3 || m.PanicCount != 3 {
+			t.Errorf("expected 3 calls and 3 panics, got %d and %d", m.CallCount, m.PanicCount)
+		}
+		if m.LastPanic == nil || m.LastPanic.Message != "light boom" {
+			t.Errorf("expected the last panic to be recorded, got %+v", m.LastPanic)
+		}
+		return
+	}
+	t.Fatal("expected the panicking function to be recorded")
+}
+
+func TestResetFunctionMetrics_ClearsLightweightCounts(t *testing.T) {
+	SetSamplingRate(100)
+	defer SetSamplingRate(1)
+	SetLightweightTracing(true)
+	defer SetLightweightTracing(false)
+
+	ctx := WithNamespace(context.Background(), "lightweight-reset")
+	for range 5 {
+		TraceFunction(ctx, lightweightFunctionForTest)
+	}
+	ResetFunctionMetrics()
+	TraceFunction(ctx, lightweightFunctionForTest)
+
+	for _, m := range FunctionTraceDetailsFor("lightweight-reset") {
+		if m.CallCount != 1 {
+			t.Errorf("expected the count to restart after a reset, got %d", m.CallCount)
+		}
+	}
+}
+
+func labeledFunctionForTest() {}
+
+func TestTraceFunctionWithLabels(t *testing.T) {
+	SetSamplingRate(1)
+	ctx := context.Background()
+	TraceFunctionWithLabels(ctx, labeledFunctionForTest, map[string]string{"tenant": "acme"})
+	TraceFunctionWithLabels(ctx, labeledFunctionForTest, map[string]string{"tenant": "acme"})
+	TraceFunctionWithLabels(ctx, labeledFunctionForTest, map[string]string{"tenant": "globex"})
+
+	name := strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(labeledFunctionForTest).Pointer()).Name(), "/", "-")
+	details := FunctionTraceDetails()
+	acme, ok := details[name+`{tenant="acme"}`]
+	if !ok {
+		t.Fatalf("expected a series for tenant acme, got %v", slices.Collect(maps.Keys(details)))
+	}
+	if acme.CallCount != 2 || acme.Labels["tenant"] != "acme" {
+		t.Errorf("expected 2 calls labeled tenant=acme, got %d calls, labels %v", acme.CallCount, acme.Labels)
+	}
+	if globex := details[name+`{tenant="globex"}`]; globex == nil || globex.CallCount != 1 {
+		t.Errorf("expected 1 call for tenant globex, got %+v", globex)
+	}
+	if _, ok := details[name]; ok {
+		t.Error("expected no unlabeled series")
+	}
+}
+
+func TestSplitFunctionLabels(t *testing.T) {
+	labels := map[string]string{"tenant": `a,c"me}`, "region": "eu"}
+	name, got := SplitFunctionLabels("pkg.F" + formatFunctionLabels(labels))
+	if name != "pkg.F" || !maps.Equal(got, labels) {
+		t.Errorf("expected pkg.F with %v, got %s with %v", labels, name, got)
+	}
+
+	for _, plain := range []string{"pkg.F", "pkg.F(struct {})", "pkg.F{broken}"} {
+		if name, got := SplitFunctionLabels(plain); name != plain || got != nil {
+			t.Errorf("expected %q unchanged, got %q with %v", plain, name, got)
+		}
+	}
+}
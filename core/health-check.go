@@ -10,7 +10,7 @@ import (
 
 // getProcessCPUUsage returns the CPU usage of the process
 func getServiceCPUUsage() (float64, error) {
-	return common.GetProcessObject().CPUPercent()
+	return processCPUPercent()
 }
 
 // getServiceGoroutines returns the number of goroutines in the service
@@ -32,7 +32,7 @@ func calculateMemoryUsagePercentage(usedMemory, totalMemory string) (float64, er
 }
 
 // calculateServiceHealth calculates service health based on CPU, memory, and goroutines
-func calculateServiceHealth(stats *models.ServiceStats) (float64, string, error) {
+func calculateServiceHealth(stats *models.ServiceStats, thresholds models.ServiceHealthThresholds) (float64, string, error) {
 	cpuUsage, err := getServiceCPUUsage()
 	if err != nil {
 		return 0, "", fmt.Errorf("failed to get service CPU usage: %w", err)
@@ -51,9 +51,9 @@ func calculateServiceHealth(stats *models.ServiceStats) (float64, string, error)
 	}
 
 	// Calculating the health ratios for CPU, memory, and goroutines
-	cpuUsageRatio := (cpuUsagePercentage / serviceHealthThresholds.MaxCPUUsage) * 100
-	memoryUsageRatio := (memoryUsagePercentage / serviceHealthThresholds.MaxMemoryUsage) * 100
-	goRoutinesRatio := (float64(getServiceGoroutines()) / float64(serviceHealthThresholds.MaxGoRoutines)) * 100
+	cpuUsageRatio := (cpuUsagePercentage / thresholds.MaxCPUUsage) * 100
+	memoryUsageRatio := (memoryUsagePercentage / thresholds.MaxMemoryUsage) * 100
+	goRoutinesRatio := (float64(getServiceGoroutines()) / float64(thresholds.MaxGoRoutines)) * 100
 	finalScore := (cpuUsageRatio + memoryUsageRatio + goRoutinesRatio) / 3
 
 	var message string
@@ -61,17 +61,17 @@ func calculateServiceHealth(stats *models.ServiceStats) (float64, string, error)
 		finalScore = 100
 		message = fmt.Sprintf(
 			"Service usage exceeds allowed limits: CPU Usage %.2f%% / %.2f%%, Memory Usage %.2f%% / %.2f%%, Goroutines %.2f / %d",
-			cpuUsageRatio, serviceHealthThresholds.MaxCPUUsage,
-			memoryUsageRatio, serviceHealthThresholds.MaxMemoryUsage,
-			goRoutinesRatio, serviceHealthThresholds.MaxGoRoutines,
+			cpuUsageRatio, thresholds.MaxCPUUsage,
+			memoryUsageRatio, thresholds.MaxMemoryUsage,
+			goRoutinesRatio, thresholds.MaxGoRoutines,
 		)
 	} else {
 		finalScore = 100 - finalScore
 		message = fmt.Sprintf(
 			"Service usage is within limits: CPU Usage %.2f%% / %.2f%%, Memory Usage %.2f%% / %.2f%%, Goroutines %.2f / %d",
-			cpuUsageRatio, serviceHealthThresholds.MaxCPUUsage,
-			memoryUsageRatio, serviceHealthThresholds.MaxMemoryUsage,
-			goRoutinesRatio, serviceHealthThresholds.MaxGoRoutines,
+			cpuUsageRatio, thresholds.MaxCPUUsage,
+			memoryUsageRatio, thresholds.MaxMemoryUsage,
+			goRoutinesRatio, thresholds.MaxGoRoutines,
 		)
 	}
 
@@ -79,10 +79,10 @@ func calculateServiceHealth(stats *models.ServiceStats) (float64, string, error)
 }
 
 // calculateSystemHealth calculates system health based on CPU and memory
-func calculateSystemHealth(stats *models.ServiceStats) (float64, string, error) {
+func calculateSystemHealth(stats *models.ServiceStats, thresholds models.ServiceHealthThresholds) (float64, string, error) {
 
 	// Calculating cpu & memory usage percentage for the system
-	cpuUsagePercentage, err := GetCPUPrecent()
+	cpuUsagePercentage, err := systemCPUPercent()
 	if err != nil {
 		return 0, "", fmt.Errorf("failed to get CPU percent: %w", err)
 	}
@@ -94,23 +94,23 @@ func calculateSystemHealth(stats *models.ServiceStats) (float64, string, error)
 		return 0, "", fmt.Errorf("failed to calculate memory usage percentage: %w", err)
 	}
 
-	cpuUsageRatio := (cpuUsagePercentage / serviceHealthThresholds.MaxCPUUsage) * 100
-	memoryUsageRatio := (memoryUsagePercentage / serviceHealthThresholds.MaxMemoryUsage) * 100
+	cpuUsageRatio := (cpuUsagePercentage / thresholds.MaxCPUUsage) * 100
+	memoryUsageRatio := (memoryUsagePercentage / thresholds.MaxMemoryUsage) * 100
 	finalScore := (cpuUsageRatio + memoryUsageRatio) / 2
 	var message string
 	if finalScore > 100 {
 		finalScore = 0
 		message = fmt.Sprintf(
 			"System usage exceeds allowed limits: CPU Usage %.2f%% / %.2f%%, Memory Usage %.2f%% / %.2f%%",
-			cpuUsageRatio, serviceHealthThresholds.MaxCPUUsage,
-			memoryUsageRatio, serviceHealthThresholds.MaxMemoryUsage,
+			cpuUsageRatio, thresholds.MaxCPUUsage,
+			memoryUsageRatio, thresholds.MaxMemoryUsage,
 		)
 	} else {
 		finalScore = 100 - finalScore
 		message = fmt.Sprintf(
 			"System usage is within limits: CPU Usage %.2f%% / %.2f%%, Memory Usage %.2f%% / %.2f%%",
-			cpuUsageRatio, serviceHealthThresholds.MaxCPUUsage,
-			memoryUsageRatio, serviceHealthThresholds.MaxMemoryUsage,
+			cpuUsageRatio, thresholds.MaxCPUUsage,
+			memoryUsageRatio, thresholds.MaxMemoryUsage,
 		)
 	}
 
@@ -119,14 +119,20 @@ func calculateSystemHealth(stats *models.ServiceStats) (float64, string, error)
 
 // CalculateHealthScore calculates the health score of both the system and service
 func CalculateHealthScore(serviceStats *models.ServiceStats) (*models.SystemHealthInPercent, error) {
+	// Both scores use the same thresholds, even if they are updated meanwhile
+	return calculateHealthScore(serviceStats, ServiceThresholds())
+}
+
+// calculateHealthScore scores serviceStats against thresholds.
+func calculateHealthScore(serviceStats *models.ServiceStats, thresholds models.ServiceHealthThresholds) (*models.SystemHealthInPercent, error) {
 	// Calculating system health
-	systemScore, systemMsg, err := calculateSystemHealth(serviceStats)
+	systemScore, systemMsg, err := calculateSystemHealth(serviceStats, thresholds)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate system health: %w", err)
 	}
 
 	// CalcCalculating service health
-	serviceScore, serviceMsg, err := calculateServiceHealth(serviceStats)
+	serviceScore, serviceMsg, err := calculateServiceHealth(serviceStats, thresholds)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate service health: %w", err)
 	}
@@ -134,12 +140,12 @@ func CalculateHealthScore(serviceStats *models.ServiceStats) (*models.SystemHeal
 	return &models.SystemHealthInPercent{
 		SystemHealth: models.HealthFields{
 			Percentage:    common.RoundFloat64(systemScore, 2),
-			AllowedByUser: serviceHealthThresholds.MaxCPUUsage,
+			AllowedByUser: thresholds.MaxCPUUsage,
 			Message:       systemMsg,
 		},
 		ServiceHealth: models.HealthFields{
 			Percentage:    common.RoundFloat64(serviceScore, 2),
-			AllowedByUser: serviceHealthThresholds.MaxCPUUsage,
+			AllowedByUser: thresholds.MaxCPUUsage,
 			Message:       serviceMsg,
 		},
 	}, nil
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TraceFunctionWithLabels traces f like TraceFunction, recording its metrics
+// as a separate series per label set, e.g. {"tenant": "acme"}. The series is
+// named `pkg.f{tenant="acme"}`; SplitFunctionLabels recovers the function name
+// and labels, which the Prometheus and stored metrics carry as labels.
+func TraceFunctionWithLabels(ctx context.Context, f func(), labels map[string]string) {
+	name := strings.ReplaceAll(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), "/", "-")
+	executeFunctionWithProfiling(ctx, name+formatFunctionLabels(labels), func(context.Context) { f() })
+}
+
+// formatFunctionLabels renders labels as `{k1="v1",k2="v2"}` with sorted keys,
+// or "" when there are none.
+func formatFunctionLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[k]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// SplitFunctionLabels splits the name of a function traced with
+// TraceFunctionWithLabels into the function name and its labels. Other names
+// are returned unchanged with nil labels.
+func SplitFunctionLabels(name string) (string, map[string]string) {
+	base, rest, ok := strings.Cut(name, "{")
+	if !ok || !strings.HasSuffix(rest, "}") {
+		return name, nil
+	}
+
+	labels := make(map[string]string)
+	rest = strings.TrimSuffix(rest, "}")
+	for rest != "" {
+		key, quoted, ok := strings.Cut(rest, "=")
+		if !ok {
+			return name, nil
+		}
+		prefix, err := strconv.QuotedPrefix(quoted)
+		if err != nil {
+			return name, nil
+		}
+		value, _ := strconv.Unquote(prefix)
+		labels[key] = value
+		rest = strings.TrimPrefix(quoted[len(prefix):], ",")
+	}
+	return base, labels
+}
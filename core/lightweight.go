@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// lightweightTracing makes calls that are not sampled only count the call.
+var lightweightTracing atomic.Bool
+
+// lightCounters maps a function key to its *lightCallCounter while
+// lightweight tracing is used. Entries are dropped with the function's metrics.
+var lightCounters sync.Map
+
+// lightCallCounter counts the calls of one function without a lock.
+type lightCallCounter struct {
+	calls   atomic.Uint64 // All calls, for the sampling decision
+	skipped atomic.Uint64 // Calls counted without being recorded in functionMetrics
+}
+
+// SetLightweightTracing sets whether traced calls that are not sampled for
+// profiling take a fast path: an atomic call count, without timing, goroutine
+// counting, history or locks. A function's first call and its sampled calls
+// are still recorded in full, so its metrics describe those calls while
+// CallCount stays exact. Panics are always recorded.
+func SetLightweightTracing(enabled bool) {
+	lightweightTracing.Store(enabled)
+}
+
+// lightCallCounterFor returns the counter of key, creating it if needed.
+func lightCallCounterFor(key string) *lightCallCounter {
+	if c, ok := lightCounters.Load(key); ok {
+		return c.(*lightCallCounter)
+	}
+	c, _ := lightCounters.LoadOrStore(key, &lightCallCounter{})
+	return c.(*lightCallCounter)
+}
+
+// skippedCalls returns the calls of key counted only by the fast path.
+func skippedCalls(key string) uint64 {
+	if c, ok := lightCounters.Load(key); ok {
+		return c.(*lightCallCounter).skipped.Load()
+	}
+	return 0
+}
+
+// traceLightweight calls fn on the fast path. Only a panic is recorded in
+// functionMetrics; otherwise the call is just counted.
+func traceLightweight(ctx context.Context, key, name string, counter *lightCallCounter, fn func(context.Context)) {
+	recovered, stack := callRecovering(ctx, fn)
+	if recovered == nil {
+		counter.skipped.Add(1)
+		return
+	}
+
+	lastPanic := newFunctionPanic(name, time.Now(), recovered, stack)
+	mu.Lock()
+	if m, exists := functionMetrics[key]; exists {
+		m.CallCount++
+		m.PanicCount++
+		m.LastPanic = lastPanic
+	} else {
+		counter.skipped.Add(1)
+	}
+	mu.Unlock()
+
+	if !swallowPanics.Load() {
+		panic(recovered)
+	}
+}
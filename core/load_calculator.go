@@ -0,0 +1,36 @@
+package core
+
+import "sync"
+
+// LoadCalculator computes the overall service load from CPU, memory and disk
+// usage percentages, returning the raw value and its display string.
+type LoadCalculator func(cpu, mem, disk float64) (float64, string)
+
+var (
+	loadCalculatorMu sync.RWMutex
+	loadCalculator   LoadCalculator = defaultLoadCalculator
+)
+
+// SetLoadCalculator sets the formula used to compute the overall service load
+// reported by the API and stored by the sync loop. Passing nil restores the
+// default, which averages CPU and memory and caps the result at 100.
+func SetLoadCalculator(fn LoadCalculator) {
+	if fn == nil {
+		fn = defaultLoadCalculator
+	}
+	loadCalculatorMu.Lock()
+	loadCalculator = fn
+	loadCalculatorMu.Unlock()
+}
+
+// calculateLoad runs the configured load calculator.
+func calculateLoad(cpu, mem, disk float64) (float64, string) {
+	loadCalculatorMu.RLock()
+	fn := loadCalculator
+	loadCalculatorMu.RUnlock()
+	return fn(cpu, mem, disk)
+}
+
+func defaultLoadCalculator(cpu, mem, _ float64) (float64, string) {
+	return CalculateOverallLoad(cpu, mem)
+}
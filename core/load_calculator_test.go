@@ -0,0 +1,39 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSetLoadCalculator(t *testing.T) {
+	defer SetLoadCalculator(nil)
+
+	var gotDisk float64 = -1
+	SetLoadCalculator(func(cpu, mem, disk float64) (float64, string) {
+		gotDisk = disk
+		return 42, "42 units"
+	})
+
+	ls := GetLoadStatistics()
+	if ls.OverallLoadOfServiceRaw != 42 || ls.OverallLoadOfService != "42 units" {
+		t.Errorf("expected custom load 42, got %v %q", ls.OverallLoadOfServiceRaw, ls.OverallLoadOfService)
+	}
+	if gotDisk != ls.SystemDiskLoadRaw {
+		t.Errorf("expected calculator to receive disk load %v, got %v", ls.SystemDiskLoadRaw, gotDisk)
+	}
+}
+
+func TestSetLoadCalculator_NilRestoresDefault(t *testing.T) {
+	SetLoadCalculator(func(cpu, mem, disk float64) (float64, string) {
+		v := 0.8*cpu + 0.2*mem
+		return v, fmt.Sprintf("%.0f%%", v)
+	})
+	if got, _ := calculateLoad(50, 100, 0); got != 60 {
+		t.Errorf("expected weighted load 60, got %v", got)
+	}
+
+	SetLoadCalculator(nil)
+	if got, _ := calculateLoad(50, 100, 0); got != 75 {
+		t.Errorf("expected default load 75, got %v", got)
+	}
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/iyashjayesh/monigo/models"
+)
+
+// loadWindow keeps the last N CPU and memory load samples so overall load can
+// be computed from their averages instead of a single instantaneous reading.
+type loadWindow struct {
+	mu   sync.Mutex
+	size int
+	cpu  []float64
+	mem  []float64
+}
+
+var (
+	// syncLoadWindow is fed once per sync cycle by SmoothLoadStatistics, so its
+	// samples are evenly spaced no matter how often stats are read elsewhere.
+	// A size of 1 behaves exactly like CalculateOverallLoad.
+	syncLoadWindow = &loadWindow{size: 1}
+
+	// callerLoadWindow backs CalculateSmoothedOverallLoad, keeping samples fed
+	// by callers out of the stored load.
+	callerLoadWindow = &loadWindow{size: 1}
+)
+
+// SetLoadWindowSize sets how many recent samples are averaged when computing
+// the overall service load. Existing samples beyond the new size are dropped.
+func SetLoadWindowSize(n int) error {
+	if n < 1 {
+		return fmt.Errorf("load window size must be >= 1, got %d", n)
+	}
+	syncLoadWindow.resize(n)
+	callerLoadWindow.resize(n)
+	return nil
+}
+
+// CalculateSmoothedOverallLoad records the given CPU and memory load as the
+// latest sample of its own window and returns the overall load computed from
+// the window averages using the configured LoadCalculator.
+func CalculateSmoothedOverallLoad(serviceCPUF, serviceMemF, systemDiskF float64) (float64, string) {
+	cpuAvg, memAvg := callerLoadWindow.add(serviceCPUF, serviceMemF)
+	return calculateLoad(cpuAvg, memAvg, systemDiskF)
+}
+
+// SmoothLoadStatistics records load as the latest sample of the sync window and
+// replaces its overall load with one computed from the window averages. Only
+// the sync loop calls it; GetLoadStatistics always reports instantaneous load.
+func SmoothLoadStatistics(load *models.LoadStatistics) {
+	cpuAvg, memAvg := syncLoadWindow.add(load.ServiceCPULoadRaw, load.ServiceMemLoadRaw)
+	load.OverallLoadOfServiceRaw, load.OverallLoadOfService = calculateLoad(cpuAvg, memAvg, load.SystemDiskLoadRaw)
+}
+
+func (w *loadWindow) resize(n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.size = n
+	w.cpu = trimSamples(w.cpu, n)
+	w.mem = trimSamples(w.mem, n)
+}
+
+// add records a sample and returns the CPU and memory averages over the window.
+func (w *loadWindow) add(serviceCPUF, serviceMemF float64) (cpuAvg, memAvg float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cpu = trimSamples(append(w.cpu, serviceCPUF), w.size)
+	w.mem = trimSamples(append(w.mem, serviceMemF), w.size)
+	return mean(w.cpu), mean(w.mem)
+}
+
+// trimSamples keeps only the newest n samples.
+func trimSamples(samples []float64, n int) []float64 {
+	if len(samples) <= n {
+		return samples
+	}
+	return append(samples[:0], samples[len(samples)-n:]...)
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iyashjayesh/monigo/models"
+)
+
+func stddev(values []float64) float64 {
+	m := mean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+func TestLoadWindow_SmoothsSpikes(t *testing.T) {
+	series := []float64{10, 90, 10, 10, 95, 10, 10, 10, 85, 10, 10, 10}
+
+	w := &loadWindow{size: 4}
+	var raw, smoothed []float64
+	for _, v := range series {
+		instant, _ := CalculateOverallLoad(v, v)
+		cpuAvg, memAvg := w.add(v, v)
+		avg, _ := CalculateOverallLoad(cpuAvg, memAvg)
+		raw = append(raw, instant)
+		smoothed = append(smoothed, avg)
+	}
+
+	if stddev(smoothed) >= stddev(raw) {
+		t.Errorf("expected smoothed load to be less volatile: stddev smoothed=%.2f raw=%.2f", stddev(smoothed), stddev(raw))
+	}
+	if len(w.cpu) != 4 || len(w.mem) != 4 {
+		t.Errorf("expected window to hold 4 samples, got cpu=%d mem=%d", len(w.cpu), len(w.mem))
+	}
+}
+
+func TestLoadWindow_SizeOneReturnsLatestSample(t *testing.T) {
+	w := &loadWindow{size: 1}
+	for _, v := range []float64{20, 80, 40} {
+		cpuAvg, memAvg := w.add(v, v/2)
+		if cpuAvg != v || memAvg != v/2 {
+			t.Errorf("add(%v, %v) = %v, %v, want the sample itself", v, v/2, cpuAvg, memAvg)
+		}
+	}
+}
+
+func TestSetLoadWindowSize(t *testing.T) {
+	defer SetLoadWindowSize(1)
+
+	if err := SetLoadWindowSize(0); err == nil {
+		t.Error("expected error for window size 0")
+	}
+
+	if err := SetLoadWindowSize(3); err != nil {
+		t.Fatalf("SetLoadWindowSize: %v", err)
+	}
+	for _, v := range []float64{10, 20, 30, 40} {
+		CalculateSmoothedOverallLoad(v, v, 0)
+	}
+	// Only the last three samples (30, 40, 40) are averaged.
+	if got, _ := CalculateSmoothedOverallLoad(40, 40, 0); got != 110.0/3 {
+		t.Errorf("expected %v, got %v", 110.0/3, got)
+	}
+
+	if err := SetLoadWindowSize(1); err != nil {
+		t.Fatalf("SetLoadWindowSize: %v", err)
+	}
+	if got := len(callerLoadWindow.cpu); got != 1 {
+		t.Errorf("expected window to shrink to 1 sample, got %d", got)
+	}
+	if got := len(syncLoadWindow.cpu); got > 1 {
+		t.Errorf("expected sync window to shrink to 1 sample, got %d", got)
+	}
+}
+
+func TestSmoothLoadStatistics_OnlySyncFeedsWindow(t *testing.T) {
+	defer SetLoadWindowSize(1)
+	if err := SetLoadWindowSize(2); err != nil {
+		t.Fatalf("SetLoadWindowSize: %v", err)
+	}
+	syncLoadWindow.cpu, syncLoadWindow.mem = nil, nil
+
+	GetLoadStatistics()
+	CalculateSmoothedOverallLoad(90, 90, 0)
+	if got := len(syncLoadWindow.cpu); got != 0 {
+		t.Fatalf("expected only SmoothLoadStatistics to feed the sync window, got %d samples", got)
+	}
+
+	SmoothLoadStatistics(&models.LoadStatistics{ServiceCPULoadRaw: 20, ServiceMemLoadRaw: 20})
+	load := models.LoadStatistics{ServiceCPULoadRaw: 60, ServiceMemLoadRaw: 40}
+	SmoothLoadStatistics(&load)
+	// CPU avg 40, memory avg 30.
+	if load.OverallLoadOfServiceRaw != 35 {
+		t.Errorf("expected smoothed load 35, got %v", load.OverallLoadOfServiceRaw)
+	}
+}
@@ -0,0 +1,47 @@
+package core
+
+import "container/list"
+
+// lruIndex tracks the recency of string keys so the least recently used one
+// can be evicted. It is not safe for concurrent use; callers hold their own lock.
+type lruIndex struct {
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+func newLRUIndex() *lruIndex {
+	return &lruIndex{ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// touch marks key as most recently used, adding it if needed.
+func (l *lruIndex) touch(key string) {
+	if e, ok := l.items[key]; ok {
+		l.ll.MoveToFront(e)
+		return
+	}
+	l.items[key] = l.ll.PushFront(key)
+}
+
+// oldest removes and returns the least recently used key.
+func (l *lruIndex) oldest() (string, bool) {
+	e := l.ll.Back()
+	if e == nil {
+		return "", false
+	}
+	key := e.Value.(string)
+	l.ll.Remove(e)
+	delete(l.items, key)
+	return key, true
+}
+
+// remove drops key, if present.
+func (l *lruIndex) remove(key string) {
+	if e, ok := l.items[key]; ok {
+		l.ll.Remove(e)
+		delete(l.items, key)
+	}
+}
+
+func (l *lruIndex) len() int {
+	return l.ll.Len()
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"runtime"
 	"sync"
 	"time"
@@ -9,13 +10,33 @@ import (
 	"github.com/iyashjayesh/monigo/internal/logger"
 	"github.com/iyashjayesh/monigo/models"
 	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
 	"github.com/shirou/gopsutil/mem"
-	"github.com/shirou/gopsutil/process"
+	"github.com/shirou/gopsutil/net"
 )
 
 var (
-	mu                      sync.Mutex
-	serviceHealthThresholds models.ServiceHealthThresholds
+	mu sync.Mutex
+
+	// serviceHealthThresholds is read by every health calculation and may be
+	// updated while the service runs. Namespaces without thresholds of their
+	// own use values.
+	serviceHealthThresholds = struct {
+		mu         sync.RWMutex
+		values     models.ServiceHealthThresholds
+		namespaces map[string]models.ServiceHealthThresholds
+	}{}
+)
+
+// Sources of the system statistics, replaceable in tests to simulate
+// collection errors.
+var (
+	systemCPUPercent  = GetCPUPrecent
+	processCPUPercent = func() (float64, error) { return common.GetProcessObject().CPUPercent() }
+	virtualMemory     = mem.VirtualMemory
+	swapMemory        = mem.SwapMemory
+	netIOCounters     = net.IOCounters
+	diskIOCounters    = disk.IOCounters
 )
 
 // GetCPUPrecent returns the total number of requests
@@ -36,7 +57,7 @@ func GetCPUPrecent() (float64, error) {
 
 // GetVirtualMemoryStats returns the virtual memory statistics
 func GetVirtualMemoryStats() (mem.VirtualMemoryStat, error) {
-	memInfo, err := mem.VirtualMemory()
+	memInfo, err := virtualMemory()
 	if err != nil {
 		logger.Log.Error("Error fetching memory usage", "error", err)
 		return mem.VirtualMemoryStat{}, err
@@ -46,8 +67,8 @@ func GetVirtualMemoryStats() (mem.VirtualMemoryStat, error) {
 }
 
 // Fetches and returns process CPU and memory usage
-func getProcessUsage(proc *process.Process, memsStats *mem.VirtualMemoryStat) (float64, float64, error) {
-	procCPUPercent, err := proc.CPUPercent()
+func getProcessUsage(memsStats *mem.VirtualMemoryStat) (float64, float64, error) {
+	procCPUPercent, err := processCPUPercent()
 	if err != nil {
 		return 0, 0, err
 	}
@@ -62,7 +83,74 @@ func getProcessUsage(proc *process.Process, memsStats *mem.VirtualMemoryStat) (f
 
 // SetServiceThresholds sets the service thresholds to calculate the overall service health.
 func ConfigureServiceThresholds(thresholdsValues *models.ServiceHealthThresholds) {
-	serviceHealthThresholds = *thresholdsValues
+	serviceHealthThresholds.mu.Lock()
+	defer serviceHealthThresholds.mu.Unlock()
+	serviceHealthThresholds.values = *thresholdsValues
+}
+
+// UpdateServiceThresholds replaces the service thresholds while the service is
+// running; subsequent health calculations use the new values. Zero fields keep
+// their current value, so e.g. only MaxCPUUsage can be tightened.
+func UpdateServiceThresholds(t *models.ServiceHealthThresholds) error {
+	return UpdateServiceThresholdsFor("", t)
+}
+
+// UpdateServiceThresholdsFor is UpdateServiceThresholds for the health scored
+// in namespace (see WithNamespace). Fields left out start from the default
+// namespace's thresholds until namespace is first updated.
+func UpdateServiceThresholdsFor(namespace string, t *models.ServiceHealthThresholds) error {
+	if t == nil {
+		return fmt.Errorf("thresholds are required")
+	}
+	if t.MaxCPUUsage < 0 || t.MaxMemoryUsage < 0 || t.MaxGoRoutines < 0 {
+		return fmt.Errorf("thresholds must not be negative")
+	}
+
+	serviceHealthThresholds.mu.Lock()
+	defer serviceHealthThresholds.mu.Unlock()
+	values, _ := namespaceThresholds(namespace)
+	if t.MaxCPUUsage > 0 {
+		values.MaxCPUUsage = t.MaxCPUUsage
+	}
+	if t.MaxMemoryUsage > 0 {
+		values.MaxMemoryUsage = t.MaxMemoryUsage
+	}
+	if t.MaxGoRoutines > 0 {
+		values.MaxGoRoutines = t.MaxGoRoutines
+	}
+
+	if namespace == "" {
+		serviceHealthThresholds.values = values
+		return nil
+	}
+	if serviceHealthThresholds.namespaces == nil {
+		serviceHealthThresholds.namespaces = make(map[string]models.ServiceHealthThresholds)
+	}
+	serviceHealthThresholds.namespaces[namespace] = values
+	return nil
+}
+
+// ServiceThresholds returns the thresholds currently used for health scoring.
+func ServiceThresholds() models.ServiceHealthThresholds {
+	return ServiceThresholdsFor("")
+}
+
+// ServiceThresholdsFor returns the thresholds used for health scoring in namespace.
+func ServiceThresholdsFor(namespace string) models.ServiceHealthThresholds {
+	serviceHealthThresholds.mu.RLock()
+	defer serviceHealthThresholds.mu.RUnlock()
+	values, _ := namespaceThresholds(namespace)
+	return values
+}
+
+// namespaceThresholds returns the thresholds of namespace, and whether they
+// were set for it rather than taken from the default namespace. Callers hold
+// serviceHealthThresholds.mu.
+func namespaceThresholds(namespace string) (models.ServiceHealthThresholds, bool) {
+	if values, ok := serviceHealthThresholds.namespaces[namespace]; ok {
+		return values, true
+	}
+	return serviceHealthThresholds.values, false
 }
 
 // newRecord creates a new Record with appropriate units and human-readable formats.
@@ -92,9 +180,36 @@ func newRecord(name, description string, value interface{}) models.Record {
 	}
 }
 
-// ReadMemStats reads and returns the memory statistics
+// defaultMemStatsInterval lets the consumers of one sync cycle or scrape share
+// a single runtime.ReadMemStats call, which briefly stops the world.
+const defaultMemStatsInterval = time.Second
+
+var memStatsCache = struct {
+	mu       sync.Mutex
+	interval time.Duration
+	readAt   time.Time
+	stats    runtime.MemStats
+}{interval: defaultMemStatsInterval}
+
+// SetMemStatsInterval sets how long a MemStats read is reused by ReadMemStats.
+// A value <= 0 reads fresh stats on every call.
+func SetMemStatsInterval(d time.Duration) {
+	memStatsCache.mu.Lock()
+	defer memStatsCache.mu.Unlock()
+	memStatsCache.interval = d
+	memStatsCache.readAt = time.Time{}
+}
+
+// ReadMemStats returns the memory statistics, reading them at most once per
+// interval set by SetMemStatsInterval. The result is a copy the caller owns.
 func ReadMemStats() *runtime.MemStats {
-	memStats := runtime.MemStats{}
-	runtime.ReadMemStats(&memStats)
+	memStatsCache.mu.Lock()
+	defer memStatsCache.mu.Unlock()
+
+	if memStatsCache.readAt.IsZero() || time.Since(memStatsCache.readAt) >= memStatsCache.interval {
+		runtime.ReadMemStats(&memStatsCache.stats)
+		memStatsCache.readAt = time.Now()
+	}
+	memStats := memStatsCache.stats
 	return &memStats
 }
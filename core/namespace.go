@@ -0,0 +1,45 @@
+package core
+
+import (
+	"context"
+	"strings"
+)
+
+type namespaceKey struct{}
+
+// WithNamespace returns a copy of ctx that scopes functions traced with it,
+// and metrics read through it, to namespace. Isolated Monigo instances use
+// their service name as namespace; the empty namespace is the default one.
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// NamespaceFromContext returns the namespace set by WithNamespace, or "".
+func NamespaceFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ns, _ := ctx.Value(namespaceKey{}).(string)
+	return ns
+}
+
+// namespaceSep separates the namespace from the function name in a key.
+const namespaceSep = "\x00"
+
+// functionKey is the key of a traced function in its namespace. Keys of the
+// default namespace are the plain function names.
+func functionKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + namespaceSep + name
+}
+
+// splitFunctionKey splits a key built by functionKey.
+func splitFunctionKey(key string) (namespace, name string) {
+	namespace, name, ok := strings.Cut(key, namespaceSep)
+	if !ok {
+		return "", key
+	}
+	return namespace, name
+}
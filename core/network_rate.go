@@ -0,0 +1,49 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// networkThroughput keeps the previous network counters to turn the totals
+// into bytes per second.
+type networkThroughput struct {
+	mu             sync.Mutex
+	at             time.Time
+	received, sent float64
+	// Rates over the last interval.
+	receivedRate, sentRate float64
+}
+
+// serviceNetworkThroughput is fed by every GetServiceStats call.
+var serviceNetworkThroughput = &networkThroughput{}
+
+// add records the counters read at `at` and returns the receive and send
+// rates since the previous sample. The first sample returns 0. A counter that
+// went down (e.g. an interface was reset) is counted from 0.
+func (n *networkThroughput) add(received, sent float64, at time.Time) (receivedRate, sentRate float64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.at.IsZero() {
+		n.at, n.received, n.sent = at, received, sent
+		return 0, 0
+	}
+	// Reads in the same instant reuse the last rates.
+	elapsed := at.Sub(n.at).Seconds()
+	if elapsed <= 0 {
+		return n.receivedRate, n.sentRate
+	}
+	n.receivedRate = counterIncrease(n.received, received) / elapsed
+	n.sentRate = counterIncrease(n.sent, sent) / elapsed
+	n.at, n.received, n.sent = at, received, sent
+	return n.receivedRate, n.sentRate
+}
+
+// counterIncrease returns how much a counter grew from prev to cur.
+func counterIncrease(prev, cur float64) float64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
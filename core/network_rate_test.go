@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/net"
+)
+
+func TestNetworkThroughput(t *testing.T) {
+	n := &networkThroughput{}
+	start := time.Unix(1700000000, 0)
+
+	if recv, sent := n.add(1000, 500, start); recv != 0 || sent != 0 {
+		t.Errorf("expected no throughput from the first sample, got %v/%v", recv, sent)
+	}
+	recv, sent := n.add(21000, 2500, start.Add(10*time.Second))
+	if recv != 2000 || sent != 200 {
+		t.Errorf("expected 2000/200 bytes per second, got %v/%v", recv, sent)
+	}
+
+	// A second read in the same instant keeps the last rates.
+	if recv, sent := n.add(21000, 2500, start.Add(10*time.Second)); recv != 2000 || sent != 200 {
+		t.Errorf("expected the last rates to be reused, got %v/%v", recv, sent)
+	}
+
+	// A counter reset is counted from 0.
+	if recv, _ := n.add(500, 2700, start.Add(15*time.Second)); recv != 100 {
+		t.Errorf("expected 100 bytes per second after a reset, got %v", recv)
+	}
+}
+
+func TestGetServiceStats_NetworkThroughput(t *testing.T) {
+	origNet, origThroughput := netIOCounters, serviceNetworkThroughput
+	clockAt := time.Unix(1700000000, 0)
+	now = func() time.Time { return clockAt }
+	serviceNetworkThroughput = &networkThroughput{}
+	defer func() {
+		netIOCounters, serviceNetworkThroughput = origNet, origThroughput
+		now = time.Now
+	}()
+
+	counters := []net.IOCountersStat{{BytesRecv: 4096, BytesSent: 1024}}
+	netIOCounters = func(bool) ([]net.IOCountersStat, error) { return counters, nil }
+	GetServiceStats(context.Background())
+
+	clockAt = clockAt.Add(2 * time.Second)
+	counters = []net.IOCountersStat{{BytesRecv: 8192, BytesSent: 2048}, {BytesRecv: 100, BytesSent: 0}}
+	stats := GetServiceStats(context.Background())
+
+	if got := stats.NetworkThroughput.BytesReceivedPerSecond; got != 2098 {
+		t.Errorf("expected 2098 bytes received per second, got %v", got)
+	}
+	if got := stats.NetworkThroughput.BytesSentPerSecond; got != 512 {
+		t.Errorf("expected 512 bytes sent per second, got %v", got)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package core
+
+import "os/exec"
+
+// killProcessGroup is a no-op without unix process groups; cmd.WaitDelay
+// still bounds how long a surviving child can hold the output pipe.
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -1,14 +1,20 @@
 package core
 
 import (
+	"fmt"
 	"os"
 	"runtime"
 	"runtime/pprof"
 	"strings"
+	"sync/atomic"
 
 	"github.com/iyashjayesh/monigo/models"
 )
 
+// profileFileExt is the extension of the written profiles. runtime/pprof
+// writes them gzip-compressed, which `go tool pprof` reads as is.
+const profileFileExt = ".prof.gz"
+
 // StartCPUProfile starts the CPU profile and writes it to the specified file.
 func StartCPUProfile(filename string) (*os.File, error) {
 	f, err := os.Create(filename)
@@ -32,7 +38,59 @@ func WriteHeapProfile(filename string) error {
 		return err
 	}
 	runtime.GC() // Get up-to-date statistics
-	return pprof.WriteHeapProfile(f)
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var blockProfiling, mutexProfiling atomic.Bool
+
+// EnableBlockProfiling records every blocking event on channels, select and
+// sync primitives, and makes sampled traced calls also write a block profile.
+// The runtime's profile is cumulative, so it shows the blocking up to the call.
+func EnableBlockProfiling() {
+	runtime.SetBlockProfileRate(1)
+	blockProfiling.Store(true)
+}
+
+// DisableBlockProfiling stops recording blocking events and writing block profiles.
+func DisableBlockProfiling() {
+	blockProfiling.Store(false)
+	runtime.SetBlockProfileRate(0)
+}
+
+// EnableMutexProfiling records every contended mutex, and makes sampled
+// traced calls also write a mutex profile. Like the block profile, it is
+// cumulative.
+func EnableMutexProfiling() {
+	runtime.SetMutexProfileFraction(1)
+	mutexProfiling.Store(true)
+}
+
+// DisableMutexProfiling stops recording mutex contention and writing mutex profiles.
+func DisableMutexProfiling() {
+	mutexProfiling.Store(false)
+	runtime.SetMutexProfileFraction(0)
+}
+
+// WriteLookupProfile writes the named runtime/pprof profile, e.g. "block" or
+// "mutex", to the specified file.
+func WriteLookupProfile(name, filename string) error {
+	p := pprof.Lookup(name)
+	if p == nil {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := p.WriteTo(f, 0); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 // CollectGoRoutinesInfo returns the number of running Go routines and their stack traces split into separate goroutine blocks.
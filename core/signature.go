@@ -0,0 +1,121 @@
+package core
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// funcSignature is the reflection analysis of a traced function that doesn't
+// change between calls.
+type funcSignature struct {
+	name     string         // see generateFunctionName
+	in       []reflect.Type // parameter types
+	variadic bool           // whether the last parameter is variadic
+	// The function is a method expression such as (*T).Method, whose first
+	// parameter is the receiver.
+	methodExpr bool
+	// The function is a method value such as t.Method with a pointer
+	// receiver, which is bound to the value (see boundReceiverIsNil).
+	methodValue bool
+}
+
+// argType returns the type argument i must be assignable to. Trailing
+// arguments of a variadic function take the element type of its last parameter.
+func (s *funcSignature) argType(i int) reflect.Type {
+	if s.variadic && i >= len(s.in)-1 {
+		return s.in[len(s.in)-1].Elem()
+	}
+	return s.in[i]
+}
+
+// acceptsArgs reports whether the function can be called with n arguments.
+func (s *funcSignature) acceptsArgs(n int) bool {
+	if s.variadic {
+		return n >= len(s.in)-1
+	}
+	return n == len(s.in)
+}
+
+// signatureKey identifies a function by its code pointer and type; closures
+// created from the same literal share both.
+type signatureKey struct {
+	pc  uintptr
+	typ reflect.Type
+}
+
+// signatureCacheDisabled turns off the signature cache; it is on by default.
+var signatureCacheDisabled atomic.Bool
+
+// signatures maps a signatureKey to its *funcSignature.
+var signatures sync.Map
+
+// SetSignatureCaching sets whether TraceFunctionWithArgs, TraceFunctionWithReturn
+// and TraceFunctionWithReturns cache the name and parameter types of each
+// traced function, so repeated calls skip analysing it again. It is enabled
+// by default; disabling it drops the cached signatures.
+func SetSignatureCaching(enabled bool) {
+	signatureCacheDisabled.Store(!enabled)
+	if !enabled {
+		signatures.Clear()
+	}
+}
+
+// signatureOf returns the signature of fnValue, a function value.
+func signatureOf(fnValue reflect.Value) *funcSignature {
+	fnType := fnValue.Type()
+	if signatureCacheDisabled.Load() {
+		return newFuncSignature(fnValue, fnType)
+	}
+
+	key := signatureKey{pc: fnValue.Pointer(), typ: fnType}
+	if sig, ok := signatures.Load(key); ok {
+		return sig.(*funcSignature)
+	}
+	sig, _ := signatures.LoadOrStore(key, newFuncSignature(fnValue, fnType))
+	return sig.(*funcSignature)
+}
+
+func newFuncSignature(fnValue reflect.Value, fnType reflect.Type) *funcSignature {
+	in := make([]reflect.Type, fnType.NumIn())
+	for i := range in {
+		in[i] = fnType.In(i)
+	}
+	funcName := runtime.FuncForPC(fnValue.Pointer()).Name()
+	return &funcSignature{
+		name:        generateFunctionName(fnValue, fnType),
+		in:          in,
+		variadic:    fnType.IsVariadic(),
+		methodExpr:  isMethodExpression(fnValue, fnType, funcName),
+		methodValue: isPointerMethodValue(funcName),
+	}
+}
+
+// isMethodExpression reports whether fnValue is a method of the type of its
+// first parameter, named funcName by the runtime.
+func isMethodExpression(fnValue reflect.Value, fnType reflect.Type, funcName string) bool {
+	if fnType.NumIn() == 0 {
+		return false
+	}
+	method, ok := fnType.In(0).MethodByName(funcName[strings.LastIndex(funcName, ".")+1:])
+	// Interface methods have no Func.
+	return ok && method.Func.IsValid() && method.Func.Pointer() == fnValue.Pointer()
+}
+
+// isPointerMethodValue reports whether funcName, as named by the runtime, is
+// the wrapper of a method value with a pointer receiver, e.g. "pkg.(*T).Get-fm".
+func isPointerMethodValue(funcName string) bool {
+	return strings.HasSuffix(funcName, "-fm") && strings.Contains(funcName, ".(*")
+}
+
+// boundReceiverIsNil reports whether fnValue, a method value with a pointer
+// receiver, is bound to a nil receiver. A method value is a closure holding
+// the code pointer of its wrapper followed by the bound receiver.
+func boundReceiverIsNil(fnValue reflect.Value) bool {
+	f := fnValue.Interface()
+	closure := (*[2]unsafe.Pointer)(unsafe.Pointer(&f))[1]
+	return closure != nil && (*[2]unsafe.Pointer)(closure)[1] == nil
+}
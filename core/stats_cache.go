@@ -0,0 +1,79 @@
+package core
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iyashjayesh/monigo/models"
+)
+
+// statsCacheTTL is how long a collected ServiceStats snapshot is reused, as a
+// time.Duration; 0 (the default) disables the cache.
+var statsCacheTTL atomic.Int64
+
+// statsCacheKey identifies a snapshot: the byte unit its fields are formatted
+// in and the collected sections.
+type statsCacheKey struct {
+	unit string
+	opts CollectOptions
+}
+
+// statsCacheEntry is a snapshot. Its mutex is held during a collection, so
+// concurrent callers wait for it instead of collecting again.
+type statsCacheEntry struct {
+	mu          sync.Mutex
+	stats       models.ServiceStats
+	collectedAt time.Time
+}
+
+var statsCache = struct {
+	mu      sync.Mutex
+	entries map[statsCacheKey]*statsCacheEntry
+}{entries: make(map[statsCacheKey]*statsCacheEntry)}
+
+// SetStatsCacheTTL makes GetServiceStats and GetServiceStatsSelective return
+// the previous snapshot when it was collected less than d ago, so a burst of
+// scrapes or API requests doesn't sample the CPU for a second each. Callers
+// arriving during a collection wait for its result. Zero or negative (the
+// default) disables the cache.
+func SetStatsCacheTTL(d time.Duration) {
+	statsCacheTTL.Store(int64(max(d, 0)))
+
+	statsCache.mu.Lock()
+	statsCache.entries = make(map[statsCacheKey]*statsCacheEntry)
+	statsCache.mu.Unlock()
+}
+
+// cachedServiceStats returns the snapshot of key if it is within the TTL, or
+// collects and caches a new one.
+func cachedServiceStats(key statsCacheKey, collect func() models.ServiceStats) models.ServiceStats {
+	ttl := time.Duration(statsCacheTTL.Load())
+	if ttl <= 0 {
+		return collect()
+	}
+
+	statsCache.mu.Lock()
+	entry, ok := statsCache.entries[key]
+	if !ok {
+		entry = &statsCacheEntry{}
+		statsCache.entries[key] = entry
+	}
+	statsCache.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.collectedAt.IsZero() || now().Sub(entry.collectedAt) >= ttl {
+		entry.stats = collect()
+		entry.collectedAt = now()
+	}
+	return copyServiceStats(entry.stats)
+}
+
+// copyServiceStats copies stats so callers can't modify a cached snapshot's slices.
+func copyServiceStats(stats models.ServiceStats) models.ServiceStats {
+	stats.CPUStatistics.PerCore = slices.Clone(stats.CPUStatistics.PerCore)
+	stats.CPUStatistics.Sensors = slices.Clone(stats.CPUStatistics.Sensors)
+	return stats
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iyashjayesh/monigo/models"
+)
+
+// countCollectionsForTest replaces the stats collection with a slow fake that
+// counts its calls.
+func countCollectionsForTest(t *testing.T) *atomic.Int32 {
+	t.Helper()
+	var calls atomic.Int32
+	orig := collectStats
+	collectStats = func(unit string, opts CollectOptions) models.ServiceStats {
+		n := calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		var stats models.ServiceStats
+		stats.CoreStatistics.Goroutines = int(n)
+		stats.CPUStatistics.PerCore = []float64{1, 2}
+		return stats
+	}
+	t.Cleanup(func() {
+		collectStats = orig
+		SetStatsCacheTTL(0)
+	})
+	return &calls
+}
+
+func TestSetStatsCacheTTL_SingleCollection(t *testing.T) {
+	calls := countCollectionsForTest(t)
+	SetStatsCacheTTL(time.Minute)
+
+	const callers = 50
+	var wg sync.WaitGroup
+	results := make([]models.ServiceStats, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = GetServiceStats(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected one collection for %d concurrent callers, got %d", callers, n)
+	}
+	for i, stats := range results {
+		if stats.CoreStatistics.Goroutines != 1 {
+			t.Errorf("caller %d: expected the shared snapshot, got %+v", i, stats.CoreStatistics)
+		}
+	}
+
+	// Snapshots are copies, so a caller can't modify the cached one.
+	results[0].CPUStatistics.PerCore[0] = 99
+	if got := GetServiceStats(context.Background()).CPUStatistics.PerCore[0]; got != 1 {
+		t.Errorf("expected the cached snapshot unchanged, got per-core %v", got)
+	}
+
+	// Other sections or byte units are collected separately.
+	GetServiceStats(WithByteUnit(context.Background(), "MB"))
+	GetServiceStatsSelective(context.Background(), CollectOptions{Memory: true})
+	if n := calls.Load(); n != 3 {
+		t.Errorf("expected a collection per unit and section set, got %d", n)
+	}
+}
+
+func TestSetStatsCacheTTL_Expiry(t *testing.T) {
+	calls := countCollectionsForTest(t)
+	clockAt := time.Unix(1_700_000_000, 0)
+	now = func() time.Time { return clockAt }
+	defer func() { now = time.Now }()
+
+	SetStatsCacheTTL(time.Second)
+	GetServiceStats(context.Background())
+	clockAt = clockAt.Add(500 * time.Millisecond)
+	GetServiceStats(context.Background())
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected the snapshot reused within the TTL, got %d collections", n)
+	}
+
+	clockAt = clockAt.Add(time.Second)
+	if stats := GetServiceStats(context.Background()); stats.CoreStatistics.Goroutines != 2 {
+		t.Errorf("expected a new collection after the TTL, got %+v", stats.CoreStatistics)
+	}
+
+	// Disabled, every call collects.
+	SetStatsCacheTTL(0)
+	GetServiceStats(context.Background())
+	GetServiceStats(context.Background())
+	if n := calls.Load(); n != 4 {
+		t.Errorf("expected every call to collect without a TTL, got %d collections", n)
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/iyashjayesh/monigo/core"
+
+var (
+	tracingMu      sync.RWMutex
+	tracerProvider trace.TracerProvider
+	baggageKeys    []string
+)
+
+// SetTracerProvider enables function-trace spans using the given provider.
+// Passing nil disables span creation (the default).
+func SetTracerProvider(tp trace.TracerProvider) {
+	tracingMu.Lock()
+	tracerProvider = tp
+	tracingMu.Unlock()
+}
+
+// SetBaggageKeys sets which OTel baggage members from the traced context are
+// copied onto function-trace spans as attributes.
+func SetBaggageKeys(keys []string) {
+	copied := make([]string, len(keys))
+	copy(copied, keys)
+
+	tracingMu.Lock()
+	baggageKeys = copied
+	tracingMu.Unlock()
+}
+
+// startFunctionSpan starts a span for a traced function when tracing is enabled
+// and returns ctx carrying it. The returned end func must always be called.
+func startFunctionSpan(ctx context.Context, name string) (context.Context, func()) {
+	tracingMu.RLock()
+	tp := tracerProvider
+	keys := baggageKeys
+	tracingMu.RUnlock()
+
+	if tp == nil {
+		return ctx, func() {}
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("code.function", name)}
+	attrs = append(attrs, baggageAttributes(ctx, keys)...)
+
+	ctx, span := tp.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, func() { span.End() }
+}
+
+// baggageAttributes returns the selected baggage members of ctx as span attributes.
+func baggageAttributes(ctx context.Context, keys []string) []attribute.KeyValue {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	bag := baggage.FromContext(ctx)
+	attrs := make([]attribute.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		member := bag.Member(key)
+		if member.Key() == "" {
+			continue
+		}
+		attrs = append(attrs, attribute.String(key, member.Value()))
+	}
+	return attrs
+}
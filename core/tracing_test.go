@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceFunction_BaggageAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	SetBaggageKeys([]string{"user.id", "tenant"})
+	defer func() {
+		SetTracerProvider(nil)
+		SetBaggageKeys(nil)
+	}()
+
+	userID, _ := baggage.NewMember("user.id", "42")
+	tenant, _ := baggage.NewMember("tenant", "acme")
+	ignored, _ := baggage.NewMember("session", "secret")
+	bag, err := baggage.New(userID, tenant, ignored)
+	if err != nil {
+		t.Fatalf("baggage.New: %v", err)
+	}
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	TraceFunction(ctx, func() {})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	attrs := make(map[string]string)
+	for _, kv := range spans[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	if attrs["user.id"] != "42" {
+		t.Errorf("expected user.id=42, got %q", attrs["user.id"])
+	}
+	if attrs["tenant"] != "acme" {
+		t.Errorf("expected tenant=acme, got %q", attrs["tenant"])
+	}
+	if _, ok := attrs["session"]; ok {
+		t.Error("expected unselected baggage key to be omitted")
+	}
+}
+
+func TestTraceFunction_NoSpansWhenDisabled(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	SetTracerProvider(nil)
+
+	TraceFunction(context.Background(), func() {})
+
+	if n := len(recorder.Ended()); n != 0 {
+		t.Errorf("expected no spans without a tracer provider, got %d", n)
+	}
+	_ = tp.Shutdown(context.Background())
+}
+
+type ctxKeyForTest struct{}
+
+func contextFunctionForTest(context.Context) {}
+
+func TestTraceContextFunc(t *testing.T) {
+	SetSamplingRate(1)
+	ctx := context.WithValue(context.Background(), ctxKeyForTest{}, "request-42")
+
+	var got any
+	TraceContextFunc(ctx, func(ctx context.Context) { got = ctx.Value(ctxKeyForTest{}) })
+	if got != "request-42" {
+		t.Errorf("expected the function to receive the passed context, got value %v", got)
+	}
+
+	TraceContextFunc(ctx, contextFunctionForTest)
+	var found bool
+	for name, m := range FunctionTraceDetails() {
+		if strings.HasSuffix(name, "contextFunctionForTest") {
+			found = m.CallCount > 0
+		}
+	}
+	if !found {
+		t.Error("expected metrics to be recorded for the traced function")
+	}
+}
+
+func TestTraceContextFunc_PassesSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer SetTracerProvider(nil)
+
+	var inner trace.SpanContext
+	TraceContextFunc(context.Background(), func(ctx context.Context) {
+		inner = trace.SpanContextFromContext(ctx)
+	})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if inner.SpanID() != spans[0].SpanContext().SpanID() {
+		t.Error("expected the function to receive the context of its span")
+	}
+}
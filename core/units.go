@@ -0,0 +1,47 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/iyashjayesh/monigo/common"
+)
+
+type byteUnitKey struct{}
+
+// defaultByteUnit is the unit byte-valued statistics are formatted in when
+// the context does not set one.
+var defaultByteUnit atomic.Value
+
+func init() {
+	defaultByteUnit.Store(common.ByteUnitAuto)
+}
+
+// SetByteUnit sets the default unit of byte-valued statistics: "auto" (the
+// default), "bytes", "KB", "MB", "GB" or "TB".
+func SetByteUnit(unit string) error {
+	u, err := common.ParseByteUnit(unit)
+	if err != nil {
+		return err
+	}
+	defaultByteUnit.Store(u)
+	return nil
+}
+
+// WithByteUnit returns a copy of ctx in which GetServiceStats formats
+// byte-valued statistics in unit, which must be valid for common.ParseByteUnit.
+func WithByteUnit(ctx context.Context, unit string) context.Context {
+	return context.WithValue(ctx, byteUnitKey{}, unit)
+}
+
+// byteUnitFromContext returns the unit set by WithByteUnit, or the default.
+func byteUnitFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if unit, ok := ctx.Value(byteUnitKey{}).(string); ok {
+			if u, err := common.ParseByteUnit(unit); err == nil {
+				return u
+			}
+		}
+	}
+	return defaultByteUnit.Load().(string)
+}
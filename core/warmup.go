@@ -0,0 +1,45 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/iyashjayesh/monigo/models"
+)
+
+// initializingMessage is reported as the health message during the warmup.
+const initializingMessage = "[Initializing] Service health is not scored yet while metrics warm up after startup."
+
+// now is time.Now, replaceable in tests.
+var now = time.Now
+
+// healthWarmup holds the window after startup during which CPU load and
+// function metrics are too sparse to score health.
+var healthWarmup = struct {
+	mu     sync.RWMutex
+	start  time.Time
+	period time.Duration
+}{}
+
+// ConfigureHealthWarmup starts a warmup of period from now, during which
+// health is reported as initializing rather than scored. A zero period
+// disables it.
+func ConfigureHealthWarmup(period time.Duration) {
+	healthWarmup.mu.Lock()
+	defer healthWarmup.mu.Unlock()
+	healthWarmup.start = now()
+	healthWarmup.period = period
+}
+
+// inHealthWarmup reports whether the health warmup is still running.
+func inHealthWarmup() bool {
+	healthWarmup.mu.RLock()
+	defer healthWarmup.mu.RUnlock()
+	return healthWarmup.period > 0 && now().Sub(healthWarmup.start) < healthWarmup.period
+}
+
+// initializingHealth is the neutral health reported during the warmup.
+func initializingHealth() models.ServiceHealth {
+	h := models.Health{Initializing: true, Message: initializingMessage}
+	return models.ServiceHealth{SystemHealth: h, ServiceHealth: h}
+}
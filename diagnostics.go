@@ -0,0 +1,139 @@
+package monigo
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+
+	"github.com/iyashjayesh/monigo/timeseries"
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/mem"
+)
+
+// Names of the checks run by Diagnose.
+const (
+	DiagnosticPort     = "port"
+	DiagnosticStorage  = "storage"
+	DiagnosticCPU      = "cpu"
+	DiagnosticMemory   = "memory"
+	DiagnosticDisk     = "disk"
+	DiagnosticGoTool   = "go_tool"
+	DiagnosticBasePath = "base_path"
+)
+
+// DiagnosticCheck is the outcome of a single Diagnose check.
+type DiagnosticCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// DiagnosticsReport is the result of Diagnose.
+type DiagnosticsReport struct {
+	Passed bool              `json:"passed"`
+	Checks []DiagnosticCheck `json:"checks"`
+}
+
+// Check returns the check with the given name.
+func (r DiagnosticsReport) Check(name string) (DiagnosticCheck, bool) {
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return DiagnosticCheck{}, false
+}
+
+// storageDataDir is timeseries.DataDir, replaceable in tests.
+var storageDataDir = timeseries.DataDir
+
+// Diagnose runs a self-test of everything MoniGo depends on: the dashboard
+// port, storage and base path writability, gopsutil access to CPU, memory and
+// disk stats, and the go tool used for pprof reports. It does not start MoniGo.
+func (m *Monigo) Diagnose() DiagnosticsReport {
+	checks := []DiagnosticCheck{
+		m.diagnosePort(),
+		m.diagnoseStorage(),
+		diagnose(DiagnosticCPU, func() error {
+			_, err := cpu.Percent(0, false)
+			return err
+		}),
+		diagnose(DiagnosticMemory, func() error {
+			_, err := mem.VirtualMemory()
+			return err
+		}),
+		diagnose(DiagnosticDisk, func() error {
+			_, err := disk.Usage("/")
+			return err
+		}),
+		diagnose(DiagnosticGoTool, func() error {
+			_, err := exec.LookPath("go")
+			return err
+		}),
+		diagnose(DiagnosticBasePath, func() error {
+			return checkWritableDir(BasePath)
+		}),
+	}
+
+	report := DiagnosticsReport{Passed: true, Checks: checks}
+	for _, c := range checks {
+		if !c.Passed {
+			report.Passed = false
+		}
+	}
+	return report
+}
+
+// diagnose runs fn and converts its error into a check result.
+func diagnose(name string, fn func() error) DiagnosticCheck {
+	if err := fn(); err != nil {
+		return DiagnosticCheck{Name: name, Reason: err.Error()}
+	}
+	return DiagnosticCheck{Name: name, Passed: true}
+}
+
+func (m *Monigo) diagnosePort() DiagnosticCheck {
+	if m.Headless {
+		return DiagnosticCheck{Name: DiagnosticPort, Passed: true, Reason: "headless mode, no dashboard port needed"}
+	}
+
+	port := m.DashboardPort
+	if port <= 0 || port > 65535 {
+		port = 8080
+	}
+	if m.dashboardRunning.Load() {
+		return DiagnosticCheck{Name: DiagnosticPort, Passed: true, Reason: fmt.Sprintf("dashboard already running on port %d", port)}
+	}
+	return diagnose(DiagnosticPort, func() error {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			return fmt.Errorf("port %d is not available: %w", port, err)
+		}
+		return listener.Close()
+	})
+}
+
+func (m *Monigo) diagnoseStorage() DiagnosticCheck {
+	if m.StorageType == "memory" {
+		return DiagnosticCheck{Name: DiagnosticStorage, Passed: true, Reason: "in-memory storage"}
+	}
+	return diagnose(DiagnosticStorage, func() error {
+		return checkWritableDir(storageDataDir())
+	})
+}
+
+// checkWritableDir creates dir if needed and verifies a file can be written in it.
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("cannot create %s: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".monigo-diagnose-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s: %w", dir, err)
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
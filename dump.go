@@ -0,0 +1,93 @@
+package monigo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/models"
+)
+
+// DumpStats writes a human-readable table of the current CPU, memory,
+// goroutine and health statistics to w. It is handy from a debug handler or
+// a signal handler when a dashboard isn't available.
+func DumpStats(w io.Writer) error {
+	return writeStatsTable(w, core.GetServiceStats(context.Background()))
+}
+
+func writeStatsTable(w io.Writer, stats models.ServiceStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	rows := [][2]string{
+		{"Service CPU", stats.LoadStatistics.ServiceCPULoad},
+		{"System CPU", stats.LoadStatistics.SystemCPULoad},
+		{"Service Memory", stats.LoadStatistics.ServiceMemLoad},
+		{"System Memory", stats.LoadStatistics.SystemMemLoad},
+		{"Memory Used By Service", stats.MemoryStatistics.MemoryUsedByService},
+		{"Overall Load", stats.LoadStatistics.OverallLoadOfService},
+		{"Goroutines", fmt.Sprintf("%d", stats.CoreStatistics.Goroutines)},
+		{"Uptime", stats.CoreStatistics.Uptime},
+		{"Service Health", formatHealth(stats.Health.ServiceHealth)},
+		{"System Health", formatHealth(stats.Health.SystemHealth)},
+	}
+
+	fmt.Fprintln(tw, "METRIC\tVALUE")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\n", row[0], row[1])
+	}
+	return tw.Flush()
+}
+
+// writeGoroutineSummary writes the number of goroutines in each state, taken
+// from the "goroutine N [state]:" header of every stack, most common first.
+func writeGoroutineSummary(w io.Writer, info models.GoRoutinesStatistic) error {
+	counts := make(map[string]int)
+	for _, stack := range info.StackView {
+		counts[goroutineState(stack)]++
+	}
+	states := make([]string, 0, len(counts))
+	for state := range counts {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if counts[states[i]] != counts[states[j]] {
+			return counts[states[i]] > counts[states[j]]
+		}
+		return states[i] < states[j]
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "GOROUTINE STATE\tCOUNT\n")
+	for _, state := range states {
+		fmt.Fprintf(tw, "%s\t%d\n", state, counts[state])
+	}
+	fmt.Fprintf(tw, "Total\t%d\n", info.NumberOfGoroutines)
+	return tw.Flush()
+}
+
+// goroutineState returns the state from a stack's header without its wait
+// duration, e.g. "chan receive" for "goroutine 7 [chan receive, 2 minutes]:".
+func goroutineState(stack string) string {
+	start := strings.IndexByte(stack, '[')
+	end := strings.IndexByte(stack, ']')
+	if start < 0 || end < start {
+		return "unknown"
+	}
+	state, _, _ := strings.Cut(stack[start+1:end], ",")
+	return state
+}
+
+func formatHealth(h models.Health) string {
+	if h.Initializing {
+		return "initializing"
+	}
+	status := "unhealthy"
+	if h.Healthy {
+		status = "healthy"
+	}
+	return fmt.Sprintf("%s (%.2f%%)", status, h.Percent)
+}
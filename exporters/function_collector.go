@@ -0,0 +1,129 @@
+package exporters
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// FunctionMetricsCollector exposes per-function trace metrics to Prometheus.
+// Every scrape works from a single core.FunctionTraceDetails snapshot, so the
+// values reported for a function are always consistent with each other.
+type FunctionMetricsCollector struct {
+	mu sync.RWMutex
+
+	executionSeconds *prometheus.Desc
+	memoryBytes      *prometheus.Desc
+	callsTotal       *prometheus.Desc
+	panicsTotal      *prometheus.Desc
+}
+
+var (
+	functionCollectorOnce sync.Once
+	functionCollector     *FunctionMetricsCollector
+)
+
+// NewFunctionMetricsCollector returns a singleton instance of FunctionMetricsCollector.
+func NewFunctionMetricsCollector() *FunctionMetricsCollector {
+	functionCollectorOnce.Do(func() {
+		functionCollector = &FunctionMetricsCollector{}
+		functionCollector.setConstLabels(nil)
+	})
+	return functionCollector
+}
+
+// setConstLabels (re)creates all descriptors with the given const labels.
+func (c *FunctionMetricsCollector) setConstLabels(constLabels prometheus.Labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.executionSeconds = prometheus.NewDesc(
+		"monigo_function_execution_seconds",
+		"Execution time of the last traced call of the function in seconds.",
+		[]string{"function"}, constLabels,
+	)
+	c.memoryBytes = prometheus.NewDesc(
+		"monigo_function_memory_bytes",
+		"Memory allocated by the last sampled call of the function in bytes.",
+		[]string{"function"}, constLabels,
+	)
+	c.callsTotal = prometheus.NewDesc(
+		"monigo_function_calls_total",
+		"Number of traced calls of the function.",
+		[]string{"function"}, constLabels,
+	)
+	c.panicsTotal = prometheus.NewDesc(
+		"monigo_function_panics_total",
+		"Number of traced calls of the function that panicked.",
+		[]string{"function"}, constLabels,
+	)
+}
+
+// Describe sends the descriptors of the function metrics to the provided channel.
+func (c *FunctionMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ch <- c.executionSeconds
+	ch <- c.memoryBytes
+	ch <- c.callsTotal
+	ch <- c.panicsTotal
+}
+
+// Collect emits one sample per traced function for each metric.
+func (c *FunctionMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	details := core.FunctionTraceDetails()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name, m := range details {
+		name, labels := core.SplitFunctionLabels(name)
+		emit := func(desc *prometheus.Desc, valueType prometheus.ValueType, value float64) {
+			ch <- withLabels(prometheus.MustNewConstMetric(desc, valueType, value, name), labels)
+		}
+		emit(c.executionSeconds, prometheus.GaugeValue, m.ExecutionTime.Seconds())
+		emit(c.memoryBytes, prometheus.GaugeValue, float64(m.MemoryUsage))
+		emit(c.callsTotal, prometheus.CounterValue, float64(m.CallCount))
+		emit(c.panicsTotal, prometheus.CounterValue, float64(m.PanicCount))
+	}
+}
+
+// labeledMetric adds the labels of a function traced with
+// core.TraceFunctionWithLabels to a metric. The labels vary per call site, so
+// they are not part of the descriptor, which keeps the collector checked.
+type labeledMetric struct {
+	prometheus.Metric
+	labels []*dto.LabelPair
+}
+
+// withLabels returns m with the extra labels, or m itself when there are none.
+func withLabels(m prometheus.Metric, labels map[string]string) prometheus.Metric {
+	if len(labels) == 0 {
+		return m
+	}
+	pairs := make([]*dto.LabelPair, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, &dto.LabelPair{Name: proto.String(k), Value: proto.String(v)})
+	}
+	return &labeledMetric{Metric: m, labels: pairs}
+}
+
+func (m *labeledMetric) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+	for _, pair := range m.labels {
+		// Labels of the descriptor win over clashing function labels.
+		if !slices.ContainsFunc(out.Label, func(l *dto.LabelPair) bool { return l.GetName() == pair.GetName() }) {
+			out.Label = append(out.Label, pair)
+		}
+	}
+	slices.SortFunc(out.Label, func(a, b *dto.LabelPair) int { return strings.Compare(a.GetName(), b.GetName()) })
+	return nil
+}
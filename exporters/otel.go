@@ -2,6 +2,11 @@ package exporters
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
 	"sync"
 	"time"
 
@@ -11,25 +16,45 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	otelmetric "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
 
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpcinsecure "google.golang.org/grpc/credentials/insecure"
+)
+
+// DefaultExportInterval is used when OTelConfig.ExportInterval is zero.
+const DefaultExportInterval = 30 * time.Second
+
+// Supported OTLP transport protocols.
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
 )
 
 // OTelExporter implements the internal exporter.Exporter interface
-// and pushes metrics to an OpenTelemetry Collector via OTLP/gRPC.
+// and pushes metrics to an OpenTelemetry Collector via OTLP/gRPC or OTLP/HTTP.
 type OTelExporter struct {
 	provider *metric.MeterProvider
 	meter    otelmetric.Meter
+	resource *resource.Resource
 
 	mu       sync.RWMutex
 	gauges   map[string]otelmetric.Float64ObservableGauge
 	counters map[string]otelmetric.Float64Counter
+	// Registry counters hold running totals; the last total exported per
+	// series, so only the increase is added to the OTel counter.
+	counterTotals map[string]float64
 
-	// Latest gauge values, read by callbacks registered once per gauge.
-	gaugeValues sync.Map // map[string]gaugeSnapshot
+	// Latest gauge values per series, read by callbacks registered once per gauge.
+	gaugeValues sync.Map // map[series key]gaugeSnapshot
 }
 
 type gaugeSnapshot struct {
+	name  string
 	value float64
 	attrs []attribute.KeyValue
 }
@@ -38,41 +63,170 @@ type gaugeSnapshot struct {
 type OTelConfig struct {
 	Endpoint string
 	Headers  map[string]string
-	Insecure bool // When true, use insecure gRPC (default true for backward compat)
+	Insecure bool   // When true, use insecure gRPC (default true for backward compat)
+	Protocol string // "grpc" (default) or "http"
+
+	// ExportInterval is how often metrics are pushed (default 30s, minimum 1s).
+	ExportInterval time.Duration
+
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+	// ResourceAttributes are additional resource attributes attached to every metric.
+	ResourceAttributes map[string]string
+
+	// HTTPClient, if set, sends the "http" protocol's requests, e.g. through a
+	// proxy or with custom timeouts. A client whose *http.Transport has a
+	// TLSClientConfig makes the export use https.
+	HTTPClient *http.Client
+	// DialOptions are passed to the "grpc" protocol's connection, e.g.
+	// grpc.WithTransportCredentials for a custom CA or grpc.WithContextDialer
+	// for a proxy. They take precedence over the exporter's own options.
+	DialOptions []grpc.DialOption
 }
 
-// NewOTelExporter creates and initializes an OTel OTLP metric exporter.
-func NewOTelExporter(ctx context.Context, cfg OTelConfig) (*OTelExporter, error) {
-	opts := []otlpmetricgrpc.Option{
-		otlpmetricgrpc.WithEndpoint(cfg.Endpoint),
+// Validate checks the configuration for unsupported values.
+func (c OTelConfig) Validate() error {
+	switch c.Protocol {
+	case "", ProtocolGRPC, ProtocolHTTP:
+	default:
+		return fmt.Errorf("unsupported OTLP protocol %q: must be %q or %q", c.Protocol, ProtocolGRPC, ProtocolHTTP)
+	}
+	if c.ExportInterval != 0 && c.ExportInterval < time.Second {
+		return fmt.Errorf("OTel export interval %s is too short: must be at least 1s", c.ExportInterval)
 	}
+	return nil
+}
 
-	if cfg.Insecure || len(cfg.Headers) == 0 {
-		opts = append(opts, otlpmetricgrpc.WithInsecure())
+// exportInterval returns the configured export interval or the default.
+func (c OTelConfig) exportInterval() time.Duration {
+	if c.ExportInterval == 0 {
+		return DefaultExportInterval
 	}
+	return c.ExportInterval
+}
 
-	if len(cfg.Headers) > 0 {
-		opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+// newPeriodicReader builds the reader that pushes metrics every interval; replaceable in tests.
+var newPeriodicReader = func(exporter metric.Exporter, interval time.Duration) metric.Reader {
+	return metric.NewPeriodicReader(exporter, metric.WithInterval(interval))
+}
+
+// NewOTelExporter creates and initializes an OTel OTLP metric exporter.
+func NewOTelExporter(ctx context.Context, cfg OTelConfig) (*OTelExporter, error) {
+	exporter, err := newMetricExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	exporter, err := otlpmetricgrpc.New(ctx, opts...)
+	res, err := newResource(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	provider := metric.NewMeterProvider(
-		metric.WithReader(metric.NewPeriodicReader(exporter, metric.WithInterval(30*time.Second))),
+		metric.WithResource(res),
+		metric.WithReader(newPeriodicReader(exporter, cfg.exportInterval())),
 	)
 	meter := provider.Meter("monigo")
 
 	return &OTelExporter{
-		provider: provider,
-		meter:    meter,
-		gauges:   make(map[string]otelmetric.Float64ObservableGauge),
-		counters: make(map[string]otelmetric.Float64Counter),
+		provider:      provider,
+		meter:         meter,
+		resource:      res,
+		gauges:        make(map[string]otelmetric.Float64ObservableGauge),
+		counters:      make(map[string]otelmetric.Float64Counter),
+		counterTotals: make(map[string]float64),
 	}, nil
 }
 
+// newResource builds the OTel resource describing this service and host.
+func newResource(cfg OTelConfig) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{
+		semconv.ProcessRuntimeName("go"),
+		semconv.ProcessRuntimeVersion(runtime.Version()),
+	}
+	if cfg.ServiceName != "" {
+		attrs = append(attrs, semconv.ServiceName(cfg.ServiceName))
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		attrs = append(attrs, semconv.HostName(hostname))
+	}
+	for k, v := range cfg.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	return resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, attrs...))
+}
+
+// newMetricExporter builds the OTLP metric exporter for the configured protocol.
+func newMetricExporter(ctx context.Context, cfg OTelConfig) (metric.Exporter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	insecure := cfg.Insecure || len(cfg.Headers) == 0
+
+	if cfg.Protocol == ProtocolHTTP {
+		opts := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(cfg.Endpoint),
+		}
+		if cfg.HTTPClient != nil {
+			opts = append(opts, otlpmetrichttp.WithHTTPClient(cfg.HTTPClient))
+			if transport, ok := cfg.HTTPClient.Transport.(*http.Transport); ok && transport.TLSClientConfig != nil {
+				insecure = false
+			}
+		}
+		if insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	}
+
+	opts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(cfg.Endpoint),
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+	}
+	if len(cfg.DialOptions) == 0 {
+		if insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+	}
+
+	// The exporter adds its credentials after any dial options passed to it,
+	// so dial here to let the given options override them.
+	creds := credentials.NewTLS(nil)
+	if insecure {
+		creds = grpcinsecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(cfg.Endpoint, append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, cfg.DialOptions...)...)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := otlpmetricgrpc.New(ctx, append(opts, otlpmetricgrpc.WithGRPCConn(conn))...)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &connExporter{Exporter: exporter, conn: conn}, nil
+}
+
+// connExporter is a gRPC metric exporter that closes the connection it was
+// given on shutdown.
+type connExporter struct {
+	metric.Exporter
+	conn *grpc.ClientConn
+}
+
+func (e *connExporter) Shutdown(ctx context.Context) error {
+	err := e.Exporter.Shutdown(ctx)
+	return errors.Join(err, e.conn.Close())
+}
+
 // Export sends metrics to the OTel collector.
 // Instruments are created once and reused on subsequent calls.
 func (o *OTelExporter) Export(ctx context.Context, metrics []*registry.MetricValue) error {
@@ -110,10 +264,12 @@ func (o *OTelExporter) exportGauge(m *registry.MetricValue) error {
 			}
 			name := m.Name
 			_, err = o.meter.RegisterCallback(func(_ context.Context, observer otelmetric.Observer) error {
-				if snap, ok := o.gaugeValues.Load(name); ok {
-					s := snap.(gaugeSnapshot)
-					observer.ObserveFloat64(gauge, s.value, otelmetric.WithAttributes(s.attrs...))
-				}
+				o.gaugeValues.Range(func(_, snap any) bool {
+					if s := snap.(gaugeSnapshot); s.name == name {
+						observer.ObserveFloat64(gauge, s.value, otelmetric.WithAttributes(s.attrs...))
+					}
+					return true
+				})
 				return nil
 			}, gauge)
 			if err != nil {
@@ -126,7 +282,8 @@ func (o *OTelExporter) exportGauge(m *registry.MetricValue) error {
 		o.mu.Unlock()
 	}
 
-	o.gaugeValues.Store(m.Name, gaugeSnapshot{
+	o.gaugeValues.Store(registry.SeriesKey(m.Name, m.Labels), gaugeSnapshot{
+		name:  m.Name,
 		value: m.Value,
 		attrs: labelsToAttributes(m.Labels),
 	})
@@ -153,7 +310,19 @@ func (o *OTelExporter) exportCounter(ctx context.Context, m *registry.MetricValu
 		o.mu.Unlock()
 	}
 
-	counter.Add(ctx, m.Value, otelmetric.WithAttributes(labelsToAttributes(m.Labels)...))
+	// A total below the last one means the registry counter was reset.
+	key := registry.SeriesKey(m.Name, m.Labels)
+	o.mu.Lock()
+	increase := m.Value
+	if last, ok := o.counterTotals[key]; ok && m.Value >= last {
+		increase = m.Value - last
+	}
+	o.counterTotals[key] = m.Value
+	o.mu.Unlock()
+
+	if increase > 0 {
+		counter.Add(ctx, increase, otelmetric.WithAttributes(labelsToAttributes(m.Labels)...))
+	}
 	return nil
 }
 
@@ -175,4 +344,3 @@ func labelsToAttributes(labels map[string]string) []attribute.KeyValue {
 	}
 	return attrs
 }
-
@@ -0,0 +1,317 @@
+package exporters
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iyashjayesh/monigo/internal/registry"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+)
+
+func TestNewMetricExporter_Protocol(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		protocol string
+		check    func(interface{}) bool
+	}{
+		{"", func(e interface{}) bool { _, ok := e.(*otlpmetricgrpc.Exporter); return ok }},
+		{ProtocolGRPC, func(e interface{}) bool { _, ok := e.(*otlpmetricgrpc.Exporter); return ok }},
+		{ProtocolHTTP, func(e interface{}) bool { _, ok := e.(*otlpmetrichttp.Exporter); return ok }},
+	}
+
+	for _, tt := range tests {
+		exp, err := newMetricExporter(ctx, OTelConfig{Endpoint: "localhost:4317", Protocol: tt.protocol})
+		if err != nil {
+			t.Fatalf("protocol %q: unexpected error: %v", tt.protocol, err)
+		}
+		if !tt.check(exp) {
+			t.Errorf("protocol %q: unexpected exporter type %T", tt.protocol, exp)
+		}
+		_ = exp.Shutdown(ctx)
+	}
+}
+
+func TestOTelConfig_ValidateRejectsUnknownProtocol(t *testing.T) {
+	cfg := OTelConfig{Endpoint: "localhost:4317", Protocol: "udp"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for unknown protocol")
+	}
+	if _, err := NewOTelExporter(context.Background(), cfg); err == nil {
+		t.Error("expected NewOTelExporter to reject unknown protocol")
+	}
+}
+
+func TestNewOTelExporter_ResourceAttributes(t *testing.T) {
+	exp, err := NewOTelExporter(context.Background(), OTelConfig{
+		Endpoint:           "localhost:4317",
+		ServiceName:        "order-service",
+		ResourceAttributes: map[string]string{"deployment.environment": "staging"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() {
+		// Nothing is listening on the endpoint; don't wait for the final flush.
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_ = exp.Shutdown(ctx)
+	}()
+
+	attrs := make(map[string]string)
+	for _, kv := range exp.resource.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	hostname, _ := os.Hostname()
+	expected := map[string]string{
+		"service.name":            "order-service",
+		"host.name":               hostname,
+		"process.runtime.version": runtime.Version(),
+		"deployment.environment":  "staging",
+	}
+	for k, v := range expected {
+		if attrs[k] != v {
+			t.Errorf("resource attribute %s: expected %q, got %q", k, v, attrs[k])
+		}
+	}
+}
+
+func TestNewOTelExporter_ExportInterval(t *testing.T) {
+	tests := []struct {
+		configured time.Duration
+		expected   time.Duration
+	}{
+		{0, DefaultExportInterval},
+		{5 * time.Second, 5 * time.Second},
+	}
+
+	orig := newPeriodicReader
+	defer func() { newPeriodicReader = orig }()
+
+	for _, tt := range tests {
+		var got time.Duration
+		newPeriodicReader = func(_ metric.Exporter, interval time.Duration) metric.Reader {
+			got = interval
+			return metric.NewManualReader()
+		}
+
+		exp, err := NewOTelExporter(context.Background(), OTelConfig{Endpoint: "localhost:4317", ExportInterval: tt.configured})
+		if err != nil {
+			t.Fatalf("interval %s: unexpected error: %v", tt.configured, err)
+		}
+		if got != tt.expected {
+			t.Errorf("interval %s: expected reader interval %s, got %s", tt.configured, tt.expected, got)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		_ = exp.Shutdown(ctx)
+		cancel()
+	}
+}
+
+func TestOTelConfig_ValidateRejectsShortInterval(t *testing.T) {
+	cfg := OTelConfig{Endpoint: "localhost:4317", ExportInterval: 500 * time.Millisecond}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for export interval below 1s")
+	}
+}
+
+func TestOTelExporter_CounterExportsIncreases(t *testing.T) {
+	reader := metric.NewManualReader()
+	orig := newPeriodicReader
+	newPeriodicReader = func(metric.Exporter, time.Duration) metric.Reader { return reader }
+	defer func() { newPeriodicReader = orig }()
+
+	exp, err := NewOTelExporter(context.Background(), OTelConfig{Endpoint: "localhost:4317"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer exp.Shutdown(context.Background())
+
+	// The registry holds running totals, exported on every pipeline tick.
+	for _, total := range []float64{3, 5, 5} {
+		m := &registry.MetricValue{Name: "monigo_exports_total", Value: total, Type: registry.Counter}
+		if err := exp.Export(context.Background(), []*registry.MetricValue{m}); err != nil {
+			t.Fatalf("Export error: %v", err)
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect error: %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "monigo_exports_total" {
+				continue
+			}
+			sum := m.Data.(metricdata.Sum[float64])
+			if got := sum.DataPoints[0].Value; got != 5 {
+				t.Errorf("expected the counter to equal the registry total 5, got %v", got)
+			}
+			return
+		}
+	}
+	t.Error("expected the counter to be exported")
+}
+
+func TestOTelExporter_GaugeExportsEverySeries(t *testing.T) {
+	reader := metric.NewManualReader()
+	orig := newPeriodicReader
+	newPeriodicReader = func(metric.Exporter, time.Duration) metric.Reader { return reader }
+	defer func() { newPeriodicReader = orig }()
+
+	exp, err := NewOTelExporter(context.Background(), OTelConfig{Endpoint: "localhost:4317"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer exp.Shutdown(context.Background())
+
+	metrics := []*registry.MetricValue{
+		{Name: "monigo_queue_depth", Value: 3, Labels: map[string]string{"queue": "orders"}, Type: registry.Gauge},
+		{Name: "monigo_queue_depth", Value: 7, Labels: map[string]string{"queue": "billing"}, Type: registry.Gauge},
+	}
+	if err := exp.Export(context.Background(), metrics); err != nil {
+		t.Fatalf("Export error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect error: %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "monigo_queue_depth" {
+				continue
+			}
+			got := map[string]float64{}
+			for _, dp := range m.Data.(metricdata.Gauge[float64]).DataPoints {
+				queue, _ := dp.Attributes.Value("queue")
+				got[queue.AsString()] = dp.Value
+			}
+			if len(got) != 2 || got["orders"] != 3 || got["billing"] != 7 {
+				t.Errorf("expected a data point per series, got %v", got)
+			}
+			return
+		}
+	}
+	t.Error("expected the gauge to be exported")
+}
+
+// recordingTransport records the requests sent through it and answers them
+// with an empty 200.
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, req)
+	t.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestNewMetricExporter_HTTPClient(t *testing.T) {
+	ctx := context.Background()
+	transport := &recordingTransport{}
+	exp, err := newMetricExporter(ctx, OTelConfig{
+		Endpoint:   "collector.internal:4318",
+		Protocol:   ProtocolHTTP,
+		HTTPClient: &http.Client{Transport: transport},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer exp.Shutdown(ctx)
+
+	if err := exp.Export(ctx, &metricdata.ResourceMetrics{}); err != nil {
+		t.Fatalf("Export error: %v", err)
+	}
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.requests) != 1 {
+		t.Fatalf("expected one request through the given client, got %d", len(transport.requests))
+	}
+	if got := transport.requests[0].URL.String(); got != "http://collector.internal:4318/v1/metrics" {
+		t.Errorf("unexpected request URL %s", got)
+	}
+}
+
+func TestNewMetricExporter_HTTPClientTLS(t *testing.T) {
+	var requests int
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	// The server's client trusts its certificate, like a client set up with a
+	// custom CA bundle.
+	ctx := context.Background()
+	exp, err := newMetricExporter(ctx, OTelConfig{
+		Endpoint:   server.Listener.Addr().String(),
+		Protocol:   ProtocolHTTP,
+		Insecure:   true,
+		HTTPClient: server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer exp.Shutdown(ctx)
+
+	if err := exp.Export(ctx, &metricdata.ResourceMetrics{}); err != nil {
+		t.Fatalf("Export error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected the export to reach the TLS server, got %d requests", requests)
+	}
+}
+
+func TestNewMetricExporter_DialOptions(t *testing.T) {
+	dialed := make(chan string, 10)
+	exp, err := newMetricExporter(context.Background(), OTelConfig{
+		Endpoint: "127.0.0.1:4317",
+		DialOptions: []grpc.DialOption{
+			grpc.WithContextDialer(func(_ context.Context, addr string) (net.Conn, error) {
+				dialed <- addr
+				return nil, errors.New("proxy unavailable")
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := exp.Export(ctx, &metricdata.ResourceMetrics{}); err == nil {
+		t.Error("expected the export to fail without a connection")
+	}
+	if err := exp.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown error: %v", err)
+	}
+
+	select {
+	case addr := <-dialed:
+		if addr != "127.0.0.1:4317" {
+			t.Errorf("expected the dialer to be asked for the endpoint, got %q", addr)
+		}
+	default:
+		t.Error("expected the connection to use the given dialer")
+	}
+}
@@ -2,74 +2,272 @@ package exporters
 
 import (
 	"context"
+	"errors"
+	"runtime"
+	"runtime/debug"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/internal/logger"
+	"github.com/iyashjayesh/monigo/internal/registry"
+	"github.com/iyashjayesh/monigo/models"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 // MonigoCollector implements the prometheus.Collector interface.
 type MonigoCollector struct {
-	cpuUsage    *prometheus.Desc
-	memoryUsage *prometheus.Desc
-	goroutines  *prometheus.Desc
+	mu sync.RWMutex
+
+	cpuUsage     *prometheus.Desc
+	cpuCoreUsage *prometheus.Desc
+	memoryUsage  *prometheus.Desc
+	goroutines   *prometheus.Desc
 
 	diskReadBytes  *prometheus.Desc
 	diskWriteBytes *prometheus.Desc
+
+	networkReceiveRate  *prometheus.Desc
+	networkTransmitRate *prometheus.Desc
+
+	syncCycleDuration *prometheus.Desc
+	syncCycleOverruns *prometheus.Desc
+
+	exporterExports  *prometheus.Desc
+	exporterDuration *prometheus.Desc
+
+	scrapeDuration *prometheus.Desc
+	up             *prometheus.Desc
+	buildInfo      *prometheus.Desc
 }
 
 var (
 	once      sync.Once
 	collector *MonigoCollector
+
+	// serviceStats collects the sections of the service stats the collector
+	// exports, skipping the health scoring; replaceable in tests.
+	serviceStats = func(ctx context.Context) models.ServiceStats {
+		return core.GetServiceStatsSelective(ctx, collectOptions)
+	}
+	collectOptions = core.CollectOptions{CPU: true, Memory: true, Load: true, Disk: true, Network: true}
+
+	// readBuildInfo is debug.ReadBuildInfo, replaceable in tests.
+	readBuildInfo = debug.ReadBuildInfo
 )
 
 // NewMonigoCollector returns a singleton instance of MonigoCollector.
 func NewMonigoCollector() *MonigoCollector {
 	once.Do(func() {
-		collector = &MonigoCollector{
-			cpuUsage: prometheus.NewDesc(
-				"monigo_cpu_usage_percent",
-				"Current system CPU usage percentage.",
-				nil, nil,
-			),
-			memoryUsage: prometheus.NewDesc(
-				"monigo_memory_usage_bytes",
-				"Current system memory usage in bytes.",
-				nil, nil,
-			),
-			goroutines: prometheus.NewDesc(
-				"monigo_goroutines_count",
-				"Number of goroutines running.",
-				nil, nil,
-			),
-			diskReadBytes: prometheus.NewDesc(
-				"monigo_disk_read_bytes_total",
-				"Total bytes read from disk.",
-				nil, nil,
-			),
-			diskWriteBytes: prometheus.NewDesc(
-				"monigo_disk_write_bytes_total",
-				"Total bytes written to disk.",
-				nil, nil,
-			),
-		}
+		collector = &MonigoCollector{}
+		collector.setConstLabels(nil)
 	})
 	return collector
 }
 
+// labeledCollector is a collector whose descriptors carry configurable const labels.
+type labeledCollector interface {
+	prometheus.Collector
+	setConstLabels(prometheus.Labels)
+}
+
+// ErrCollectorsRegistered is returned by SetConstLabels once the collectors
+// were registered, since a registered collector's descriptors must not change.
+var ErrCollectorsRegistered = errors.New("const labels must be set before the MoniGo collectors are registered")
+
+// collectorsRegistered is set once RegisterWith has been called.
+var collectorsRegistered atomic.Bool
+
+func collectors() []labeledCollector {
+	return []labeledCollector{NewMonigoCollector(), NewFunctionMetricsCollector(), NewStoredMetricsCollector()}
+}
+
+// SetConstLabels attaches the given labels (e.g. env, region) to every metric
+// exposed by the MoniGo, function and stored-metric collectors. It must be called before
+// RegisterWith.
+func SetConstLabels(labels map[string]string) error {
+	if collectorsRegistered.Load() {
+		return ErrCollectorsRegistered
+	}
+	for _, c := range collectors() {
+		c.setConstLabels(prometheus.Labels(labels))
+	}
+	return nil
+}
+
+// RegisterWith registers the MoniGo, function and stored-metric collectors with reg.
+// Their const labels are fixed from then on. Collectors that are already
+// registered with reg are skipped, so calling it twice is harmless.
+func RegisterWith(reg prometheus.Registerer) error {
+	collectorsRegistered.Store(true)
+	for _, c := range collectors() {
+		if err := reg.Register(c); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if errors.As(err, &are) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// setConstLabels (re)creates all descriptors with the given const labels.
+func (c *MonigoCollector) setConstLabels(constLabels prometheus.Labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cpuUsage = prometheus.NewDesc(
+		"monigo_cpu_usage_percent",
+		"Current system CPU usage percentage.",
+		nil, constLabels,
+	)
+	c.cpuCoreUsage = prometheus.NewDesc(
+		"monigo_cpu_core_usage_percent",
+		"Current usage percentage of each logical CPU core.",
+		[]string{"core"}, constLabels,
+	)
+	c.memoryUsage = prometheus.NewDesc(
+		"monigo_memory_usage_bytes",
+		"Current system memory usage in bytes.",
+		nil, constLabels,
+	)
+	c.goroutines = prometheus.NewDesc(
+		"monigo_goroutines_count",
+		"Number of goroutines running.",
+		nil, constLabels,
+	)
+	c.diskReadBytes = prometheus.NewDesc(
+		"monigo_disk_read_bytes_total",
+		"Total bytes read from disk.",
+		nil, constLabels,
+	)
+	c.diskWriteBytes = prometheus.NewDesc(
+		"monigo_disk_write_bytes_total",
+		"Total bytes written to disk.",
+		nil, constLabels,
+	)
+	c.networkReceiveRate = prometheus.NewDesc(
+		"monigo_network_receive_bytes_per_second",
+		"Bytes received per second over all network interfaces since the previous reading.",
+		nil, constLabels,
+	)
+	c.networkTransmitRate = prometheus.NewDesc(
+		"monigo_network_transmit_bytes_per_second",
+		"Bytes sent per second over all network interfaces since the previous reading.",
+		nil, constLabels,
+	)
+	c.syncCycleDuration = prometheus.NewDesc(
+		registry.SyncCycleDurationSeconds,
+		"Duration of the last metrics sync cycle in seconds.",
+		nil, constLabels,
+	)
+	c.syncCycleOverruns = prometheus.NewDesc(
+		registry.SyncCycleOverrunsTotal,
+		"Number of sync cycles that took longer than the sync interval.",
+		nil, constLabels,
+	)
+	c.exporterExports = prometheus.NewDesc(
+		registry.ExporterExportsTotal,
+		"Number of exports attempted per exporter, by result (success, failure, skipped).",
+		[]string{"exporter", "result"}, constLabels,
+	)
+	c.exporterDuration = prometheus.NewDesc(
+		registry.ExporterDurationSeconds,
+		"Duration of the last export per exporter in seconds.",
+		[]string{"exporter"}, constLabels,
+	)
+	c.scrapeDuration = prometheus.NewDesc(
+		"monigo_scrape_duration_seconds",
+		"Duration of collecting the MoniGo metrics for this scrape in seconds.",
+		nil, constLabels,
+	)
+	c.up = prometheus.NewDesc(
+		"monigo_up",
+		"Whether collecting the MoniGo metrics for this scrape succeeded (1) or panicked (0).",
+		nil, constLabels,
+	)
+	c.buildInfo = prometheus.NewDesc(
+		"monigo_build_info",
+		"Go version, main module version and VCS commit the service was built from; always 1.",
+		[]string{"go_version", "version", "commit"}, constLabels,
+	)
+}
+
 // Describe sends the super-set of all possible descriptors of metrics
 // collected by this Collector to the provided channel.
 func (c *MonigoCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	ch <- c.cpuUsage
+	ch <- c.cpuCoreUsage
 	ch <- c.memoryUsage
 	ch <- c.goroutines
 	ch <- c.diskReadBytes
 	ch <- c.diskWriteBytes
+	ch <- c.networkReceiveRate
+	ch <- c.networkTransmitRate
+	ch <- c.syncCycleDuration
+	ch <- c.syncCycleOverruns
+	ch <- c.exporterExports
+	ch <- c.exporterDuration
+	ch <- c.scrapeDuration
+	ch <- c.up
+	ch <- c.buildInfo
 }
 
-// Collect is called by the Prometheus registry when collecting metrics.
+// Collect is called by the Prometheus registry when collecting metrics. It
+// also reports how long the collection took, and monigo_up as 0 instead of
+// failing the scrape when the collection panics.
 func (c *MonigoCollector) Collect(ch chan<- prometheus.Metric) {
-	stats := core.GetServiceStats(context.Background())
+	start := time.Now()
+	up := 1.0
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Log.Error("collecting Prometheus metrics panicked", "panic", r)
+				up = 0
+			}
+		}()
+		c.collect(ch)
+	}()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ch <- prometheus.MustNewConstMetric(c.scrapeDuration, prometheus.GaugeValue, time.Since(start).Seconds())
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up)
+	ch <- prometheus.MustNewConstMetric(c.buildInfo, prometheus.GaugeValue, 1, buildInfoLabels()...)
+}
+
+// buildInfoLabels returns the go_version, version and commit labels of
+// monigo_build_info. Values the binary doesn't record are "unknown".
+func buildInfoLabels() []string {
+	goVersion, version, commit := runtime.Version(), "unknown", "unknown"
+	if info, ok := readBuildInfo(); ok {
+		if info.GoVersion != "" {
+			goVersion = info.GoVersion
+		}
+		if info.Main.Version != "" {
+			version = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && setting.Value != "" {
+				commit = setting.Value
+			}
+		}
+	}
+	return []string{goVersion, version, commit}
+}
+
+// collect sends the service metrics.
+func (c *MonigoCollector) collect(ch chan<- prometheus.Metric) {
+	stats := serviceStats(context.Background())
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
 	// CPU Load - use raw float64 values directly, no string parsing
 	ch <- prometheus.MustNewConstMetric(
@@ -78,6 +276,15 @@ func (c *MonigoCollector) Collect(ch chan<- prometheus.Metric) {
 		stats.LoadStatistics.SystemCPULoadRaw,
 	)
 
+	for coreNum, usage := range stats.CPUStatistics.PerCore {
+		ch <- prometheus.MustNewConstMetric(
+			c.cpuCoreUsage,
+			prometheus.GaugeValue,
+			usage,
+			strconv.Itoa(coreNum),
+		)
+	}
+
 	// Memory - use raw bytes value directly
 	ch <- prometheus.MustNewConstMetric(
 		c.memoryUsage,
@@ -103,4 +310,35 @@ func (c *MonigoCollector) Collect(ch chan<- prometheus.Metric) {
 		prometheus.CounterValue,
 		float64(stats.DiskIO.WriteBytes),
 	)
+
+	// Network throughput
+	ch <- prometheus.MustNewConstMetric(
+		c.networkReceiveRate,
+		prometheus.GaugeValue,
+		stats.NetworkThroughput.BytesReceivedPerSecond,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.networkTransmitRate,
+		prometheus.GaugeValue,
+		stats.NetworkThroughput.BytesSentPerSecond,
+	)
+
+	// Sync loop and exporter self-metrics
+	var syncDuration, syncOverruns float64
+	if m, ok := registry.Default().Get(registry.SyncCycleDurationSeconds); ok {
+		syncDuration = m.Value
+	}
+	if m, ok := registry.Default().Get(registry.SyncCycleOverrunsTotal); ok {
+		syncOverruns = m.Value
+	}
+	for _, m := range registry.Default().GetByPrefix("monigo_exporter_") {
+		switch m.Name {
+		case registry.ExporterExportsTotal:
+			ch <- prometheus.MustNewConstMetric(c.exporterExports, prometheus.CounterValue, m.Value, m.Labels["exporter"], m.Labels["result"])
+		case registry.ExporterDurationSeconds:
+			ch <- prometheus.MustNewConstMetric(c.exporterDuration, prometheus.GaugeValue, m.Value, m.Labels["exporter"])
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.syncCycleDuration, prometheus.GaugeValue, syncDuration)
+	ch <- prometheus.MustNewConstMetric(c.syncCycleOverruns, prometheus.CounterValue, syncOverruns)
 }
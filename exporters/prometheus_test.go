@@ -1,8 +1,18 @@
 package exporters
 
 import (
+	"context"
+	"errors"
+	"runtime"
+	"runtime/debug"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/internal/registry"
+	"github.com/iyashjayesh/monigo/models"
+	"github.com/iyashjayesh/monigo/timeseries"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -21,7 +31,7 @@ func TestNewMonigoCollector(t *testing.T) {
 
 func TestDescribe(t *testing.T) {
 	c := NewMonigoCollector()
-	ch := make(chan *prometheus.Desc, 10)
+	ch := make(chan *prometheus.Desc, 15)
 
 	go func() {
 		c.Describe(ch)
@@ -32,8 +42,8 @@ func TestDescribe(t *testing.T) {
 	for range ch {
 		count++
 	}
-	if count != 5 {
-		t.Errorf("expected 5 descriptors, got %d", count)
+	if count != 15 {
+		t.Errorf("expected 15 descriptors, got %d", count)
 	}
 }
 
@@ -50,7 +60,396 @@ func TestCollect(t *testing.T) {
 	for range ch {
 		count++
 	}
-	if count != 5 {
-		t.Errorf("expected 5 metrics, got %d", count)
+	// 12 single metrics plus one per CPU core.
+	if want := 12 + runtime.NumCPU(); count != want {
+		t.Errorf("expected %d metrics, got %d", want, count)
 	}
 }
+
+func TestSetConstLabels(t *testing.T) {
+	core.SetSamplingRate(1)
+	core.TraceFunction(context.Background(), func() {})
+
+	if err := SetConstLabels(map[string]string{"env": "prod"}); err != nil {
+		t.Fatalf("SetConstLabels error: %v", err)
+	}
+	defer func() {
+		collectorsRegistered.Store(false)
+		SetConstLabels(nil)
+	}()
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := RegisterWith(reg); err != nil {
+		t.Fatalf("RegisterWith error: %v", err)
+	}
+
+	// Descriptors of a registered collector must not change.
+	if err := SetConstLabels(map[string]string{"env": "dev"}); !errors.Is(err, ErrCollectorsRegistered) {
+		t.Errorf("expected ErrCollectorsRegistered after registration, got %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather error: %v", err)
+	}
+	var checked int
+	for _, mf := range families {
+		if !strings.HasPrefix(mf.GetName(), "monigo_") {
+			continue
+		}
+		checked++
+		for _, m := range mf.GetMetric() {
+			found := false
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == "env" && lp.GetValue() == "prod" {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("metric %s: expected env=prod label", mf.GetName())
+			}
+		}
+	}
+	// 13 system metrics plus 4 function metrics.
+	if checked != 17 {
+		t.Errorf("expected 17 monigo metric families, got %d", checked)
+	}
+}
+
+func TestFunctionMetricsCollector(t *testing.T) {
+	core.SetSamplingRate(1)
+	core.TraceFunction(context.Background(), func() {})
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(NewFunctionMetricsCollector()); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather error: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, mf := range families {
+		if len(mf.GetMetric()) == 0 {
+			continue
+		}
+		if l := mf.GetMetric()[0].GetLabel(); len(l) != 1 || l[0].GetName() != "function" {
+			t.Errorf("metric %s: expected a single function label, got %v", mf.GetName(), l)
+		}
+		found[mf.GetName()] = true
+	}
+
+	for _, name := range []string{
+		"monigo_function_execution_seconds",
+		"monigo_function_memory_bytes",
+		"monigo_function_calls_total",
+		"monigo_function_panics_total",
+	} {
+		if !found[name] {
+			t.Errorf("expected metric %s after tracing a function", name)
+		}
+	}
+}
+
+func labeledFunctionForTest() {}
+
+func TestFunctionMetricsCollector_Labels(t *testing.T) {
+	core.SetSamplingRate(1)
+	core.TraceFunctionWithLabels(context.Background(), labeledFunctionForTest, map[string]string{"tenant": "acme"})
+	core.TraceFunctionWithLabels(context.Background(), labeledFunctionForTest, map[string]string{"tenant": "globex"})
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(NewFunctionMetricsCollector()); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather error: %v", err)
+	}
+
+	tenants := make(map[string]bool)
+	for _, mf := range families {
+		if mf.GetName() != "monigo_function_calls_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			if strings.HasSuffix(labels["function"], "labeledFunctionForTest") {
+				tenants[labels["tenant"]] = m.GetCounter().GetValue() == 1
+			}
+		}
+	}
+	if !tenants["acme"] || !tenants["globex"] {
+		t.Errorf("expected one series with a single call per tenant, got %v", tenants)
+	}
+}
+
+func TestRegisterWith_Twice(t *testing.T) {
+	defer collectorsRegistered.Store(false)
+
+	reg := prometheus.NewRegistry()
+	for i := 0; i < 2; i++ {
+		if err := RegisterWith(reg); err != nil {
+			t.Fatalf("RegisterWith call %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestCollect_ExporterMetrics(t *testing.T) {
+	reg := registry.Default()
+	reg.IncrementCounter(registry.ExporterExportsTotal, 3, map[string]string{"exporter": "otel", "result": "success"})
+	reg.IncrementCounter(registry.ExporterExportsTotal, 1, map[string]string{"exporter": "otel", "result": "failure"})
+	reg.RecordHistogram(registry.ExporterDurationSeconds, 0.25, map[string]string{"exporter": "otel"})
+	defer func() {
+		reg.Delete(registry.ExporterExportsTotal)
+		reg.Delete(registry.ExporterDurationSeconds)
+	}()
+
+	promReg := prometheus.NewPedanticRegistry()
+	if err := promReg.Register(NewMonigoCollector()); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	families, err := promReg.Gather()
+	if err != nil {
+		t.Fatalf("Gather error: %v", err)
+	}
+
+	got := map[string]float64{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			labels := mf.GetName()
+			for _, lp := range m.GetLabel() {
+				labels += "," + lp.GetName() + "=" + lp.GetValue()
+			}
+			if m.GetCounter() != nil {
+				got[labels] = m.GetCounter().GetValue()
+			} else {
+				got[labels] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	want := map[string]float64{
+		"monigo_exporter_exports_total,exporter=otel,result=success": 3,
+		"monigo_exporter_exports_total,exporter=otel,result=failure": 1,
+		"monigo_exporter_duration_seconds,exporter=otel":             0.25,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, got[k])
+		}
+	}
+}
+
+// gatherGauges registers c with a new registry and returns its gauge values by name.
+func gatherGauges(t *testing.T, c prometheus.Collector) map[string]float64 {
+	t.Helper()
+	promReg := prometheus.NewPedanticRegistry()
+	if err := promReg.Register(c); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	families, err := promReg.Gather()
+	if err != nil {
+		t.Fatalf("Gather error: %v", err)
+	}
+	gauges := map[string]float64{}
+	for _, mf := range families {
+		if m := mf.GetMetric(); len(m) == 1 && m[0].GetGauge() != nil {
+			gauges[mf.GetName()] = m[0].GetGauge().GetValue()
+		}
+	}
+	return gauges
+}
+
+func TestCollect_ScrapeMetrics(t *testing.T) {
+	gauges := gatherGauges(t, NewMonigoCollector())
+
+	duration, ok := gauges["monigo_scrape_duration_seconds"]
+	if !ok {
+		t.Fatal("expected monigo_scrape_duration_seconds in the scrape")
+	}
+	if duration < 0 {
+		t.Errorf("expected a non-negative scrape duration, got %v", duration)
+	}
+	if up, ok := gauges["monigo_up"]; !ok || up != 1 {
+		t.Errorf("expected monigo_up 1, got %v (present: %v)", up, ok)
+	}
+}
+
+func TestCollect_BuildInfo(t *testing.T) {
+	labels := gatherBuildInfo(t)
+	if labels["go_version"] != runtime.Version() {
+		t.Errorf("expected go_version %q, got %q", runtime.Version(), labels["go_version"])
+	}
+
+	orig := readBuildInfo
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			GoVersion: "go1.99.0",
+			Main:      debug.Module{Path: "example.com/orders", Version: "v1.4.2"},
+			Settings:  []debug.BuildSetting{{Key: "vcs.revision", Value: "3f2c9ab"}},
+		}, true
+	}
+	defer func() { readBuildInfo = orig }()
+
+	labels = gatherBuildInfo(t)
+	want := map[string]string{"go_version": "go1.99.0", "version": "v1.4.2", "commit": "3f2c9ab"}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, labels[k])
+		}
+	}
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+	labels = gatherBuildInfo(t)
+	if labels["go_version"] != runtime.Version() || labels["version"] != "unknown" || labels["commit"] != "unknown" {
+		t.Errorf("expected fallback labels without build info, got %v", labels)
+	}
+}
+
+// gatherBuildInfo scrapes the MoniGo collector and returns the labels of
+// monigo_build_info, checking its value is 1.
+func gatherBuildInfo(t *testing.T) map[string]string {
+	t.Helper()
+	promReg := prometheus.NewPedanticRegistry()
+	if err := promReg.Register(NewMonigoCollector()); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	families, err := promReg.Gather()
+	if err != nil {
+		t.Fatalf("Gather error: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != "monigo_build_info" {
+			continue
+		}
+		m := mf.GetMetric()[0]
+		if v := m.GetGauge().GetValue(); v != 1 {
+			t.Errorf("expected monigo_build_info 1, got %v", v)
+		}
+		labels := map[string]string{}
+		for _, lp := range m.GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		return labels
+	}
+	t.Fatal("expected monigo_build_info in the scrape")
+	return nil
+}
+
+func TestCollect_PanicSetsUpToZero(t *testing.T) {
+	orig := serviceStats
+	serviceStats = func(context.Context) models.ServiceStats { panic("stats unavailable") }
+	defer func() { serviceStats = orig }()
+
+	gauges := gatherGauges(t, NewMonigoCollector())
+
+	if up, ok := gauges["monigo_up"]; !ok || up != 0 {
+		t.Errorf("expected monigo_up 0 after a panic, got %v (present: %v)", up, ok)
+	}
+	if _, ok := gauges["monigo_scrape_duration_seconds"]; !ok {
+		t.Error("expected monigo_scrape_duration_seconds after a panic")
+	}
+}
+
+func TestCollect_NetworkThroughput(t *testing.T) {
+	orig := serviceStats
+	serviceStats = func(context.Context) models.ServiceStats {
+		var stats models.ServiceStats
+		stats.NetworkThroughput.BytesReceivedPerSecond = 2048
+		stats.NetworkThroughput.BytesSentPerSecond = 512
+		return stats
+	}
+	defer func() { serviceStats = orig }()
+
+	gauges := gatherGauges(t, NewMonigoCollector())
+
+	if got := gauges["monigo_network_receive_bytes_per_second"]; got != 2048 {
+		t.Errorf("expected monigo_network_receive_bytes_per_second 2048, got %v", got)
+	}
+	if got := gauges["monigo_network_transmit_bytes_per_second"]; got != 512 {
+		t.Errorf("expected monigo_network_transmit_bytes_per_second 512, got %v", got)
+	}
+}
+
+func TestStoredMetricsCollector(t *testing.T) {
+	timeseries.SetStorageType("memory")
+	sto, err := timeseries.GetStorageInstance()
+	if err != nil {
+		t.Fatalf("GetStorageInstance error: %v", err)
+	}
+	defer timeseries.CloseStorage()
+
+	now := time.Now().Unix()
+	err = sto.InsertRows([]timeseries.Row{
+		{Metric: "heap_alloc_by_service", Labels: timeseries.SeriesLabels(), DataPoint: timeseries.DataPoint{Timestamp: now - 10, Value: 1024}},
+		{Metric: "heap_alloc_by_service", Labels: timeseries.SeriesLabels(), DataPoint: timeseries.DataPoint{Timestamp: now, Value: 2048}},
+		{Metric: "gc_pause_duration", Labels: timeseries.SeriesLabels(), DataPoint: timeseries.DataPoint{Timestamp: now, Value: 1.5}},
+	})
+	if err != nil {
+		t.Fatalf("InsertRows error: %v", err)
+	}
+
+	SetPrometheusMetrics([]string{"heap_alloc_by_service", "never_stored", "heap_alloc_by_service"})
+	defer SetPrometheusMetrics(nil)
+
+	values := gatherStoredMetrics(t)
+	if len(values) != 1 {
+		t.Errorf("expected only the stored metric in the scrape, got %v", values)
+	}
+	if got := values["heap_alloc_by_service"]; got != 2048 {
+		t.Errorf("expected the latest heap_alloc_by_service 2048, got %v", got)
+	}
+
+	// The list can change after registration.
+	SetPrometheusMetrics([]string{"gc_pause_duration"})
+	values = gatherStoredMetrics(t)
+	if _, ok := values["heap_alloc_by_service"]; ok || values["gc_pause_duration"] != 1.5 {
+		t.Errorf("expected only gc_pause_duration 1.5, got %v", values)
+	}
+}
+
+func TestStoredMetricsCollector_NoneByDefault(t *testing.T) {
+	orig := latestStoredValue
+	latestStoredValue = func(string) (float64, bool) {
+		t.Error("expected no storage reads without configured metrics")
+		return 0, false
+	}
+	defer func() { latestStoredValue = orig }()
+
+	if values := gatherStoredMetrics(t); len(values) != 0 {
+		t.Errorf("expected no stored metrics, got %v", values)
+	}
+}
+
+// gatherStoredMetrics scrapes the stored-metric collector and returns the
+// values by metric label.
+func gatherStoredMetrics(t *testing.T) map[string]float64 {
+	t.Helper()
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(NewStoredMetricsCollector()); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather error: %v", err)
+	}
+	values := map[string]float64{}
+	for _, mf := range families {
+		if mf.GetName() != "monigo_stored_metric" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == "metric" {
+					values[lp.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return values
+}
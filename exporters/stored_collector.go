@@ -0,0 +1,100 @@
+package exporters
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/iyashjayesh/monigo/internal/logger"
+	"github.com/iyashjayesh/monigo/timeseries"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StoredMetricsCollector exposes the latest stored value of each metric set
+// with SetPrometheusMetrics, e.g. MemStats fields without a dedicated gauge,
+// as monigo_stored_metric{metric="heap_alloc_by_service"}. One metric family
+// keeps the collector's descriptors fixed while the list changes.
+type StoredMetricsCollector struct {
+	mu    sync.RWMutex
+	value *prometheus.Desc
+}
+
+var (
+	storedCollectorOnce sync.Once
+	storedCollector     *StoredMetricsCollector
+
+	prometheusMetrics = struct {
+		mu    sync.RWMutex
+		names []string
+	}{}
+
+	// latestStoredValue returns the newest stored value of metric; replaceable in tests.
+	latestStoredValue = func(metric string) (float64, bool) {
+		latest, ok, err := timeseries.Latest(metric, timeseries.SeriesLabels())
+		if err != nil || !ok {
+			logger.Log.Debug("no stored value for Prometheus metric", "metric", metric, "error", err)
+			return 0, false
+		}
+		return latest.Value, true
+	}
+)
+
+// NewStoredMetricsCollector returns a singleton instance of StoredMetricsCollector.
+func NewStoredMetricsCollector() *StoredMetricsCollector {
+	storedCollectorOnce.Do(func() {
+		storedCollector = &StoredMetricsCollector{}
+		storedCollector.setConstLabels(nil)
+	})
+	return storedCollector
+}
+
+// SetPrometheusMetrics sets the stored metrics (e.g. "heap_alloc_by_service",
+// "gc_pause_duration") exposed by the StoredMetricsCollector. It can be
+// called at any time; nil exposes none, the default.
+func SetPrometheusMetrics(metrics []string) {
+	names := slices.Clone(metrics)
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	prometheusMetrics.mu.Lock()
+	defer prometheusMetrics.mu.Unlock()
+	prometheusMetrics.names = names
+}
+
+// PrometheusMetrics returns the stored metrics exposed via Prometheus.
+func PrometheusMetrics() []string {
+	prometheusMetrics.mu.RLock()
+	defer prometheusMetrics.mu.RUnlock()
+	return slices.Clone(prometheusMetrics.names)
+}
+
+// setConstLabels (re)creates the descriptor with the given const labels.
+func (c *StoredMetricsCollector) setConstLabels(constLabels prometheus.Labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.value = prometheus.NewDesc(
+		"monigo_stored_metric",
+		"Latest stored value of the metric, for the metrics set with SetPrometheusMetrics.",
+		[]string{"metric"}, constLabels,
+	)
+}
+
+// Describe sends the descriptor of the stored metrics to the provided channel.
+func (c *StoredMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ch <- c.value
+}
+
+// Collect emits the latest value of each configured metric. Metrics with no
+// stored value are left out.
+func (c *StoredMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, metric := range PrometheusMetrics() {
+		if v, ok := latestStoredValue(metric); ok {
+			ch <- prometheus.MustNewConstMetric(c.value, prometheus.GaugeValue, v, metric)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package monigo
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Flag names defined by RegisterFlags.
+const (
+	FlagServiceName        = "monigo-service-name"
+	FlagPort               = "monigo-port"
+	FlagAutoPort           = "monigo-auto-port"
+	FlagRetentionPeriod    = "monigo-retention"
+	FlagSyncFrequency      = "monigo-sync-frequency"
+	FlagTimeZone           = "monigo-timezone"
+	FlagAPIPath            = "monigo-api-path"
+	FlagMaxCPUUsage        = "monigo-max-cpu"
+	FlagMaxMemoryUsage     = "monigo-max-memory"
+	FlagMaxGoRoutines      = "monigo-max-goroutines"
+	FlagSamplingRate       = "monigo-sampling-rate"
+	FlagStorageType        = "monigo-storage"
+	FlagHostLabel          = "monigo-host"
+	FlagTags               = "monigo-tags"
+	FlagHeadless           = "monigo-headless"
+	FlagOTelEndpoint       = "monigo-otel-endpoint"
+	FlagOTelProtocol       = "monigo-otel-protocol"
+	FlagOTelExportInterval = "monigo-otel-interval"
+)
+
+// RegisterFlags defines flags for the main Monigo configuration fields on fs.
+// Call FromFlags after fs.Parse to build the configuration.
+func RegisterFlags(fs *flag.FlagSet) {
+	fs.String(FlagServiceName, "", "MoniGo service name (required)")
+	fs.Int(FlagPort, 8080, "MoniGo dashboard port")
+	fs.Bool(FlagAutoPort, false, "use the next free port when the dashboard port is taken")
+	fs.String(FlagRetentionPeriod, "7d", "how long to keep metrics, e.g. 7d or 12h")
+	fs.String(FlagSyncFrequency, "5m", "metric flush interval, 1s-24h")
+	fs.String(FlagTimeZone, "Local", "time zone used for timestamps")
+	fs.String(FlagAPIPath, "", "base path of the MoniGo API (default /monigo/api/v1)")
+	fs.Float64(FlagMaxCPUUsage, 95, "CPU usage percent above which the service is unhealthy")
+	fs.Float64(FlagMaxMemoryUsage, 95, "memory usage percent above which the service is unhealthy")
+	fs.Int(FlagMaxGoRoutines, 100, "goroutine count above which the service is unhealthy")
+	fs.Int(FlagSamplingRate, 0, "trace one in N function calls (0 keeps the current rate)")
+	fs.String(FlagStorageType, "", "metric storage, 'disk' or 'memory' (default disk)")
+	fs.String(FlagHostLabel, "", "host label on stored metrics (default hostname)")
+	fs.Var(&tagsFlag{}, FlagTags, "extra metric labels as comma-separated key=value pairs")
+	fs.Bool(FlagHeadless, false, "collect metrics without serving the dashboard")
+	fs.String(FlagOTelEndpoint, "", "OTLP collector endpoint; empty disables OTel export")
+	fs.String(FlagOTelProtocol, "", "OTLP protocol, 'grpc' or 'http' (default grpc)")
+	fs.Duration(FlagOTelExportInterval, 0, "OTel export interval, at least 1s (default 30s)")
+}
+
+// FromFlags builds a Monigo from flags defined by RegisterFlags on fs, which
+// must already be parsed. Flags missing from fs are left at their zero value.
+// Like Build, it panics if the resulting configuration is invalid.
+func FromFlags(fs *flag.FlagSet) *Monigo {
+	b := NewBuilder().
+		WithServiceName(flagValue[string](fs, FlagServiceName)).
+		WithPort(flagValue[int](fs, FlagPort)).
+		WithAutoPort(flagValue[bool](fs, FlagAutoPort)).
+		WithRetentionPeriod(flagValue[string](fs, FlagRetentionPeriod)).
+		WithDataPointsSyncFrequency(flagValue[string](fs, FlagSyncFrequency)).
+		WithTimeZone(flagValue[string](fs, FlagTimeZone)).
+		WithCustomBaseAPIPath(flagValue[string](fs, FlagAPIPath)).
+		WithMaxCPUUsage(flagValue[float64](fs, FlagMaxCPUUsage)).
+		WithMaxMemoryUsage(flagValue[float64](fs, FlagMaxMemoryUsage)).
+		WithMaxGoRoutines(flagValue[int](fs, FlagMaxGoRoutines)).
+		WithSamplingRate(flagValue[int](fs, FlagSamplingRate)).
+		WithStorageType(flagValue[string](fs, FlagStorageType)).
+		WithHostLabel(flagValue[string](fs, FlagHostLabel)).
+		WithHeadless(flagValue[bool](fs, FlagHeadless)).
+		WithOTelEndpoint(flagValue[string](fs, FlagOTelEndpoint)).
+		WithOTelProtocol(flagValue[string](fs, FlagOTelProtocol)).
+		WithOTelExportInterval(flagValue[time.Duration](fs, FlagOTelExportInterval))
+
+	if f := fs.Lookup(FlagTags); f != nil {
+		if tags, ok := f.Value.(*tagsFlag); ok && len(*tags) > 0 {
+			b.WithTags(*tags)
+		}
+	}
+	return b.Build()
+}
+
+// flagValue returns the value of the named flag, or T's zero value if fs
+// does not define it.
+func flagValue[T any](fs *flag.FlagSet, name string) T {
+	var zero T
+	f := fs.Lookup(name)
+	if f == nil {
+		return zero
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return zero
+	}
+	v, ok := getter.Get().(T)
+	if !ok {
+		return zero
+	}
+	return v
+}
+
+// tagsFlag parses comma-separated key=value pairs into a map. It may be
+// repeated; later pairs overwrite earlier ones.
+type tagsFlag map[string]string
+
+func (t *tagsFlag) String() string {
+	if t == nil || len(*t) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(*t))
+	for k, v := range *t {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (t *tagsFlag) Set(s string) error {
+	if *t == nil {
+		*t = make(tagsFlag)
+	}
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return fmt.Errorf("invalid tag %q, expected key=value", pair)
+		}
+		(*t)[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return nil
+}
+
+func (t *tagsFlag) Get() any {
+	return map[string]string(*t)
+}
@@ -0,0 +1,95 @@
+package monigo
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFromFlags(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	RegisterFlags(fs)
+	err := fs.Parse([]string{
+		"-monigo-service-name=cli-tool",
+		"-monigo-port", "9191",
+		"-monigo-storage=memory",
+		"-monigo-retention=12h",
+		"-monigo-max-goroutines=500",
+		"-monigo-tags", "env=prod, region=eu",
+		"-monigo-tags=team=infra",
+		"-monigo-headless",
+		"-monigo-otel-interval=10s",
+	})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	m := FromFlags(fs)
+	if m.ServiceName != "cli-tool" {
+		t.Errorf("expected service name 'cli-tool', got %q", m.ServiceName)
+	}
+	if m.DashboardPort != 9191 {
+		t.Errorf("expected port 9191, got %d", m.DashboardPort)
+	}
+	if m.StorageType != "memory" {
+		t.Errorf("expected storage 'memory', got %q", m.StorageType)
+	}
+	if m.DataRetentionPeriod != "12h" {
+		t.Errorf("expected retention '12h', got %q", m.DataRetentionPeriod)
+	}
+	if m.MaxGoRoutines != 500 {
+		t.Errorf("expected max goroutines 500, got %d", m.MaxGoRoutines)
+	}
+	if want := map[string]string{"env": "prod", "region": "eu", "team": "infra"}; !reflect.DeepEqual(m.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, m.Tags)
+	}
+	if !m.Headless {
+		t.Error("expected headless")
+	}
+	if m.OTelExportInterval != 10*time.Second {
+		t.Errorf("expected OTel interval 10s, got %v", m.OTelExportInterval)
+	}
+
+	// Unset flags keep their defaults.
+	if m.DataPointsSyncFrequency != "5m" || m.MaxCPUUsage != 95 || m.TimeZone != "Local" {
+		t.Errorf("unexpected defaults: sync=%q cpu=%v tz=%q", m.DataPointsSyncFrequency, m.MaxCPUUsage, m.TimeZone)
+	}
+}
+
+func TestFromFlags_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing service name", nil},
+		{"bad storage", []string{"-monigo-service-name=x", "-monigo-storage=s3"}},
+		{"bad port", []string{"-monigo-service-name=x", "-monigo-port=70000"}},
+		{"reserved tag", []string{"-monigo-service-name=x", "-monigo-tags=host=a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("app", flag.ContinueOnError)
+			RegisterFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			defer func() {
+				if recover() == nil {
+					t.Error("expected FromFlags to panic")
+				}
+			}()
+			FromFlags(fs)
+		})
+	}
+}
+
+func TestTagsFlag_Malformed(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+	if err := fs.Parse([]string{"-monigo-tags=env"}); err == nil {
+		t.Error("expected an error for a tag without '='")
+	}
+}
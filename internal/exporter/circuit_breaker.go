@@ -0,0 +1,102 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/iyashjayesh/monigo/internal/logger"
+	"github.com/iyashjayesh/monigo/internal/registry"
+)
+
+// ErrCircuitOpen is returned by a circuit-breaker exporter while it is
+// skipping exports after repeated failures.
+var ErrCircuitOpen = errors.New("exporter circuit breaker is open")
+
+// now is time.Now, replaceable in tests.
+var now = time.Now
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker wraps an exporter and stops calling it after too many
+// consecutive failures. Once the cooldown has passed, a single export is let
+// through as a probe: success closes the breaker, failure opens it again.
+type CircuitBreaker struct {
+	exporter  Exporter
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// WithCircuitBreaker wraps e so it opens after threshold consecutive failures
+// and short-circuits exports for cooldown before probing again.
+func WithCircuitBreaker(e Exporter, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{exporter: e, threshold: threshold, cooldown: cooldown}
+}
+
+// Export calls the wrapped exporter unless the breaker is open.
+func (b *CircuitBreaker) Export(ctx context.Context, metrics []*registry.MetricValue) error {
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+	err := b.exporter.Export(ctx, metrics)
+	b.record(err)
+	return err
+}
+
+// Name returns the wrapped exporter's name.
+func (b *CircuitBreaker) Name() string {
+	return b.exporter.Name()
+}
+
+// allow reports whether an export may go through, moving an open breaker to
+// half-open once the cooldown has passed. Only one probe runs at a time.
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = now()
+		logger.Log.Warn("exporter circuit breaker opened", "name", b.exporter.Name(), "failures", b.failures, "cooldown", b.cooldown)
+	}
+}
@@ -3,6 +3,7 @@ package exporter
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/iyashjayesh/monigo/internal/logger"
 	"github.com/iyashjayesh/monigo/internal/registry"
@@ -15,18 +16,31 @@ type Exporter interface {
 
 type MultiExporter struct {
 	exporters []Exporter
+	registry  *registry.Registry
 }
 
+// NewMultiExporter fans out to the given exporters, recording each export's
+// result and duration into the default registry.
 func NewMultiExporter(exporters ...Exporter) *MultiExporter {
-	return &MultiExporter{exporters: exporters}
+	return &MultiExporter{exporters: exporters, registry: registry.Default()}
+}
+
+// SetRegistry changes the registry export results are recorded into.
+func (m *MultiExporter) SetRegistry(r *registry.Registry) {
+	m.registry = r
 }
 
 // Export fans out to all exporters, collecting errors without short-circuiting.
 func (m *MultiExporter) Export(ctx context.Context, metrics []*registry.MetricValue) error {
 	var errs []error
 	for _, e := range m.exporters {
-		if err := e.Export(ctx, metrics); err != nil {
-			logger.Log.Error("exporter failed", "name", e.Name(), "error", err)
+		if err := ExportAndRecord(ctx, m.registry, e, metrics); err != nil {
+			if errors.Is(err, ErrCircuitOpen) {
+				// Already logged when the breaker opened; don't repeat it every interval.
+				logger.Log.Debug("exporter skipped", "name", e.Name(), "error", err)
+			} else {
+				logger.Log.Error("exporter failed", "name", e.Name(), "error", err)
+			}
 			errs = append(errs, err)
 		}
 	}
@@ -37,3 +51,26 @@ func (m *MultiExporter) Export(ctx context.Context, metrics []*registry.MetricVa
 func (m *MultiExporter) Name() string {
 	return "multi"
 }
+
+// ExportAndRecord runs e.Export and records the outcome into r as
+// monigo_exporter_exports_total{exporter,result} and
+// monigo_exporter_duration_seconds{exporter}. Exports skipped by an open
+// circuit breaker are counted with result="skipped" and no duration.
+func ExportAndRecord(ctx context.Context, r *registry.Registry, e Exporter, metrics []*registry.MetricValue) error {
+	start := time.Now()
+	err := e.Export(ctx, metrics)
+	elapsed := time.Since(start)
+
+	result := "success"
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		result = "skipped"
+	case err != nil:
+		result = "failure"
+	}
+	r.IncrementCounter(registry.ExporterExportsTotal, 1, map[string]string{"exporter": e.Name(), "result": result})
+	if result != "skipped" {
+		r.RecordHistogram(registry.ExporterDurationSeconds, elapsed.Seconds(), map[string]string{"exporter": e.Name()})
+	}
+	return err
+}
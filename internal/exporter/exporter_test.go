@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/iyashjayesh/monigo/internal/registry"
 )
@@ -80,3 +81,218 @@ func containsStr(s, sub string) bool {
 	}
 	return false
 }
+
+type countingExporter struct {
+	calls int
+	err   error
+}
+
+func (c *countingExporter) Export(_ context.Context, _ []*registry.MetricValue) error {
+	c.calls++
+	return c.err
+}
+
+func (c *countingExporter) Name() string { return "counting" }
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	clock := time.Unix(1000, 0)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	inner := &countingExporter{err: errors.New("collector unreachable")}
+	b := WithCircuitBreaker(inner, 3, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := b.Export(ctx, nil); !errors.Is(err, inner.err) {
+			t.Fatalf("failure %d: expected the exporter's error, got %v", i+1, err)
+		}
+	}
+
+	// Open: exports are short-circuited during the cooldown.
+	clock = clock.Add(30 * time.Second)
+	if err := b.Export(ctx, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if inner.calls != 3 {
+		t.Fatalf("expected no calls while open, got %d", inner.calls)
+	}
+
+	// Half-open: a failed probe opens the breaker again for a full cooldown.
+	clock = clock.Add(30 * time.Second)
+	if err := b.Export(ctx, nil); !errors.Is(err, inner.err) {
+		t.Fatalf("expected the probe to reach the exporter, got %v", err)
+	}
+	clock = clock.Add(59 * time.Second)
+	if err := b.Export(ctx, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the breaker to reopen after a failed probe, got %v", err)
+	}
+
+	// A successful probe closes it.
+	inner.err = nil
+	clock = clock.Add(time.Second)
+	if err := b.Export(ctx, nil); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	inner.err = errors.New("transient")
+	for i := 0; i < 2; i++ {
+		b.Export(ctx, nil)
+	}
+	if b.state != breakerClosed {
+		t.Error("expected the breaker to stay closed below the threshold after recovering")
+	}
+	if inner.calls != 7 {
+		t.Errorf("expected 7 calls to the exporter, got %d", inner.calls)
+	}
+}
+
+func TestMultiExporter_WithOpenBreaker(t *testing.T) {
+	failing := WithCircuitBreaker(&countingExporter{err: errors.New("down")}, 1, time.Hour)
+	healthy := &countingExporter{}
+	multi := NewMultiExporter(failing, healthy)
+
+	multi.Export(context.Background(), nil)
+	err := multi.Export(context.Background(), nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen from the multi exporter, got %v", err)
+	}
+	if healthy.calls != 2 {
+		t.Errorf("expected the healthy exporter to keep exporting, got %d calls", healthy.calls)
+	}
+}
+
+// flakyExporter fails every other export.
+type flakyExporter struct {
+	calls int
+}
+
+func (f *flakyExporter) Export(_ context.Context, _ []*registry.MetricValue) error {
+	f.calls++
+	if f.calls%2 == 0 {
+		return errors.New("flaky")
+	}
+	return nil
+}
+
+func (f *flakyExporter) Name() string { return "flaky" }
+
+func exportCount(r *registry.Registry, name, result string) float64 {
+	for _, m := range r.GetAll() {
+		if m.Name == registry.ExporterExportsTotal && m.Labels["exporter"] == name && m.Labels["result"] == result {
+			return m.Value
+		}
+	}
+	return 0
+}
+
+func TestMultiExporter_RecordsExportResults(t *testing.T) {
+	reg := registry.NewRegistry()
+	multi := NewMultiExporter(&flakyExporter{}, &fakeExporter{name: "ok"})
+	multi.SetRegistry(reg)
+
+	for i := 0; i < 5; i++ {
+		multi.Export(context.Background(), nil)
+	}
+
+	if got := exportCount(reg, "flaky", "success"); got != 3 {
+		t.Errorf("expected 3 flaky successes, got %v", got)
+	}
+	if got := exportCount(reg, "flaky", "failure"); got != 2 {
+		t.Errorf("expected 2 flaky failures, got %v", got)
+	}
+	if got := exportCount(reg, "ok", "success"); got != 5 {
+		t.Errorf("expected 5 successes, got %v", got)
+	}
+	if got := exportCount(reg, "ok", "failure"); got != 0 {
+		t.Errorf("expected no failures, got %v", got)
+	}
+
+	var durations int
+	for _, m := range reg.GetAll() {
+		if m.Name == registry.ExporterDurationSeconds {
+			durations++
+			if m.Value < 0 {
+				t.Errorf("exporter %s: negative duration %v", m.Labels["exporter"], m.Value)
+			}
+		}
+	}
+	if durations != 2 {
+		t.Errorf("expected a duration series per exporter, got %d", durations)
+	}
+}
+
+func TestMultiExporter_RecordsSkippedExports(t *testing.T) {
+	reg := registry.NewRegistry()
+	multi := NewMultiExporter(WithCircuitBreaker(&fakeExporter{name: "down", err: errors.New("down")}, 1, time.Hour))
+	multi.SetRegistry(reg)
+
+	multi.Export(context.Background(), nil)
+	multi.Export(context.Background(), nil)
+
+	if got := exportCount(reg, "down", "failure"); got != 1 {
+		t.Errorf("expected 1 failure, got %v", got)
+	}
+	if got := exportCount(reg, "down", "skipped"); got != 1 {
+		t.Errorf("expected 1 skipped export, got %v", got)
+	}
+}
+
+// recordingExporter keeps the names of the metrics it was given.
+type recordingExporter struct {
+	fakeExporter
+	names []string
+}
+
+func (r *recordingExporter) Export(_ context.Context, metrics []*registry.MetricValue) error {
+	for _, m := range metrics {
+		r.names = append(r.names, m.Name)
+	}
+	return nil
+}
+
+func TestMetricFilter(t *testing.T) {
+	f := MetricFilter{Allow: []string{"monigo_goroutines", "monigo_*_load"}, Deny: []string{"monigo_system_*"}}
+	tests := map[string]bool{
+		"monigo_goroutines":       true,
+		"monigo_service_cpu_load": true,
+		"monigo_system_cpu_load":  false, // denied although allowed
+		"monigo_heap_alloc":       false, // not allowed
+	}
+	for name, want := range tests {
+		if got := f.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if !(MetricFilter{}).Match("anything") {
+		t.Error("expected an empty filter to let every metric pass")
+	}
+	if !(MetricFilter{Deny: []string{"monigo_up"}}).Match("monigo_goroutines") {
+		t.Error("expected a deny-only filter to let other metrics pass")
+	}
+	if err := (MetricFilter{Deny: []string{"monigo_[cpu"}}).Validate(); err == nil {
+		t.Error("expected a malformed pattern to be rejected")
+	}
+}
+
+func TestWithMetricFilter(t *testing.T) {
+	rec := &recordingExporter{fakeExporter: fakeExporter{name: "otel"}}
+	e := WithMetricFilter(rec, MetricFilter{Allow: []string{"cpu", "memory"}, Deny: []string{"memory"}})
+
+	metrics := []*registry.MetricValue{{Name: "cpu"}, {Name: "memory"}, {Name: "disk"}}
+	if err := e.Export(context.Background(), metrics); err != nil {
+		t.Fatalf("Export error: %v", err)
+	}
+	if len(rec.names) != 1 || rec.names[0] != "cpu" {
+		t.Errorf("expected only cpu to reach the exporter, got %v", rec.names)
+	}
+	if e.Name() != "otel" {
+		t.Errorf("expected the wrapped exporter's name, got %q", e.Name())
+	}
+
+	// Nothing is exported when every metric is filtered out.
+	rec.names = nil
+	if err := e.Export(context.Background(), metrics[2:]); err != nil || rec.names != nil {
+		t.Errorf("expected no export, got %v (error %v)", rec.names, err)
+	}
+}
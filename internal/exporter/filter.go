@@ -0,0 +1,89 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/iyashjayesh/monigo/internal/registry"
+)
+
+// MetricFilter selects registry metrics by name. Patterns are metric names or
+// path.Match globs such as "monigo_*_load".
+type MetricFilter struct {
+	Allow []string // if set, only matching metrics pass
+	Deny  []string // matching metrics never pass, even if allowed
+}
+
+// Validate reports a malformed pattern.
+func (f MetricFilter) Validate() error {
+	for _, pattern := range append(append([]string(nil), f.Allow...), f.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid metric pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Empty reports whether f lets every metric pass.
+func (f MetricFilter) Empty() bool {
+	return len(f.Allow) == 0 && len(f.Deny) == 0
+}
+
+// Match reports whether the metric called name passes f.
+func (f MetricFilter) Match(name string) bool {
+	if matchAny(f.Deny, name) {
+		return false
+	}
+	return len(f.Allow) == 0 || matchAny(f.Allow, name)
+}
+
+// Apply returns the metrics passing f.
+func (f MetricFilter) Apply(metrics []*registry.MetricValue) []*registry.MetricValue {
+	if f.Empty() {
+		return metrics
+	}
+	kept := make([]*registry.MetricValue, 0, len(metrics))
+	for _, m := range metrics {
+		if f.Match(m.Name) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// FilteredExporter wraps an exporter so it only receives the metrics passing
+// a MetricFilter.
+type FilteredExporter struct {
+	exporter Exporter
+	filter   MetricFilter
+}
+
+// WithMetricFilter wraps e so metrics not passing f are dropped before it.
+func WithMetricFilter(e Exporter, f MetricFilter) *FilteredExporter {
+	return &FilteredExporter{exporter: e, filter: f}
+}
+
+// Export passes the metrics matching the filter to the wrapped exporter.
+// Nothing is exported when none match.
+func (e *FilteredExporter) Export(ctx context.Context, metrics []*registry.MetricValue) error {
+	metrics = e.filter.Apply(metrics)
+	if len(metrics) == 0 {
+		return nil
+	}
+	return e.exporter.Export(ctx, metrics)
+}
+
+// Name returns the wrapped exporter's name.
+func (e *FilteredExporter) Name() string {
+	return e.exporter.Name()
+}
@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// newLogExporter builds the OTLP/HTTP log exporter for endpoint, sending with
+// client if set; replaceable in tests.
+var newLogExporter = func(ctx context.Context, endpoint string, client *http.Client) (sdklog.Exporter, error) {
+	opts := []otlploghttp.Option{otlploghttp.WithEndpoint(endpoint)}
+	secure := false
+	if client != nil {
+		opts = append(opts, otlploghttp.WithHTTPClient(client))
+		transport, ok := client.Transport.(*http.Transport)
+		secure = ok && transport.TLSClientConfig != nil
+	}
+	if !secure {
+		opts = append(opts, otlploghttp.WithInsecure())
+	}
+	return otlploghttp.New(ctx, opts...)
+}
+
+// InitOTel routes MoniGo's logs to the OTLP/HTTP endpoint (e.g. "localhost:4318")
+// in addition to the current logger, whose level still applies. Records are
+// sent with client if set, and over https if its *http.Transport has a
+// TLSClientConfig. The returned function flushes pending records and restores
+// the previous logger.
+func InitOTel(ctx context.Context, endpoint string, client *http.Client) (shutdown func(context.Context) error, err error) {
+	exporter, err := newLogExporter(ctx, endpoint, client)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)))
+
+	previous := get()
+	installed := slog.New(&otelHandler{
+		next:   previous.Handler(),
+		logger: provider.Logger("github.com/iyashjayesh/monigo"),
+	})
+	loggerHolder.Store(installed)
+
+	return func(ctx context.Context) error {
+		// Leave a logger set after InitOTel in place.
+		loggerHolder.CompareAndSwap(installed, previous)
+		return provider.Shutdown(ctx)
+	}, nil
+}
+
+// otelHandler is a slog.Handler that passes records to next and emits them
+// as OTel log records.
+type otelHandler struct {
+	next   slog.Handler
+	logger otellog.Logger
+	attrs  []otellog.KeyValue
+	group  string // dotted prefix for attribute keys
+}
+
+func (h *otelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *otelHandler) Handle(ctx context.Context, r slog.Record) error {
+	var rec otellog.Record
+	rec.SetTimestamp(r.Time)
+	rec.SetBody(otellog.StringValue(r.Message))
+	rec.SetSeverity(severity(r.Level))
+	rec.SetSeverityText(r.Level.String())
+	rec.AddAttributes(h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		if kv, ok := convertAttr(h.group, a); ok {
+			rec.AddAttributes(kv)
+		}
+		return true
+	})
+	h.logger.Emit(ctx, rec)
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *otelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = append([]otellog.KeyValue(nil), h.attrs...)
+	for _, a := range attrs {
+		if kv, ok := convertAttr(h.group, a); ok {
+			clone.attrs = append(clone.attrs, kv)
+		}
+	}
+	return &clone
+}
+
+func (h *otelHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+// severity maps a slog level to the OTel severity number, so that
+// Debug, Info, Warn and Error map to DEBUG, INFO, WARN and ERROR.
+func severity(level slog.Level) otellog.Severity {
+	return otellog.Severity(level + 9)
+}
+
+// convertAttr converts a slog attribute, prefixing its key with group.
+// Empty attributes are dropped, as slog handlers do.
+func convertAttr(group string, a slog.Attr) (otellog.KeyValue, bool) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return otellog.KeyValue{}, false
+	}
+	return otellog.KeyValue{Key: group + a.Key, Value: convertValue(a.Value)}, true
+}
+
+func convertValue(v slog.Value) otellog.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return otellog.StringValue(v.String())
+	case slog.KindInt64:
+		return otellog.Int64Value(v.Int64())
+	case slog.KindUint64:
+		return otellog.Int64Value(int64(v.Uint64()))
+	case slog.KindFloat64:
+		return otellog.Float64Value(v.Float64())
+	case slog.KindBool:
+		return otellog.BoolValue(v.Bool())
+	case slog.KindGroup:
+		kvs := make([]otellog.KeyValue, 0, len(v.Group()))
+		for _, a := range v.Group() {
+			if kv, ok := convertAttr("", a); ok {
+				kvs = append(kvs, kv)
+			}
+		}
+		return otellog.MapValue(kvs...)
+	default: // durations, times, errors and other values
+		return otellog.StringValue(v.String())
+	}
+}
@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// mockLogExporter records the exported log records.
+type mockLogExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *mockLogExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *mockLogExporter) Shutdown(context.Context) error   { return nil }
+func (e *mockLogExporter) ForceFlush(context.Context) error { return nil }
+
+func TestInitOTel(t *testing.T) {
+	exp := &mockLogExporter{}
+	orig := newLogExporter
+	newLogExporter = func(context.Context, string, *http.Client) (sdklog.Exporter, error) { return exp, nil }
+	defer func() { newLogExporter = orig }()
+
+	var buf bytes.Buffer
+	local := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	SetLogger(local)
+	defer Init(slog.LevelInfo)
+
+	shutdown, err := InitOTel(context.Background(), "localhost:4318", nil)
+	if err != nil {
+		t.Fatalf("InitOTel error: %v", err)
+	}
+	Log.Warn("storage slow", "duration_ms", 120)
+	Get().WithGroup("sync").Error("cycle failed", "error", errors.New("boom"))
+	Log.Debug("below the local level")
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown error: %v", err)
+	}
+	if Get() != local {
+		t.Error("expected shutdown to restore the previous logger")
+	}
+	if !strings.Contains(buf.String(), "storage slow") {
+		t.Errorf("expected records to still reach the local logger, got %q", buf.String())
+	}
+
+	if len(exp.records) != 2 {
+		t.Fatalf("expected 2 exported records, got %d", len(exp.records))
+	}
+	warn, failed := exp.records[0], exp.records[1]
+	if warn.Body().AsString() != "storage slow" || warn.Severity() != otellog.SeverityWarn {
+		t.Errorf("unexpected warn record: body %q severity %v", warn.Body().AsString(), warn.Severity())
+	}
+	if got := attrs(warn)["duration_ms"]; got.AsInt64() != 120 {
+		t.Errorf("expected duration_ms 120, got %v", got)
+	}
+	if failed.Severity() != otellog.SeverityError {
+		t.Errorf("expected ERROR severity, got %v", failed.Severity())
+	}
+	if got := attrs(failed)["sync.error"]; got.AsString() != "boom" {
+		t.Errorf("expected grouped error attribute, got %v", got)
+	}
+}
+
+func TestInitOTelExporterError(t *testing.T) {
+	orig := newLogExporter
+	newLogExporter = func(context.Context, string, *http.Client) (sdklog.Exporter, error) {
+		return nil, errors.New("bad endpoint")
+	}
+	defer func() { newLogExporter = orig }()
+
+	before := Get()
+	if _, err := InitOTel(context.Background(), "::", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if Get() != before {
+		t.Error("expected the logger to be unchanged on error")
+	}
+}
+
+// recordingTransport records the requests sent through it and answers them
+// with an empty 200.
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, req)
+	t.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestInitOTelHTTPClient(t *testing.T) {
+	transport := &recordingTransport{}
+	before := Get()
+	defer SetLogger(before)
+
+	shutdown, err := InitOTel(context.Background(), "collector.internal:4318", &http.Client{Transport: transport})
+	if err != nil {
+		t.Fatalf("InitOTel error: %v", err)
+	}
+	Log.Warn("storage slow")
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown error: %v", err)
+	}
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.requests) == 0 {
+		t.Fatal("expected the records to be sent with the given client")
+	}
+	if got := transport.requests[0].URL.String(); got != "http://collector.internal:4318/v1/logs" {
+		t.Errorf("unexpected request URL %s", got)
+	}
+}
+
+func attrs(r sdklog.Record) map[string]otellog.Value {
+	m := make(map[string]otellog.Value)
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		m[kv.Key] = kv.Value
+		return true
+	})
+	return m
+}
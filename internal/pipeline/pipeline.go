@@ -19,7 +19,13 @@ type Pipeline struct {
 	wg       sync.WaitGroup
 }
 
+// NewPipeline exports the contents of r through e every interval. Export
+// results are recorded back into r; a MultiExporter is pointed at r so each
+// of its exporters is recorded separately.
 func NewPipeline(r *registry.Registry, e exporter.Exporter, interval time.Duration) *Pipeline {
+	if multi, ok := e.(*exporter.MultiExporter); ok {
+		multi.SetRegistry(r)
+	}
 	return &Pipeline{
 		registry: r,
 		exporter: e,
@@ -40,7 +46,7 @@ func (p *Pipeline) Start(ctx context.Context) {
 			case <-ticker.C:
 				metrics := p.registry.GetAll()
 				if len(metrics) > 0 {
-					if err := p.exporter.Export(ctx, metrics); err != nil {
+					if err := p.export(ctx, metrics); err != nil {
 						logger.Log.Error("pipeline export failed", "exporter", p.exporter.Name(), "error", err)
 					}
 				}
@@ -53,6 +59,13 @@ func (p *Pipeline) Start(ctx context.Context) {
 	}()
 }
 
+func (p *Pipeline) export(ctx context.Context, metrics []*registry.MetricValue) error {
+	if _, ok := p.exporter.(*exporter.MultiExporter); ok {
+		return p.exporter.Export(ctx, metrics)
+	}
+	return exporter.ExportAndRecord(ctx, p.registry, p.exporter, metrics)
+}
+
 // Stop gracefully stops the pipeline. Safe to call multiple times.
 func (p *Pipeline) Stop() {
 	p.stopOnce.Do(func() {
@@ -2,11 +2,13 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/iyashjayesh/monigo/internal/exporter"
 	"github.com/iyashjayesh/monigo/internal/registry"
 )
 
@@ -69,6 +71,38 @@ func TestPipelineExportsMetrics(t *testing.T) {
 	}
 }
 
+func TestPipelineWithMetricFilter(t *testing.T) {
+	r := registry.NewRegistry()
+	r.SetGauge("monigo_goroutines", 42, nil)
+	r.SetGauge("monigo_heap_alloc_by_service", 1024, nil)
+	r.SetGauge("monigo_service_health_percent", 90, nil)
+
+	exp := &mockExporter{}
+	filter := exporter.MetricFilter{Allow: []string{"monigo_goroutines", "monigo_*_health_percent"}}
+	p := NewPipeline(r, exporter.WithMetricFilter(exp, filter), 10*time.Millisecond)
+
+	p.Start(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	p.Stop()
+
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+	if len(exp.received) == 0 {
+		t.Fatal("expected received metrics")
+	}
+	// The recorded export results are filtered out as well.
+	for _, metrics := range exp.received {
+		if len(metrics) != 2 {
+			t.Fatalf("expected only the 2 allowed metrics, got %d", len(metrics))
+		}
+		for _, m := range metrics {
+			if m.Name == "monigo_heap_alloc_by_service" {
+				t.Errorf("expected %s to be filtered out", m.Name)
+			}
+		}
+	}
+}
+
 func TestPipelineContextCancellation(t *testing.T) {
 	r := registry.NewRegistry()
 	exp := &mockExporter{}
@@ -103,3 +137,24 @@ func TestPipelineDoubleStop(t *testing.T) {
 	// Should not panic.
 	p.Stop()
 }
+
+func TestPipelineRecordsExportResults(t *testing.T) {
+	r := registry.NewRegistry()
+	r.SetGauge("test_metric", 42, nil)
+
+	exp := &mockExporter{err: errors.New("unreachable")}
+	p := NewPipeline(r, exp, 10*time.Millisecond)
+	p.Start(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	p.Stop()
+
+	var failures float64
+	for _, m := range r.GetAll() {
+		if m.Name == registry.ExporterExportsTotal && m.Labels["exporter"] == "mock" && m.Labels["result"] == "failure" {
+			failures = m.Value
+		}
+	}
+	if failures == 0 || failures != float64(exp.callCount.Load()) {
+		t.Errorf("expected a failure recorded per export (%d), got %v", exp.callCount.Load(), failures)
+	}
+}
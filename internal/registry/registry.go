@@ -1,10 +1,20 @@
 package registry
 
 import (
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+// Names of metrics monigo records about itself.
+const (
+	SyncCycleDurationSeconds = "monigo_sync_cycle_duration_seconds"
+	SyncCycleOverrunsTotal   = "monigo_sync_cycle_overruns_total"
+	ExporterExportsTotal     = "monigo_exporter_exports_total"
+	ExporterDurationSeconds  = "monigo_exporter_duration_seconds"
+)
+
 type MetricType int
 
 const (
@@ -21,11 +31,42 @@ type MetricValue struct {
 	Type      MetricType
 }
 
+// Registry holds one value per series, where a series is a metric name plus
+// its label set.
 type Registry struct {
 	mu      sync.RWMutex
 	metrics map[string]*MetricValue
 }
 
+// SeriesKey identifies a series by its name and sorted label pairs.
+func SeriesKey(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+	}
+	return b.String()
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the process-wide registry monigo records its own metrics into.
+func Default() *Registry {
+	return defaultRegistry
+}
+
 func NewRegistry() *Registry {
 	return &Registry{
 		metrics: make(map[string]*MetricValue),
@@ -35,7 +76,7 @@ func NewRegistry() *Registry {
 func (r *Registry) SetGauge(name string, value float64, labels map[string]string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.metrics[name] = &MetricValue{
+	r.metrics[SeriesKey(name, labels)] = &MetricValue{
 		Name:      name,
 		Value:     value,
 		Labels:    labels,
@@ -48,11 +89,12 @@ func (r *Registry) SetGauge(name string, value float64, labels map[string]string
 func (r *Registry) IncrementCounter(name string, delta float64, labels map[string]string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if m, ok := r.metrics[name]; ok && m.Type == Counter {
+	key := SeriesKey(name, labels)
+	if m, ok := r.metrics[key]; ok && m.Type == Counter {
 		m.Value += delta
 		m.Timestamp = time.Now()
 	} else {
-		r.metrics[name] = &MetricValue{
+		r.metrics[key] = &MetricValue{
 			Name:      name,
 			Value:     delta,
 			Labels:    labels,
@@ -67,7 +109,7 @@ func (r *Registry) IncrementCounter(name string, delta float64, labels map[strin
 func (r *Registry) RecordHistogram(name string, value float64, labels map[string]string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.metrics[name] = &MetricValue{
+	r.metrics[SeriesKey(name, labels)] = &MetricValue{
 		Name:      name,
 		Value:     value,
 		Labels:    labels,
@@ -89,9 +131,49 @@ func (r *Registry) GetAll() []*MetricValue {
 	return values
 }
 
-// Delete removes a metric by name.
+// Get returns a copy of the series of the named metric recorded without
+// labels, and whether there is one. Labeled series are found with GetByPrefix.
+func (r *Registry) Get(name string) (*MetricValue, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, ok := r.metrics[SeriesKey(name, nil)]
+	if !ok {
+		return nil, false
+	}
+	cp := *v
+	return &cp, true
+}
+
+// GetByPrefix returns a copy of every series whose metric name starts with
+// prefix, ordered by name and labels.
+func (r *Registry) GetByPrefix(prefix string) []*MetricValue {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]string, 0)
+	for key, v := range r.metrics {
+		if strings.HasPrefix(v.Name, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	values := make([]*MetricValue, 0, len(keys))
+	for _, key := range keys {
+		cp := *r.metrics[key]
+		values = append(values, &cp)
+	}
+	return values
+}
+
+// Delete removes every series of the named metric.
 func (r *Registry) Delete(name string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	delete(r.metrics, name)
+	for key, m := range r.metrics {
+		if m.Name == name {
+			delete(r.metrics, key)
+		}
+	}
 }
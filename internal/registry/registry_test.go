@@ -95,6 +95,52 @@ func TestGetAllReturnsSnapshot(t *testing.T) {
 	}
 }
 
+func TestGet(t *testing.T) {
+	r := NewRegistry()
+	r.SetGauge("cpu", 42, nil)
+	r.SetGauge("memory", 7, nil)
+	r.SetGauge("disk", 3, map[string]string{"device": "sda"})
+
+	m, ok := r.Get("cpu")
+	if !ok {
+		t.Fatal("expected cpu to be found")
+	}
+	if m.Name != "cpu" || m.Value != 42 {
+		t.Errorf("expected cpu=42, got %s=%v", m.Name, m.Value)
+	}
+
+	m.Value = 999
+	if fresh, _ := r.Get("cpu"); fresh.Value != 42 {
+		t.Error("Get should return a copy; original was modified")
+	}
+
+	if _, ok := r.Get("missing"); ok {
+		t.Error("expected a missing name not to be found")
+	}
+	if _, ok := r.Get("disk"); ok {
+		t.Error("expected a metric with only labeled series not to be found")
+	}
+}
+
+func TestGetByPrefix(t *testing.T) {
+	r := NewRegistry()
+	r.IncrementCounter("monigo_exports", 1, map[string]string{"exporter": "otel"})
+	r.IncrementCounter("monigo_exports", 2, map[string]string{"exporter": "prometheus"})
+	r.SetGauge("monigo_duration", 0.5, nil)
+	r.SetGauge("cpu", 42, nil)
+
+	metrics := r.GetByPrefix("monigo_")
+	if len(metrics) != 3 {
+		t.Fatalf("expected 3 series, got %d", len(metrics))
+	}
+	if metrics[0].Name != "monigo_duration" || metrics[1].Labels["exporter"] != "otel" || metrics[2].Value != 2 {
+		t.Errorf("expected series ordered by name and labels, got %v, %v, %v", metrics[0], metrics[1], metrics[2])
+	}
+	if n := len(r.GetByPrefix("none_")); n != 0 {
+		t.Errorf("expected no series for an unknown prefix, got %d", n)
+	}
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	r := NewRegistry()
 	var wg sync.WaitGroup
@@ -115,3 +161,29 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("expected 2 metrics, got %d", len(metrics))
 	}
 }
+
+func TestSeriesAreKeyedByLabels(t *testing.T) {
+	r := NewRegistry()
+	r.IncrementCounter("exports", 1, map[string]string{"exporter": "otel", "result": "success"})
+	r.IncrementCounter("exports", 1, map[string]string{"result": "success", "exporter": "otel"})
+	r.IncrementCounter("exports", 1, map[string]string{"exporter": "otel", "result": "failure"})
+
+	metrics := r.GetAll()
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(metrics))
+	}
+	for _, m := range metrics {
+		want := 1.0
+		if m.Labels["result"] == "success" {
+			want = 2
+		}
+		if m.Value != want {
+			t.Errorf("series %v: expected %v, got %v", m.Labels, want, m.Value)
+		}
+	}
+
+	r.Delete("exports")
+	if n := len(r.GetAll()); n != 0 {
+		t.Errorf("expected Delete to remove every series, got %d left", n)
+	}
+}
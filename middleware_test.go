@@ -1,10 +1,18 @@
 package monigo
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/iyashjayesh/monigo/api"
 )
 
 func TestBasicAuthMiddleware(t *testing.T) {
@@ -250,3 +258,405 @@ func TestCustomAuthFunction(t *testing.T) {
 		t.Errorf("Expected status 401, got %d", w.Code)
 	}
 }
+
+func TestCustomAuthFunction_APIReturnsJSONError(t *testing.T) {
+	m := &Monigo{
+		ServiceName:  "test-service",
+		AuthFunction: func(r *http.Request) bool { return false },
+	}
+	handler := GetSecuredUnifiedHandler(m)
+
+	req := httptest.NewRequest("GET", baseAPIPath+"/metrics", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, got %d", w.Code)
+	}
+	var body api.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON error body: %v", err)
+	}
+	if body.Error.Code != api.ErrCodeUnauthorized {
+		t.Errorf("expected code %q, got %q", api.ErrCodeUnauthorized, body.Error.Code)
+	}
+}
+
+func TestUnknownAPIPathReturnsJSONNotFound(t *testing.T) {
+	handler := GetUnifiedHandler()
+
+	req := httptest.NewRequest("GET", baseAPIPath+"/does-not-exist", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %d", w.Code)
+	}
+	var body api.NotFoundResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON error body: %v", err)
+	}
+	if body.Error.Code != api.ErrCodeNotFound {
+		t.Errorf("expected code %q, got %q", api.ErrCodeNotFound, body.Error.Code)
+	}
+	assertAvailableEndpoints(t, body.AvailableEndpoints, baseAPIPath)
+}
+
+func TestUnknownAPIPathReturnsJSONNotFound_Fiber(t *testing.T) {
+	app := fiber.New()
+	app.Use(GetFiberHandler("/custom/api"))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/custom/api/does-not-exist", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("expected a JSON content type, got %q", ct)
+	}
+	var body api.NotFoundResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON error body: %v", err)
+	}
+	if body.Error.Code != api.ErrCodeNotFound {
+		t.Errorf("expected code %q, got %q", api.ErrCodeNotFound, body.Error.Code)
+	}
+	assertAvailableEndpoints(t, body.AvailableEndpoints, "/custom/api")
+}
+
+func TestFiberHandlerForwardsQueryString(t *testing.T) {
+	app := fiber.New()
+	app.Use(GetFiberHandler())
+
+	// Without its query string the request would lack the name and get a 400.
+	resp, err := app.Test(httptest.NewRequest("GET", baseAPIPath+"/function-details?name=fiberUnknownFunction", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status 404 for an unknown function, got %d", resp.StatusCode)
+	}
+}
+
+// assertAvailableEndpoints checks that endpoints lists every API path under
+// apiPath, sorted, and no admin endpoint.
+func assertAvailableEndpoints(t *testing.T, endpoints []string, apiPath string) {
+	t.Helper()
+	for _, want := range []string{apiPath + "/metrics", apiPath + "/latest", apiPath + "/query_range", apiPath + "/config"} {
+		if !slices.Contains(endpoints, want) {
+			t.Errorf("expected %s in the available endpoints, got %v", want, endpoints)
+		}
+	}
+	if !slices.IsSorted(endpoints) {
+		t.Errorf("expected the endpoints sorted, got %v", endpoints)
+	}
+	for _, path := range endpoints {
+		if !strings.HasPrefix(path, apiPath+"/") || strings.Contains(path, "/admin/") {
+			t.Errorf("unexpected endpoint %s", path)
+		}
+	}
+}
+
+// fakeClock is a manually advanced Clock for rate limiter tests.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+type fakeTicker struct {
+	c      chan time.Time
+	period time.Duration
+	next   time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{c: make(chan time.Time), period: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+func (c *fakeClock) tickerCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.tickers)
+}
+
+// Advance moves the clock forward by d and delivers any ticks that became due.
+// Each tick is delivered synchronously to the ticker's reader.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	tickers := append([]*fakeTicker(nil), c.tickers...)
+	c.mu.Unlock()
+
+	for _, t := range tickers {
+		for !t.next.After(now) {
+			t.c <- t.next
+			t.next = t.next.Add(t.period)
+		}
+	}
+}
+
+func TestRateLimiter_ResetsAtWindowBoundary(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	rl := &rateLimiter{requests: 2, window: time.Minute, clock: clock, clients: make(map[string]*clientInfo)}
+
+	allowed := func() bool {
+		ok, _, _ := rl.allow("10.0.0.1")
+		return ok
+	}
+
+	if !allowed() || !allowed() {
+		t.Fatal("expected the first two requests to be allowed")
+	}
+	if allowed() {
+		t.Fatal("expected the third request in the window to be limited")
+	}
+
+	// The window is inclusive: exactly one window later still counts as the same one.
+	clock.Advance(time.Minute)
+	if allowed() {
+		t.Error("expected the limit to hold exactly at the window boundary")
+	}
+
+	clock.Advance(time.Nanosecond)
+	if !allowed() {
+		t.Error("expected the count to reset just past the window boundary")
+	}
+}
+
+func TestRateLimitMiddleware_CleanupEvictsIdleClients(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	rl := &rateLimiter{requests: 1, window: time.Minute, clock: clock, clients: make(map[string]*clientInfo)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rl.runCleanup(ctx)
+	for clock.tickerCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	rl.allow("10.0.0.1")
+	clock.Advance(time.Minute)
+	rl.allow("10.0.0.2")
+
+	// First cleanup tick: 10.0.0.1 is exactly two windows old, so it is kept.
+	clock.Advance(time.Minute)
+	rl.mu.Lock()
+	if len(rl.clients) != 2 {
+		t.Errorf("expected both clients after the first cleanup, got %d", len(rl.clients))
+	}
+	rl.mu.Unlock()
+
+	// Second cleanup tick: 10.0.0.1 is four windows old, 10.0.0.2 three.
+	clock.Advance(2 * time.Minute)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rl.mu.Lock()
+		n := len(rl.clients)
+		rl.mu.Unlock()
+		if n == 0 || time.Now().After(deadline) {
+			if n != 0 {
+				t.Errorf("expected idle clients to be evicted, %d left", n)
+			}
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRateLimitMiddleware_WithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	middleware, stop := RateLimitMiddleware(1, time.Minute, WithRateLimitClock(clock))
+	defer stop()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if got := codes(); got != http.StatusOK {
+		t.Fatalf("expected 200, got %d", got)
+	}
+	if got := codes(); got != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", got)
+	}
+	clock.Advance(time.Minute + time.Second)
+	if got := codes(); got != http.StatusOK {
+		t.Errorf("expected 200 after the window, got %d", got)
+	}
+}
+
+func TestRateLimitMiddleware_Headers(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	middleware, stop := RateLimitMiddleware(2, time.Minute, WithRateLimitClock(clock))
+	defer stop()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, wantRemaining := range []string{"1", "0"} {
+		w := serve()
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("expected X-RateLimit-Limit 2, got %q", got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("expected X-RateLimit-Remaining %s, got %q", wantRemaining, got)
+		}
+		if got := w.Header().Get("Retry-After"); got != "" {
+			t.Errorf("expected no Retry-After on an allowed request, got %q", got)
+		}
+	}
+
+	clock.Advance(20*time.Second + 500*time.Millisecond)
+	w := serve()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("expected X-RateLimit-Remaining 0, got %q", got)
+	}
+	// 39.5s left in the window, rounded up.
+	if got := w.Header().Get("Retry-After"); got != "40" {
+		t.Errorf("expected Retry-After 40, got %q", got)
+	}
+}
+
+func TestRateLimitMiddleware_CustomHandler(t *testing.T) {
+	middleware, stop := RateLimitMiddleware(0, time.Minute, WithRateLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.WriteError(w, http.StatusTooManyRequests, "rate_limited", "Slow down")
+	})))
+	defer stop()
+
+	w := httptest.NewRecorder()
+	middleware(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After to be set before the custom handler runs")
+	}
+	var resp api.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error.Code != "rate_limited" {
+		t.Errorf("expected the custom handler's JSON body, got %q", w.Body.String())
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var order []string
+	marker := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name+" in")
+				next.ServeHTTP(w, r)
+				order = append(order, name+" out")
+			})
+		}
+	}
+	handler := Chain(marker("first"), nil, marker("second"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := []string{"first in", "second in", "handler", "second out", "first out"}
+	if len(order) != len(want) {
+		t.Fatalf("expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, order)
+		}
+	}
+}
+
+func TestChain_Empty(t *testing.T) {
+	called := false
+	handler := Chain()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !called {
+		t.Error("expected an empty chain to call the handler")
+	}
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	var events []AuditEvent
+	audit := AuditMiddleware(func(e AuditEvent) { events = append(events, e) })
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	for name, handler := range map[string]http.Handler{
+		"audit outside auth": Chain(audit, BasicAuthMiddleware("admin", "secret"))(ok),
+		"audit inside auth":  Chain(BasicAuthMiddleware("admin", "secret"), audit)(ok),
+	} {
+		events = nil
+		before := time.Now()
+
+		req := httptest.NewRequest(http.MethodGet, baseAPIPath+"/metrics", nil)
+		req.RemoteAddr = "10.0.0.7:5123"
+		req.SetBasicAuth("admin", "secret")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if len(events) != 1 {
+			t.Fatalf("%s: expected one audit event, got %d", name, len(events))
+		}
+		e := events[0]
+		if e.Principal != "admin" || e.IP != "10.0.0.7" || e.Method != http.MethodGet || e.Path != baseAPIPath+"/metrics" || e.Status != http.StatusOK {
+			t.Errorf("%s: unexpected event %+v", name, e)
+		}
+		if e.Time.Before(before) || e.Time.After(time.Now()) {
+			t.Errorf("%s: expected the request time, got %v", name, e.Time)
+		}
+	}
+
+	// A rejected request is audited without a principal.
+	events = nil
+	Chain(audit, BasicAuthMiddleware("admin", "secret"))(ok).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(events) != 1 || events[0].Principal != "" || events[0].Status != http.StatusUnauthorized {
+		t.Errorf("expected an unauthenticated 401 event, got %+v", events)
+	}
+
+	// API keys are recorded by label, and static assets are not audited.
+	events = nil
+	handler := Chain(audit, APIKeyMiddleware("k3y"))(ok)
+	req := httptest.NewRequest(http.MethodGet, baseAPIPath+"/function", nil)
+	req.Header.Set("X-API-Key", "k3y")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/css/style.css", nil))
+	if len(events) != 1 || events[0].Principal != APIKeyPrincipal {
+		t.Errorf("expected one event for the API key, got %+v", events)
+	}
+}
@@ -31,15 +31,29 @@ type ServiceStats struct {
 		BytesSent     float64 `json:"bytes_sent"`
 		BytesReceived float64 `json:"bytes_received"`
 	} `json:"network_io"`
+	NetworkThroughput NetworkThroughput `json:"network_throughput"`
+
+	// Set when the disk or network I/O counters could not be read, so the zero
+	// values are not real.
+	DiskIOUnavailable    bool `json:"disk_io_unavailable,omitempty"`
+	NetworkIOUnavailable bool `json:"network_io_unavailable,omitempty"`
 
 	// Health
 	Health ServiceHealth `json:"health"`
 }
 
+// NetworkThroughput is the network I/O rate since the previous read of the
+// counters; zero on the first.
+type NetworkThroughput struct {
+	BytesSentPerSecond     float64 `json:"bytes_sent_per_second"`
+	BytesReceivedPerSecond float64 `json:"bytes_received_per_second"`
+}
+
 // CoreStatistics represents the core statistics of the service.
 type CoreStatistics struct {
-	Goroutines int    `json:"goroutines"`
-	Uptime     string `json:"uptime"`
+	Goroutines    int     `json:"goroutines"`
+	Uptime        string  `json:"uptime"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
 	// RequestCount               int64         `json:"request_count"`
 	// TotalDurationTookByRequest time.Duration `json:"total_duration_took_by_request"`
 }
@@ -64,6 +78,11 @@ type LoadStatistics struct {
 	OverallLoadOfServiceRaw float64 `json:"-"`
 	SystemDiskLoadRaw       float64 `json:"-"`
 	TotalDiskLoadRaw        float64 `json:"-"`
+
+	// Unavailable is set when the CPU or memory readings the load is derived
+	// from could not be read, e.g. for lack of permissions, so the zero
+	// values are not real.
+	Unavailable bool `json:"unavailable,omitempty"`
 }
 
 // CPUStatistics represents the CPU statistics of the service.
@@ -74,6 +93,21 @@ type CPUStatistics struct {
 	CoresUsedByService          float64 `json:"cores_used_by_service"`
 	CoresUsedByServiceInPercent string  `json:"cores_used_by_service_in_percent"`
 	CoresUsedBySystemInPercent  string  `json:"cores_used_by_system_in_percent"`
+
+	PerCore []float64   `json:"per_core_percent"` // Utilization of each logical core, by core number
+	Sensors []CPUSensor `json:"sensors"`          // Empty where the platform doesn't expose sensors
+
+	// Unavailable is set when the CPU usage could not be read, e.g. for lack
+	// of permissions, so the zero usage values are not real.
+	Unavailable bool `json:"unavailable,omitempty"`
+}
+
+// CPUSensor is a temperature or frequency reading. Each reading sets only one
+// of the two values.
+type CPUSensor struct {
+	Name               string  `json:"name"`
+	TemperatureCelsius float64 `json:"temperature_celsius,omitempty"`
+	FrequencyMHz       float64 `json:"frequency_mhz,omitempty"`
 }
 
 // MemoryStatistics represents the memory statistics of the service.
@@ -95,6 +129,10 @@ type MemoryStatistics struct {
 	AvailableMemoryRaw     float64 `json:"-"`
 	GCPauseDurationRaw     float64 `json:"-"`
 	StackMemoryUsageRaw    float64 `json:"-"`
+
+	// Unavailable is set when the system memory could not be read, e.g. for
+	// lack of permissions. The fields read from the Go runtime are still set.
+	Unavailable bool `json:"unavailable,omitempty"`
 }
 
 // ServiceHealth represents the health of the service.
@@ -109,6 +147,9 @@ type Health struct {
 	Healthy bool    `json:"healthy"`
 	Message string  `json:"message"`
 	IconMsg string  `json:"icon_msg"`
+	// Initializing is set during the warmup after startup, when health is
+	// not scored; Percent and Healthy are then unset.
+	Initializing bool `json:"initializing,omitempty"`
 }
 
 // RawMemStatsRecords holds a list of raw memory statistic records.
@@ -133,23 +174,114 @@ type GoRoutinesStatistic struct {
 
 // FunctionTraceDetails represents the function trace details.
 type FunctionTraceDetails struct {
-	FunctionName      string   `json:"function_name"`
-	CoreProfile       Profiles `json:"core_profile"`
-	FunctionCodeTrace string   `json:"function_code_trace"`
+	FunctionName       string   `json:"function_name"`
+	CoreProfile        Profiles `json:"core_profile"`
+	FunctionCodeTrace  string   `json:"function_code_trace"`
+	ProfilingAvailable bool     `json:"profiling_available"` // False when pprof output could not be produced
+	Message            string   `json:"message,omitempty"`   // Explains why profiling is unavailable
+	// ProfileAge is how long ago the reported profile was captured.
+	ProfileAge time.Duration `json:"profile_age,omitempty"`
 }
 
 // Profiles represents the profiles.
 type Profiles struct {
-	CPU string `json:"cpu_profile"`
-	Mem string `json:"mem_profile"`
+	CPU   string `json:"cpu_profile"`
+	Mem   string `json:"mem_profile"`
+	Block string `json:"block_profile,omitempty"` // Set when block profiling is enabled
+	Mutex string `json:"mutex_profile,omitempty"` // Set when mutex profiling is enabled
 }
 
 // FunctionMetrics represents the function metrics.
 type FunctionMetrics struct {
-	FunctionLastRanAt  time.Time     `json:"function_last_ran_at"`
-	CPUProfileFilePath string        `json:"cpu_profile_file_path"`
-	MemProfileFilePath string        `json:"mem_profile_file_path"`
-	MemoryUsage        uint64        `json:"memory_usage"`
-	GoroutineCount     int           `json:"goroutine_count"`
-	ExecutionTime      time.Duration `json:"execution_time"`
+	FunctionLastRanAt  time.Time      `json:"function_last_ran_at"`
+	CPUProfileFilePath string         `json:"cpu_profile_file_path"`
+	MemProfileFilePath string         `json:"mem_profile_file_path"`
+	MemoryUsage        uint64         `json:"memory_usage"`
+	AllocsCount        uint64         `json:"allocs_count"` // Heap allocations during the last sampled call
+	FreesCount         uint64         `json:"frees_count"`  // Heap objects freed during the last sampled call
+	CallCount          uint64         `json:"call_count"`
+	GoroutineCount     int            `json:"goroutine_count"`
+	ExecutionTime      time.Duration  `json:"execution_time"`
+	PanicCount         uint64         `json:"panic_count"`
+	LastPanic          *FunctionPanic `json:"last_panic,omitempty"`
+	// Labels are set for functions traced with core.TraceFunctionWithLabels.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Set on sampled calls while core.EnableBlockProfiling/EnableMutexProfiling is on.
+	BlockProfileFilePath string `json:"block_profile_file_path,omitempty"`
+	MutexProfileFilePath string `json:"mutex_profile_file_path,omitempty"`
+	// ProfiledAt is when the profile files were written; zero until a call is sampled.
+	ProfiledAt time.Time `json:"profiled_at"`
+}
+
+// NamedFunctionMetrics is the FunctionMetrics of one traced function, with
+// its name, for ordered listings.
+type NamedFunctionMetrics struct {
+	Name string `json:"name"`
+	FunctionMetrics
+}
+
+// FunctionPanic describes a panic recovered from a traced function.
+type FunctionPanic struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Stack   string    `json:"stack"`
+}
+
+// FunctionSample is one execution of a traced function. MemoryUsage is only
+// measured for sampled calls and is 0 otherwise.
+type FunctionSample struct {
+	Timestamp     time.Time     `json:"timestamp"`
+	ExecutionTime time.Duration `json:"execution_time"`
+	MemoryUsage   uint64        `json:"memory_usage"`
+}
+
+// MetricsDelta represents the change in cumulative metrics over a time window.
+type MetricsDelta struct {
+	Since  time.Time          `json:"since"`
+	Until  time.Time          `json:"until"`
+	Deltas map[string]float64 `json:"deltas"`
+}
+
+// HealthHistory is the service and system health over a time range, sampled
+// every Step for charting.
+type HealthHistory struct {
+	Start  time.Time            `json:"start"`
+	End    time.Time            `json:"end"`
+	Step   string               `json:"step"`
+	Points []HealthHistoryPoint `json:"points"`
+}
+
+// HealthHistoryPoint is one sample of HealthHistory. A score is omitted when
+// no point was stored for it within the step.
+type HealthHistoryPoint struct {
+	Time          time.Time `json:"time"`
+	ServiceHealth *float64  `json:"service_health_percent,omitempty"`
+	SystemHealth  *float64  `json:"system_health_percent,omitempty"`
+}
+
+// StorageStats describes how much data the metrics store holds.
+type StorageStats struct {
+	StorageType string     `json:"storage_type"`
+	DataDir     string     `json:"data_dir,omitempty"`
+	SizeBytes   int64      `json:"size_bytes"`
+	PointCount  int        `json:"point_count"` // Estimate based on the core service metrics
+	Oldest      *time.Time `json:"oldest,omitempty"`
+	Newest      *time.Time `json:"newest,omitempty"`
+}
+
+// DeleteMetricRequest is the body of the admin delete-metric endpoint.
+type DeleteMetricRequest struct {
+	Metric string            `json:"metric"`
+	Labels map[string]string `json:"labels,omitempty"` // Empty deletes every series of the metric
+}
+
+// SyncState is the body and response of the admin sync endpoint.
+type SyncState struct {
+	Paused *bool `json:"paused"`
+}
+
+// SamplingRateState is the body and response of the admin sampling-rate
+// endpoint. A rate of N profiles one in every N traced calls.
+type SamplingRateState struct {
+	Rate *int `json:"rate"`
 }
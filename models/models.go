@@ -10,6 +10,62 @@ type ServiceInfo struct {
 	ServiceStartTime time.Time `json:"service_start_time"`
 	GoVersion        string    `json:"go_version"`
 	ProcessId        int32     `json:"process_id"`
+
+	// Branding for the dashboard; the title defaults to the service name.
+	DashboardTitle     string `json:"dashboard_title"`
+	ServiceDescription string `json:"service_description,omitempty"`
+}
+
+// MonigoConfig is a snapshot of a Monigo instance's effective configuration,
+// with defaults applied and secrets such as OTel header values redacted.
+type MonigoConfig struct {
+	ServiceName             string            `json:"service_name"`
+	DashboardTitle          string            `json:"dashboard_title"`
+	ServiceDescription      string            `json:"service_description,omitempty"`
+	DashboardPort           int               `json:"dashboard_port"`
+	AutoPort                bool              `json:"auto_port"`
+	Headless                bool              `json:"headless"`
+	APIPath                 string            `json:"api_path"`
+	DataPointsSyncFrequency string            `json:"db_sync_frequency"`
+	DataRetentionPeriod     string            `json:"retention_period"`
+	TimeZone                string            `json:"time_zone"`
+	MaxCPUUsage             float64           `json:"max_cpu_usage"`
+	MaxMemoryUsage          float64           `json:"max_memory_usage"`
+	MaxGoRoutines           int               `json:"max_go_routines"`
+	HealthWarmup            string            `json:"health_warmup,omitempty"`
+	StatsCacheTTL           string            `json:"stats_cache_ttl,omitempty"`
+	SamplingRate            int               `json:"sampling_rate"`
+	StorageType             string            `json:"storage_type"`
+	StorageWAL              bool              `json:"storage_wal"`
+	PrettyJSON              bool              `json:"pretty_json"`
+	ProfileReportTypes      []string          `json:"profile_report_types,omitempty"`
+	MaxProfileAge           string            `json:"max_profile_age,omitempty"`
+	HostLabel               string            `json:"host_label,omitempty"`
+	Tags                    map[string]string `json:"tags,omitempty"`
+	PrometheusMetrics       []string          `json:"prometheus_metrics,omitempty"`
+	LoadWindowSize          int               `json:"load_window_size,omitempty"` // 0 means the default
+	FunctionHistorySize     int               `json:"function_history_size,omitempty"`
+	ByteUnit                string            `json:"byte_unit"`
+	MaxResponsePoints       int               `json:"max_response_points"`
+	EnableSignalDump        bool              `json:"enable_signal_dump"`
+	Isolated                bool              `json:"isolated"`
+	CounterStorage          string            `json:"counter_storage"`
+	ClockSkewPolicy         string            `json:"clock_skew_policy"`
+	Telemetry               bool              `json:"telemetry"`
+	TelemetryEndpoint       string            `json:"telemetry_endpoint,omitempty"`
+
+	OTelEndpoint           string            `json:"otel_endpoint,omitempty"`
+	OTelProtocol           string            `json:"otel_protocol,omitempty"`
+	OTelHeaders            map[string]string `json:"otel_headers,omitempty"` // values are redacted
+	OTelResourceAttributes map[string]string `json:"otel_resource_attributes,omitempty"`
+	OTelExportInterval     string            `json:"otel_export_interval,omitempty"`
+	OTelMetricsAllow       []string          `json:"otel_metrics_allow,omitempty"`
+	OTelMetricsDeny        []string          `json:"otel_metrics_deny,omitempty"`
+	OTelLogsEndpoint       string            `json:"otel_logs_endpoint,omitempty"`
+
+	// Whether an auth function or admin middleware guards the endpoints.
+	AuthConfigured  bool `json:"auth_configured"`
+	AdminConfigured bool `json:"admin_configured"`
 }
 
 // ServiceHealthThresholds is the struct to store the service health thresholds
@@ -22,8 +78,18 @@ type ServiceHealthThresholds struct {
 // FetchDataPoints is the struct to fetch the data points from the storage
 type FetchDataPoints struct {
 	FieldName []string `json:"field_name"`
-	StartTime string   `json:"start_time"` // "2006-01-02T15:04:05Z07:00"
-	EndTime   string   `json:"end_time"`   // "2006-01-02T15:04:05Z07:00"
+	StartTime string   `json:"start_time"`      // "2006-01-02T15:04:05Z07:00"
+	EndTime   string   `json:"end_time"`        // "2006-01-02T15:04:05Z07:00"
+	Range     string   `json:"range,omitempty"` // "last-1h"; overrides start_time and end_time
+}
+
+// ServiceMetricsResponse holds the points returned for a FetchDataPoints
+// request. When the range holds more points than the server's cap, they are
+// sampled every Step and Downsampled is set.
+type ServiceMetricsResponse struct {
+	Points      []map[string]interface{} `json:"points"`
+	Downsampled bool                     `json:"downsampled"`
+	Step        string                   `json:"step,omitempty"`
 }
 
 // DataPointsInfo is the struct to store the data points information
@@ -35,11 +101,83 @@ type DataPointsInfo struct {
 // ReportsRequest is the struct to store the reports request
 type ReportsRequest struct {
 	Topic     string `json:"topic"`
-	StartTime string `json:"start_time"` // "2006-01-02T15:04:05Z07:00"
-	EndTime   string `json:"end_time"`   // "2006-01-02T15:04:05Z07:00"
+	StartTime string `json:"start_time"`      // "2006-01-02T15:04:05Z07:00"
+	EndTime   string `json:"end_time"`        // "2006-01-02T15:04:05Z07:00"
+	Range     string `json:"range,omitempty"` // "last-1h"; overrides start_time and end_time
 	TimeFrame string `json:"time_frame"`
 }
 
+// ReportTopics describes the topics accepted by the reports endpoint and the
+// request parameters they share.
+type ReportTopics struct {
+	Topics     []ReportTopic     `json:"topics"`
+	Parameters []ReportParameter `json:"parameters"`
+}
+
+// ReportTopic is a report topic and the stored metrics it returns.
+type ReportTopic struct {
+	Name    string   `json:"name"`
+	Metrics []string `json:"metrics"`
+}
+
+// ReportParameter is a field of the ReportsRequest body.
+type ReportParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// ReportCompareRequest asks for the metrics of a report topic over two time
+// windows, e.g. before and after a deploy.
+type ReportCompareRequest struct {
+	Topic      string       `json:"topic"`
+	Baseline   ReportWindow `json:"baseline"`
+	Comparison ReportWindow `json:"comparison"`
+}
+
+// ReportWindow is a time window given as RFC3339 start and end times or a
+// relative range, which takes precedence.
+type ReportWindow struct {
+	StartTime string `json:"start_time,omitempty"` // "2006-01-02T15:04:05Z07:00"
+	EndTime   string `json:"end_time,omitempty"`   // "2006-01-02T15:04:05Z07:00"
+	Range     string `json:"range,omitempty"`      // "last-1h"
+}
+
+// ReportComparison holds the series of both windows of a ReportCompareRequest
+// and, per metric, their summaries and the change between them.
+type ReportComparison struct {
+	Topic      string             `json:"topic"`
+	Baseline   ReportWindowData   `json:"baseline"`
+	Comparison ReportWindowData   `json:"comparison"`
+	Metrics    []MetricComparison `json:"metrics"`
+}
+
+// ReportWindowData is the resolved window and its points, in the shape of the
+// reports endpoint.
+type ReportWindowData struct {
+	Start  time.Time                `json:"start"`
+	End    time.Time                `json:"end"`
+	Points []map[string]interface{} `json:"points"`
+}
+
+// MetricComparison compares one metric across the two windows.
+// PercentChange is the change of the average, omitted when either window has
+// no points or the baseline average is 0.
+type MetricComparison struct {
+	Name          string        `json:"name"`
+	Baseline      MetricSummary `json:"baseline"`
+	Comparison    MetricSummary `json:"comparison"`
+	PercentChange *float64      `json:"percent_change,omitempty"`
+}
+
+// MetricSummary aggregates the points of a metric in a window.
+type MetricSummary struct {
+	Count int     `json:"count"`
+	Avg   float64 `json:"avg"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
 // SystemHealthInPercent is the struct to store the system health in percentage
 type SystemHealthInPercent struct {
 	SystemHealth  HealthFields `json:"system_health_percentage"`
@@ -51,3 +189,50 @@ type HealthFields struct {
 	AllowedByUser float64 `json:"allowed_by_user"`
 	Message       string  `json:"message"`
 }
+
+// Label is a metric label (key-value pair).
+type Label struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// DataPoint is a single time-series data point.
+type DataPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// Row is a single metric row to be inserted into storage.
+type Row struct {
+	Metric    string    `json:"metric"`
+	Labels    []Label   `json:"labels"`
+	DataPoint DataPoint `json:"data_point"`
+}
+
+// LatestValue is the newest stored point of a metric.
+type LatestValue struct {
+	Metric    string  `json:"metric"`
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// QueryRangeResponse is the Prometheus HTTP API style response of query_range.
+type QueryRangeResponse struct {
+	Status    string          `json:"status"` // "success" or "error"
+	Data      *QueryRangeData `json:"data,omitempty"`
+	ErrorType string          `json:"errorType,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// QueryRangeData holds the series matched by a query_range selector.
+type QueryRangeData struct {
+	ResultType string             `json:"resultType"` // always "matrix"
+	Result     []QueryRangeSeries `json:"result"`
+}
+
+// QueryRangeSeries is one series of a matrix result. Each value is a
+// [unix seconds, "value"] pair, as in the Prometheus HTTP API.
+type QueryRangeSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]interface{}  `json:"values"`
+}
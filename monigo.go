@@ -11,8 +11,10 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -21,9 +23,13 @@ import (
 	"github.com/iyashjayesh/monigo/common"
 	"github.com/iyashjayesh/monigo/core"
 	"github.com/iyashjayesh/monigo/exporters"
+	"github.com/iyashjayesh/monigo/internal/exporter"
 	"github.com/iyashjayesh/monigo/internal/logger"
+	"github.com/iyashjayesh/monigo/internal/pipeline"
+	"github.com/iyashjayesh/monigo/internal/registry"
 	"github.com/iyashjayesh/monigo/models"
 	"github.com/iyashjayesh/monigo/timeseries"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -55,6 +61,7 @@ func init() {
 type Monigo struct {
 	ServiceName             string    `json:"service_name"`
 	DashboardPort           int       `json:"dashboard_port"`
+	AutoPort                bool      `json:"auto_port"` // Scan for the next free port when DashboardPort is taken
 	DataPointsSyncFrequency string    `json:"db_sync_frequency"`
 	DataRetentionPeriod     string    `json:"retention_period"`
 	TimeZone                string    `json:"time_zone"`
@@ -68,20 +75,104 @@ type Monigo struct {
 	Headless                bool      `json:"headless"`
 	SamplingRate            int       `json:"sampling_rate"`
 	StorageType             string    `json:"storage_type"`
+	PrettyJSON              bool      `json:"pretty_json"`
+	ProfileReportTypes      []string  `json:"profile_report_types"`
+	HostLabel               string    `json:"host_label"`
+	LoadWindowSize          int       `json:"load_window_size"`
+	FunctionHistorySize     int       `json:"function_history_size"`
+	ByteUnit                string    `json:"byte_unit,omitempty"`
+	MaxResponsePoints       int       `json:"max_response_points,omitempty"`
+	EnableSignalDump        bool      `json:"enable_signal_dump"`
+
+	// Isolated scopes this instance's stored metrics and traced functions to
+	// its service name, so several instances can run in one process.
+	Isolated bool `json:"isolated"`
+
+	// DashboardTitle (default: the service name) and ServiceDescription are
+	// returned by the service-info endpoint for the dashboard to display.
+	DashboardTitle     string `json:"dashboard_title,omitempty"`
+	ServiceDescription string `json:"service_description,omitempty"`
+
+	// HealthWarmup is how long after startup health is reported as
+	// initializing rather than scored from the still sparse metrics.
+	HealthWarmup time.Duration `json:"health_warmup,omitempty"`
+	// MaxProfileAge, if set, makes function reports refuse profiles captured
+	// longer ago, which may no longer match the current metrics.
+	MaxProfileAge time.Duration `json:"max_profile_age,omitempty"`
+	// StatsCacheTTL is how long a collected service stats snapshot is served
+	// to further callers before collecting again (default: 0, off).
+	StatsCacheTTL time.Duration `json:"stats_cache_ttl,omitempty"`
+
+	// CounterStorage stores the network and disk counters as "total"
+	// (default), per-second "rate" or "both".
+	CounterStorage string `json:"counter_storage,omitempty"`
+	// ClockSkewPolicy handles a clock jumping backwards: "clamp" (default)
+	// stores the sample just after the last one, "skip" drops it.
+	ClockSkewPolicy string `json:"clock_skew_policy,omitempty"`
+	// StorageWAL logs rows to a write-ahead log before storing them, so rows
+	// lost to a crash are stored on the next start.
+	StorageWAL bool `json:"storage_wal,omitempty"`
+
+	// Tags are extra labels (e.g. env, region) attached to every stored metric.
+	Tags map[string]string `json:"tags,omitempty"`
+	// PrometheusMetrics are stored metrics (e.g. "heap_alloc_by_service")
+	// whose latest value is exposed as monigo_stored_metric{metric="..."}.
+	PrometheusMetrics []string `json:"prometheus_metrics,omitempty"`
+
+	// Telemetry opts in to a one-time anonymous usage report (Go version,
+	// OS, storage type, enabled exporters) POSTed to TelemetryEndpoint at
+	// startup. Nothing is sent unless both are set.
+	Telemetry         bool   `json:"telemetry"`
+	TelemetryEndpoint string `json:"telemetry_endpoint,omitempty"`
 
 	// OpenTelemetry Configuration
 	OTelEndpoint string            `json:"otel_endpoint,omitempty"`
 	OTelHeaders  map[string]string `json:"-"`
+	OTelProtocol string            `json:"otel_protocol,omitempty"`
+
+	OTelResourceAttributes map[string]string `json:"otel_resource_attributes,omitempty"`
+	OTelExportInterval     time.Duration     `json:"otel_export_interval,omitempty"`
+	// OTelMetricsAllow, if set, limits the exported registry metrics to the
+	// matching names or globs (e.g. "monigo_*_load"); OTelMetricsDeny drops
+	// matching metrics.
+	OTelMetricsAllow []string `json:"otel_metrics_allow,omitempty"`
+	OTelMetricsDeny  []string `json:"otel_metrics_deny,omitempty"`
+	// OTelLogsEndpoint is an OTLP/HTTP endpoint (e.g. "localhost:4318") that
+	// MoniGo's own logs are also sent to.
+	OTelLogsEndpoint string `json:"otel_logs_endpoint,omitempty"`
+	// OTelHTTPClient sends the OTLP/HTTP metrics and logs, e.g. through a
+	// corporate proxy or with a custom CA bundle; OTelDialOptions configure
+	// the OTLP/gRPC connection the same way.
+	OTelHTTPClient  *http.Client      `json:"-"`
+	OTelDialOptions []grpc.DialOption `json:"-"`
 
 	// Security and Middleware Configuration
 	DashboardMiddleware []func(http.Handler) http.Handler `json:"-"`
 	APIMiddleware       []func(http.Handler) http.Handler `json:"-"`
 	AuthFunction        func(*http.Request) bool          `json:"-"`
+	AdminMiddleware     []func(http.Handler) http.Handler `json:"-"` // Guards the admin endpoints
+
+	// OnReady is called with the bound port once the dashboard is listening,
+	// before it serves any request.
+	OnReady func(port int) `json:"-"`
 
 	// Holds a reference so we can shut down cleanly.
 	otelExporter *exporters.OTelExporter
+	// Feeds the registry's metrics to otelExporter.
+	otelPipeline *pipeline.Pipeline
+	// Flushes the OTel logs bridge; nil when none is installed.
+	stopOTelLogs func(context.Context) error
+	// Stops the signal dump handler; nil when none is registered.
+	stopSignalDump func()
+	// Set while this instance's dashboard server is serving.
+	dashboardRunning atomic.Bool
+	// Set between a successful setup and Shutdown.
+	live bool
 }
 
+// liveInstances counts the instances sharing the storage and sync loop.
+var liveInstances atomic.Int32
+
 // MonigoInt is the interface to start the monigo service
 type MonigoInt interface {
 	Start() error
@@ -104,6 +195,15 @@ func setDashboardPort(m *Monigo) error {
 	}
 
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", m.DashboardPort))
+	if err != nil && m.AutoPort && m.isAddrInUse(err) {
+		requested := m.DashboardPort
+		listener, err = listenOnFreePort(requested+1, autoPortRange)
+		if err != nil {
+			return fmt.Errorf("[MoniGo] No free port in %d-%d: %v", requested+1, requested+autoPortRange, err)
+		}
+		m.DashboardPort = listener.Addr().(*net.TCPAddr).Port
+		logger.Log.Warn("port in use, using the next free port", "requested", requested, "port", m.DashboardPort)
+	}
 	if err != nil {
 		if portInUse := m.isAddrInUse(err); portInUse {
 			logger.Log.Warn("port in use, setting to default", "requested", m.DashboardPort, "default", defaultPort)
@@ -121,6 +221,25 @@ func setDashboardPort(m *Monigo) error {
 	return nil
 }
 
+// autoPortRange is how many ports after a taken one AutoPort tries.
+const autoPortRange = 100
+
+// listenOnFreePort listens on the first free port among the n ports from start.
+func listenOnFreePort(start, n int) (net.Listener, error) {
+	var lastErr error
+	for port := start; port < start+n && port <= 65535; port++ {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err == nil {
+			return listener, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("port range exceeds 65535")
+	}
+	return nil, lastErr
+}
+
 func (m *Monigo) isAddrInUse(err error) bool {
 	var opErr *net.OpError
 	if errors.As(err, &opErr) {
@@ -146,17 +265,19 @@ func (m *Monigo) initCommon() {
 		location = time.Local
 	}
 
-	m.DataPointsSyncFrequency = common.DefaultIfEmpty(m.DataPointsSyncFrequency, "5m")
-	m.DataRetentionPeriod = common.DefaultIfEmpty(m.DataRetentionPeriod, "7d")
-	m.MaxCPUUsage = common.DefaultFloatIfZero(m.MaxCPUUsage, 95)
-	m.MaxMemoryUsage = common.DefaultFloatIfZero(m.MaxMemoryUsage, 95)
-	m.MaxGoRoutines = common.DefaultIntIfZero(m.MaxGoRoutines, 100)
+	m.DataPointsSyncFrequency = common.DefaultIfEmpty(m.DataPointsSyncFrequency, defaultSyncFrequency)
+	m.DataRetentionPeriod = common.DefaultIfEmpty(m.DataRetentionPeriod, defaultRetentionPeriod)
+	m.MaxCPUUsage = common.DefaultFloatIfZero(m.MaxCPUUsage, defaultMaxUsage)
+	m.MaxMemoryUsage = common.DefaultFloatIfZero(m.MaxMemoryUsage, defaultMaxUsage)
+	m.MaxGoRoutines = common.DefaultIntIfZero(m.MaxGoRoutines, defaultMaxGoRoutines)
 
 	core.ConfigureServiceThresholds(&models.ServiceHealthThresholds{
 		MaxCPUUsage:    m.MaxCPUUsage,
 		MaxMemoryUsage: m.MaxMemoryUsage,
 		MaxGoRoutines:  m.MaxGoRoutines,
 	})
+	core.ConfigureHealthWarmup(m.HealthWarmup)
+	core.SetStatsCacheTTL(m.StatsCacheTTL)
 
 	m.ServiceStartTime = time.Now().In(location)
 }
@@ -181,10 +302,6 @@ func (m *Monigo) setup() error {
 		return fmt.Errorf("[MoniGo] service_name is required, please provide the service name")
 	}
 
-	if err := timeseries.SetDataPointsSyncFrequency(m.DataPointsSyncFrequency); err != nil {
-		return fmt.Errorf("[MoniGo] failed to set data points sync frequency: %v", err)
-	}
-
 	m.ProcessId = common.GetProcessId()
 	m.GoVersion = runtime.Version()
 
@@ -212,13 +329,59 @@ func (m *Monigo) setup() error {
 		m.ProcessId,
 		m.DataRetentionPeriod,
 	)
+	common.SetServiceBranding(common.DefaultIfEmpty(m.DashboardTitle, m.ServiceName), m.ServiceDescription)
 
 	if m.StorageType != "" {
 		timeseries.SetStorageType(m.StorageType)
 	}
+	if m.StorageWAL {
+		timeseries.EnableWAL(true)
+	}
+	if m.HostLabel != "" {
+		timeseries.SetHostLabel(m.HostLabel)
+	}
+	if m.CounterStorage != "" {
+		if err := timeseries.SetCounterStorage(m.CounterStorage); err != nil {
+			return fmt.Errorf("[MoniGo] failed to set counter storage: %v", err)
+		}
+	}
+	if m.ClockSkewPolicy != "" {
+		if err := timeseries.SetClockSkewPolicy(m.ClockSkewPolicy); err != nil {
+			return fmt.Errorf("[MoniGo] failed to set clock skew policy: %v", err)
+		}
+	}
+	if len(m.Tags) > 0 {
+		timeseries.SetTags(m.Tags)
+		if err := exporters.SetConstLabels(m.Tags); err != nil {
+			logger.Log.Warn("tags not applied to Prometheus metrics", "error", err)
+		}
+	}
+	if len(m.PrometheusMetrics) > 0 {
+		exporters.SetPrometheusMetrics(m.PrometheusMetrics)
+	}
+	api.EnablePrometheus()
 	if m.SamplingRate > 0 {
 		core.SetSamplingRate(m.SamplingRate)
 	}
+	if m.LoadWindowSize > 0 {
+		if err := core.SetLoadWindowSize(m.LoadWindowSize); err != nil {
+			return fmt.Errorf("[MoniGo] failed to set load window size: %v", err)
+		}
+	}
+	if m.FunctionHistorySize > 0 {
+		core.SetFunctionHistorySize(m.FunctionHistorySize)
+	}
+	if m.ByteUnit != "" {
+		if err := core.SetByteUnit(m.ByteUnit); err != nil {
+			return fmt.Errorf("[MoniGo] failed to set byte unit: %v", err)
+		}
+	}
+	api.SetPrettyJSON(m.PrettyJSON)
+	api.SetMaxResponsePoints(m.MaxResponsePoints)
+	if len(m.ProfileReportTypes) > 0 {
+		core.SetAllowedReportTypes(m.ProfileReportTypes)
+	}
+	core.SetMaxProfileAge(m.MaxProfileAge)
 
 	_, err := timeseries.GetStorageInstance()
 	if err != nil {
@@ -226,29 +389,147 @@ func (m *Monigo) setup() error {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
+	// One sync loop stores the metrics of every instance in the process.
+	if timeseries.IsSyncLoopRunning() {
+		logger.Log.Info("sync loop already running, sharing it", "service", m.ServiceName)
+	} else if err := timeseries.SetDataPointsSyncFrequency(m.DataPointsSyncFrequency); err != nil {
+		return fmt.Errorf("[MoniGo] failed to set data points sync frequency: %v", err)
+	}
+
+	if m.OTelLogsEndpoint != "" && m.stopOTelLogs == nil {
+		stop, err := logger.InitOTel(context.Background(), m.OTelLogsEndpoint, m.OTelHTTPClient)
+		if err != nil {
+			logger.Log.Error("failed to initialize OTel logs bridge", "error", err)
+		} else {
+			m.stopOTelLogs = stop
+		}
+	}
+
+	// A repeated setup replaces the exporter rather than leaking the old one.
+	if err := m.stopOTel(context.Background()); err != nil {
+		logger.Log.Warn("failed to shut down the previous OTel exporter", "error", err)
+	}
 	if m.OTelEndpoint != "" {
 		otelExp, otelErr := exporters.NewOTelExporter(context.Background(), exporters.OTelConfig{
 			Endpoint: m.OTelEndpoint,
 			Headers:  m.OTelHeaders,
 			Insecure: true,
+			Protocol: m.OTelProtocol,
+
+			ServiceName:        m.ServiceName,
+			ResourceAttributes: m.OTelResourceAttributes,
+			ExportInterval:     m.OTelExportInterval,
+
+			HTTPClient:  m.OTelHTTPClient,
+			DialOptions: m.OTelDialOptions,
 		})
 		if otelErr != nil {
 			logger.Log.Error("failed to initialize OTel exporter", "error", otelErr)
 		} else {
 			m.otelExporter = otelExp
-			logger.Log.Info("OTel exporter initialized", "endpoint", m.OTelEndpoint)
+			// The sync loop sets the service metrics in the default registry.
+			interval := m.OTelExportInterval
+			if interval == 0 {
+				interval = exporters.DefaultExportInterval
+			}
+			filter := exporter.MetricFilter{Allow: m.OTelMetricsAllow, Deny: m.OTelMetricsDeny}
+			m.otelPipeline = pipeline.NewPipeline(registry.Default(), exporter.WithMetricFilter(otelExp, filter), interval)
+			m.otelPipeline.Start(context.Background())
+			logger.Log.Info("OTel exporter initialized", "endpoint", m.OTelEndpoint, "protocol", m.OTelProtocol)
 		}
 	}
 
+	api.SetConfigSource(m.namespace(), m.Config)
+
+	if m.EnableSignalDump {
+		m.registerSignalDump()
+	}
+
+	if !m.live {
+		m.live = true
+		liveInstances.Add(1)
+		go m.reportTelemetry()
+	}
 	return nil
 }
 
+// stopOTel stops the OTel pipeline and shuts down the exporter, if any.
+func (m *Monigo) stopOTel(ctx context.Context) error {
+	if m.otelPipeline != nil {
+		m.otelPipeline.Stop()
+		m.otelPipeline = nil
+	}
+	if m.otelExporter == nil {
+		return nil
+	}
+	err := m.otelExporter.Shutdown(ctx)
+	m.otelExporter = nil
+	return err
+}
+
+// namespace returns the namespace of this instance's metrics: its service
+// name when Isolated, otherwise the default namespace.
+func (m *Monigo) namespace() string {
+	if m.Isolated {
+		return m.ServiceName
+	}
+	return ""
+}
+
+// Context returns a copy of ctx that scopes functions traced with it to this
+// instance. It is only needed for Isolated instances.
+func (m *Monigo) Context(ctx context.Context) context.Context {
+	if ns := m.namespace(); ns != "" {
+		return core.WithNamespace(ctx, ns)
+	}
+	return ctx
+}
+
+// TraceFunction traces f and records its metrics in this instance.
+func (m *Monigo) TraceFunction(ctx context.Context, f func()) {
+	core.TraceFunction(m.Context(ctx), f)
+}
+
+// TraceContextFunc traces f, passing it ctx, and records its metrics in this instance.
+func (m *Monigo) TraceContextFunc(ctx context.Context, f func(context.Context)) {
+	core.TraceContextFunc(m.Context(ctx), f)
+}
+
+// scope makes handler serve this instance's metrics.
+func (m *Monigo) scope(handler http.HandlerFunc) http.HandlerFunc {
+	if m.namespace() == "" {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r.WithContext(m.Context(r.Context())))
+	}
+}
+
 // Shutdown performs a graceful cleanup of resources (OTel provider, storage, etc.).
 func (m *Monigo) Shutdown(ctx context.Context) error {
 	var errs []error
-	if m.otelExporter != nil {
-		if err := m.otelExporter.Shutdown(ctx); err != nil {
-			errs = append(errs, fmt.Errorf("otel shutdown: %w", err))
+	if m.stopSignalDump != nil {
+		m.stopSignalDump()
+		m.stopSignalDump = nil
+	}
+	if err := m.stopOTel(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("otel shutdown: %w", err))
+	}
+	if m.stopOTelLogs != nil {
+		if err := m.stopOTelLogs(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("otel logs shutdown: %w", err))
+		}
+		m.stopOTelLogs = nil
+	}
+	if m.live {
+		api.SetConfigSource(m.namespace(), nil)
+	}
+	// Storage and the sync loop are shared, so only the last instance closes them.
+	if m.live {
+		m.live = false
+		if liveInstances.Add(-1) > 0 {
+			logger.Log.Info("storage kept open for other MoniGo instances", "service", m.ServiceName)
+			return errors.Join(errs...)
 		}
 	}
 	if err := timeseries.CloseStorage(); err != nil {
@@ -274,6 +555,8 @@ func (m *Monigo) Start() error {
 	}
 
 	if m.Headless {
+		// No signal handler here: installing one would stop the embedding app
+		// from exiting on SIGINT/SIGTERM. Use StartHeadless or Shutdown instead.
 		logger.Log.Info("running in headless mode, dashboard disabled")
 		return nil
 	}
@@ -284,21 +567,128 @@ func (m *Monigo) Start() error {
 	return nil
 }
 
+// StartHeadless starts metric collection without the dashboard and returns a
+// stop func that stops the sync loop and releases storage and exporters.
+// The caller owns the lifecycle; no signal handler is installed.
+func (m *Monigo) StartHeadless() (stop func(), err error) {
+	m.Headless = true
+	m.MonigoInstanceConstructorWithoutPort()
+	if err := m.setup(); err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := m.Shutdown(ctx); err != nil {
+				logger.Log.Error("error during resource cleanup", "error", err)
+			}
+		})
+	}, nil
+}
+
 // GetGoRoutinesStats returns Go routines statistics.
 func (m *Monigo) GetGoRoutinesStats() models.GoRoutinesStatistic {
 	return core.CollectGoRoutinesInfo()
 }
 
+// Stats returns the current service statistics without going through the HTTP API.
+func (m *Monigo) Stats() models.ServiceStats {
+	return core.GetServiceStats(context.Background())
+}
+
+// LatestStoredStats returns the service statistics reconstructed from the most
+// recently stored data points.
+func (m *Monigo) LatestStoredStats() (models.ServiceStats, error) {
+	return timeseries.LatestServiceStatsFor(m.namespace())
+}
+
 // TraceFunction traces the function
 func TraceFunction(ctx context.Context, f func()) {
 	core.TraceFunction(ctx, f)
 }
 
+// TraceFunctionWithLabels traces the function, recording a separate series per
+// label set (e.g. tenant) that Prometheus and the stored metrics can break down by
+func TraceFunctionWithLabels(ctx context.Context, f func(), labels map[string]string) {
+	core.TraceFunctionWithLabels(ctx, f, labels)
+}
+
+// TraceContextFunc traces f, passing it ctx, so functions taking a context
+// don't need TraceFunctionWithArgs
+func TraceContextFunc(ctx context.Context, f func(context.Context)) {
+	core.TraceContextFunc(ctx, f)
+}
+
 // SetSamplingRate sets the sampling rate for function tracing
 func SetSamplingRate(rate int) {
 	core.SetSamplingRate(rate)
 }
 
+// ResetFunctionMetrics clears the metrics of all traced functions.
+func ResetFunctionMetrics() {
+	core.ResetFunctionMetrics()
+}
+
+// SetSwallowPanics sets whether a panic in a traced function is swallowed
+// after being recorded, instead of being re-panicked.
+func SetSwallowPanics(swallow bool) {
+	core.SetSwallowPanics(swallow)
+}
+
+// SetSlowFunctionThreshold logs a warning for each traced call taking longer
+// than d. Zero disables it.
+func SetSlowFunctionThreshold(d time.Duration) {
+	core.SetSlowFunctionThreshold(d)
+}
+
+// EnableBlockProfiling makes sampled traced calls also write a block profile,
+// showing where goroutines blocked on channels and locks.
+func EnableBlockProfiling() {
+	core.EnableBlockProfiling()
+}
+
+// EnableMutexProfiling makes sampled traced calls also write a mutex profile,
+// showing contended locks.
+func EnableMutexProfiling() {
+	core.EnableMutexProfiling()
+}
+
+// SetLightweightTracing sets whether traced calls that are not sampled only
+// count the call, skipping timing and goroutine counting, for hot loops.
+func SetLightweightTracing(enabled bool) {
+	core.SetLightweightTracing(enabled)
+}
+
+// SetSignatureCaching sets whether the name and parameter types of functions
+// traced with TraceFunctionWithArgs or TraceFunctionWithReturn(s) are cached
+// between calls. It is enabled by default.
+func SetSignatureCaching(enabled bool) {
+	core.SetSignatureCaching(enabled)
+}
+
+// SetLoadCalculator sets the formula used to compute the overall service load.
+// Passing nil restores the default.
+func SetLoadCalculator(fn core.LoadCalculator) {
+	core.SetLoadCalculator(fn)
+}
+
+// SetValueTransform sets a function applied to every service metric value
+// before it is stored, e.g. to cap or rescale it. Passing nil stores values unchanged.
+func SetValueTransform(fn func(metric string, v float64) float64) {
+	timeseries.SetValueTransform(fn)
+}
+
+// Collector supplies app-specific metrics that are stored every sync cycle.
+type Collector = core.Collector
+
+// RegisterCollector adds c to the collectors run each sync cycle.
+func RegisterCollector(c Collector) {
+	core.RegisterCollector(c)
+}
+
 // TraceFunctionWithArgs traces a function with parameters and captures the metrics
 func TraceFunctionWithArgs(ctx context.Context, f interface{}, args ...interface{}) {
 	core.TraceFunctionWithArgs(ctx, f, args...)
@@ -343,20 +733,41 @@ func (m *Monigo) startDashboard(port int, customBaseAPIPath string) error {
 
 	srv := &http.Server{
 		Addr:              fmt.Sprintf(":%d", port),
-		Handler:           mux,
+		Handler:           m.scope(mux.ServeHTTP),
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
 	m.registerShutdownHandler(srv)
 
+	listener, port, err := m.listenDashboard(srv.Addr)
+	if err != nil {
+		return fmt.Errorf("error starting the dashboard: %v", err)
+	}
+
+	m.dashboardRunning.Store(true)
+	defer m.dashboardRunning.Store(false)
+
 	logger.Log.Info("dashboard started", "url", fmt.Sprintf("http://localhost:%d", port))
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("error starting the dashboard: %v", err)
 	}
 
 	return nil
 }
 
+// listenDashboard binds addr and calls OnReady with the bound port.
+func (m *Monigo) listenDashboard(addr string) (net.Listener, int, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, 0, err
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	if m.OnReady != nil {
+		m.OnReady(port)
+	}
+	return listener, port, nil
+}
+
 // StartSecuredDashboard starts the dashboard with middleware support
 func StartSecuredDashboard(m *Monigo) error {
 	if m.DashboardPort <= 0 || m.DashboardPort > 65535 {
@@ -375,8 +786,16 @@ func StartSecuredDashboard(m *Monigo) error {
 
 	m.registerShutdownHandler(srv)
 
-	logger.Log.Info("secured dashboard started", "url", fmt.Sprintf("http://localhost:%d", m.DashboardPort))
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	listener, port, err := m.listenDashboard(srv.Addr)
+	if err != nil {
+		return fmt.Errorf("error starting the secured dashboard: %v", err)
+	}
+
+	m.dashboardRunning.Store(true)
+	defer m.dashboardRunning.Store(false)
+
+	logger.Log.Info("secured dashboard started", "url", fmt.Sprintf("http://localhost:%d", port))
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("error starting the secured dashboard: %v", err)
 	}
 
@@ -391,10 +810,10 @@ func (m *Monigo) registerShutdownHandler(srv *http.Server) {
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
 
-		logger.Log.Info("shutting down dashboard server")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		logger.Log.Info("shutting down dashboard server")
 		if err := srv.Shutdown(ctx); err != nil {
 			logger.Log.Error("error during server shutdown", "error", err)
 		}
@@ -412,8 +831,18 @@ func registerAPIEndpoints(mux *http.ServeMux, apiPath string) {
 	mux.HandleFunc(fmt.Sprintf("%s/go-routines-stats", apiPath), api.GetGoRoutinesStats)
 	mux.HandleFunc(fmt.Sprintf("%s/function", apiPath), api.GetFunctionTraceDetails)
 	mux.HandleFunc(fmt.Sprintf("%s/function-details", apiPath), api.ViewFunctionMetrics)
+	mux.HandleFunc(fmt.Sprintf("%s/function-flamegraph", apiPath), api.GetFunctionFlamegraph)
+	mux.HandleFunc(fmt.Sprintf("%s/function-history", apiPath), api.GetFunctionHistory)
+	mux.HandleFunc(fmt.Sprintf("%s/metrics-delta", apiPath), api.GetMetricsDelta)
+	mux.HandleFunc(fmt.Sprintf("%s/health-history", apiPath), api.GetHealthHistory)
+	mux.HandleFunc(fmt.Sprintf("%s/query_range", apiPath), api.QueryRange)
+	mux.HandleFunc(fmt.Sprintf("%s/storage-stats", apiPath), api.GetStorageStats)
+	mux.HandleFunc(fmt.Sprintf("%s/latest", apiPath), api.GetLatest)
 	mux.HandleFunc("/metrics", api.PrometheusMetricsHandler)
 	mux.HandleFunc(fmt.Sprintf("%s/reports", apiPath), api.GetReportData)
+	mux.HandleFunc(fmt.Sprintf("%s/reports/topics", apiPath), api.GetReportTopics)
+	mux.HandleFunc(fmt.Sprintf("%s/reports/compare", apiPath), api.CompareReports)
+	mux.HandleFunc(fmt.Sprintf("%s/config", apiPath), api.GetConfig)
 }
 
 // RegisterDashboardHandlers registers all dashboard handlers to the provided HTTP mux
@@ -464,14 +893,24 @@ func GetAPIHandlers(customBaseAPIPath ...string) map[string]http.HandlerFunc {
 	}
 
 	return map[string]http.HandlerFunc{
-		fmt.Sprintf("%s/metrics", apiPath):           api.GetServiceStatistics,
-		fmt.Sprintf("%s/service-info", apiPath):      api.GetServiceInfoAPI,
-		fmt.Sprintf("%s/service-metrics", apiPath):   api.GetServiceMetricsFromStorage,
-		fmt.Sprintf("%s/go-routines-stats", apiPath): api.GetGoRoutinesStats,
-		fmt.Sprintf("%s/function", apiPath):          api.GetFunctionTraceDetails,
-		fmt.Sprintf("%s/function-details", apiPath):  api.ViewFunctionMetrics,
-		"/metrics":                                   api.PrometheusMetricsHandler,
-		fmt.Sprintf("%s/reports", apiPath):           api.GetReportData,
+		fmt.Sprintf("%s/metrics", apiPath):             api.GetServiceStatistics,
+		fmt.Sprintf("%s/service-info", apiPath):        api.GetServiceInfoAPI,
+		fmt.Sprintf("%s/service-metrics", apiPath):     api.GetServiceMetricsFromStorage,
+		fmt.Sprintf("%s/go-routines-stats", apiPath):   api.GetGoRoutinesStats,
+		fmt.Sprintf("%s/function", apiPath):            api.GetFunctionTraceDetails,
+		fmt.Sprintf("%s/function-details", apiPath):    api.ViewFunctionMetrics,
+		fmt.Sprintf("%s/function-flamegraph", apiPath): api.GetFunctionFlamegraph,
+		fmt.Sprintf("%s/function-history", apiPath):    api.GetFunctionHistory,
+		fmt.Sprintf("%s/metrics-delta", apiPath):       api.GetMetricsDelta,
+		fmt.Sprintf("%s/health-history", apiPath):      api.GetHealthHistory,
+		fmt.Sprintf("%s/query_range", apiPath):         api.QueryRange,
+		fmt.Sprintf("%s/storage-stats", apiPath):       api.GetStorageStats,
+		fmt.Sprintf("%s/latest", apiPath):              api.GetLatest,
+		"/metrics":                                     api.PrometheusMetricsHandler,
+		fmt.Sprintf("%s/reports", apiPath):             api.GetReportData,
+		fmt.Sprintf("%s/reports/topics", apiPath):      api.GetReportTopics,
+		fmt.Sprintf("%s/reports/compare", apiPath):     api.CompareReports,
+		fmt.Sprintf("%s/config", apiPath):              api.GetConfig,
 	}
 }
 
@@ -519,7 +958,13 @@ func GetSecuredUnifiedHandler(m *Monigo, customBaseAPIPath ...string) http.Handl
 		apiPath = customBaseAPIPath[0]
 	}
 
+	adminHandlers := m.guardedAdminHandlers(apiPath)
+
 	baseHandler := func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := adminHandlers[r.URL.Path]; ok {
+			handler(w, r)
+			return
+		}
 		if strings.HasPrefix(r.URL.Path, apiPath) {
 			routeToAPIHandler(w, r, apiPath)
 			return
@@ -527,7 +972,7 @@ func GetSecuredUnifiedHandler(m *Monigo, customBaseAPIPath ...string) http.Handl
 		serveHtmlSite(w, r)
 	}
 
-	return applyMiddlewareChain(baseHandler, m.DashboardMiddleware, m.AuthFunction)
+	return applyMiddlewareChain(m.scope(baseHandler), m.DashboardMiddleware, m.AuthFunction, apiPath)
 }
 
 // GetSecuredAPIHandlers returns secured API handlers
@@ -538,30 +983,86 @@ func GetSecuredAPIHandlers(m *Monigo, customBaseAPIPath ...string) map[string]ht
 	}
 
 	baseHandlers := map[string]http.HandlerFunc{
-		fmt.Sprintf("%s/metrics", apiPath):           api.GetServiceStatistics,
-		fmt.Sprintf("%s/service-info", apiPath):      api.GetServiceInfoAPI,
-		fmt.Sprintf("%s/service-metrics", apiPath):   api.GetServiceMetricsFromStorage,
-		fmt.Sprintf("%s/go-routines-stats", apiPath): api.GetGoRoutinesStats,
-		fmt.Sprintf("%s/function", apiPath):          api.GetFunctionTraceDetails,
-		fmt.Sprintf("%s/function-details", apiPath):  api.ViewFunctionMetrics,
-		"/metrics":                                   api.PrometheusMetricsHandler,
-		fmt.Sprintf("%s/reports", apiPath):           api.GetReportData,
+		fmt.Sprintf("%s/metrics", apiPath):             api.GetServiceStatistics,
+		fmt.Sprintf("%s/service-info", apiPath):        api.GetServiceInfoAPI,
+		fmt.Sprintf("%s/service-metrics", apiPath):     api.GetServiceMetricsFromStorage,
+		fmt.Sprintf("%s/go-routines-stats", apiPath):   api.GetGoRoutinesStats,
+		fmt.Sprintf("%s/function", apiPath):            api.GetFunctionTraceDetails,
+		fmt.Sprintf("%s/function-details", apiPath):    api.ViewFunctionMetrics,
+		fmt.Sprintf("%s/function-flamegraph", apiPath): api.GetFunctionFlamegraph,
+		fmt.Sprintf("%s/function-history", apiPath):    api.GetFunctionHistory,
+		fmt.Sprintf("%s/metrics-delta", apiPath):       api.GetMetricsDelta,
+		fmt.Sprintf("%s/health-history", apiPath):      api.GetHealthHistory,
+		fmt.Sprintf("%s/query_range", apiPath):         api.QueryRange,
+		fmt.Sprintf("%s/storage-stats", apiPath):       api.GetStorageStats,
+		fmt.Sprintf("%s/latest", apiPath):              api.GetLatest,
+		"/metrics":                                     api.PrometheusMetricsHandler,
+		fmt.Sprintf("%s/reports", apiPath):             api.GetReportData,
+		fmt.Sprintf("%s/reports/topics", apiPath):      api.GetReportTopics,
+		fmt.Sprintf("%s/reports/compare", apiPath):     api.CompareReports,
+		fmt.Sprintf("%s/config", apiPath):              api.GetConfig,
 	}
 
 	securedHandlers := make(map[string]http.HandlerFunc)
 	for path, handler := range baseHandlers {
-		securedHandlers[path] = applyMiddlewareChain(handler, m.APIMiddleware, nil)
+		securedHandlers[path] = applyMiddlewareChain(m.scope(handler), m.APIMiddleware, nil, apiPath)
+	}
+
+	for path, handler := range m.guardedAdminHandlers(apiPath) {
+		securedHandlers[path] = handler
 	}
 
 	return securedHandlers
 }
 
+// adminAPIHandlers returns the endpoints that modify MoniGo's state. They are
+// only served by the secured handlers, and only when an admin guard is configured.
+func adminAPIHandlers(apiPath string) map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		fmt.Sprintf("%s/admin/delete-metric", apiPath):   api.DeleteMetric,
+		fmt.Sprintf("%s/admin/sync", apiPath):            api.SyncControl,
+		fmt.Sprintf("%s/admin/reset-functions", apiPath): api.ResetFunctions,
+		fmt.Sprintf("%s/admin/thresholds", apiPath):      api.Thresholds,
+		fmt.Sprintf("%s/admin/sampling-rate", apiPath):   api.SamplingRate,
+		fmt.Sprintf("%s/debug/dump", apiPath):            api.DumpStorage,
+	}
+}
+
+// guardedAdminHandlers returns the admin endpoints wrapped with the API
+// middleware, the admin middleware and the auth function. It returns nil when
+// no admin guard is configured.
+func (m *Monigo) guardedAdminHandlers(apiPath string) map[string]http.HandlerFunc {
+	if !m.hasAdminGuard() {
+		return nil
+	}
+
+	middleware := make([]func(http.Handler) http.Handler, 0, len(m.APIMiddleware)+len(m.AdminMiddleware))
+	middleware = append(middleware, m.APIMiddleware...)
+	middleware = append(middleware, m.AdminMiddleware...)
+
+	guarded := make(map[string]http.HandlerFunc)
+	for path, handler := range adminAPIHandlers(apiPath) {
+		guarded[path] = applyMiddlewareChain(m.scope(handler), middleware, m.AuthFunction, apiPath)
+	}
+	return guarded
+}
+
+// hasAdminGuard reports whether an auth function or admin middleware is
+// configured. Dashboard and API middleware don't count: they may only rate
+// limit or log, and don't have to authenticate.
+func (m *Monigo) hasAdminGuard() bool {
+	return m.AuthFunction != nil || len(m.AdminMiddleware) > 0
+}
+
 // GetSecuredStaticHandler returns the static file handler with middleware
 func GetSecuredStaticHandler(m *Monigo) http.HandlerFunc {
-	return applyMiddlewareChain(serveHtmlSite, m.DashboardMiddleware, m.AuthFunction)
+	return applyMiddlewareChain(serveHtmlSite, m.DashboardMiddleware, m.AuthFunction, "")
 }
 
-func applyMiddlewareChain(handler http.HandlerFunc, middleware []func(http.Handler) http.Handler, authFunc func(*http.Request) bool) http.HandlerFunc {
+// applyMiddlewareChain wraps handler with the middleware and auth check.
+// Auth failures under apiPath get the JSON error envelope; an empty apiPath
+// means plain-text errors only.
+func applyMiddlewareChain(handler http.HandlerFunc, middleware []func(http.Handler) http.Handler, authFunc func(*http.Request) bool, apiPath string) http.HandlerFunc {
 	var finalHandler http.Handler = http.HandlerFunc(handler)
 
 	if authFunc != nil {
@@ -571,6 +1072,10 @@ func applyMiddlewareChain(handler http.HandlerFunc, middleware []func(http.Handl
 				return
 			}
 			if !authFunc(r) {
+				if apiPath != "" && strings.HasPrefix(r.URL.Path, apiPath) {
+					api.WriteError(w, http.StatusUnauthorized, api.ErrCodeUnauthorized, "Unauthorized")
+					return
+				}
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
@@ -578,9 +1083,7 @@ func applyMiddlewareChain(handler http.HandlerFunc, middleware []func(http.Handl
 		})
 	}
 
-	for i := len(middleware) - 1; i >= 0; i-- {
-		finalHandler = middleware[i](finalHandler)
-	}
+	finalHandler = Chain(middleware...)(finalHandler)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		finalHandler.ServeHTTP(w, r)
@@ -603,13 +1106,46 @@ func routeToAPIHandler(w http.ResponseWriter, r *http.Request, apiPath string) {
 		api.GetFunctionTraceDetails(w, r)
 	case path == fmt.Sprintf("%s/function-details", apiPath):
 		api.ViewFunctionMetrics(w, r)
+	case path == fmt.Sprintf("%s/function-flamegraph", apiPath):
+		api.GetFunctionFlamegraph(w, r)
+	case path == fmt.Sprintf("%s/function-history", apiPath):
+		api.GetFunctionHistory(w, r)
+	case path == fmt.Sprintf("%s/metrics-delta", apiPath):
+		api.GetMetricsDelta(w, r)
+	case path == fmt.Sprintf("%s/health-history", apiPath):
+		api.GetHealthHistory(w, r)
+	case path == fmt.Sprintf("%s/query_range", apiPath):
+		api.QueryRange(w, r)
+	case path == fmt.Sprintf("%s/storage-stats", apiPath):
+		api.GetStorageStats(w, r)
+	case path == fmt.Sprintf("%s/latest", apiPath):
+		api.GetLatest(w, r)
 	case path == fmt.Sprintf("%s/reports", apiPath):
 		api.GetReportData(w, r)
+	case path == fmt.Sprintf("%s/reports/topics", apiPath):
+		api.GetReportTopics(w, r)
+	case path == fmt.Sprintf("%s/reports/compare", apiPath):
+		api.CompareReports(w, r)
+	case path == fmt.Sprintf("%s/config", apiPath):
+		api.GetConfig(w, r)
 	default:
-		http.NotFound(w, r)
+		api.WriteNotFound(w, apiEndpoints(apiPath))
 	}
 }
 
+// apiEndpoints returns the sorted paths served under apiPath, listed when an
+// unknown API path is requested. Admin endpoints are left out.
+func apiEndpoints(apiPath string) []string {
+	var paths []string
+	for path := range GetAPIHandlers(apiPath) {
+		if strings.HasPrefix(path, apiPath) {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
 func routeToFiberAPIHandler(c *fiber.Ctx, path, apiPath string) error {
 	switch {
 	case path == fmt.Sprintf("%s/metrics", apiPath):
@@ -624,11 +1160,30 @@ func routeToFiberAPIHandler(c *fiber.Ctx, path, apiPath string) error {
 		return handleFiberAPI(c, api.GetFunctionTraceDetails)
 	case path == fmt.Sprintf("%s/function-details", apiPath):
 		return handleFiberAPI(c, api.ViewFunctionMetrics)
+	case path == fmt.Sprintf("%s/function-flamegraph", apiPath):
+		return handleFiberAPI(c, api.GetFunctionFlamegraph)
+	case path == fmt.Sprintf("%s/function-history", apiPath):
+		return handleFiberAPI(c, api.GetFunctionHistory)
+	case path == fmt.Sprintf("%s/metrics-delta", apiPath):
+		return handleFiberAPI(c, api.GetMetricsDelta)
+	case path == fmt.Sprintf("%s/health-history", apiPath):
+		return handleFiberAPI(c, api.GetHealthHistory)
+	case path == fmt.Sprintf("%s/query_range", apiPath):
+		return handleFiberAPI(c, api.QueryRange)
+	case path == fmt.Sprintf("%s/storage-stats", apiPath):
+		return handleFiberAPI(c, api.GetStorageStats)
+	case path == fmt.Sprintf("%s/latest", apiPath):
+		return handleFiberAPI(c, api.GetLatest)
 	case path == fmt.Sprintf("%s/reports", apiPath):
 		return handleFiberAPI(c, api.GetReportData)
+	case path == fmt.Sprintf("%s/reports/topics", apiPath):
+		return handleFiberAPI(c, api.GetReportTopics)
+	case path == fmt.Sprintf("%s/reports/compare", apiPath):
+		return handleFiberAPI(c, api.CompareReports)
+	case path == fmt.Sprintf("%s/config", apiPath):
+		return handleFiberAPI(c, api.GetConfig)
 	default:
-		c.Status(404).SendString("Not Found")
-		return nil
+		return c.Status(http.StatusNotFound).JSON(api.NewNotFoundResponse(apiEndpoints(apiPath)))
 	}
 }
 
@@ -636,9 +1191,14 @@ func handleFiberAPI(c *fiber.Ctx, handler func(http.ResponseWriter, *http.Reques
 	respWriter := &fiberResponseWriter{c: c}
 	body := c.Request().Body()
 
+	target := "http://localhost" + string(c.Request().URI().Path())
+	if query := c.Request().URI().QueryString(); len(query) > 0 {
+		target += "?" + string(query)
+	}
+
 	req, err := http.NewRequest(
 		string(c.Request().Header.Method()),
-		"http://localhost"+string(c.Request().URI().Path()),
+		target,
 		strings.NewReader(string(body)),
 	)
 	if err != nil {
@@ -658,14 +1218,13 @@ func handleFiberAPI(c *fiber.Ctx, handler func(http.ResponseWriter, *http.Reques
 	return nil
 }
 
-// resolveStaticPath maps a URL path to an embedded file path and content type.
+// resolveStaticPath maps a URL path to a static FS file path and content type.
 func resolveStaticPath(urlPath string) (filePath string, contentType string) {
-	baseDir := "static"
-	filePath = baseDir + urlPath
+	filePath = strings.TrimPrefix(urlPath, "/")
 	if urlPath == "/" {
-		filePath = baseDir + "/index.html"
+		filePath = "index.html"
 	} else if urlPath == "/favicon.ico" {
-		filePath = baseDir + "/assets/favicon.ico"
+		filePath = "assets/favicon.ico"
 	}
 
 	ext := filepath.Ext(filePath)
@@ -679,7 +1238,7 @@ func resolveStaticPath(urlPath string) (filePath string, contentType string) {
 func serveFiberStaticFiles(c *fiber.Ctx, path string) error {
 	filePath, contentType := resolveStaticPath(path)
 
-	file, err := staticFiles.ReadFile(filePath)
+	file, err := readStaticFile(filePath)
 	if err != nil {
 		c.Status(404).SendString("File not found")
 		return nil
@@ -692,7 +1251,7 @@ func serveFiberStaticFiles(c *fiber.Ctx, path string) error {
 func serveHtmlSite(w http.ResponseWriter, r *http.Request) {
 	filePath, contentType := resolveStaticPath(r.URL.Path)
 
-	file, err := staticFiles.ReadFile(filePath)
+	file, err := readStaticFile(filePath)
 	if err != nil {
 		http.Error(w, "Could not load "+filePath, http.StatusInternalServerError)
 		return
@@ -745,7 +1304,7 @@ func BasicAuthMiddleware(username, password string) func(http.Handler) http.Hand
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, WithPrincipal(r, user))
 		})
 	}
 }
@@ -766,7 +1325,7 @@ func APIKeyMiddleware(apiKey string) func(http.Handler) http.Handler {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, WithPrincipal(r, APIKeyPrincipal))
 		})
 	}
 }
@@ -791,69 +1350,6 @@ func IPWhitelistMiddleware(allowedIPs []string) func(http.Handler) http.Handler
 	}
 }
 
-// RateLimitMiddleware creates a simple rate limiting middleware.
-// The returned stop function should be called during shutdown to release the cleanup goroutine.
-func RateLimitMiddleware(requests int, window time.Duration) (mw func(http.Handler) http.Handler, stop func()) {
-	type clientInfo struct {
-		count     int
-		lastReset time.Time
-	}
-
-	var mu sync.Mutex
-	clients := make(map[string]*clientInfo)
-
-	ctx, cancel := context.WithCancel(context.Background())
-
-	go func() {
-		ticker := time.NewTicker(window * 2)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				mu.Lock()
-				for ip, info := range clients {
-					if time.Since(info.lastReset) > window*2 {
-						delete(clients, ip)
-					}
-				}
-				mu.Unlock()
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
-
-	mw = func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			clientIP := getClientIP(r)
-			now := time.Now()
-
-			mu.Lock()
-			client, exists := clients[clientIP]
-			if !exists {
-				client = &clientInfo{count: 0, lastReset: now}
-				clients[clientIP] = client
-			}
-			if now.Sub(client.lastReset) > window {
-				client.count = 0
-				client.lastReset = now
-			}
-			if client.count >= requests {
-				mu.Unlock()
-				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
-				return
-			}
-			client.count++
-			mu.Unlock()
-
-			next.ServeHTTP(w, r)
-		})
-	}
-
-	stop = cancel
-	return
-}
-
 // LoggingMiddleware creates a request logging middleware
 func LoggingMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -867,6 +1363,20 @@ func LoggingMiddleware() func(http.Handler) http.Handler {
 	}
 }
 
+// Chain composes middlewares into one. The first middleware is the outermost:
+// Chain(a, b)(h) serves a request through a, then b, then h. Nil middlewares
+// are skipped.
+func Chain(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			if middlewares[i] != nil {
+				next = middlewares[i](next)
+			}
+		}
+		return next
+	}
+}
+
 // ---- Helper functions ----
 
 func getClientIP(r *http.Request) string {
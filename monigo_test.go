@@ -0,0 +1,535 @@
+package monigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/models"
+	"github.com/iyashjayesh/monigo/timeseries"
+)
+
+func TestStats(t *testing.T) {
+	m := NewBuilder().WithServiceName("stats-test").Build()
+	m.MonigoInstanceConstructorWithoutPort()
+
+	stats := m.Stats()
+	if stats.CoreStatistics.Goroutines <= 0 {
+		t.Errorf("expected goroutine count > 0, got %d", stats.CoreStatistics.Goroutines)
+	}
+	if stats.CPUStatistics.TotalCores <= 0 {
+		t.Errorf("expected total cores > 0, got %f", stats.CPUStatistics.TotalCores)
+	}
+	if stats.MemoryStatistics.TotalSystemMemoryRaw <= 0 {
+		t.Errorf("expected total system memory > 0, got %f", stats.MemoryStatistics.TotalSystemMemoryRaw)
+	}
+}
+
+func TestStartHeadless_StopStopsSyncLoop(t *testing.T) {
+	m := NewBuilder().
+		WithServiceName("headless-test").
+		WithStorageType("memory").
+		WithDataPointsSyncFrequency("50ms").
+		Build()
+
+	stop, err := m.StartHeadless()
+	if err != nil {
+		t.Fatalf("StartHeadless error: %v", err)
+	}
+	if !timeseries.IsSyncLoopRunning() {
+		t.Fatal("expected sync loop to be running after StartHeadless")
+	}
+
+	stop()
+
+	deadline := time.Now().Add(time.Second)
+	for timeseries.IsSyncLoopRunning() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if timeseries.IsSyncLoopRunning() {
+		t.Error("expected sync loop to stop after calling stop")
+	}
+
+	// Calling stop again must be safe.
+	stop()
+}
+
+func TestAdminEndpoints_OnlyWhenProtected(t *testing.T) {
+	path := baseAPIPath + "/admin/delete-metric"
+
+	if _, ok := GetAPIHandlers()[path]; ok {
+		t.Error("admin endpoint must not be exposed by the unsecured handlers")
+	}
+
+	unprotected := NewBuilder().WithServiceName("admin-test").Build()
+	if _, ok := GetSecuredAPIHandlers(unprotected)[path]; ok {
+		t.Error("admin endpoint must not be exposed without protection configured")
+	}
+
+	rateLimit, stop := RateLimitMiddleware(100, time.Minute)
+	defer stop()
+
+	for name, m := range map[string]*Monigo{
+		"dashboard middleware": NewBuilder().
+			WithServiceName("admin-test").
+			WithDashboardMiddleware(BasicAuthMiddleware("admin", "secret")).
+			Build(),
+		"api middleware": NewBuilder().
+			WithServiceName("admin-test").
+			WithAPIMiddleware(rateLimit).
+			Build(),
+	} {
+		if _, ok := GetSecuredAPIHandlers(m)[path]; ok {
+			t.Errorf("%s: admin endpoint must not be exposed without an admin guard", name)
+		}
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"metric": "goroutines"}`))
+		req.SetBasicAuth("admin", "secret")
+		w := httptest.NewRecorder()
+		GetSecuredUnifiedHandler(m)(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404 from the unified handler, got %d", name, w.Code)
+		}
+	}
+
+	for name, m := range map[string]*Monigo{
+		"admin middleware": NewBuilder().
+			WithServiceName("admin-test").
+			WithAdminMiddleware(APIKeyMiddleware("secret")).
+			Build(),
+		"auth function": NewBuilder().
+			WithServiceName("admin-test").
+			WithAuthFunction(func(*http.Request) bool { return false }).
+			Build(),
+	} {
+		handlers := GetSecuredAPIHandlers(m)
+		if _, ok := handlers[baseAPIPath+"/debug/dump"]; !ok {
+			t.Errorf("%s: expected debug dump endpoint when an admin guard is configured", name)
+		}
+		handler, ok := handlers[path]
+		if !ok {
+			t.Fatalf("%s: expected admin endpoint when an admin guard is configured", name)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"metric": "goroutines"}`))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401 from the secured handler, got %d", name, w.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"metric": "goroutines"}`))
+		w = httptest.NewRecorder()
+		GetSecuredUnifiedHandler(m)(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401 from the unified handler, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestDiagnose_ReportsAllChecks(t *testing.T) {
+	m := NewBuilder().WithServiceName("diagnose-test").WithHeadless(true).Build()
+
+	report := m.Diagnose()
+	for _, name := range []string{
+		DiagnosticPort, DiagnosticStorage, DiagnosticCPU, DiagnosticMemory,
+		DiagnosticDisk, DiagnosticGoTool, DiagnosticBasePath,
+	} {
+		if _, ok := report.Check(name); !ok {
+			t.Errorf("expected report to include %q check", name)
+		}
+	}
+}
+
+func TestDiagnose_UnwritableBasePath(t *testing.T) {
+	// A path beneath a regular file can never be created, even as root.
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	orig, origDataDir := BasePath, storageDataDir
+	BasePath = filepath.Join(blocker, "monigo")
+	storageDataDir = func() string { return filepath.Join(blocker, "data") }
+	defer func() { BasePath, storageDataDir = orig, origDataDir }()
+
+	m := NewBuilder().WithServiceName("diagnose-test").WithHeadless(true).Build()
+	report := m.Diagnose()
+
+	if report.Passed {
+		t.Error("expected report to fail with an unwritable base path")
+	}
+	for _, name := range []string{DiagnosticBasePath, DiagnosticStorage} {
+		c, _ := report.Check(name)
+		if c.Passed || c.Reason == "" {
+			t.Errorf("expected %q check to fail with a reason, got %+v", name, c)
+		}
+	}
+}
+
+func TestDiagnose_PortInUse(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	m := NewBuilder().WithServiceName("diagnose-test").WithPort(port).Build()
+	if c := m.diagnosePort(); c.Passed {
+		t.Errorf("expected port check to fail while another listener holds port %d", port)
+	}
+
+	// The port being held by our own dashboard is not a failure.
+	m.dashboardRunning.Store(true)
+	if c := m.diagnosePort(); !c.Passed || !strings.Contains(c.Reason, "already running") {
+		t.Errorf("expected port check to pass while the dashboard is running, got %+v", c)
+	}
+}
+
+func TestDumpStats(t *testing.T) {
+	var buf bytes.Buffer
+	if err := DumpStats(&buf); err != nil {
+		t.Fatalf("DumpStats error: %v", err)
+	}
+
+	out := buf.String()
+	for _, label := range []string{"CPU", "Memory", "Goroutines", "Service Health"} {
+		if !strings.Contains(out, label) {
+			t.Errorf("expected output to contain %q, got:\n%s", label, out)
+		}
+	}
+}
+
+func TestWriteGoroutineSummary(t *testing.T) {
+	info := models.GoRoutinesStatistic{
+		NumberOfGoroutines: 4,
+		StackView: []string{
+			"goroutine 1 [running]:\nmain.main()\n",
+			"goroutine 7 [chan receive, 2 minutes]:\nmain.worker()\n",
+			"goroutine 8 [chan receive]:\nmain.worker()\n",
+			"goroutine 9 [select]:\nmain.loop()\n",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeGoroutineSummary(&buf, info); err != nil {
+		t.Fatalf("writeGoroutineSummary error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := [][]string{{"GOROUTINE", "STATE", "COUNT"}, {"chan", "receive", "2"}, {"running", "1"}, {"select", "1"}, {"Total", "4"}}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got:\n%s", len(want), buf.String())
+	}
+	for i, fields := range want {
+		if got := strings.Fields(lines[i]); strings.Join(got, " ") != strings.Join(fields, " ") {
+			t.Errorf("line %d: expected %q, got %q", i, fields, got)
+		}
+	}
+}
+
+func TestSetStaticFS(t *testing.T) {
+	custom := fstest.MapFS{
+		"index.html":    {Data: []byte("<h1>Acme Monitoring</h1>")},
+		"assets/app.js": {Data: []byte("console.log('acme')")},
+	}
+	if err := SetStaticFS(custom); err != nil {
+		t.Fatalf("SetStaticFS error: %v", err)
+	}
+	defer SetStaticFS(nil)
+
+	rec := httptest.NewRecorder()
+	serveHtmlSite(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Acme Monitoring") {
+		t.Errorf("expected custom index to be served, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	serveHtmlSite(rec, httptest.NewRequest(http.MethodGet, "/assets/app.js", nil))
+	if got := rec.Header().Get("Content-Type"); got != "application/javascript" {
+		t.Errorf("expected application/javascript, got %q", got)
+	}
+
+	SetStaticFS(nil)
+	rec = httptest.NewRecorder()
+	serveHtmlSite(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(rec.Body.String(), "Acme Monitoring") {
+		t.Error("expected built-in dashboard after SetStaticFS(nil)")
+	}
+}
+
+func TestSetStaticFS_RequiresIndex(t *testing.T) {
+	if err := SetStaticFS(fstest.MapFS{"app.js": {Data: []byte("x")}}); err == nil {
+		t.Error("expected error for FS without index.html")
+	}
+}
+
+func TestSetDashboardPort_AutoPort(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	taken := l.Addr().(*net.TCPAddr).Port
+
+	m := NewBuilder().WithServiceName("auto-port-test").WithPort(taken).WithAutoPort(true).Build()
+	if err := setDashboardPort(m); err != nil {
+		t.Fatalf("setDashboardPort: %v", err)
+	}
+
+	got := m.GetRunningPort()
+	if got == taken {
+		t.Fatalf("expected a port other than the taken %d", taken)
+	}
+	free, err := net.Listen("tcp", fmt.Sprintf(":%d", got))
+	if err != nil {
+		t.Fatalf("expected chosen port %d to be free: %v", got, err)
+	}
+	free.Close()
+}
+
+func TestOnReady_FiresBeforeServing(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	var ready atomic.Bool
+	readyPort := make(chan int, 1)
+	servedBeforeReady := make(chan bool, 1)
+
+	m := NewBuilder().
+		WithServiceName("on-ready-test").
+		WithPort(port).
+		WithOnReady(func(p int) {
+			ready.Store(true)
+			readyPort <- p
+		}).
+		WithDashboardMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case servedBeforeReady <- !ready.Load():
+				default:
+				}
+				next.ServeHTTP(w, r)
+			})
+		}).
+		Build()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- StartSecuredDashboard(m) }()
+
+	select {
+	case got := <-readyPort:
+		if got != port {
+			t.Fatalf("expected OnReady with port %d, got %d", port, got)
+		}
+	case err := <-errCh:
+		t.Fatalf("dashboard exited before ready: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnReady was not called")
+	}
+
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d%s/service-info", port, baseAPIPath))
+	if err != nil {
+		t.Fatalf("request after OnReady: %v", err)
+	}
+	resp.Body.Close()
+	if <-servedBeforeReady {
+		t.Error("a request was served before OnReady fired")
+	}
+}
+
+func ordersWork()  { time.Sleep(time.Millisecond) }
+func billingWork() { time.Sleep(time.Millisecond) }
+
+func TestIsolatedInstances(t *testing.T) {
+	orders := NewBuilder().WithServiceName("orders").WithStorageType("memory").WithIsolation(true).Build()
+	billing := NewBuilder().WithServiceName("billing").WithStorageType("memory").WithIsolation(true).Build()
+	if err := orders.Initialize(); err != nil {
+		t.Fatalf("Initialize error: %v", err)
+	}
+	defer orders.Shutdown(context.Background())
+	if err := billing.Initialize(); err != nil {
+		t.Fatalf("Initialize error: %v", err)
+	}
+
+	// Traced functions are only listed by their own instance's API.
+	orders.TraceFunction(context.Background(), ordersWork)
+	billing.TraceFunction(context.Background(), billingWork)
+	for m, want := range map[*Monigo]string{orders: "ordersWork", billing: "billingWork"} {
+		w := httptest.NewRecorder()
+		GetSecuredAPIHandlers(m)[baseAPIPath+"/function"](w, httptest.NewRequest(http.MethodGet, baseAPIPath+"/function", nil))
+		var functions []models.NamedFunctionMetrics
+		if err := json.Unmarshal(w.Body.Bytes(), &functions); err != nil {
+			t.Fatalf("%s: decoding functions: %v", m.ServiceName, err)
+		}
+		if len(functions) != 1 {
+			t.Errorf("%s: expected only its own function, got %d", m.ServiceName, len(functions))
+		}
+		for _, f := range functions {
+			if !strings.HasSuffix(f.Name, want) {
+				t.Errorf("%s: unexpected function %s", m.ServiceName, f.Name)
+			}
+		}
+	}
+
+	// Service metrics are stored once and read by every instance, and keep
+	// being stored after billing shuts down.
+	countPoints := func(service string) int {
+		points, err := timeseries.GetDataPoints("goroutines", timeseries.MetricLabels(service, "goroutines"), 0, time.Now().Unix()+1)
+		if err != nil {
+			t.Fatalf("GetDataPoints error: %v", err)
+		}
+		return len(points)
+	}
+	ordersBefore, billingBefore := countPoints("orders"), countPoints("billing")
+
+	stats := orders.Stats()
+	if err := timeseries.StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+	if err := billing.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown error: %v", err)
+	}
+	if err := timeseries.StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+
+	if got := countPoints("orders") - ordersBefore; got != 2 {
+		t.Errorf("expected 2 new orders points, got %d", got)
+	}
+	if got := countPoints("billing") - billingBefore; got != 2 {
+		t.Errorf("expected 2 new billing points, got %d", got)
+	}
+	if _, err := orders.LatestStoredStats(); err != nil {
+		t.Errorf("expected orders' storage to stay open after billing shut down: %v", err)
+	}
+}
+
+func TestIsolatedAdminEndpoints(t *testing.T) {
+	allow := func(next http.Handler) http.Handler { return next }
+	orders := NewBuilder().WithServiceName("orders-admin").WithIsolation(true).WithAdminMiddleware(allow).Build()
+	handlers := GetSecuredAPIHandlers(orders)
+
+	core.SetSamplingRate(1)
+	orders.TraceFunction(context.Background(), ordersWork)
+	TraceFunction(context.Background(), billingWork)
+
+	w := httptest.NewRecorder()
+	handlers[baseAPIPath+"/admin/reset-functions"](w, httptest.NewRequest(http.MethodPost, baseAPIPath+"/admin/reset-functions", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if details := core.FunctionTraceDetailsFor("orders-admin"); len(details) != 0 {
+		t.Errorf("expected orders' functions to be reset, got %d", len(details))
+	}
+	kept := false
+	for name := range core.FunctionTraceDetails() {
+		kept = kept || strings.HasSuffix(name, "billingWork")
+	}
+	if !kept {
+		t.Error("expected the default namespace's functions to be kept")
+	}
+
+	orig := core.ServiceThresholds()
+	w = httptest.NewRecorder()
+	handlers[baseAPIPath+"/admin/thresholds"](w, httptest.NewRequest(http.MethodPost, baseAPIPath+"/admin/thresholds", strings.NewReader(`{"max_cpu_usage": 42}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := core.ServiceThresholdsFor("orders-admin").MaxCPUUsage; got != 42 {
+		t.Errorf("expected orders' MaxCPUUsage 42, got %v", got)
+	}
+	if got := core.ServiceThresholds(); got != orig {
+		t.Errorf("expected the default thresholds unchanged, got %+v", got)
+	}
+}
+
+func TestSetup_ReplacesOTelExporter(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer collector.Close()
+
+	m := NewBuilder().
+		WithServiceName("otel-setup").
+		WithStorageType("memory").
+		WithOTelEndpoint(strings.TrimPrefix(collector.URL, "http://")).
+		WithOTelProtocol("http").
+		Build()
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("Initialize error: %v", err)
+	}
+	defer m.Shutdown(context.Background())
+	first := m.otelExporter
+	if first == nil {
+		t.Fatal("expected an OTel exporter")
+	}
+
+	if err := m.setup(); err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	if m.otelExporter == nil || m.otelExporter == first {
+		t.Error("expected setup to create a new OTel exporter")
+	}
+	if err := first.Shutdown(context.Background()); err == nil {
+		t.Error("expected the previous OTel exporter to be shut down")
+	}
+}
+
+func TestServiceInfoBranding(t *testing.T) {
+	serviceInfo := func(handlers map[string]http.HandlerFunc) models.ServiceInfo {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handlers[baseAPIPath+"/service-info"](w, httptest.NewRequest(http.MethodGet, baseAPIPath+"/service-info", nil))
+		var info models.ServiceInfo
+		if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+			t.Fatalf("decoding service info: %v", err)
+		}
+		return info
+	}
+
+	m := NewBuilder().
+		WithServiceName("branded").
+		WithStorageType("memory").
+		WithDashboardTitle("Orders Dashboard").
+		WithServiceDescription("Takes and tracks orders").
+		Build()
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("Initialize error: %v", err)
+	}
+	defer m.Shutdown(context.Background())
+
+	info := serviceInfo(GetAPIHandlers())
+	if info.DashboardTitle != "Orders Dashboard" || info.ServiceDescription != "Takes and tracks orders" {
+		t.Errorf("expected the configured title and description, got %q and %q", info.DashboardTitle, info.ServiceDescription)
+	}
+
+	// An isolated instance reports its own branding, defaulting the title
+	// to its service name.
+	billing := NewBuilder().WithServiceName("billing").WithStorageType("memory").WithIsolation(true).Build()
+	if err := billing.Initialize(); err != nil {
+		t.Fatalf("Initialize error: %v", err)
+	}
+	defer billing.Shutdown(context.Background())
+
+	info = serviceInfo(GetSecuredAPIHandlers(billing))
+	if info.DashboardTitle != "billing" || info.ServiceDescription != "" {
+		t.Errorf("expected the service name as title and no description, got %q and %q", info.DashboardTitle, info.ServiceDescription)
+	}
+	if cfg := billing.Config(); cfg.DashboardTitle != "billing" {
+		t.Errorf("expected the config to report the default title, got %q", cfg.DashboardTitle)
+	}
+}
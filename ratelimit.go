@@ -0,0 +1,168 @@
+package monigo
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Clock is the time source of RateLimitMiddleware.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+
+func (t realTicker) Stop() { t.t.Stop() }
+
+// RateLimitOption configures RateLimitMiddleware.
+type RateLimitOption func(*rateLimiter)
+
+// WithRateLimitClock sets the time source used for windows and cleanup.
+// It defaults to real time.
+func WithRateLimitClock(c Clock) RateLimitOption {
+	return func(rl *rateLimiter) {
+		rl.clock = c
+	}
+}
+
+// WithRateLimitHandler sets the handler that responds to throttled requests.
+// The rate limit headers are already set when it is called. It defaults to a
+// plain 429 Too Many Requests.
+func WithRateLimitHandler(h http.Handler) RateLimitOption {
+	return func(rl *rateLimiter) {
+		rl.limited = h
+	}
+}
+
+// rateLimiter counts requests per client IP in fixed windows.
+type rateLimiter struct {
+	requests int
+	window   time.Duration
+	clock    Clock
+	limited  http.Handler
+
+	mu      sync.Mutex
+	clients map[string]*clientInfo
+}
+
+type clientInfo struct {
+	count     int
+	lastReset time.Time
+}
+
+// allow records a request from ip and reports whether it is within the limit,
+// how many requests are left in the window and when the window resets.
+func (rl *rateLimiter) allow(ip string) (ok bool, remaining int, resetIn time.Duration) {
+	now := rl.clock.Now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	client, exists := rl.clients[ip]
+	if !exists {
+		client = &clientInfo{count: 0, lastReset: now}
+		rl.clients[ip] = client
+	}
+	if now.Sub(client.lastReset) > rl.window {
+		client.count = 0
+		client.lastReset = now
+	}
+	resetIn = client.lastReset.Add(rl.window).Sub(now)
+	if client.count >= rl.requests {
+		return false, 0, resetIn
+	}
+	client.count++
+	return true, rl.requests - client.count, resetIn
+}
+
+// retryAfterSeconds rounds d up to whole seconds, as Retry-After requires, and
+// never returns less than one.
+func retryAfterSeconds(d time.Duration) int {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		return 1
+	}
+	return secs
+}
+
+// evictIdle forgets clients whose window started more than two windows ago.
+func (rl *rateLimiter) evictIdle() {
+	now := rl.clock.Now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for ip, info := range rl.clients {
+		if now.Sub(info.lastReset) > rl.window*2 {
+			delete(rl.clients, ip)
+		}
+	}
+}
+
+// runCleanup evicts idle clients every two windows until ctx is done.
+func (rl *rateLimiter) runCleanup(ctx context.Context) {
+	ticker := rl.clock.NewTicker(rl.window * 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			rl.evictIdle()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RateLimitMiddleware creates a simple rate limiting middleware.
+// The returned stop function should be called during shutdown to release the cleanup goroutine.
+func RateLimitMiddleware(requests int, window time.Duration, opts ...RateLimitOption) (mw func(http.Handler) http.Handler, stop func()) {
+	rl := &rateLimiter{
+		requests: requests,
+		window:   window,
+		clock:    realClock{},
+		limited: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		}),
+		clients: make(map[string]*clientInfo),
+	}
+	for _, opt := range opts {
+		opt(rl)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go rl.runCleanup(ctx)
+
+	mw = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, remaining, resetIn := rl.allow(getClientIP(r))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.requests))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(resetIn)))
+				rl.limited.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	stop = cancel
+	return
+}
@@ -0,0 +1,53 @@
+package monigo
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/internal/logger"
+)
+
+// registerSignalDump logs a stats snapshot every time one of dumpSignals is
+// received, until Shutdown stops it. It is a no-op on platforms without such a
+// signal and when a handler is already registered.
+func (m *Monigo) registerSignalDump() {
+	if len(dumpSignals) == 0 {
+		logger.Log.Warn("signal stats dump is not supported on this platform")
+		return
+	}
+	if m.stopSignalDump != nil {
+		return
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, dumpSignals...)
+	go func() {
+		for range sigChan {
+			logStatsDump()
+		}
+	}()
+
+	m.stopSignalDump = func() {
+		signal.Stop(sigChan)
+		close(sigChan)
+	}
+}
+
+// logStatsDump logs the DumpStats table followed by a summary of the running
+// goroutines grouped by state.
+func logStatsDump() {
+	var buf bytes.Buffer
+	if err := writeStatsTable(&buf, core.GetServiceStats(context.Background())); err != nil {
+		logger.Log.Error("stats dump failed", "error", err)
+		return
+	}
+	buf.WriteByte('\n')
+	if err := writeGoroutineSummary(&buf, core.CollectGoRoutinesInfo()); err != nil {
+		logger.Log.Error("stats dump failed", "error", err)
+		return
+	}
+	logger.Log.Info("stats dump", "stats", buf.String())
+}
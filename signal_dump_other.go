@@ -0,0 +1,8 @@
+//go:build !unix
+
+package monigo
+
+import "os"
+
+// dumpSignals is empty because SIGUSR1 is unix-only.
+var dumpSignals []os.Signal
@@ -0,0 +1,11 @@
+//go:build unix
+
+package monigo
+
+import (
+	"os"
+	"syscall"
+)
+
+// dumpSignals triggers a stats dump when EnableSignalDump is set.
+var dumpSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,69 @@
+//go:build unix
+
+package monigo
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/iyashjayesh/monigo/internal/logger"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSignalDump(t *testing.T) {
+	var out syncBuffer
+	orig := logger.Get()
+	logger.SetLogger(slog.New(slog.NewTextHandler(&out, nil)))
+	defer logger.SetLogger(orig)
+
+	m := NewBuilder().WithServiceName("signal-dump-test").WithSignalDump(true).Build()
+	m.registerSignalDump()
+	m.registerSignalDump() // A second registration must not add another handler.
+	if m.stopSignalDump == nil {
+		t.Fatal("expected a stop function after registering the signal dump")
+	}
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("sending SIGUSR1: %v", err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(out.String(), "stats dump") && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// Give a duplicate handler time to log as well.
+	time.Sleep(100 * time.Millisecond)
+	m.stopSignalDump()
+
+	got := out.String()
+	for _, want := range []string{"stats dump", "Service CPU", "Service Health", "GOROUTINE STATE", "Total"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected dump output to contain %q, got:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "stats dump"); n != 1 {
+		t.Errorf("expected exactly one dump per signal, got %d", n)
+	}
+}
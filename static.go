@@ -0,0 +1,40 @@
+package monigo
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+)
+
+var (
+	staticFSMu sync.RWMutex
+	staticFS   fs.FS // nil means the built-in embedded dashboard
+)
+
+// SetStaticFS replaces the dashboard files served by MoniGo, e.g. for a
+// white-label build. Files are looked up from the root of fsys, which must
+// contain an index.html. Passing nil restores the built-in dashboard.
+func SetStaticFS(fsys fs.FS) error {
+	if fsys != nil {
+		if _, err := fs.Stat(fsys, "index.html"); err != nil {
+			return fmt.Errorf("[MoniGo] static FS must contain index.html: %w", err)
+		}
+	}
+
+	staticFSMu.Lock()
+	staticFS = fsys
+	staticFSMu.Unlock()
+	return nil
+}
+
+// readStaticFile reads a dashboard file from the configured static FS.
+func readStaticFile(name string) ([]byte, error) {
+	staticFSMu.RLock()
+	fsys := staticFS
+	staticFSMu.RUnlock()
+
+	if fsys == nil {
+		return staticFiles.ReadFile("static/" + name)
+	}
+	return fs.ReadFile(fsys, name)
+}
@@ -0,0 +1,86 @@
+package monigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/iyashjayesh/monigo/internal/logger"
+)
+
+// telemetryTimeout bounds the telemetry POST so it never holds up startup.
+const telemetryTimeout = 5 * time.Second
+
+// telemetrySent makes the telemetry report one-time per process, however many
+// instances are started.
+var telemetrySent atomic.Bool
+
+// TelemetryPayload is the anonymous usage report sent when Telemetry is
+// enabled. It carries no service name, host, tags or metric data.
+type TelemetryPayload struct {
+	GoVersion   string   `json:"go_version"`
+	OS          string   `json:"os"`
+	Arch        string   `json:"arch"`
+	StorageType string   `json:"storage_type"`
+	Exporters   []string `json:"exporters"`
+}
+
+// telemetryPayload returns the usage report of this instance.
+func (m *Monigo) telemetryPayload() TelemetryPayload {
+	exporters := []string{"prometheus"}
+	if m.OTelEndpoint != "" {
+		exporters = append(exporters, "otel")
+	}
+	return TelemetryPayload{
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		StorageType: m.StorageType,
+		Exporters:   exporters,
+	}
+}
+
+// reportTelemetry POSTs the usage report to TelemetryEndpoint, once per
+// process. It does nothing unless Telemetry is enabled and an endpoint is set.
+func (m *Monigo) reportTelemetry() {
+	if !m.Telemetry || m.TelemetryEndpoint == "" {
+		return
+	}
+	if !telemetrySent.CompareAndSwap(false, true) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryTimeout)
+	defer cancel()
+	if err := sendTelemetry(ctx, m.TelemetryEndpoint, m.telemetryPayload()); err != nil {
+		logger.Log.Debug("telemetry report not sent", "error", err)
+	}
+}
+
+// sendTelemetry POSTs payload as JSON to endpoint.
+func sendTelemetry(ctx context.Context, endpoint string, payload TelemetryPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
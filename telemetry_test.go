@@ -0,0 +1,97 @@
+package monigo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// telemetryServer records the bodies POSTed to it.
+func telemetryServer(t *testing.T) (*httptest.Server, func() []map[string]any) {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		bodies []map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected a JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding telemetry payload: %v", err)
+		}
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { telemetrySent.Store(false) })
+	telemetrySent.Store(false)
+	return srv, func() []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(bodies)
+	}
+}
+
+func TestReportTelemetry_DisabledSendsNothing(t *testing.T) {
+	srv, received := telemetryServer(t)
+
+	(&Monigo{TelemetryEndpoint: srv.URL}).reportTelemetry()
+	(&Monigo{Telemetry: true}).reportTelemetry()
+	(NewBuilder().WithServiceName("telemetry").WithTelemetry("").Build()).reportTelemetry()
+
+	if got := received(); len(got) != 0 {
+		t.Errorf("expected no telemetry when disabled, got %v", got)
+	}
+}
+
+func TestReportTelemetry_Payload(t *testing.T) {
+	srv, received := telemetryServer(t)
+
+	m := NewBuilder().
+		WithServiceName("secret-service").
+		WithStorageType("memory").
+		WithOTelEndpoint("localhost:4317").
+		WithTelemetry(srv.URL).
+		Build()
+	m.reportTelemetry()
+	m.reportTelemetry() // one-time per process
+
+	got := received()
+	if len(got) != 1 {
+		t.Fatalf("expected exactly one report, got %d", len(got))
+	}
+	want := map[string]any{
+		"go_version":   runtime.Version(),
+		"os":           runtime.GOOS,
+		"arch":         runtime.GOARCH,
+		"storage_type": "memory",
+		"exporters":    []any{"prometheus", "otel"},
+	}
+	if len(got[0]) != len(want) {
+		t.Errorf("expected only the keys of %v, got %v", want, got[0])
+	}
+	for k, v := range want {
+		b1, _ := json.Marshal(v)
+		b2, _ := json.Marshal(got[0][k])
+		if string(b1) != string(b2) {
+			t.Errorf("%s: expected %s, got %s", k, b1, b2)
+		}
+	}
+}
+
+func TestBuilderInvalidTelemetryEndpoint(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for a non-http TelemetryEndpoint")
+		}
+	}()
+
+	NewBuilder().WithServiceName("test").WithTelemetry("localhost:8080").Build()
+}
@@ -0,0 +1,78 @@
+package timeseries
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iyashjayesh/monigo/internal/logger"
+)
+
+// ClockSkewPolicy controls how StoreServiceMetrics handles a timestamp earlier
+// than the last stored one, e.g. after an NTP correction moved the clock back.
+type ClockSkewPolicy string
+
+const (
+	// ClockSkewClamp stores the sample one second after the last stored one
+	// (the default), so series stay ordered until the clock catches up.
+	ClockSkewClamp ClockSkewPolicy = "clamp"
+	// ClockSkewSkip logs and drops the sample.
+	ClockSkewSkip ClockSkewPolicy = "skip"
+)
+
+// ParseClockSkewPolicy validates policy. An empty policy means ClockSkewClamp.
+func ParseClockSkewPolicy(policy string) (ClockSkewPolicy, error) {
+	switch ClockSkewPolicy(policy) {
+	case "":
+		return ClockSkewClamp, nil
+	case ClockSkewClamp, ClockSkewSkip:
+		return ClockSkewPolicy(policy), nil
+	}
+	return "", fmt.Errorf("unsupported clock skew policy %q: must be %q or %q", policy, ClockSkewClamp, ClockSkewSkip)
+}
+
+// now is time.Now, replaceable in tests.
+var now = time.Now
+
+var clock = struct {
+	mu     sync.Mutex
+	policy ClockSkewPolicy
+	last   int64 // timestamp of the last stored service metrics
+}{policy: ClockSkewClamp}
+
+// SetClockSkewPolicy sets how a backward clock jump is handled.
+func SetClockSkewPolicy(policy string) error {
+	p, err := ParseClockSkewPolicy(policy)
+	if err != nil {
+		return err
+	}
+	clock.mu.Lock()
+	clock.policy = p
+	clock.mu.Unlock()
+	return nil
+}
+
+// checkClockSkew returns the timestamp to store service metrics at, and false
+// when they should be skipped. Timestamps equal to the last one are kept, as
+// two stores may happen within a second.
+func checkClockSkew(timestamp int64) (int64, bool) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	if timestamp >= clock.last {
+		return timestamp, true
+	}
+	if clock.policy == ClockSkewSkip {
+		logger.Log.Warn("clock moved backwards, skipping service metrics", "timestamp", timestamp, "last", clock.last)
+		return 0, false
+	}
+	logger.Log.Warn("clock moved backwards, clamping timestamp", "timestamp", timestamp, "last", clock.last)
+	return clock.last + 1, true
+}
+
+// recordTimestamp remembers timestamp as the last stored one.
+func recordTimestamp(timestamp int64) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	clock.last = max(clock.last, timestamp)
+}
@@ -0,0 +1,108 @@
+package timeseries
+
+import (
+	"strings"
+	"sync"
+)
+
+// defaultDedupMaxSkips is how many consecutive identical points may be
+// skipped before a fresh one is written anyway.
+const defaultDedupMaxSkips = 10
+
+type dedupEntry struct {
+	value   float64
+	skipped int
+}
+
+var dedup = struct {
+	mu       sync.Mutex
+	enabled  bool
+	maxSkips int
+	last     map[string]*dedupEntry
+}{
+	maxSkips: defaultDedupMaxSkips,
+	last:     make(map[string]*dedupEntry),
+}
+
+// SetDedupEnabled enables or disables skipping data points whose value equals
+// the last written value for the same metric and labels.
+func SetDedupEnabled(enabled bool) {
+	dedup.mu.Lock()
+	defer dedup.mu.Unlock()
+	dedup.enabled = enabled
+	dedup.last = make(map[string]*dedupEntry)
+}
+
+// SetDedupMaxSkips sets how many identical points in a row may be skipped
+// before one is written regardless, so constant series never go stale.
+func SetDedupMaxSkips(n int) {
+	if n < 1 {
+		n = defaultDedupMaxSkips
+	}
+	dedup.mu.Lock()
+	dedup.maxSkips = n
+	dedup.mu.Unlock()
+}
+
+// dedupRows filters out rows that repeat the last written value of their series.
+// The dedup state is only updated by calling commit, which callers must do
+// once the kept rows were written successfully.
+func dedupRows(rows []Row) (kept []Row, commit func()) {
+	dedup.mu.Lock()
+	defer dedup.mu.Unlock()
+
+	if !dedup.enabled {
+		return rows, func() {}
+	}
+
+	var written, skipped []string
+	kept = rows[:0:0]
+	for _, row := range rows {
+		key := seriesKey(row.Metric, row.Labels)
+		entry, ok := dedup.last[key]
+		if ok && entry.value == row.DataPoint.Value && entry.skipped < dedup.maxSkips {
+			skipped = append(skipped, key)
+			continue
+		}
+		written = append(written, key)
+		kept = append(kept, row)
+	}
+
+	return kept, func() {
+		dedup.mu.Lock()
+		defer dedup.mu.Unlock()
+		for i, key := range written {
+			dedup.last[key] = &dedupEntry{value: kept[i].DataPoint.Value}
+		}
+		for _, key := range skipped {
+			if entry, ok := dedup.last[key]; ok {
+				entry.skipped++
+			}
+		}
+	}
+}
+
+// seriesKey identifies a series by metric name and labels.
+func seriesKey(metric string, labels []Label) string {
+	var b strings.Builder
+	b.WriteString(metric)
+	for _, l := range labels {
+		b.WriteByte('|')
+		b.WriteString(l.Name)
+		b.WriteByte('=')
+		b.WriteString(l.Value)
+	}
+	return b.String()
+}
+
+// forgetDedup drops the dedup state of every series of metric so the next
+// point is always written, e.g. after the metric was deleted.
+func forgetDedup(metric string) {
+	dedup.mu.Lock()
+	defer dedup.mu.Unlock()
+	for key := range dedup.last {
+		if key == metric || strings.HasPrefix(key, metric+"|") {
+			delete(dedup.last, key)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package timeseries
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iyashjayesh/monigo/models"
+)
+
+// CumulativeMetrics are the stored metrics that only ever increase (until a
+// process restart resets them) and therefore make sense as deltas.
+var CumulativeMetrics = []string{
+	"bytes_sent",
+	"bytes_received",
+	"disk_read_bytes",
+	"disk_write_bytes",
+	"num_gc",
+}
+
+// MetricsDelta returns how much each cumulative metric grew between since and until,
+// computed from stored data points.
+func MetricsDelta(since, until time.Time) (models.MetricsDelta, error) {
+	return MetricsDeltaFor("", since, until)
+}
+
+// MetricsDeltaFor is MetricsDelta for the series read by namespace.
+func MetricsDeltaFor(namespace string, since, until time.Time) (models.MetricsDelta, error) {
+	result := models.MetricsDelta{
+		Since:  since,
+		Until:  until,
+		Deltas: make(map[string]float64, len(CumulativeMetrics)),
+	}
+
+	for _, metric := range CumulativeMetrics {
+		points, err := GetDataPoints(metric, MetricLabels(namespace, metric), since.Unix(), until.Unix())
+		if err != nil && !isNoDataPoints(err) {
+			return result, fmt.Errorf("error reading %s: %w", metric, err)
+		}
+		result.Deltas[metric] = counterIncrease(points)
+	}
+	return result, nil
+}
+
+// counterIncrease sums the increases between consecutive points. A decrease is
+// treated as a counter reset, in which case the new value counts as the increase.
+func counterIncrease(points []DataPoint) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+
+	sorted := make([]DataPoint, len(points))
+	copy(sorted, points)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	var increase float64
+	for i := 1; i < len(sorted); i++ {
+		diff := sorted[i].Value - sorted[i-1].Value
+		if diff < 0 {
+			diff = sorted[i].Value
+		}
+		increase += diff
+	}
+	return increase
+}
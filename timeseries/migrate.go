@@ -0,0 +1,141 @@
+package timeseries
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// migrateBatchSize is the number of rows MigrateStorage inserts at a time.
+const migrateBatchSize = 1000
+
+// ErrMigrateNotSupported is returned when the source storage backend cannot
+// list its series.
+var ErrMigrateNotSupported = errors.New("storage backend does not support listing its series for migration")
+
+// SeriesRef identifies a stored series.
+type SeriesRef struct {
+	Metric string
+	Labels []Label
+}
+
+// seriesLister is implemented by storage backends that can list their series.
+type seriesLister interface {
+	ListSeries() []SeriesRef
+}
+
+// MigrateStorage copies every series of from into to, e.g. when switching
+// from memory to disk storage. Series are copied one at a time and inserted
+// in batches, so only one series is held in memory. Disk storage only knows
+// the series written since the process started plus the current
+// SeriesLabels of the core service metrics.
+func MigrateStorage(from, to Storage) error {
+	lister, ok := from.(seriesLister)
+	selector, canSelect := from.(seriesSelector)
+	if !ok || !canSelect {
+		return ErrMigrateNotSupported
+	}
+
+	for _, ref := range lister.ListSeries() {
+		points, err := selectExactSeries(selector, ref)
+		if err != nil {
+			return fmt.Errorf("reading series %s: %w", seriesKey(ref.Metric, ref.Labels), err)
+		}
+		for len(points) > 0 {
+			n := min(len(points), migrateBatchSize)
+			rows := make([]Row, n)
+			for i, p := range points[:n] {
+				rows[i] = Row{Metric: ref.Metric, Labels: ref.Labels, DataPoint: p}
+			}
+			if err := to.InsertRows(rows); err != nil {
+				return fmt.Errorf("writing series %s: %w", seriesKey(ref.Metric, ref.Labels), err)
+			}
+			points = points[n:]
+		}
+	}
+	return nil
+}
+
+// MigrateActiveStorage copies every series of the active storage instance into to.
+func MigrateActiveStorage(to Storage) error {
+	sto, err := GetStorageInstance()
+	if err != nil {
+		return fmt.Errorf("error getting storage instance: %w", err)
+	}
+	return MigrateStorage(sto, to)
+}
+
+// selectExactSeries returns the points of exactly the series ref, leaving out
+// series whose labels merely contain ref's.
+func selectExactSeries(selector seriesSelector, ref SeriesRef) ([]DataPoint, error) {
+	series, err := selector.SelectSeries(ref.Metric, ref.Labels, 0, math.MaxInt64)
+	if err != nil && !isNoDataPoints(err) {
+		return nil, err
+	}
+	key := canonicalLabelsKey(ref.Labels)
+	for _, s := range series {
+		if canonicalLabelsKey(s.Labels) == key {
+			return s.Points, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListSeries returns every stored series, ordered by metric and labels.
+func (s *InMemoryStorage) ListSeries() []SeriesRef {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byKey := make(map[string]SeriesRef)
+	for metric, points := range s.data {
+		for _, p := range points {
+			key := metric + canonicalLabelsKey(p.labels)
+			if _, ok := byKey[key]; !ok {
+				byKey[key] = SeriesRef{Metric: metric, Labels: append([]Label(nil), p.labels...)}
+			}
+		}
+	}
+	return sortedSeriesRefs(byKey)
+}
+
+// ListSeries returns the series written since the process started and, for
+// every core service metric, the current SeriesLabels, ordered by metric and
+// labels. tstorage cannot enumerate older series.
+func (s *StorageWrapper) ListSeries() []SeriesRef {
+	byKey := make(map[string]SeriesRef)
+	add := func(metric string, labels []Label) {
+		key := metric + canonicalLabelsKey(labels)
+		if _, ok := byKey[key]; !ok {
+			byKey[key] = SeriesRef{Metric: metric, Labels: append([]Label(nil), labels...)}
+		}
+	}
+
+	current := SeriesLabels()
+	for metric := range storedStatsFields {
+		add(metric, current)
+	}
+	s.seriesMu.RLock()
+	for metric, sets := range s.series {
+		for _, labels := range sets {
+			add(metric, labels)
+		}
+	}
+	s.seriesMu.RUnlock()
+	return sortedSeriesRefs(byKey)
+}
+
+// sortedSeriesRefs returns the series ordered by key.
+func sortedSeriesRefs(byKey map[string]SeriesRef) []SeriesRef {
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	refs := make([]SeriesRef, 0, len(keys))
+	for _, key := range keys {
+		refs = append(refs, byKey[key])
+	}
+	return refs
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/iyashjayesh/monigo/common"
 	"github.com/iyashjayesh/monigo/core"
 	"github.com/iyashjayesh/monigo/internal/logger"
+	"github.com/iyashjayesh/monigo/internal/registry"
 	"github.com/nakabonne/tstorage"
 )
 
@@ -26,12 +31,18 @@ type Storage interface {
 // InMemoryStorage provides an in-memory implementation of the Storage interface.
 type InMemoryStorage struct {
 	mu   sync.RWMutex
-	data map[string][]DataPoint
+	data map[string][]labeledPoint
+}
+
+// labeledPoint is a data point together with the labels of its series.
+type labeledPoint struct {
+	DataPoint
+	labels []Label
 }
 
 func NewInMemoryStorage() *InMemoryStorage {
 	return &InMemoryStorage{
-		data: make(map[string][]DataPoint),
+		data: make(map[string][]labeledPoint),
 	}
 }
 
@@ -39,11 +50,13 @@ func (s *InMemoryStorage) InsertRows(rows []Row) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	for _, row := range rows {
-		s.data[row.Metric] = append(s.data[row.Metric], row.DataPoint)
+		s.data[row.Metric] = append(s.data[row.Metric], labeledPoint{DataPoint: row.DataPoint, labels: row.Labels})
 	}
 	return nil
 }
 
+// Select returns the points of metric within [start, end] whose series carries
+// every one of the given labels, see selectsSeries. Nil labels match all series.
 func (s *InMemoryStorage) Select(metric string, labels []Label, start, end int64) ([]DataPoint, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -54,13 +67,109 @@ func (s *InMemoryStorage) Select(metric string, labels []Label, start, end int64
 
 	var result []DataPoint
 	for _, p := range points {
-		if p.Timestamp >= start && p.Timestamp <= end {
-			result = append(result, p)
+		if p.Timestamp >= start && p.Timestamp <= end && selectsSeries(p.labels, labels) {
+			result = append(result, p.DataPoint)
 		}
 	}
 	return result, nil
 }
 
+// Latest returns the last appended point of metric whose series carries
+// every given label.
+func (s *InMemoryStorage) Latest(metric string, labels []Label) (DataPoint, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	points := s.data[metric]
+	for i := len(points) - 1; i >= 0; i-- {
+		if selectsSeries(points[i].labels, labels) {
+			return points[i].DataPoint, true, nil
+		}
+	}
+	return DataPoint{}, false, nil
+}
+
+// hasLabels reports whether have contains every label in want.
+func hasLabels(have, want []Label) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// selectsSeries reports whether a query for the labels want reads the series
+// labeled have: it must carry every wanted label, and a series labeled for a
+// namespace (see NamespaceLabels) is only read by queries naming the service.
+// Nil labels read all series.
+func selectsSeries(have, want []Label) bool {
+	if !hasLabels(have, want) {
+		return false
+	}
+	return len(want) == 0 || !hasLabelName(have, ServiceLabelName) || hasLabelName(want, ServiceLabelName)
+}
+
+// hasLabelName reports whether labels contains a label called name.
+func hasLabelName(labels []Label, name string) bool {
+	for _, l := range labels {
+		if l.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteMetric removes all points of metric whose series labels select (see selectsSeries).
+func (s *InMemoryStorage) DeleteMetric(metric string, labels []Label) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	points := s.data[metric]
+	kept := points[:0]
+	for _, p := range points {
+		if !selectsSeries(p.labels, labels) {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == 0 {
+		delete(s.data, metric)
+		return nil
+	}
+	s.data[metric] = kept
+	return nil
+}
+
+// Dump returns every stored row with its labels, ordered by metric and timestamp.
+func (s *InMemoryStorage) Dump() []Row {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var rows []Row
+	for metric, points := range s.data {
+		for _, p := range points {
+			rows = append(rows, Row{
+				Metric:    metric,
+				Labels:    append([]Label(nil), p.labels...),
+				DataPoint: p.DataPoint,
+			})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Metric != rows[j].Metric {
+			return rows[i].Metric < rows[j].Metric
+		}
+		return rows[i].DataPoint.Timestamp < rows[j].DataPoint.Timestamp
+	})
+	return rows
+}
+
 func (s *InMemoryStorage) Close() error {
 	return nil
 }
@@ -70,20 +179,139 @@ type StorageWrapper struct {
 	storage tstorage.Storage
 	closed  bool
 	mu      sync.Mutex
+
+	// tstorage cannot delete points, so deletions are recorded as in-memory
+	// tombstones that hide older points from Select until the process restarts.
+	tombstonesMu sync.RWMutex
+	tombstones   []tombstone
+
+	// tstorage only matches exact label sets, so the label sets written by
+	// this process are remembered to answer queries on a subset of labels.
+	seriesMu sync.RWMutex
+	series   map[string]map[string][]Label // metric -> canonical key -> labels
+}
+
+// tombstone hides points of a metric up to and including before.
+type tombstone struct {
+	metric string
+	labels []Label
+	before int64
 }
 
 // InsertRows inserts rows into the storage, converting monigo types to tstorage types.
 func (s *StorageWrapper) InsertRows(rows []Row) error {
-	return s.storage.InsertRows(toTStorageRows(rows))
+	if err := s.storage.InsertRows(toTStorageRows(rows)); err != nil {
+		return err
+	}
+	s.rememberSeries(rows)
+	return nil
 }
 
 // Select retrieves data points from the storage, converting tstorage types to monigo types.
+// Like InMemoryStorage, it returns points of every series whose labels contain
+// the given labels. Subset matching covers series written since the process
+// started and the current SeriesLabels; older series need their exact label set.
 func (s *StorageWrapper) Select(metric string, labels []Label, start, end int64) ([]DataPoint, error) {
-	points, err := s.storage.Select(metric, toTStorageLabels(labels), start, end)
-	if err != nil {
-		return nil, err
+	var result []DataPoint
+	for _, set := range s.matchingSeries(metric, labels) {
+		points, err := s.storage.Select(metric, toTStorageLabels(set), start, end)
+		if isNoDataPoints(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s.applyTombstones(metric, set, fromTStorageDataPoints(points))...)
+	}
+	if len(result) == 0 {
+		return nil, tstorage.ErrNoDataPoints
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Timestamp < result[j].Timestamp })
+	return result, nil
+}
+
+// rememberSeries records the label sets of written rows.
+func (s *StorageWrapper) rememberSeries(rows []Row) {
+	s.seriesMu.Lock()
+	defer s.seriesMu.Unlock()
+	if s.series == nil {
+		s.series = make(map[string]map[string][]Label)
+	}
+	for _, row := range rows {
+		sets, ok := s.series[row.Metric]
+		if !ok {
+			sets = make(map[string][]Label)
+			s.series[row.Metric] = sets
+		}
+		key := canonicalLabelsKey(row.Labels)
+		if _, ok := sets[key]; !ok {
+			sets[key] = append([]Label(nil), row.Labels...)
+		}
+	}
+}
+
+// matchingSeries returns the distinct label sets to query for metric: the
+// given labels themselves plus every known series containing them.
+func (s *StorageWrapper) matchingSeries(metric string, labels []Label) [][]Label {
+	seen := map[string]bool{canonicalLabelsKey(labels): true}
+	result := [][]Label{labels}
+	add := func(set []Label) {
+		key := canonicalLabelsKey(set)
+		if !seen[key] && selectsSeries(set, labels) {
+			seen[key] = true
+			result = append(result, set)
+		}
+	}
+
+	add(SeriesLabels())
+	s.seriesMu.RLock()
+	for _, set := range s.series[metric] {
+		add(set)
+	}
+	s.seriesMu.RUnlock()
+	return result
+}
+
+// canonicalLabelsKey identifies a label set independent of label order,
+// matching how tstorage identifies series.
+func canonicalLabelsKey(labels []Label) string {
+	sorted := append([]Label(nil), labels...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return seriesKey("", sorted)
+}
+
+// DeleteMetric records a tombstone hiding all existing points of metric whose
+// series labels select (see selectsSeries). This is best-effort: tombstones are not
+// persisted, and the points remain on disk until retention removes them.
+func (s *StorageWrapper) DeleteMetric(metric string, labels []Label) error {
+	s.tombstonesMu.Lock()
+	defer s.tombstonesMu.Unlock()
+	s.tombstones = append(s.tombstones, tombstone{metric: metric, labels: labels, before: time.Now().Unix()})
+	return nil
+}
+
+// applyTombstones drops points hidden by a matching tombstone.
+func (s *StorageWrapper) applyTombstones(metric string, labels []Label, points []DataPoint) []DataPoint {
+	s.tombstonesMu.RLock()
+	defer s.tombstonesMu.RUnlock()
+
+	var before int64 = -1
+	for _, t := range s.tombstones {
+		if t.metric == metric && selectsSeries(labels, t.labels) && t.before > before {
+			before = t.before
+		}
+	}
+	if before < 0 {
+		return points
+	}
+
+	var kept []DataPoint
+	for _, p := range points {
+		if p.Timestamp > before {
+			kept = append(kept, p)
+		}
 	}
-	return fromTStorageDataPoints(points), nil
+	return kept
 }
 
 // Close closes the storage connection.
@@ -105,12 +333,19 @@ type storageManager struct {
 	cancel    context.CancelFunc
 	once      sync.Once
 	closeOnce sync.Once
+	walOnce   sync.Once
 	mu        sync.Mutex
+
+	syncWG      sync.WaitGroup
+	syncRunning atomic.Bool
 }
 
 var (
 	manager     = &storageManager{}
 	storageType = "disk" // "disk" or "memory"
+
+	// collectServiceStats is core.GetServiceStats, replaceable in tests.
+	collectServiceStats = core.GetServiceStats
 )
 
 // SetStorageType sets the storage type
@@ -118,6 +353,35 @@ func SetStorageType(t string) {
 	storageType = t
 }
 
+// isNoDataPoints reports whether err is tstorage's error for an empty time range,
+// which callers treat as an empty result rather than a failure.
+func isNoDataPoints(err error) bool {
+	return errors.Is(err, tstorage.ErrNoDataPoints)
+}
+
+// dataDir returns the directory used by disk storage. Tests override it.
+var dataDir = func() string {
+	return filepath.Join(common.GetBasePath(), "data")
+}
+
+// DataDir returns the directory used by disk storage.
+func DataDir() string {
+	return dataDir()
+}
+
+// NewDiskStorage opens the disk storage at dir with the configured retention,
+// e.g. as the target of MigrateStorage. The active instance uses DataDir.
+func NewDiskStorage(dir string) (*StorageWrapper, error) {
+	storageInstance, err := tstorage.NewStorage(
+		tstorage.WithDataPath(dir),
+		tstorage.WithRetention(common.GetDataRetentionPeriod()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &StorageWrapper{storage: storageInstance}, nil
+}
+
 // GetStorageInstance initializes and returns a Storage instance.
 func GetStorageInstance() (Storage, error) {
 	var err error
@@ -128,30 +392,81 @@ func GetStorageInstance() (Storage, error) {
 			return
 		}
 
-		basePath := common.GetBasePath()
-		storageInstance, initErr := tstorage.NewStorage(
-			tstorage.WithDataPath(filepath.Join(basePath, "data")),
-			tstorage.WithRetention(common.GetDataRetentionPeriod()),
-		)
+		storageInstance, initErr := NewDiskStorage(dataDir())
 		if initErr != nil {
 			err = initErr
 			logger.Log.Error("initializing storage", "error", err)
 			return
 		}
-		manager.storage = &StorageWrapper{storage: storageInstance}
+		manager.storage = storageInstance
 		// Initialize context and cancel function for goroutines
 		manager.ctx, manager.cancel = context.WithCancel(context.Background())
 	})
+	if manager.storage != nil && walEnabled.Load() {
+		manager.walOnce.Do(func() {
+			if replayErr := replayWAL(manager.storage); replayErr != nil {
+				logger.Log.Error("replaying storage WAL", "error", replayErr)
+			}
+		})
+	}
 	return manager.storage, err
 }
 
-// CloseStorage closes the storage instance and stops any running goroutines.
+// ErrDeleteNotSupported is returned when the storage backend cannot delete series.
+var ErrDeleteNotSupported = errors.New("storage backend does not support deleting metrics")
+
+// metricDeleter is implemented by storage backends that can delete series.
+type metricDeleter interface {
+	DeleteMetric(metric string, labels []Label) error
+}
+
+// DeleteMetric removes the series of metric matching labels from storage, as
+// selected by Select. Empty labels delete every series of the metric.
+func DeleteMetric(metric string, labels []Label) error {
+	sto, err := GetStorageInstance()
+	if err != nil {
+		return fmt.Errorf("error getting storage instance: %w", err)
+	}
+	d, ok := sto.(metricDeleter)
+	if !ok {
+		return ErrDeleteNotSupported
+	}
+	if err := d.DeleteMetric(metric, labels); err != nil {
+		return err
+	}
+	forgetDedup(metric)
+	return nil
+}
+
+// ErrDumpNotSupported is returned when the storage backend cannot list its rows.
+var ErrDumpNotSupported = errors.New("storage backend does not support dumping rows")
+
+// rowDumper is implemented by storage backends that can list every stored row.
+type rowDumper interface {
+	Dump() []Row
+}
+
+// DumpStorage returns every row held by the storage backend, for debugging.
+func DumpStorage() ([]Row, error) {
+	sto, err := GetStorageInstance()
+	if err != nil {
+		return nil, fmt.Errorf("error getting storage instance: %w", err)
+	}
+	d, ok := sto.(rowDumper)
+	if !ok {
+		return nil, ErrDumpNotSupported
+	}
+	return d.Dump(), nil
+}
+
+// CloseStorage stops the sync loop, waits for it to exit and closes the storage instance.
 func CloseStorage() error {
 	var err error
 	manager.closeOnce.Do(func() {
 		if manager.cancel != nil {
 			manager.cancel() // Stop any goroutines
 		}
+		manager.syncWG.Wait()
 		if manager.storage != nil {
 			if closeErr := manager.storage.Close(); closeErr != nil {
 				logger.Log.Error("closing storage", "error", closeErr)
@@ -185,17 +500,72 @@ func PurgeStorage() error {
 	return nil
 }
 
+// Bounds for the data points sync frequency.
+const (
+	DefaultSyncFrequency = 5 * time.Minute
+	MinSyncFrequency     = time.Second
+	MaxSyncFrequency     = 24 * time.Hour
+)
+
+// normalizeSyncFrequency parses freq and clamps it to [MinSyncFrequency, MaxSyncFrequency].
+// An empty value yields DefaultSyncFrequency.
+func normalizeSyncFrequency(freq string) (time.Duration, error) {
+	if freq == "" {
+		return DefaultSyncFrequency, nil
+	}
+
+	d, err := common.ParseRetention(freq)
+	if err != nil {
+		return 0, fmt.Errorf("[MoniGo] invalid data points sync frequency: %w", err)
+	}
+
+	switch {
+	case d < MinSyncFrequency:
+		logger.Log.Warn("sync frequency too small, clamping", "requested", d, "using", MinSyncFrequency)
+		return MinSyncFrequency, nil
+	case d > MaxSyncFrequency:
+		logger.Log.Warn("sync frequency too large, clamping", "requested", d, "using", MaxSyncFrequency)
+		return MaxSyncFrequency, nil
+	}
+	return d, nil
+}
+
+// MaxSyncJitter is the largest fraction of the sync interval a cycle may be shifted by.
+const MaxSyncJitter = 0.5
+
+// syncJitter holds the jitter fraction as float64 bits.
+var syncJitter atomic.Uint64
+
+// SetSyncJitter randomizes each sync cycle by up to ±fraction of the sync
+// interval, so instances started together don't hit shared storage and
+// collectors at the same instant. The fraction is clamped to [0, MaxSyncJitter];
+// 0 (the default) disables jitter.
+func SetSyncJitter(fraction float64) {
+	fraction = math.Max(0, math.Min(fraction, MaxSyncJitter))
+	syncJitter.Store(math.Float64bits(fraction))
+}
+
+// nextSyncDelay returns interval shifted by a random amount within the configured jitter.
+func nextSyncDelay(interval time.Duration) time.Duration {
+	fraction := math.Float64frombits(syncJitter.Load())
+	if fraction == 0 {
+		return interval
+	}
+	offset := (rand.Float64()*2 - 1) * fraction
+	return time.Duration(float64(interval) * (1 + offset))
+}
+
 // SetDataPointsSyncFrequency sets the frequency at which data points are synchronized.
+// Values outside [MinSyncFrequency, MaxSyncFrequency] are clamped; unparseable values return an error.
 func SetDataPointsSyncFrequency(frequency ...string) error {
-	freqStr := "5m"
+	freqStr := ""
 	if len(frequency) > 0 {
 		freqStr = frequency[0]
 	}
 
-	freqTime, err := time.ParseDuration(freqStr)
+	freqTime, err := normalizeSyncFrequency(freqStr)
 	if err != nil {
-		logger.Log.Warn("invalid frequency format, using default 5m", "error", err)
-		freqTime = 5 * time.Minute
+		return err
 	}
 
 	// Ensure storage is initialized before starting the sync loop
@@ -204,26 +574,87 @@ func SetDataPointsSyncFrequency(frequency ...string) error {
 	}
 
 	// Initializing service metrics once
-	serviceMetrics := core.GetServiceStats(context.Background())
-	if err := StoreServiceMetrics(&serviceMetrics); err != nil {
+	if err := runSyncCycle(context.Background(), freqTime); err != nil {
 		return errors.New("[MoniGo] error storing service metrics, err: " + err.Error())
 	}
 
-	ticker := time.NewTicker(freqTime)
+	timer := time.NewTimer(nextSyncDelay(freqTime))
+	manager.syncWG.Add(1)
+	manager.syncRunning.Store(true)
 	go func() {
-		defer ticker.Stop()
+		defer manager.syncWG.Done()
+		defer manager.syncRunning.Store(false)
+		defer timer.Stop()
 		for {
 			select {
 			case <-manager.ctx.Done():
 				return
-			case <-ticker.C:
-				serviceMetrics := core.GetServiceStats(manager.ctx)
-				if err := StoreServiceMetrics(&serviceMetrics); err != nil {
+			case <-timer.C:
+				if err := runSyncCycle(manager.ctx, freqTime); err != nil {
 					logger.Log.Error("storing service metrics", "error", err)
 				}
+				timer.Reset(nextSyncDelay(freqTime))
 			}
 		}
 	}()
 
 	return nil
 }
+
+// IsSyncLoopRunning reports whether the background sync loop is active.
+func IsSyncLoopRunning() bool {
+	return manager.syncRunning.Load()
+}
+
+// syncPaused is set while the sync loop should skip its cycles.
+var syncPaused atomic.Bool
+
+// PauseSync makes the sync loop skip its cycles, e.g. during a noisy
+// maintenance window, until ResumeSync is called. Skipped cycles store
+// nothing and are not counted as overruns.
+func PauseSync() {
+	if !syncPaused.Swap(true) {
+		logger.Log.Info("metric sync paused")
+	}
+}
+
+// ResumeSync undoes PauseSync; the next cycle collects and stores as usual.
+func ResumeSync() {
+	if syncPaused.Swap(false) {
+		logger.Log.Info("metric sync resumed")
+	}
+}
+
+// IsSyncPaused reports whether PauseSync is in effect.
+func IsSyncPaused() bool {
+	return syncPaused.Load()
+}
+
+// runSyncCycle collects and stores one round of service metrics, also setting
+// them as gauges in the default registry, and records how long it took and
+// whether it overran the sync interval. It does nothing while
+// the sync is paused.
+func runSyncCycle(ctx context.Context, interval time.Duration) error {
+	if syncPaused.Load() {
+		logger.Log.Debug("sync cycle skipped, sync is paused")
+		return nil
+	}
+	start := time.Now()
+	serviceMetrics := collectServiceStats(ctx)
+	core.SmoothLoadStatistics(&serviceMetrics.LoadStatistics)
+	reg := registry.Default()
+	recordServiceGauges(reg, &serviceMetrics)
+	err := errors.Join(
+		StoreServiceMetrics(&serviceMetrics),
+		storeCollectorRows(core.CollectRows(), start.Unix()),
+		storeFunctionSamples(),
+	)
+	elapsed := time.Since(start)
+
+	reg.SetGauge(registry.SyncCycleDurationSeconds, elapsed.Seconds(), nil)
+	if elapsed > interval {
+		reg.IncrementCounter(registry.SyncCycleOverrunsTotal, 1, nil)
+		logger.Log.Warn("sync cycle overran its interval", "duration", elapsed, "interval", interval)
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package timeseries
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// CounterStorage controls how the network and disk counters are stored.
+type CounterStorage string
+
+const (
+	// CounterTotals stores the cumulative totals only (the default).
+	CounterTotals CounterStorage = "total"
+	// CounterRates stores per-second rates instead of the totals.
+	CounterRates CounterStorage = "rate"
+	// CounterTotalsAndRates stores both.
+	CounterTotalsAndRates CounterStorage = "both"
+)
+
+// RateMetricSuffix is appended to a counter's name for its per-second rate,
+// e.g. "bytes_sent_per_second".
+const RateMetricSuffix = "_per_second"
+
+// RateCounters are the counters that can be stored as per-second rates.
+var RateCounters = []string{
+	"bytes_sent",
+	"bytes_received",
+	"disk_read_bytes",
+	"disk_write_bytes",
+}
+
+// ParseCounterStorage validates mode. An empty mode means CounterTotals.
+func ParseCounterStorage(mode string) (CounterStorage, error) {
+	switch CounterStorage(mode) {
+	case "":
+		return CounterTotals, nil
+	case CounterTotals, CounterRates, CounterTotalsAndRates:
+		return CounterStorage(mode), nil
+	}
+	return "", fmt.Errorf("unsupported counter storage %q: must be %q, %q or %q", mode, CounterTotals, CounterRates, CounterTotalsAndRates)
+}
+
+type counterSample struct {
+	value     float64
+	timestamp int64
+}
+
+var rates = struct {
+	mu   sync.Mutex
+	mode CounterStorage
+	last map[string]counterSample
+}{
+	mode: CounterTotals,
+	last: make(map[string]counterSample),
+}
+
+// SetCounterStorage sets whether the network and disk counters are stored as
+// totals, per-second rates or both. With rates only, MetricsDelta and the
+// totals in LatestServiceStats have no data.
+func SetCounterStorage(mode string) error {
+	m, err := ParseCounterStorage(mode)
+	if err != nil {
+		return err
+	}
+	rates.mu.Lock()
+	defer rates.mu.Unlock()
+	rates.mode = m
+	rates.last = make(map[string]counterSample)
+	return nil
+}
+
+// counterRateRows applies the counter storage mode to rows. A rate row is the
+// increase of the counter since its previous sync divided by the seconds in
+// between; the first sample of a series only primes it. A decrease is treated
+// as a counter reset, as in counterIncrease. The samples are only updated by
+// calling commit, which callers must do once the rows were written.
+func counterRateRows(rows []Row) (out []Row, commit func()) {
+	rates.mu.Lock()
+	defer rates.mu.Unlock()
+
+	if rates.mode == CounterTotals {
+		return rows, func() {}
+	}
+
+	samples := make(map[string]counterSample)
+	out = rows[:0:0]
+	for _, row := range rows {
+		if !slices.Contains(RateCounters, row.Metric) {
+			out = append(out, row)
+			continue
+		}
+		if rates.mode == CounterTotalsAndRates {
+			out = append(out, row)
+		}
+
+		key := seriesKey(row.Metric, row.Labels)
+		sample := counterSample{value: row.DataPoint.Value, timestamp: row.DataPoint.Timestamp}
+		samples[key] = sample
+		prev, ok := rates.last[key]
+		if !ok || sample.timestamp <= prev.timestamp {
+			continue
+		}
+		increase := sample.value - prev.value
+		if increase < 0 {
+			increase = sample.value
+		}
+		out = append(out, Row{
+			Metric:    row.Metric + RateMetricSuffix,
+			Labels:    row.Labels,
+			DataPoint: DataPoint{Timestamp: sample.timestamp, Value: increase / float64(sample.timestamp-prev.timestamp)},
+		})
+	}
+
+	return out, func() {
+		rates.mu.Lock()
+		defer rates.mu.Unlock()
+		for key, sample := range samples {
+			rates.last[key] = sample
+		}
+	}
+}
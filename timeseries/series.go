@@ -0,0 +1,101 @@
+package timeseries
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Series is the data points of one stored series with its full label set.
+type Series struct {
+	Labels []Label
+	Points []DataPoint
+}
+
+// seriesSelector is implemented by storage backends that can return matching
+// series separately instead of merging their points.
+type seriesSelector interface {
+	SelectSeries(metric string, labels []Label, start, end int64) ([]Series, error)
+}
+
+// GetSeries returns every series of metric whose labels contain labels, each
+// with its points between start and end in timestamp order. Backends that
+// can't separate series return one series carrying the requested labels.
+func GetSeries(metric string, labels []Label, start, end int64) ([]Series, error) {
+	sto, err := GetStorageInstance()
+	if err != nil {
+		return nil, fmt.Errorf("error getting storage instance: %w", err)
+	}
+	if s, ok := sto.(seriesSelector); ok {
+		return s.SelectSeries(metric, labels, start, end)
+	}
+
+	points, err := sto.Select(metric, labels, start, end)
+	if err != nil && !isNoDataPoints(err) {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Timestamp < points[j].Timestamp })
+	return []Series{{Labels: append([]Label(nil), labels...), Points: points}}, nil
+}
+
+// SelectSeries returns the points of every series of metric whose labels
+// contain labels, one Series per label set, ordered by labels.
+func (s *InMemoryStorage) SelectSeries(metric string, labels []Label, start, end int64) ([]Series, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byKey := make(map[string]*Series)
+	for _, p := range s.data[metric] {
+		if p.Timestamp < start || p.Timestamp > end || !selectsSeries(p.labels, labels) {
+			continue
+		}
+		key := canonicalLabelsKey(p.labels)
+		series, ok := byKey[key]
+		if !ok {
+			series = &Series{Labels: append([]Label(nil), p.labels...)}
+			byKey[key] = series
+		}
+		series.Points = append(series.Points, p.DataPoint)
+	}
+	return sortedSeries(byKey), nil
+}
+
+// SelectSeries returns the points of every known series of metric whose
+// labels contain labels, one Series per label set, ordered by labels. See
+// Select for which series are known.
+func (s *StorageWrapper) SelectSeries(metric string, labels []Label, start, end int64) ([]Series, error) {
+	byKey := make(map[string]*Series)
+	for _, set := range s.matchingSeries(metric, labels) {
+		points, err := s.storage.Select(metric, toTStorageLabels(set), start, end)
+		if isNoDataPoints(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if points := s.applyTombstones(metric, set, fromTStorageDataPoints(points)); len(points) > 0 {
+			byKey[canonicalLabelsKey(set)] = &Series{Labels: append([]Label(nil), set...), Points: points}
+		}
+	}
+	return sortedSeries(byKey), nil
+}
+
+// sortedSeries returns the series ordered by label set, each with its points
+// in timestamp order.
+func sortedSeries(byKey map[string]*Series) []Series {
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]Series, 0, len(keys))
+	for _, key := range keys {
+		series := byKey[key]
+		sort.SliceStable(series.Points, func(i, j int) bool { return series.Points[i].Timestamp < series.Points[j].Timestamp })
+		result = append(result, *series)
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package timeseries
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iyashjayesh/monigo/common"
+	"github.com/iyashjayesh/monigo/models"
+)
+
+// StorageStats reports the on-disk size of the metrics store, an estimate of
+// the stored point count and the oldest/newest stored timestamps. The point
+// count and bounds are computed from the core service metrics within the
+// retention period.
+func StorageStats() (models.StorageStats, error) {
+	stats := models.StorageStats{StorageType: "memory"}
+
+	sto, err := GetStorageInstance()
+	if err != nil {
+		return stats, fmt.Errorf("error getting storage instance: %w", err)
+	}
+
+	if _, inMemory := sto.(*InMemoryStorage); !inMemory {
+		stats.StorageType = "disk"
+		stats.DataDir = dataDir()
+		if stats.SizeBytes, err = dirSize(stats.DataDir); err != nil {
+			return stats, fmt.Errorf("error measuring %s: %w", stats.DataDir, err)
+		}
+	}
+
+	end := time.Now().Unix() + 1
+	start := end - int64(common.GetDataRetentionPeriod().Seconds())
+	labels := SeriesLabels()
+
+	var oldest, newest int64
+	for metric := range storedStatsFields {
+		points, err := sto.Select(metric, labels, start, end)
+		if isNoDataPoints(err) {
+			continue
+		}
+		if err != nil {
+			return stats, fmt.Errorf("error reading %s: %w", metric, err)
+		}
+		for _, p := range points {
+			if stats.PointCount == 0 || p.Timestamp < oldest {
+				oldest = p.Timestamp
+			}
+			if stats.PointCount == 0 || p.Timestamp > newest {
+				newest = p.Timestamp
+			}
+			stats.PointCount++
+		}
+	}
+
+	if stats.PointCount > 0 {
+		o, n := time.Unix(oldest, 0), time.Unix(newest, 0)
+		stats.Oldest, stats.Newest = &o, &n
+	}
+	return stats, nil
+}
+
+// dirSize returns the total size of the regular files under dir.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
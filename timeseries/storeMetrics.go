@@ -1,15 +1,38 @@
 package timeseries
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/iyashjayesh/monigo/common"
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/internal/logger"
+	"github.com/iyashjayesh/monigo/internal/registry"
 	"github.com/iyashjayesh/monigo/models"
 )
 
-// GetHostLabel returns a Label with the actual hostname
+// hostLabelOverride replaces the hostname in the host label when non-empty.
+var hostLabelOverride atomic.Value // string
+
+// SetHostLabel overrides the value of the host label attached to stored rows.
+// Passing an empty string restores the real hostname.
+func SetHostLabel(value string) {
+	hostLabelOverride.Store(value)
+}
+
+// GetHostLabel returns the host Label, using the override set by SetHostLabel
+// or the actual hostname.
 func GetHostLabel() Label {
+	if v, _ := hostLabelOverride.Load().(string); v != "" {
+		return Label{Name: "host", Value: v}
+	}
 	hostname, err := os.Hostname()
 	if err != nil {
 		hostname = "unknown"
@@ -17,6 +40,84 @@ func GetHostLabel() Label {
 	return Label{Name: "host", Value: hostname}
 }
 
+var (
+	tagsMu sync.RWMutex
+	tags   []Label
+)
+
+// SetTags sets extra labels (e.g. env, region) attached to every stored row
+// alongside the host label. A "host" key is ignored; use SetHostLabel instead.
+func SetTags(t map[string]string) {
+	labels := make([]Label, 0, len(t))
+	for k, v := range t {
+		if k == "" || k == "host" {
+			logger.Log.Warn("ignoring reserved or empty tag key", "key", k)
+			continue
+		}
+		labels = append(labels, Label{Name: k, Value: v})
+	}
+	// Keep a stable order so the same tags always identify the same series.
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+	tagsMu.Lock()
+	tags = labels
+	tagsMu.Unlock()
+}
+
+// SeriesLabels returns the full label set of series written by StoreServiceMetrics:
+// the host label followed by any configured tags.
+func SeriesLabels() []Label {
+	tagsMu.RLock()
+	defer tagsMu.RUnlock()
+
+	labels := make([]Label, 0, len(tags)+1)
+	labels = append(labels, GetHostLabel())
+	return append(labels, tags...)
+}
+
+// ServiceLabelName is the label that scopes the series of a namespace, the
+// service name of an isolated Monigo instance.
+const ServiceLabelName = "service"
+
+// NamespaceLabels returns the label set of series stored for namespace, such
+// as the executions of its traced functions: the SeriesLabels plus a service
+// label, which replaces a "service" tag. The default namespace "" has the
+// plain SeriesLabels. A series labeled for a namespace is only read by
+// queries naming it, so reads of the default namespace don't match it.
+func NamespaceLabels(namespace string) []Label {
+	labels := SeriesLabels()
+	if namespace == "" {
+		return labels
+	}
+
+	scoped := labels[:1]
+	for _, l := range labels[1:] {
+		if l.Name != ServiceLabelName {
+			scoped = append(scoped, l)
+		}
+	}
+	scoped = append(scoped, Label{Name: ServiceLabelName, Value: namespace})
+	tags := scoped[1:]
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return scoped
+}
+
+// MetricLabels returns the labels to read metric for namespace with. Only
+// function metrics are stored per namespace; the service metrics are
+// process-wide, stored once and read under the SeriesLabels by every namespace.
+func MetricLabels(namespace, metric string) []Label {
+	if NamespacedMetric(metric) {
+		return NamespaceLabels(namespace)
+	}
+	return SeriesLabels()
+}
+
+// NamespacedMetric reports whether metric is stored per namespace, with a
+// service label outside the default one.
+func NamespacedMetric(metric string) bool {
+	return metric == FunctionExecutionMetric || metric == FunctionMemoryMetric
+}
+
 // GetDataPoints retrieves data points for a given metric and labels.
 func GetDataPoints(metric string, labels []Label, start, end int64) ([]DataPoint, error) {
 	sto, err := GetStorageInstance()
@@ -26,7 +127,123 @@ func GetDataPoints(metric string, labels []Label, start, end int64) ([]DataPoint
 	return sto.Select(metric, labels, start, end)
 }
 
-// StoreServiceMetrics stores service metrics in the time-series storage.
+// latestWindow is the first range Latest queries on storage without a direct
+// lookup, twice the default sync frequency so it usually holds a point.
+const latestWindow = 2 * DefaultSyncFrequency
+
+// latestSelector is implemented by storage backends that can return the
+// newest point of a metric without a range query.
+type latestSelector interface {
+	Latest(metric string, labels []Label) (DataPoint, bool, error)
+}
+
+// Latest returns the newest point of metric among the series carrying every
+// given label, and false when there is none. Storage without a direct lookup
+// is queried over a recent window, doubled until it covers the retention
+// period.
+func Latest(metric string, labels []Label) (DataPoint, bool, error) {
+	sto, err := GetStorageInstance()
+	if err != nil {
+		return DataPoint{}, false, fmt.Errorf("error getting storage instance: %w", err)
+	}
+	if l, ok := sto.(latestSelector); ok {
+		return l.Latest(metric, labels)
+	}
+
+	end := now().Unix() + 1
+	retention := int64(common.GetDataRetentionPeriod().Seconds())
+	for window := int64(latestWindow.Seconds()); ; window *= 2 {
+		points, err := sto.Select(metric, labels, end-window, end)
+		if err != nil && !isNoDataPoints(err) {
+			return DataPoint{}, false, err
+		}
+		if len(points) > 0 {
+			return newestPoint(points), true, nil
+		}
+		if window >= retention {
+			return DataPoint{}, false, nil
+		}
+	}
+}
+
+// newestPoint returns the point with the latest timestamp, the last one on ties.
+func newestPoint(points []DataPoint) DataPoint {
+	latest := points[0]
+	for _, p := range points[1:] {
+		if p.Timestamp >= latest.Timestamp {
+			latest = p
+		}
+	}
+	return latest
+}
+
+// ErrNoStoredStats is returned when no service metrics have been stored yet.
+var ErrNoStoredStats = errors.New("no stored service metrics found")
+
+// storedStatsFields maps stored metric names back onto ServiceStats fields.
+var storedStatsFields = map[string]func(*models.ServiceStats, float64){
+	"goroutines":              func(s *models.ServiceStats, v float64) { s.CoreStatistics.Goroutines = int(v) },
+	"overall_load_of_service": func(s *models.ServiceStats, v float64) { s.LoadStatistics.OverallLoadOfServiceRaw = v },
+	"service_cpu_load":        func(s *models.ServiceStats, v float64) { s.LoadStatistics.ServiceCPULoadRaw = v },
+	"service_memory_load":     func(s *models.ServiceStats, v float64) { s.LoadStatistics.ServiceMemLoadRaw = v },
+	"system_cpu_load":         func(s *models.ServiceStats, v float64) { s.LoadStatistics.SystemCPULoadRaw = v },
+	"system_memory_load":      func(s *models.ServiceStats, v float64) { s.LoadStatistics.SystemMemLoadRaw = v },
+	"system_disk_load":        func(s *models.ServiceStats, v float64) { s.LoadStatistics.SystemDiskLoadRaw = v },
+	"total_disk_size":         func(s *models.ServiceStats, v float64) { s.LoadStatistics.TotalDiskLoadRaw = v },
+	"total_cores":             func(s *models.ServiceStats, v float64) { s.CPUStatistics.TotalCores = v },
+	"cores_used_by_service":   func(s *models.ServiceStats, v float64) { s.CPUStatistics.CoresUsedByService = v },
+	"cores_used_by_system":    func(s *models.ServiceStats, v float64) { s.CPUStatistics.CoresUsedBySystem = v },
+	"total_system_memory":     func(s *models.ServiceStats, v float64) { s.MemoryStatistics.TotalSystemMemoryRaw = v },
+	"memory_used_by_system":   func(s *models.ServiceStats, v float64) { s.MemoryStatistics.MemoryUsedBySystemRaw = v },
+	"memory_used_by_service":  func(s *models.ServiceStats, v float64) { s.MemoryStatistics.MemoryUsedByServiceRaw = v },
+	"available_memory":        func(s *models.ServiceStats, v float64) { s.MemoryStatistics.AvailableMemoryRaw = v },
+	"gc_pause_duration":       func(s *models.ServiceStats, v float64) { s.MemoryStatistics.GCPauseDurationRaw = v },
+	"stack_memory_usage":      func(s *models.ServiceStats, v float64) { s.MemoryStatistics.StackMemoryUsageRaw = v },
+	"heap_alloc_by_service":   func(s *models.ServiceStats, v float64) { s.HeapAllocByServiceRaw = uint64(v) },
+	"heap_alloc_by_system":    func(s *models.ServiceStats, v float64) { s.HeapAllocBySystemRaw = uint64(v) },
+	"total_alloc_by_service":  func(s *models.ServiceStats, v float64) { s.TotalAllocByServiceRaw = uint64(v) },
+	"total_memory_by_os":      func(s *models.ServiceStats, v float64) { s.TotalMemoryByOSRaw = uint64(v) },
+	"bytes_sent":              func(s *models.ServiceStats, v float64) { s.NetworkIO.BytesSent = v },
+	"bytes_received":          func(s *models.ServiceStats, v float64) { s.NetworkIO.BytesReceived = v },
+	"disk_read_bytes":         func(s *models.ServiceStats, v float64) { s.DiskIO.ReadBytes = uint64(v) },
+	"disk_write_bytes":        func(s *models.ServiceStats, v float64) { s.DiskIO.WriteBytes = uint64(v) },
+	"service_health_percent":  func(s *models.ServiceStats, v float64) { s.Health.ServiceHealth.Percent = v },
+	"system_health_percent":   func(s *models.ServiceStats, v float64) { s.Health.SystemHealth.Percent = v },
+}
+
+// LatestServiceStats reconstructs ServiceStats from the most recent stored data points.
+// Only raw numeric fields are populated; formatted strings are left empty.
+func LatestServiceStats() (models.ServiceStats, error) {
+	return LatestServiceStatsFor("")
+}
+
+// LatestServiceStatsFor is LatestServiceStats for namespace. The service
+// metrics are process-wide, so every namespace reads the same values.
+func LatestServiceStatsFor(namespace string) (models.ServiceStats, error) {
+	var stats models.ServiceStats
+
+	found := false
+	for metric, set := range storedStatsFields {
+		latest, ok, err := Latest(metric, MetricLabels(namespace, metric))
+		if err != nil {
+			return stats, fmt.Errorf("error reading %s: %w", metric, err)
+		}
+		if !ok {
+			continue
+		}
+		set(&stats, latest.Value)
+		found = true
+	}
+
+	if !found {
+		return stats, ErrNoStoredStats
+	}
+	return stats, nil
+}
+
+// StoreServiceMetrics stores service metrics in the time-series storage. They
+// describe the whole process, so they are stored once under the SeriesLabels
+// and read by every namespace.
 func StoreServiceMetrics(serviceMetrics *models.ServiceStats) error {
 	sto, err := GetStorageInstance()
 	if err != nil {
@@ -38,23 +255,186 @@ func StoreServiceMetrics(serviceMetrics *models.ServiceStats) error {
 		return fmt.Errorf("error loading location: %w", err)
 	}
 
-	currentTime := time.Now().In(location)
-	timestamp := currentTime.Unix()
-	label := GetHostLabel()
+	currentTime := now().In(location)
+	timestamp, ok := checkClockSkew(currentTime.Unix())
+	if !ok {
+		return nil
+	}
+	rows := serviceMetricsRows(serviceMetrics, SeriesLabels(), timestamp)
+
+	rows, commitRates := counterRateRows(rows)
+	transformValues(rows)
+	rows, commitDedup := dedupRows(rows)
+	if len(rows) == 0 {
+		commitRates()
+		commitDedup()
+		return nil
+	}
+
+	if err := insertRows(sto, rows); err != nil {
+		return fmt.Errorf("error storing service metrics: %w", err)
+	}
+	recordTimestamp(timestamp)
+	commitRates()
+	commitDedup()
+	return nil
+}
+
+// serviceMetricsRows generates the rows of one sync cycle for the series labels.
+func serviceMetricsRows(serviceMetrics *models.ServiceStats, labels []Label, timestamp int64) []Row {
+	rows := serviceStatsRows(serviceMetrics, labels[0], timestamp)
+	if len(labels) > 1 {
+		for i := range rows {
+			rows[i].Labels = labels
+		}
+	}
+	return append(rows, generatePerCoreCPURows(serviceMetrics, labels, timestamp)...)
+}
+
+// serviceStatsRows generates the rows of one sync cycle, except the per-core
+// CPU usage, labeled with label.
+func serviceStatsRows(serviceMetrics *models.ServiceStats, label Label, timestamp int64) []Row {
 	var rows []Row
 	rows = append(rows, generateCoreStatsRows(serviceMetrics, label, timestamp)...)
-	rows = append(rows, generateLoadStatsRows(serviceMetrics, label, timestamp)...)
-	rows = append(rows, generateCPUStatsRows(serviceMetrics, label, timestamp)...)
-	rows = append(rows, generateMemoryStatsRows(serviceMetrics, label, timestamp)...)
-	rows = append(rows, generateNetworkIORows(serviceMetrics, label, timestamp)...)
-	rows = append(rows, generateHealthStatsRows(serviceMetrics, label, timestamp)...)
+	// Statistics that could not be read are skipped rather than stored as zero.
+	if !serviceMetrics.LoadStatistics.Unavailable {
+		rows = append(rows, generateLoadStatsRows(serviceMetrics, label, timestamp)...)
+	}
+	if !serviceMetrics.CPUStatistics.Unavailable {
+		rows = append(rows, generateCPUStatsRows(serviceMetrics, label, timestamp)...)
+	}
+	if !serviceMetrics.MemoryStatistics.Unavailable {
+		rows = append(rows, generateMemoryStatsRows(serviceMetrics, label, timestamp)...)
+	}
+	if !serviceMetrics.NetworkIOUnavailable {
+		rows = append(rows, generateNetworkIORows(serviceMetrics, label, timestamp)...)
+	}
+	if !serviceMetrics.DiskIOUnavailable {
+		rows = append(rows, generateDiskIORows(serviceMetrics, label, timestamp)...)
+	}
+	return append(rows, generateHealthStatsRows(serviceMetrics, label, timestamp)...)
+}
 
-	if err := sto.InsertRows(rows); err != nil {
-		return fmt.Errorf("error storing service metrics: %w", err)
+// RegistryMetricPrefix is prepended to the stored metric names of the service
+// metrics recorded as gauges in the registry, e.g. "monigo_goroutines".
+const RegistryMetricPrefix = "monigo_"
+
+// recordServiceGauges sets a gauge in reg for every service metric of one sync
+// cycle, labeled with the series labels, so the exporters fed by the registry
+// (e.g. OTel) export them. The per-core CPU usage is left out.
+func recordServiceGauges(reg *registry.Registry, serviceMetrics *models.ServiceStats) {
+	labels := SeriesLabels()
+	labelMap := make(map[string]string, len(labels))
+	for _, l := range labels {
+		labelMap[l.Name] = l.Value
+	}
+	for _, row := range serviceStatsRows(serviceMetrics, labels[0], 0) {
+		reg.SetGauge(RegistryMetricPrefix+row.Metric, row.DataPoint.Value, labelMap)
+	}
+}
+
+// storeCollectorRows stores rows from registered collectors. Rows without
+// labels get the series labels and rows without a timestamp get timestamp.
+func storeCollectorRows(rows []Row, timestamp int64) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	sto, err := GetStorageInstance()
+	if err != nil {
+		return fmt.Errorf("error getting storage instance: %w", err)
+	}
+
+	labels := SeriesLabels()
+	for i := range rows {
+		if len(rows[i].Labels) == 0 {
+			rows[i].Labels = labels
+		}
+		if rows[i].DataPoint.Timestamp == 0 {
+			rows[i].DataPoint.Timestamp = timestamp
+		}
+	}
+
+	if err := insertRows(sto, rows); err != nil {
+		return fmt.Errorf("error storing collector metrics: %w", err)
 	}
 	return nil
 }
 
+// Metrics stored for every traced function execution, labeled with the function name.
+const (
+	FunctionExecutionMetric = "function_execution_ms"
+	FunctionMemoryMetric    = "function_memory_bytes"
+)
+
+// lastFunctionSync is the time up to which function samples were stored.
+var lastFunctionSync struct {
+	mu sync.Mutex
+	at time.Time
+}
+
+// storeFunctionSamples stores the function executions recorded since the
+// previous call. Executions that fell out of a function's history before
+// being stored are lost.
+func storeFunctionSamples() error {
+	lastFunctionSync.mu.Lock()
+	since := lastFunctionSync.at
+	lastFunctionSync.mu.Unlock()
+
+	var rows []Row
+	var latest time.Time
+	for ns, functions := range core.FunctionSamplesSince(since) {
+		nsLabels := NamespaceLabels(ns)
+		for name, samples := range functions {
+			name, fnLabels := core.SplitFunctionLabels(name)
+			labels := append(append(make([]Label, 0, len(nsLabels)+1+len(fnLabels)), nsLabels...), Label{Name: "function", Value: name})
+			labels = append(labels, functionLabels(fnLabels, nsLabels)...)
+			for _, s := range samples {
+				ts := s.Timestamp.Unix()
+				rows = append(rows,
+					Row{Metric: FunctionExecutionMetric, Labels: labels, DataPoint: DataPoint{Timestamp: ts, Value: float64(s.ExecutionTime) / float64(time.Millisecond)}},
+					Row{Metric: FunctionMemoryMetric, Labels: labels, DataPoint: DataPoint{Timestamp: ts, Value: float64(s.MemoryUsage)}},
+				)
+				if s.Timestamp.After(latest) {
+					latest = s.Timestamp
+				}
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	sto, err := GetStorageInstance()
+	if err != nil {
+		return fmt.Errorf("error getting storage instance: %w", err)
+	}
+	if err := insertRows(sto, rows); err != nil {
+		return fmt.Errorf("error storing function samples: %w", err)
+	}
+
+	lastFunctionSync.mu.Lock()
+	if latest.After(lastFunctionSync.at) {
+		lastFunctionSync.at = latest
+	}
+	lastFunctionSync.mu.Unlock()
+	return nil
+}
+
+// functionLabels returns the labels of a function traced with
+// core.TraceFunctionWithLabels, sorted by name. Labels clashing with the
+// series labels are dropped.
+func functionLabels(labels map[string]string, series []Label) []Label {
+	result := make([]Label, 0, len(labels))
+	for k, v := range labels {
+		if k == "function" || slices.ContainsFunc(series, func(l Label) bool { return l.Name == k }) {
+			continue
+		}
+		result = append(result, Label{Name: k, Value: v})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
 // generateCoreStatsRows generates rows for core statistics.
 func generateCoreStatsRows(serviceMetrics *models.ServiceStats, label Label, timestamp int64) []Row {
 	return []Row{
@@ -123,6 +503,29 @@ func generateCPUStatsRows(serviceMetrics *models.ServiceStats, label Label, time
 	}
 }
 
+// generatePerCoreCPURows generates one cpu_core_usage row per logical core,
+// labeled with the core number on top of the series labels.
+func generatePerCoreCPURows(serviceMetrics *models.ServiceStats, labels []Label, timestamp int64) []Row {
+	rows := make([]Row, 0, len(serviceMetrics.CPUStatistics.PerCore))
+	for coreNum, usage := range serviceMetrics.CPUStatistics.PerCore {
+		coreLabels := append(labels[:len(labels):len(labels)], Label{Name: "core", Value: strconv.Itoa(coreNum)})
+		rows = append(rows, Row{
+			Metric:    "cpu_core_usage",
+			DataPoint: DataPoint{Timestamp: timestamp, Value: usage},
+			Labels:    coreLabels,
+		})
+	}
+	return rows
+}
+
+// systemMemoryMetrics are the memory metrics read from the system rather
+// than the Go runtime, skipped when the system memory is unavailable.
+var systemMemoryMetrics = map[string]bool{
+	"total_system_memory":   true,
+	"memory_used_by_system": true,
+	"available_memory":      true,
+}
+
 // generateMemoryStatsRows generates rows for memory statistics.
 func generateMemoryStatsRows(serviceMetrics *models.ServiceStats, label Label, timestamp int64) []Row {
 	rows := []Row{
@@ -157,6 +560,9 @@ func generateMemoryStatsRows(serviceMetrics *models.ServiceStats, label Label, t
 			Labels:    []Label{label},
 		},
 	}
+	if serviceMetrics.MemoryStatistics.Unavailable {
+		rows = slices.DeleteFunc(rows, func(r Row) bool { return systemMemoryMetrics[r.Metric] })
+	}
 
 	// Adding raw memory statistics records
 	for _, record := range serviceMetrics.MemoryStatistics.RawMemStatsRecords {
@@ -214,8 +620,31 @@ func generateNetworkIORows(serviceMetrics *models.ServiceStats, label Label, tim
 	}
 }
 
+// generateDiskIORows generates rows for cumulative disk IO statistics.
+func generateDiskIORows(serviceMetrics *models.ServiceStats, label Label, timestamp int64) []Row {
+	return []Row{
+		{
+			Metric:    "disk_read_bytes",
+			DataPoint: DataPoint{Timestamp: timestamp, Value: float64(serviceMetrics.DiskIO.ReadBytes)},
+			Labels:    []Label{label},
+		},
+		{
+			Metric:    "disk_write_bytes",
+			DataPoint: DataPoint{Timestamp: timestamp, Value: float64(serviceMetrics.DiskIO.WriteBytes)},
+			Labels:    []Label{label},
+		},
+	}
+}
+
 // generateHealthStatsRows generates rows for service and system health statistics.
+// No rows are generated while health is initializing, so the warmup doesn't
+// show as a drop to 0%.
 func generateHealthStatsRows(serviceMetrics *models.ServiceStats, label Label, timestamp int64) []Row {
+	// Health scored without its CPU or memory inputs reads as 0%.
+	if serviceMetrics.Health.ServiceHealth.Initializing ||
+		serviceMetrics.CPUStatistics.Unavailable || serviceMetrics.MemoryStatistics.Unavailable {
+		return nil
+	}
 	return []Row{
 		{
 			Metric:    "service_health_percent",
@@ -1,12 +1,20 @@
 package timeseries
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
 	"testing"
 	"time"
 
 	"github.com/iyashjayesh/monigo/common"
+	"github.com/iyashjayesh/monigo/core"
+	"github.com/iyashjayesh/monigo/internal/registry"
 	"github.com/iyashjayesh/monigo/models"
+	"github.com/nakabonne/tstorage"
 )
 
 func init() {
@@ -72,6 +80,131 @@ func TestGetHostLabel(t *testing.T) {
 	}
 }
 
+// recordingStorage captures inserted rows for assertions on labels.
+type recordingStorage struct {
+	*InMemoryStorage
+	rows      []Row
+	insertErr error // returned by InsertRows when set
+}
+
+func (s *recordingStorage) InsertRows(rows []Row) error {
+	if s.insertErr != nil {
+		return s.insertErr
+	}
+	s.rows = append(s.rows, rows...)
+	return s.InMemoryStorage.InsertRows(rows)
+}
+
+// useRecordingStorage installs a recordingStorage as the storage singleton.
+func useRecordingStorage() *recordingStorage {
+	rec := &recordingStorage{InMemoryStorage: NewInMemoryStorage()}
+	manager = &storageManager{}
+	manager.once.Do(func() {
+		manager.storage = rec
+		manager.ctx, manager.cancel = context.WithCancel(context.Background())
+	})
+	return rec
+}
+
+func TestSetHostLabel(t *testing.T) {
+	rec := useRecordingStorage()
+	SetHostLabel("orders-api")
+	defer SetHostLabel("")
+
+	stats := models.ServiceStats{}
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+	if len(rec.rows) == 0 {
+		t.Fatal("expected rows to be stored")
+	}
+	for _, row := range rec.rows {
+		if len(row.Labels) == 0 || row.Labels[0] != (Label{Name: "host", Value: "orders-api"}) {
+			t.Fatalf("row %s: expected host=orders-api label, got %v", row.Metric, row.Labels)
+		}
+	}
+
+	SetHostLabel("")
+	hostname, _ := os.Hostname()
+	if got := GetHostLabel().Value; got != hostname {
+		t.Errorf("expected host label to fall back to %q, got %q", hostname, got)
+	}
+}
+
+func TestStoreServiceMetrics_CustomLoadCalculator(t *testing.T) {
+	rec := useRecordingStorage()
+	core.SetLoadCalculator(func(cpu, mem, disk float64) (float64, string) {
+		v := 0.7*cpu + 0.3*mem + 10
+		return v, common.ParseFloat64ToString(v) + "%"
+	})
+	defer core.SetLoadCalculator(nil)
+
+	stats := models.ServiceStats{LoadStatistics: core.GetLoadStatistics()}
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+
+	want := 0.7*stats.LoadStatistics.ServiceCPULoadRaw + 0.3*stats.LoadStatistics.ServiceMemLoadRaw + 10
+	for _, row := range rec.rows {
+		if row.Metric != "overall_load_of_service" {
+			continue
+		}
+		if row.DataPoint.Value != want {
+			t.Errorf("expected stored overall load %v, got %v", want, row.DataPoint.Value)
+		}
+		return
+	}
+	t.Fatal("expected overall_load_of_service to be stored")
+}
+
+func TestSetTags(t *testing.T) {
+	rec := useRecordingStorage()
+	SetTags(map[string]string{"region": "us-east", "env": "prod", "host": "ignored"})
+	defer SetTags(nil)
+
+	stats := models.ServiceStats{CoreStatistics: models.CoreStatistics{Goroutines: 7}}
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+
+	want := []Label{GetHostLabel(), {Name: "env", Value: "prod"}, {Name: "region", Value: "us-east"}}
+	for _, row := range rec.rows {
+		if len(row.Labels) != len(want) {
+			t.Fatalf("row %s: expected labels %v, got %v", row.Metric, want, row.Labels)
+		}
+		for i := range want {
+			if row.Labels[i] != want[i] {
+				t.Fatalf("row %s: expected labels %v, got %v", row.Metric, want, row.Labels)
+			}
+		}
+	}
+
+	now := time.Now().Unix()
+	points, err := GetDataPoints("goroutines", []Label{{Name: "env", Value: "prod"}}, now-10, now+10)
+	if err != nil {
+		t.Fatalf("GetDataPoints error: %v", err)
+	}
+	if len(points) != 1 || points[0].Value != 7 {
+		t.Errorf("expected 1 goroutines point for env=prod, got %v", points)
+	}
+
+	points, err = GetDataPoints("goroutines", []Label{{Name: "env", Value: "staging"}}, now-10, now+10)
+	if err != nil {
+		t.Fatalf("GetDataPoints error: %v", err)
+	}
+	if len(points) != 0 {
+		t.Errorf("expected no goroutines points for env=staging, got %v", points)
+	}
+
+	points, err = GetDataPoints("goroutines", SeriesLabels(), now-10, now+10)
+	if err != nil {
+		t.Fatalf("GetDataPoints error: %v", err)
+	}
+	if len(points) != 1 {
+		t.Errorf("expected SeriesLabels to select the tagged series, got %v", points)
+	}
+}
+
 func TestStoreAndRetrieveMetrics(t *testing.T) {
 	// Use in-memory storage for tests
 	SetStorageType("memory")
@@ -149,3 +282,1350 @@ func TestStoreAndRetrieveMetrics(t *testing.T) {
 	// Cleanup
 	CloseStorage()
 }
+
+func TestStoreServiceMetrics_Dedup(t *testing.T) {
+	countPoints := func(dedupOn bool) int {
+		SetStorageType("memory")
+		manager = &storageManager{} // Reset singleton
+		SetDedupEnabled(dedupOn)
+		defer SetDedupEnabled(false)
+
+		stats := models.ServiceStats{
+			CPUStatistics: models.CPUStatistics{TotalCores: 8},
+		}
+		for i := 0; i < 50; i++ {
+			if err := StoreServiceMetrics(&stats); err != nil {
+				t.Fatalf("StoreServiceMetrics error: %v", err)
+			}
+		}
+
+		now := time.Now().Unix()
+		points, err := GetDataPoints("total_cores", []Label{GetHostLabel()}, now-60, now+60)
+		if err != nil {
+			t.Fatalf("GetDataPoints error: %v", err)
+		}
+		return len(points)
+	}
+
+	without := countPoints(false)
+	with := countPoints(true)
+
+	if without != 50 {
+		t.Fatalf("expected 50 points without dedup, got %d", without)
+	}
+	// One point every defaultDedupMaxSkips+1 stores keeps the series fresh.
+	if expected := (50 + defaultDedupMaxSkips) / (defaultDedupMaxSkips + 1); with != expected {
+		t.Errorf("expected %d points with dedup, got %d", expected, with)
+	}
+}
+
+func TestDedupRows_ValueChange(t *testing.T) {
+	SetDedupEnabled(true)
+	defer SetDedupEnabled(false)
+
+	label := Label{Name: "host", Value: "a"}
+	row := func(v float64) Row {
+		return Row{Metric: "m", DataPoint: DataPoint{Value: v}, Labels: []Label{label}}
+	}
+
+	var written int
+	for _, v := range []float64{1, 1, 1, 2, 2, 3} {
+		kept, commit := dedupRows([]Row{row(v)})
+		commit()
+		written += len(kept)
+	}
+	if written != 3 {
+		t.Errorf("expected 3 rows written (one per distinct run), got %d", written)
+	}
+}
+
+func TestStoreServiceMetrics_DedupAfterFailedInsert(t *testing.T) {
+	rec := useRecordingStorage()
+	SetDedupEnabled(true)
+	defer SetDedupEnabled(false)
+
+	stats := models.ServiceStats{}
+	rec.insertErr = errors.New("disk full")
+	if err := StoreServiceMetrics(&stats); err == nil {
+		t.Fatal("expected insert error")
+	}
+
+	rec.insertErr = nil
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+	if len(rec.rows) == 0 {
+		t.Error("expected rows to be written after a failed insert, got none")
+	}
+}
+
+func registryValue(name string) float64 {
+	if m, ok := registry.Default().Get(name); ok {
+		return m.Value
+	}
+	return 0
+}
+
+func TestRunSyncCycle_Overrun(t *testing.T) {
+	SetStorageType("memory")
+	manager = &storageManager{} // Reset singleton
+
+	orig := collectServiceStats
+	collectServiceStats = func(context.Context) models.ServiceStats {
+		time.Sleep(20 * time.Millisecond)
+		return models.ServiceStats{}
+	}
+	defer func() { collectServiceStats = orig }()
+
+	before := registryValue(registry.SyncCycleOverrunsTotal)
+
+	if err := runSyncCycle(context.Background(), time.Hour); err != nil {
+		t.Fatalf("runSyncCycle error: %v", err)
+	}
+	if got := registryValue(registry.SyncCycleOverrunsTotal); got != before {
+		t.Errorf("expected no overrun for a fast cycle, counter went from %v to %v", before, got)
+	}
+
+	if err := runSyncCycle(context.Background(), 5*time.Millisecond); err != nil {
+		t.Fatalf("runSyncCycle error: %v", err)
+	}
+	if got := registryValue(registry.SyncCycleOverrunsTotal); got != before+1 {
+		t.Errorf("expected overrun counter %v, got %v", before+1, got)
+	}
+	if d := registryValue(registry.SyncCycleDurationSeconds); d < 0.02 {
+		t.Errorf("expected recorded cycle duration >= 20ms, got %vs", d)
+	}
+}
+
+func TestLatestServiceStats(t *testing.T) {
+	SetStorageType("memory")
+	manager = &storageManager{} // Reset singleton
+
+	if _, err := LatestServiceStats(); err != ErrNoStoredStats {
+		t.Fatalf("expected ErrNoStoredStats on empty storage, got %v", err)
+	}
+
+	stats := models.ServiceStats{
+		CoreStatistics:   models.CoreStatistics{Goroutines: 12},
+		CPUStatistics:    models.CPUStatistics{TotalCores: 8},
+		MemoryStatistics: models.MemoryStatistics{TotalSystemMemoryRaw: 16e9},
+	}
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+
+	latest, err := LatestServiceStats()
+	if err != nil {
+		t.Fatalf("LatestServiceStats error: %v", err)
+	}
+	if latest.CoreStatistics.Goroutines != 12 {
+		t.Errorf("expected goroutines 12, got %d", latest.CoreStatistics.Goroutines)
+	}
+	if latest.CPUStatistics.TotalCores != 8 {
+		t.Errorf("expected total cores 8, got %f", latest.CPUStatistics.TotalCores)
+	}
+	if latest.MemoryStatistics.TotalSystemMemoryRaw != 16e9 {
+		t.Errorf("expected total memory 16e9, got %f", latest.MemoryStatistics.TotalSystemMemoryRaw)
+	}
+}
+
+func TestLatestServiceStats_Disk(t *testing.T) {
+	sto, _ := useDiskStorage(t)
+
+	if _, err := LatestServiceStats(); !errors.Is(err, ErrNoStoredStats) {
+		t.Fatalf("expected ErrNoStoredStats on empty disk storage, got %v", err)
+	}
+
+	row := Row{Metric: "goroutines", Labels: SeriesLabels(), DataPoint: DataPoint{Timestamp: time.Now().Unix(), Value: 7}}
+	if err := sto.InsertRows([]Row{row}); err != nil {
+		t.Fatalf("InsertRows error: %v", err)
+	}
+	stats, err := LatestServiceStats()
+	if err != nil {
+		t.Fatalf("LatestServiceStats error: %v", err)
+	}
+	if stats.CoreStatistics.Goroutines != 7 {
+		t.Errorf("expected 7 goroutines, got %d", stats.CoreStatistics.Goroutines)
+	}
+}
+
+func TestCloseStorage_StopsSyncLoop(t *testing.T) {
+	SetStorageType("memory")
+	manager = &storageManager{} // Reset singleton
+
+	if err := SetDataPointsSyncFrequency("1h"); err != nil {
+		t.Fatalf("SetDataPointsSyncFrequency error: %v", err)
+	}
+	if !IsSyncLoopRunning() {
+		t.Fatal("expected sync loop to be running")
+	}
+
+	if err := CloseStorage(); err != nil {
+		t.Fatalf("CloseStorage error: %v", err)
+	}
+	if IsSyncLoopRunning() {
+		t.Error("expected CloseStorage to wait for the sync loop to exit")
+	}
+}
+
+func TestMetricsDelta(t *testing.T) {
+	SetStorageType("memory")
+	manager = &storageManager{} // Reset singleton
+
+	sto, err := GetStorageInstance()
+	if err != nil {
+		t.Fatalf("GetStorageInstance error: %v", err)
+	}
+
+	label := GetHostLabel()
+	now := time.Now()
+	t1, t2 := now.Add(-time.Minute).Unix(), now.Unix()
+	rows := []Row{
+		{Metric: "bytes_sent", DataPoint: DataPoint{Timestamp: t1, Value: 1000}, Labels: []Label{label}},
+		{Metric: "bytes_sent", DataPoint: DataPoint{Timestamp: t2, Value: 1500}, Labels: []Label{label}},
+		{Metric: "num_gc", DataPoint: DataPoint{Timestamp: t1, Value: 10}, Labels: []Label{label}},
+		{Metric: "num_gc", DataPoint: DataPoint{Timestamp: t2, Value: 14}, Labels: []Label{label}},
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatalf("InsertRows error: %v", err)
+	}
+
+	delta, err := MetricsDelta(now.Add(-2*time.Minute), now)
+	if err != nil {
+		t.Fatalf("MetricsDelta error: %v", err)
+	}
+	if delta.Deltas["bytes_sent"] != 500 {
+		t.Errorf("expected bytes_sent delta 500, got %f", delta.Deltas["bytes_sent"])
+	}
+	if delta.Deltas["num_gc"] != 4 {
+		t.Errorf("expected num_gc delta 4, got %f", delta.Deltas["num_gc"])
+	}
+	if delta.Deltas["bytes_received"] != 0 {
+		t.Errorf("expected bytes_received delta 0 without data, got %f", delta.Deltas["bytes_received"])
+	}
+}
+
+func TestMetricsDelta_Disk(t *testing.T) {
+	sto, _ := useDiskStorage(t)
+
+	label := GetHostLabel()
+	now := time.Now()
+	t1, t2 := now.Add(-2*time.Minute).Unix(), now.Add(-time.Minute).Unix()
+	rows := []Row{
+		{Metric: "bytes_sent", DataPoint: DataPoint{Timestamp: t1, Value: 1000}, Labels: []Label{label}},
+		{Metric: "bytes_sent", DataPoint: DataPoint{Timestamp: t2, Value: 1500}, Labels: []Label{label}},
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatalf("InsertRows error: %v", err)
+	}
+
+	delta, err := MetricsDelta(now.Add(-3*time.Minute), now)
+	if err != nil {
+		t.Fatalf("MetricsDelta error: %v", err)
+	}
+	if delta.Deltas["bytes_sent"] != 500 {
+		t.Errorf("expected bytes_sent delta 500, got %f", delta.Deltas["bytes_sent"])
+	}
+
+	// A window newer than the last stored point has no data at all.
+	delta, err = MetricsDelta(now.Add(-30*time.Second), now)
+	if err != nil {
+		t.Fatalf("MetricsDelta error for empty window: %v", err)
+	}
+	for metric, v := range delta.Deltas {
+		if v != 0 {
+			t.Errorf("expected %s delta 0 for empty window, got %f", metric, v)
+		}
+	}
+}
+
+func TestCounterIncrease_Reset(t *testing.T) {
+	points := []DataPoint{
+		{Timestamp: 1, Value: 100},
+		{Timestamp: 2, Value: 150},
+		{Timestamp: 3, Value: 20}, // process restarted
+		{Timestamp: 4, Value: 50},
+	}
+	if got := counterIncrease(points); got != 100 {
+		t.Errorf("expected increase 100 across reset, got %f", got)
+	}
+}
+
+func TestNormalizeSyncFrequency(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"", DefaultSyncFrequency},
+		{"1ns", MinSyncFrequency},
+		{"10ms", MinSyncFrequency},
+		{"30s", 30 * time.Second},
+		{"5m", 5 * time.Minute},
+		{"1w", MaxSyncFrequency},
+	}
+	for _, tt := range tests {
+		got, err := normalizeSyncFrequency(tt.input)
+		if err != nil {
+			t.Errorf("normalizeSyncFrequency(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeSyncFrequency(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSetDataPointsSyncFrequency_Invalid(t *testing.T) {
+	SetStorageType("memory")
+	manager = &storageManager{} // Reset singleton
+
+	if err := SetDataPointsSyncFrequency("every five minutes"); err == nil {
+		t.Fatal("expected error for unparseable frequency")
+	}
+	if IsSyncLoopRunning() {
+		t.Error("expected sync loop not to start on invalid frequency")
+	}
+}
+
+func TestDeleteMetric_InMemory(t *testing.T) {
+	SetStorageType("memory")
+	manager = &storageManager{} // Reset singleton
+
+	sto, err := GetStorageInstance()
+	if err != nil {
+		t.Fatalf("GetStorageInstance error: %v", err)
+	}
+
+	label := GetHostLabel()
+	now := time.Now().Unix()
+	rows := []Row{
+		{Metric: "cpu_load", DataPoint: DataPoint{Timestamp: now, Value: 10}, Labels: []Label{label}},
+		{Metric: "mem_load", DataPoint: DataPoint{Timestamp: now, Value: 20}, Labels: []Label{label}},
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatalf("InsertRows error: %v", err)
+	}
+
+	if err := DeleteMetric("cpu_load", []Label{label}); err != nil {
+		t.Fatalf("DeleteMetric error: %v", err)
+	}
+
+	points, _ := GetDataPoints("cpu_load", []Label{label}, now-10, now+10)
+	if len(points) != 0 {
+		t.Errorf("expected cpu_load to be deleted, got %v", points)
+	}
+	points, _ = GetDataPoints("mem_load", []Label{label}, now-10, now+10)
+	if len(points) != 1 {
+		t.Errorf("expected mem_load to remain, got %v", points)
+	}
+}
+
+func TestDeleteMetric_StorageWrapperTombstone(t *testing.T) {
+	ts, err := tstorage.NewStorage() // no data path: tstorage keeps everything in memory
+	if err != nil {
+		t.Fatalf("tstorage.NewStorage error: %v", err)
+	}
+	sto := &StorageWrapper{storage: ts}
+	defer sto.Close()
+
+	label := Label{Name: "host", Value: "test"}
+	past := time.Now().Add(-time.Minute).Unix()
+	rows := []Row{
+		{Metric: "cpu_load", DataPoint: DataPoint{Timestamp: past, Value: 10}, Labels: []Label{label}},
+		{Metric: "mem_load", DataPoint: DataPoint{Timestamp: past, Value: 20}, Labels: []Label{label}},
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatalf("InsertRows error: %v", err)
+	}
+
+	if err := sto.DeleteMetric("cpu_load", nil); err != nil {
+		t.Fatalf("DeleteMetric error: %v", err)
+	}
+
+	now := time.Now().Unix()
+	if points, _ := sto.Select("cpu_load", []Label{label}, past-10, now+10); len(points) != 0 {
+		t.Errorf("expected cpu_load to be hidden by tombstone, got %v", points)
+	}
+	if points, _ := sto.Select("mem_load", []Label{label}, past-10, now+10); len(points) != 1 {
+		t.Errorf("expected mem_load to remain, got %v", points)
+	}
+}
+
+// useDiskStorage installs tstorage disk storage in a temp data dir as the
+// storage singleton and returns the dir.
+func useDiskStorage(t *testing.T) (Storage, string) {
+	t.Helper()
+	dir := t.TempDir()
+	origDir := dataDir
+	dataDir = func() string { return dir }
+
+	SetStorageType("disk")
+	manager = &storageManager{} // Reset singleton
+	t.Cleanup(func() {
+		CloseStorage()
+		SetStorageType("memory")
+		dataDir = origDir
+	})
+
+	sto, err := GetStorageInstance()
+	if err != nil {
+		t.Fatalf("GetStorageInstance error: %v", err)
+	}
+	return sto, dir
+}
+
+func TestSetTags_DiskSubsetMatch(t *testing.T) {
+	sto, _ := useDiskStorage(t)
+	SetTags(map[string]string{"region": "us-east", "env": "prod"})
+	defer SetTags(nil)
+
+	now := time.Now().Unix()
+	rows := []Row{
+		{Metric: "goroutines", Labels: SeriesLabels(), DataPoint: DataPoint{Timestamp: now - 2, Value: 7}},
+		{Metric: "goroutines", Labels: []Label{GetHostLabel(), {Name: "env", Value: "dev"}}, DataPoint: DataPoint{Timestamp: now - 1, Value: 9}},
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatalf("InsertRows error: %v", err)
+	}
+
+	points, err := GetDataPoints("goroutines", []Label{{Name: "env", Value: "prod"}}, now-10, now+10)
+	if err != nil {
+		t.Fatalf("GetDataPoints error: %v", err)
+	}
+	if len(points) != 1 || points[0].Value != 7 {
+		t.Errorf("expected 1 goroutines point for env=prod, got %v", points)
+	}
+
+	points, err = GetDataPoints("goroutines", []Label{GetHostLabel()}, now-10, now+10)
+	if err != nil {
+		t.Fatalf("GetDataPoints error: %v", err)
+	}
+	if len(points) != 2 || points[0].Value != 7 || points[1].Value != 9 {
+		t.Errorf("expected both series in timestamp order for the host label, got %v", points)
+	}
+
+	if _, err := GetDataPoints("goroutines", []Label{{Name: "env", Value: "staging"}}, now-10, now+10); !isNoDataPoints(err) {
+		t.Errorf("expected no data points for env=staging, got %v", err)
+	}
+}
+
+func TestStorageStats_Disk(t *testing.T) {
+	sto, dir := useDiskStorage(t)
+
+	now := time.Now().Unix()
+	const n = 50
+	rows := make([]Row, 0, n)
+	for i := 0; i < n; i++ {
+		rows = append(rows, Row{
+			Metric:    "goroutines",
+			Labels:    SeriesLabels(),
+			DataPoint: DataPoint{Timestamp: now - int64(n-i), Value: float64(i)},
+		})
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatalf("InsertRows error: %v", err)
+	}
+	// Closing flushes the buffered WAL and the partitions to dir; reopen to read them.
+	if err := CloseStorage(); err != nil {
+		t.Fatalf("CloseStorage error: %v", err)
+	}
+	manager = &storageManager{}
+
+	stats, err := StorageStats()
+	if err != nil {
+		t.Fatalf("StorageStats error: %v", err)
+	}
+	if stats.StorageType != "disk" || stats.DataDir != dir {
+		t.Errorf("expected disk storage in %s, got %s in %s", dir, stats.StorageType, stats.DataDir)
+	}
+	if stats.SizeBytes <= 0 {
+		t.Errorf("expected on-disk size > 0 after inserts, got %d", stats.SizeBytes)
+	}
+	if stats.PointCount != n {
+		t.Errorf("expected %d points, got %d", n, stats.PointCount)
+	}
+	if stats.Oldest == nil || stats.Oldest.Unix() != now-n {
+		t.Errorf("expected oldest %d, got %v", now-n, stats.Oldest)
+	}
+	if stats.Newest == nil || stats.Newest.Unix() != now-1 {
+		t.Errorf("expected newest %d, got %v", now-1, stats.Newest)
+	}
+}
+
+func TestStorageStats_Empty(t *testing.T) {
+	SetStorageType("memory")
+	manager = &storageManager{} // Reset singleton
+
+	stats, err := StorageStats()
+	if err != nil {
+		t.Fatalf("StorageStats error: %v", err)
+	}
+	if stats.StorageType != "memory" || stats.SizeBytes != 0 || stats.PointCount != 0 {
+		t.Errorf("expected empty in-memory stats, got %+v", stats)
+	}
+	if stats.Oldest != nil || stats.Newest != nil {
+		t.Errorf("expected no timestamp bounds, got %v - %v", stats.Oldest, stats.Newest)
+	}
+}
+
+type queueDepthCollector struct{ depth float64 }
+
+func (c *queueDepthCollector) Collect() []Row {
+	c.depth++
+	return []Row{{Metric: "queue_depth", DataPoint: DataPoint{Value: c.depth}}}
+}
+
+func TestRunSyncCycle_SetsRegistryGauges(t *testing.T) {
+	useRecordingStorage()
+	SetHostLabel("orders-api")
+	defer SetHostLabel("")
+
+	orig := collectServiceStats
+	collectServiceStats = func(context.Context) models.ServiceStats {
+		var stats models.ServiceStats
+		stats.CoreStatistics.Goroutines = 42
+		stats.LoadStatistics.ServiceCPULoadRaw = 12.5
+		stats.MemoryStatistics.MemoryUsedByServiceRaw = 2048
+		stats.HeapAllocByServiceRaw = 1024
+		stats.Health.ServiceHealth.Percent = 90
+		return stats
+	}
+	defer func() { collectServiceStats = orig }()
+
+	if err := runSyncCycle(context.Background(), time.Hour); err != nil {
+		t.Fatalf("runSyncCycle error: %v", err)
+	}
+
+	want := map[string]float64{
+		"monigo_goroutines":             42,
+		"monigo_service_cpu_load":       12.5,
+		"monigo_memory_used_by_service": 2048,
+		"monigo_heap_alloc_by_service":  1024,
+		"monigo_service_health_percent": 90,
+	}
+	for name, value := range want {
+		series := registry.Default().GetByPrefix(name)
+		if len(series) == 0 {
+			t.Errorf("expected %s in the registry", name)
+			continue
+		}
+		m := series[0]
+		if m.Value != value || m.Type != registry.Gauge {
+			t.Errorf("expected gauge %s=%v, got %v (type %v)", name, value, m.Value, m.Type)
+		}
+		if m.Labels["host"] != "orders-api" {
+			t.Errorf("expected %s to carry the host label, got %v", name, m.Labels)
+		}
+	}
+}
+
+func TestRunSyncCycle_StoresCollectorRows(t *testing.T) {
+	rec := useRecordingStorage()
+	core.RegisterCollector(&queueDepthCollector{})
+
+	orig := collectServiceStats
+	collectServiceStats = func(context.Context) models.ServiceStats { return models.ServiceStats{} }
+	defer func() { collectServiceStats = orig }()
+
+	for cycle := 1; cycle <= 2; cycle++ {
+		if err := runSyncCycle(context.Background(), time.Hour); err != nil {
+			t.Fatalf("runSyncCycle error: %v", err)
+		}
+
+		var got []Row
+		for _, row := range rec.rows {
+			if row.Metric == "queue_depth" {
+				got = append(got, row)
+			}
+		}
+		if len(got) != cycle {
+			t.Fatalf("cycle %d: expected %d queue_depth rows, got %d", cycle, cycle, len(got))
+		}
+		last := got[len(got)-1]
+		if last.DataPoint.Value != float64(cycle) {
+			t.Errorf("cycle %d: expected value %d, got %v", cycle, cycle, last.DataPoint.Value)
+		}
+		if last.DataPoint.Timestamp == 0 {
+			t.Errorf("cycle %d: expected the cycle timestamp to be filled in", cycle)
+		}
+		if len(last.Labels) == 0 || last.Labels[0].Name != "host" {
+			t.Errorf("cycle %d: expected series labels, got %v", cycle, last.Labels)
+		}
+	}
+}
+
+func TestStoreServiceMetrics_PerCoreRows(t *testing.T) {
+	rec := useRecordingStorage()
+	SetTags(map[string]string{"env": "prod"})
+	defer SetTags(nil)
+
+	stats := models.ServiceStats{CPUStatistics: models.CPUStatistics{PerCore: []float64{12.5, 80}}}
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+
+	got := map[string]float64{}
+	for _, row := range rec.rows {
+		if row.Metric != "cpu_core_usage" {
+			continue
+		}
+		labels := map[string]string{}
+		for _, l := range row.Labels {
+			labels[l.Name] = l.Value
+		}
+		if labels["env"] != "prod" || labels["host"] == "" {
+			t.Errorf("expected series labels on per-core row, got %v", row.Labels)
+		}
+		got[labels["core"]] = row.DataPoint.Value
+	}
+	if len(got) != 2 || got["0"] != 12.5 || got["1"] != 80 {
+		t.Errorf("expected per-core rows for cores 0 and 1, got %v", got)
+	}
+}
+
+func TestStoreServiceMetrics_SkipsUnavailable(t *testing.T) {
+	rec := useRecordingStorage()
+
+	stats := models.ServiceStats{
+		LoadStatistics:       models.LoadStatistics{Unavailable: true},
+		CPUStatistics:        models.CPUStatistics{Unavailable: true},
+		MemoryStatistics:     models.MemoryStatistics{Unavailable: true, StackMemoryUsageRaw: 4096},
+		NetworkIOUnavailable: true,
+		DiskIOUnavailable:    true,
+	}
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+
+	stored := map[string]bool{}
+	for _, row := range rec.rows {
+		stored[row.Metric] = true
+	}
+	for _, metric := range []string{
+		"overall_load_of_service", "service_cpu_load", "cores_used_by_service",
+		"total_system_memory", "available_memory", "stack_memory_usage",
+		"bytes_sent", "disk_read_bytes", "service_health_percent", "system_health_percent",
+	} {
+		if stored[metric] {
+			t.Errorf("expected unavailable metric %s not to be stored", metric)
+		}
+	}
+	if !stored["goroutines"] {
+		t.Error("expected goroutines to be stored")
+	}
+}
+
+func TestStoreServiceMetrics_SkipsHealthWithUnavailableInputs(t *testing.T) {
+	rec := useRecordingStorage()
+
+	// The memory is stored, but health scored without the CPU is not.
+	stats := models.ServiceStats{CPUStatistics: models.CPUStatistics{Unavailable: true}}
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+
+	stored := map[string]bool{}
+	for _, row := range rec.rows {
+		stored[row.Metric] = true
+	}
+	if stored["service_health_percent"] || stored["system_health_percent"] {
+		t.Error("expected health not to be stored without its CPU input")
+	}
+	if !stored["total_system_memory"] {
+		t.Error("expected the available memory statistics to be stored")
+	}
+}
+
+func TestInMemoryStorage_Dump(t *testing.T) {
+	s := NewInMemoryStorage()
+	web := []Label{{Name: "host", Value: "a"}, {Name: "env", Value: "prod"}}
+	worker := []Label{{Name: "host", Value: "b"}, {Name: "env", Value: "dev"}}
+	if err := s.InsertRows([]Row{
+		{Metric: "goroutines", Labels: worker, DataPoint: DataPoint{Timestamp: 20, Value: 7}},
+		{Metric: "goroutines", Labels: web, DataPoint: DataPoint{Timestamp: 10, Value: 5}},
+		{Metric: "cpu_core_usage", Labels: append(web, Label{Name: "core", Value: "0"}), DataPoint: DataPoint{Timestamp: 10, Value: 42}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Row{
+		{Metric: "cpu_core_usage", Labels: []Label{{Name: "host", Value: "a"}, {Name: "env", Value: "prod"}, {Name: "core", Value: "0"}}, DataPoint: DataPoint{Timestamp: 10, Value: 42}},
+		{Metric: "goroutines", Labels: web, DataPoint: DataPoint{Timestamp: 10, Value: 5}},
+		{Metric: "goroutines", Labels: worker, DataPoint: DataPoint{Timestamp: 20, Value: 7}},
+	}
+	if got := s.Dump(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Dump() = %+v, want %+v", got, want)
+	}
+
+	// Dumped labels are copies.
+	s.Dump()[0].Labels[0].Value = "changed"
+	if s.Dump()[0].Labels[0].Value != "a" {
+		t.Error("expected mutating a dumped row not to affect storage")
+	}
+}
+
+func TestNextSyncDelay_Jitter(t *testing.T) {
+	defer SetSyncJitter(0)
+	interval := time.Minute
+
+	if got := nextSyncDelay(interval); got != interval {
+		t.Errorf("expected no jitter by default, got %v", got)
+	}
+
+	SetSyncJitter(0.1)
+	lo, hi := 54*time.Second, 66*time.Second
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		d := nextSyncDelay(interval)
+		if d < lo || d > hi {
+			t.Fatalf("delay %v outside the ±10%% bound [%v, %v]", d, lo, hi)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Error("expected consecutive cycle intervals to vary")
+	}
+
+	SetSyncJitter(5)
+	for i := 0; i < 100; i++ {
+		if d := nextSyncDelay(interval); d < interval/2 || d > interval*3/2 {
+			t.Fatalf("delay %v outside the clamped ±%v bound", d, MaxSyncJitter)
+		}
+	}
+}
+
+func TestPauseSync(t *testing.T) {
+	rs := useRecordingStorage()
+	defer ResumeSync()
+
+	orig := collectServiceStats
+	collectServiceStats = func(context.Context) models.ServiceStats {
+		time.Sleep(5 * time.Millisecond)
+		return models.ServiceStats{}
+	}
+	defer func() { collectServiceStats = orig }()
+	overruns := registryValue(registry.SyncCycleOverrunsTotal)
+
+	PauseSync()
+	if !IsSyncPaused() {
+		t.Fatal("expected the sync to be paused")
+	}
+	for i := 0; i < 3; i++ {
+		if err := runSyncCycle(context.Background(), time.Millisecond); err != nil {
+			t.Fatalf("runSyncCycle error: %v", err)
+		}
+	}
+	if len(rs.rows) != 0 {
+		t.Errorf("expected no rows stored while paused, got %d", len(rs.rows))
+	}
+	if got := registryValue(registry.SyncCycleOverrunsTotal); got != overruns {
+		t.Errorf("expected paused cycles not to count as overruns, counter went from %v to %v", overruns, got)
+	}
+
+	ResumeSync()
+	if err := runSyncCycle(context.Background(), time.Hour); err != nil {
+		t.Fatalf("runSyncCycle error: %v", err)
+	}
+	if len(rs.rows) == 0 {
+		t.Error("expected rows to be stored after resuming")
+	}
+}
+
+func TestNamespaceLabels(t *testing.T) {
+	SetHostLabel("node-1")
+	SetTags(map[string]string{"service": "tagged", "region": "eu", "env": "prod"})
+	defer SetHostLabel("")
+	defer SetTags(nil)
+
+	want := []Label{
+		{Name: "host", Value: "node-1"},
+		{Name: "env", Value: "prod"},
+		{Name: "region", Value: "eu"},
+		{Name: "service", Value: "orders"},
+	}
+	if got := NamespaceLabels("orders"); !reflect.DeepEqual(got, want) {
+		t.Errorf("NamespaceLabels = %v, want %v", got, want)
+	}
+	if got := NamespaceLabels(""); !reflect.DeepEqual(got, SeriesLabels()) {
+		t.Errorf("expected the default namespace to use SeriesLabels, got %v", got)
+	}
+}
+
+func TestStoreServiceMetrics_SharedAcrossNamespaces(t *testing.T) {
+	rec := useRecordingStorage()
+	if err := StoreServiceMetrics(&models.ServiceStats{}); err != nil {
+		t.Fatalf("StoreServiceMetrics error: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, row := range rec.rows {
+		if hasLabelName(row.Labels, ServiceLabelName) {
+			t.Errorf("row %s: expected no service label, got %v", row.Metric, row.Labels)
+		}
+		if seen[row.Metric] {
+			t.Errorf("row %s: stored more than once", row.Metric)
+		}
+		seen[row.Metric] = true
+	}
+}
+
+func TestMetricLabels_Selection(t *testing.T) {
+	useRecordingStorage()
+	sto, err := GetStorageInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Unix()
+	rows := []Row{
+		{Metric: "goroutines", Labels: SeriesLabels(), DataPoint: DataPoint{Timestamp: now, Value: 7}},
+		{Metric: FunctionExecutionMetric, Labels: append(SeriesLabels(), Label{Name: "function", Value: "f"}), DataPoint: DataPoint{Timestamp: now, Value: 1}},
+		{Metric: FunctionExecutionMetric, Labels: append(NamespaceLabels("orders"), Label{Name: "function", Value: "f"}), DataPoint: DataPoint{Timestamp: now, Value: 2}},
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		t.Fatalf("InsertRows error: %v", err)
+	}
+
+	values := func(namespace, metric string) []float64 {
+		points, err := sto.Select(metric, MetricLabels(namespace, metric), now-1, now+1)
+		if err != nil {
+			t.Fatalf("Select(%s, %q) error: %v", metric, namespace, err)
+		}
+		var out []float64
+		for _, p := range points {
+			out = append(out, p.Value)
+		}
+		return out
+	}
+	for _, ns := range []string{"", "orders"} {
+		if got := values(ns, "goroutines"); !reflect.DeepEqual(got, []float64{7}) {
+			t.Errorf("namespace %q: expected the shared goroutines point, got %v", ns, got)
+		}
+	}
+	if got := values("", FunctionExecutionMetric); !reflect.DeepEqual(got, []float64{1}) {
+		t.Errorf("default namespace: expected only its own executions, got %v", got)
+	}
+	if got := values("orders", FunctionExecutionMetric); !reflect.DeepEqual(got, []float64{2}) {
+		t.Errorf("orders namespace: expected only its own executions, got %v", got)
+	}
+}
+
+func storedFunctionForTest() {}
+
+func TestStoreFunctionSamples(t *testing.T) {
+	rec := useRecordingStorage()
+	ctx := core.WithNamespace(context.Background(), "store-samples-test")
+	core.TraceFunction(ctx, storedFunctionForTest)
+	core.TraceFunction(ctx, storedFunctionForTest)
+
+	if err := storeFunctionSamples(); err != nil {
+		t.Fatalf("storeFunctionSamples error: %v", err)
+	}
+	countRows := func() (n int) {
+		for _, row := range rec.rows {
+			if row.Metric == FunctionExecutionMetric && hasLabels(row.Labels, []Label{{Name: ServiceLabelName, Value: "store-samples-test"}}) {
+				n++
+			}
+		}
+		return n
+	}
+	if n := countRows(); n != 2 {
+		t.Fatalf("expected 2 stored executions, got %d", n)
+	}
+
+	// Samples already stored are not stored again.
+	core.TraceFunction(ctx, storedFunctionForTest)
+	if err := storeFunctionSamples(); err != nil {
+		t.Fatalf("storeFunctionSamples error: %v", err)
+	}
+	if n := countRows(); n != 3 {
+		t.Errorf("expected 3 stored executions after another call, got %d", n)
+	}
+}
+
+func TestGetSeries(t *testing.T) {
+	for name, setup := range map[string]func(t *testing.T) Storage{
+		"memory": func(t *testing.T) Storage { return useRecordingStorage() },
+		"disk":   func(t *testing.T) Storage { sto, _ := useDiskStorage(t); return sto },
+	} {
+		t.Run(name, func(t *testing.T) {
+			sto := setup(t)
+			now := time.Now().Unix()
+			core0 := append(SeriesLabels(), Label{Name: "core", Value: "0"})
+			core1 := append(SeriesLabels(), Label{Name: "core", Value: "1"})
+			if err := sto.InsertRows([]Row{
+				{Metric: "cpu_core_usage", Labels: core1, DataPoint: DataPoint{Timestamp: now - 1, Value: 20}},
+				{Metric: "cpu_core_usage", Labels: core0, DataPoint: DataPoint{Timestamp: now - 2, Value: 10}},
+				{Metric: "cpu_core_usage", Labels: core0, DataPoint: DataPoint{Timestamp: now - 1, Value: 11}},
+			}); err != nil {
+				t.Fatalf("InsertRows error: %v", err)
+			}
+
+			series, err := GetSeries("cpu_core_usage", []Label{GetHostLabel()}, now-10, now+10)
+			if err != nil {
+				t.Fatalf("GetSeries error: %v", err)
+			}
+			if len(series) != 2 {
+				t.Fatalf("expected a series per core, got %+v", series)
+			}
+			if !hasLabels(series[0].Labels, []Label{{Name: "core", Value: "0"}}) || len(series[0].Points) != 2 || series[0].Points[0].Value != 10 {
+				t.Errorf("unexpected core 0 series %+v", series[0])
+			}
+			if !hasLabels(series[1].Labels, []Label{{Name: "core", Value: "1"}}) || len(series[1].Points) != 1 {
+				t.Errorf("unexpected core 1 series %+v", series[1])
+			}
+
+			series, err = GetSeries("cpu_core_usage", []Label{{Name: "core", Value: "2"}}, now-10, now+10)
+			if err != nil || len(series) != 0 {
+				t.Errorf("expected no series for core 2, got %+v, %v", series, err)
+			}
+		})
+	}
+}
+
+func TestMigrateStorage_MemoryToMemory(t *testing.T) {
+	from, to := NewInMemoryStorage(), NewInMemoryStorage()
+	host := Label{Name: "host", Value: "a"}
+	core0 := Label{Name: "core", Value: "0"}
+
+	var rows []Row
+	for ts := int64(1); ts <= 2500; ts++ { // more than one insert batch
+		rows = append(rows, Row{Metric: "goroutines", Labels: []Label{host}, DataPoint: DataPoint{Timestamp: ts, Value: float64(ts)}})
+	}
+	// A series whose labels contain another's must not be copied twice.
+	rows = append(rows,
+		Row{Metric: "cpu_core_usage", Labels: []Label{host}, DataPoint: DataPoint{Timestamp: 10, Value: 1}},
+		Row{Metric: "cpu_core_usage", Labels: []Label{host, core0}, DataPoint: DataPoint{Timestamp: 10, Value: 2}},
+	)
+	if err := from.InsertRows(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateStorage(from, to); err != nil {
+		t.Fatalf("MigrateStorage error: %v", err)
+	}
+
+	got, want := to.Dump(), from.Dump()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d rows, got %d", len(want), len(got))
+	}
+	series := to.ListSeries()
+	if len(series) != 3 {
+		t.Errorf("expected 3 series, got %v", series)
+	}
+	points, _ := to.Select("cpu_core_usage", []Label{host, core0}, 0, 100)
+	if len(points) != 1 || points[0].Value != 2 {
+		t.Errorf("expected the core series to keep its own point, got %v", points)
+	}
+}
+
+func TestMigrateStorage_MemoryToDisk(t *testing.T) {
+	from := NewInMemoryStorage()
+	labels := []Label{{Name: "host", Value: "a"}, {Name: "env", Value: "prod"}}
+	if err := from.InsertRows([]Row{
+		{Metric: "goroutines", Labels: labels, DataPoint: DataPoint{Timestamp: 100, Value: 5}},
+		{Metric: "goroutines", Labels: labels, DataPoint: DataPoint{Timestamp: 200, Value: 6}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	to, err := NewDiskStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDiskStorage error: %v", err)
+	}
+	defer to.Close()
+	if err := MigrateStorage(from, to); err != nil {
+		t.Fatalf("MigrateStorage error: %v", err)
+	}
+
+	points, err := to.Select("goroutines", labels, 0, 300)
+	if err != nil || len(points) != 2 || points[0].Value != 5 || points[1].Value != 6 {
+		t.Errorf("expected both points on disk, got %v (err %v)", points, err)
+	}
+
+	// The disk storage lists the series it was written, so it can be migrated back.
+	back := NewInMemoryStorage()
+	if err := MigrateStorage(to, back); err != nil {
+		t.Fatalf("MigrateStorage back error: %v", err)
+	}
+	if rows := back.Dump(); len(rows) != 2 {
+		t.Errorf("expected 2 rows migrated back, got %v", rows)
+	}
+}
+
+func TestMigrateStorage_Unsupported(t *testing.T) {
+	if err := MigrateStorage(selectOnlyStorage{}, NewInMemoryStorage()); !errors.Is(err, ErrMigrateNotSupported) {
+		t.Errorf("expected ErrMigrateNotSupported, got %v", err)
+	}
+}
+
+// selectOnlyStorage is a Storage without the optional interfaces.
+type selectOnlyStorage struct{}
+
+func (selectOnlyStorage) InsertRows([]Row) error { return nil }
+func (selectOnlyStorage) Select(string, []Label, int64, int64) ([]DataPoint, error) {
+	return nil, nil
+}
+func (selectOnlyStorage) Close() error { return nil }
+
+func TestCounterRateRows(t *testing.T) {
+	labels := []Label{{Name: "host", Value: "a"}}
+	scrape := func(sent, read float64, ts int64) []Row {
+		stats := models.ServiceStats{}
+		stats.NetworkIO.BytesSent = sent
+		stats.DiskIO.ReadBytes = uint64(read)
+		return serviceMetricsRows(&stats, labels, ts)
+	}
+	find := func(rows []Row, metric string) (Row, bool) {
+		for _, r := range rows {
+			if r.Metric == metric {
+				return r, true
+			}
+		}
+		return Row{}, false
+	}
+
+	for _, tc := range []struct {
+		mode       string
+		wantTotals bool
+	}{
+		{"rate", false},
+		{"both", true},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			if err := SetCounterStorage(tc.mode); err != nil {
+				t.Fatal(err)
+			}
+			defer SetCounterStorage("")
+
+			first, commit := counterRateRows(scrape(1000, 500, 100))
+			commit()
+			if _, ok := find(first, "bytes_sent"+RateMetricSuffix); ok {
+				t.Error("expected no rate from the first scrape")
+			}
+
+			// Two scrapes 10s apart, i.e. one sync interval.
+			second, commit := counterRateRows(scrape(6000, 2500, 110))
+			commit()
+			if r, ok := find(second, "bytes_sent"+RateMetricSuffix); !ok || r.DataPoint.Value != 500 || r.DataPoint.Timestamp != 110 {
+				t.Errorf("expected bytes_sent rate 500/s at 110, got %+v", r)
+			}
+			if r, _ := find(second, "disk_read_bytes"+RateMetricSuffix); r.DataPoint.Value != 200 {
+				t.Errorf("expected disk_read_bytes rate 200/s, got %v", r.DataPoint.Value)
+			}
+			if _, ok := find(second, "bytes_sent"); ok != tc.wantTotals {
+				t.Errorf("expected totals stored = %v", tc.wantTotals)
+			}
+			if _, ok := find(second, "goroutines"); !ok {
+				t.Error("expected other metrics to be kept")
+			}
+
+			// A counter reset counts the new value as the increase.
+			third, commit := counterRateRows(scrape(100, 2500, 120))
+			commit()
+			if r, _ := find(third, "bytes_sent"+RateMetricSuffix); r.DataPoint.Value != 10 {
+				t.Errorf("expected rate 10/s after a reset, got %v", r.DataPoint.Value)
+			}
+		})
+	}
+}
+
+func TestCounterRateRows_TotalsByDefault(t *testing.T) {
+	rows := []Row{{Metric: "bytes_sent", DataPoint: DataPoint{Timestamp: 1, Value: 10}}}
+	out, commit := counterRateRows(rows)
+	commit()
+	if len(out) != 1 || out[0].Metric != "bytes_sent" {
+		t.Errorf("expected rows unchanged, got %v", out)
+	}
+}
+
+func TestSetCounterStorage_Invalid(t *testing.T) {
+	if err := SetCounterStorage("delta"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+// setClock makes StoreServiceMetrics see t as the current time, starting with
+// no stored timestamp, until the returned func restores the real clock.
+func setClock(t time.Time) (restore func()) {
+	forget := func() {
+		clock.mu.Lock()
+		clock.last = 0
+		clock.mu.Unlock()
+	}
+	forget()
+	now = func() time.Time { return t }
+	return func() {
+		now = time.Now
+		forget()
+		SetClockSkewPolicy("")
+	}
+}
+
+func TestStoreServiceMetrics_ClockSkew(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	goroutines := func(rows []Row) []int64 {
+		var ts []int64
+		for _, r := range rows {
+			if r.Metric == "goroutines" {
+				ts = append(ts, r.DataPoint.Timestamp)
+			}
+		}
+		return ts
+	}
+
+	for _, tc := range []struct {
+		policy string
+		want   []int64
+	}{
+		{"clamp", []int64{t0.Unix(), t0.Unix() + 1, t0.Unix() + 2}},
+		{"skip", []int64{t0.Unix()}},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			rec := useRecordingStorage()
+			defer setClock(t0)()
+			if err := SetClockSkewPolicy(tc.policy); err != nil {
+				t.Fatal(err)
+			}
+
+			stats := models.ServiceStats{}
+			for _, at := range []time.Time{t0, t0.Add(-time.Hour), t0.Add(-time.Hour + time.Second)} {
+				now = func() time.Time { return at }
+				if err := StoreServiceMetrics(&stats); err != nil {
+					t.Fatalf("StoreServiceMetrics at %v: %v", at, err)
+				}
+			}
+			if got := goroutines(rec.rows); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected timestamps %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStoreServiceMetrics_ClockSkewDisk(t *testing.T) {
+	disk, err := NewDiskStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disk.Close()
+	manager = &storageManager{}
+	manager.once.Do(func() {
+		manager.storage = disk
+		manager.ctx, manager.cancel = context.WithCancel(context.Background())
+	})
+
+	t0 := time.Now().Truncate(time.Second)
+	defer setClock(t0)()
+	stats := models.ServiceStats{CoreStatistics: models.CoreStatistics{Goroutines: 1}}
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatal(err)
+	}
+	now = func() time.Time { return t0.Add(-10 * time.Minute) }
+	stats.CoreStatistics.Goroutines = 2
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatalf("expected a backward jump to be handled, got %v", err)
+	}
+
+	points, err := GetDataPoints("goroutines", []Label{GetHostLabel()}, t0.Unix()-3600, t0.Unix()+60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 || points[1].Timestamp != t0.Unix()+1 || points[1].Value != 2 {
+		t.Errorf("expected the second point clamped to %d, got %v", t0.Unix()+1, points)
+	}
+}
+
+func TestSetClockSkewPolicy_Invalid(t *testing.T) {
+	if err := SetClockSkewPolicy("rewind"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+func TestStoreServiceMetrics_ValueTransform(t *testing.T) {
+	rec := useRecordingStorage()
+	SetValueTransform(func(metric string, v float64) float64 {
+		if metric == "goroutines" {
+			return v * 2
+		}
+		return v
+	})
+	defer SetValueTransform(nil)
+
+	stats := models.ServiceStats{CoreStatistics: models.CoreStatistics{Goroutines: 21}}
+	stats.CPUStatistics.TotalCores = 8
+	if err := StoreServiceMetrics(&stats); err != nil {
+		t.Fatal(err)
+	}
+
+	values := make(map[string]float64)
+	for _, r := range rec.rows {
+		values[r.Metric] = r.DataPoint.Value
+	}
+	if values["goroutines"] != 42 {
+		t.Errorf("expected goroutines stored as 42, got %v", values["goroutines"])
+	}
+	if values["total_cores"] != 8 {
+		t.Errorf("expected other metrics unchanged, got total_cores %v", values["total_cores"])
+	}
+}
+
+func TestGenerateHealthStatsRows_Initializing(t *testing.T) {
+	label := Label{Name: "host", Value: "a"}
+	stats := &models.ServiceStats{}
+	stats.Health.ServiceHealth.Initializing = true
+	stats.Health.SystemHealth.Initializing = true
+	if rows := generateHealthStatsRows(stats, label, 1); len(rows) != 0 {
+		t.Errorf("expected no health rows during the warmup, got %v", rows)
+	}
+
+	stats.Health = models.ServiceHealth{}
+	if rows := generateHealthStatsRows(stats, label, 1); len(rows) != 2 {
+		t.Errorf("expected 2 health rows, got %d", len(rows))
+	}
+}
+
+// crashingStorage panics on InsertRows once crash is set, like a process
+// dying before the rows reached storage.
+type crashingStorage struct {
+	*InMemoryStorage
+	crash bool
+}
+
+func (s *crashingStorage) InsertRows(rows []Row) error {
+	if s.crash {
+		panic("crash")
+	}
+	return s.InMemoryStorage.InsertRows(rows)
+}
+
+func TestWAL_ReplaysRowsAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	origDir := dataDir
+	dataDir = func() string { return dir }
+	EnableWAL(true)
+	t.Cleanup(func() {
+		CloseStorage()
+		SetStorageType("memory")
+		EnableWAL(false)
+		dataDir = origDir
+	})
+
+	crashing := &crashingStorage{InMemoryStorage: NewInMemoryStorage()}
+	manager = &storageManager{}
+	manager.once.Do(func() {
+		manager.storage = crashing
+		manager.ctx, manager.cancel = context.WithCancel(context.Background())
+	})
+
+	now := time.Now().Unix()
+	label := GetHostLabel()
+	stored := []Row{{Metric: "goroutines", Labels: []Label{label}, DataPoint: DataPoint{Timestamp: now - 2, Value: 5}}}
+	if err := insertRows(crashing, stored); err != nil {
+		t.Fatalf("insertRows error: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, walFileName)); err != nil || info.Size() != 0 {
+		t.Fatalf("expected an empty WAL once the rows were stored, got %v, %v", info, err)
+	}
+
+	crashing.crash = true
+	lost := []Row{
+		{Metric: "goroutines", Labels: []Label{label}, DataPoint: DataPoint{Timestamp: now - 1, Value: 7}},
+		{Metric: "heap_alloc", Labels: []Label{label}, DataPoint: DataPoint{Timestamp: now - 1, Value: 1024}},
+	}
+	func() {
+		defer func() { recover() }()
+		insertRows(crashing, lost)
+		t.Fatal("expected the insert to crash")
+	}()
+
+	// Restart without closing the crashed storage.
+	SetStorageType("disk")
+	manager = &storageManager{}
+	sto, err := GetStorageInstance()
+	if err != nil {
+		t.Fatalf("GetStorageInstance error: %v", err)
+	}
+
+	points, err := sto.Select("goroutines", []Label{label}, now-10, now+10)
+	if err != nil {
+		t.Fatalf("Select error: %v", err)
+	}
+	if len(points) != 1 || points[0].Value != 7 {
+		t.Errorf("expected only the lost goroutines point to be replayed, got %v", points)
+	}
+	if points, err := sto.Select("heap_alloc", []Label{label}, now-10, now+10); err != nil || len(points) != 1 || points[0].Value != 1024 {
+		t.Errorf("expected the lost heap_alloc point to be replayed, got %v, %v", points, err)
+	}
+	if rows, err := readWAL(filepath.Join(dir, walFileName)); err != nil || len(rows) != 0 {
+		t.Errorf("expected the WAL to be cleared after the replay, got %v, %v", rows, err)
+	}
+}
+
+func TestReadWAL_SkipsIncompleteBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), walFileName)
+	data := `[{"metric":"goroutines","labels":null,"data_point":{"timestamp":1,"value":3}}]` + "\n" + `[{"metric":"heap_al`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := readWAL(path)
+	if err != nil {
+		t.Fatalf("readWAL error: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Metric != "goroutines" || rows[0].DataPoint.Value != 3 {
+		t.Errorf("expected only the complete batch, got %v", rows)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	for _, storage := range []string{"memory", "disk"} {
+		t.Run(storage, func(t *testing.T) {
+			if storage == "disk" {
+				useDiskStorage(t)
+			} else {
+				useRecordingStorage()
+			}
+			sto, err := GetStorageInstance()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			now := time.Now().Unix()
+			host := GetHostLabel()
+			other := Label{Name: "host", Value: "other"}
+			// Older than the first window queried on disk, and written first as
+			// tstorage drops points older than its current partition.
+			stale := []Row{{Metric: "goroutines", Labels: []Label{host}, DataPoint: DataPoint{Timestamp: now - 3*3600, Value: 4}}}
+			if err := sto.InsertRows(stale); err != nil {
+				t.Fatalf("InsertRows error: %v", err)
+			}
+
+			for i, v := range []float64{10, 20, 30} {
+				rows := []Row{
+					{Metric: "service_cpu_load", Labels: []Label{host}, DataPoint: DataPoint{Timestamp: now - 30 + int64(10*i), Value: v}},
+					{Metric: "service_cpu_load", Labels: []Label{other}, DataPoint: DataPoint{Timestamp: now - 30 + int64(10*i), Value: v + 1}},
+				}
+				if err := sto.InsertRows(rows); err != nil {
+					t.Fatalf("InsertRows error: %v", err)
+				}
+			}
+
+			latest, ok, err := Latest("service_cpu_load", []Label{host})
+			if err != nil || !ok {
+				t.Fatalf("expected a latest point, got %v, %v", ok, err)
+			}
+			if latest.Value != 30 || latest.Timestamp != now-10 {
+				t.Errorf("expected 30 at %d, got %+v", now-10, latest)
+			}
+			if latest, _, _ := Latest("service_cpu_load", []Label{other}); latest.Value != 31 {
+				t.Errorf("expected 31 for the other host, got %+v", latest)
+			}
+			if latest, ok, err := Latest("goroutines", []Label{host}); err != nil || !ok || latest.Value != 4 {
+				t.Errorf("expected the older goroutines point, got %+v, %v, %v", latest, ok, err)
+			}
+			if _, ok, err := Latest("never_stored", nil); ok || err != nil {
+				t.Errorf("expected no point for an unknown metric, got %v, %v", ok, err)
+			}
+		})
+	}
+}
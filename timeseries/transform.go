@@ -0,0 +1,34 @@
+package timeseries
+
+import "sync"
+
+// ValueTransform maps the value of a metric before it is stored, e.g. to cap
+// it or to convert units.
+type ValueTransform func(metric string, v float64) float64
+
+var (
+	valueTransformMu sync.RWMutex
+	valueTransform   ValueTransform
+)
+
+// SetValueTransform sets the transform StoreServiceMetrics applies to every
+// stored value, including per-second rates. Passing nil restores the
+// default, which stores values unchanged.
+func SetValueTransform(fn ValueTransform) {
+	valueTransformMu.Lock()
+	valueTransform = fn
+	valueTransformMu.Unlock()
+}
+
+// transformValues applies the configured transform to rows in place.
+func transformValues(rows []Row) {
+	valueTransformMu.RLock()
+	fn := valueTransform
+	valueTransformMu.RUnlock()
+	if fn == nil {
+		return
+	}
+	for i := range rows {
+		rows[i].DataPoint.Value = fn(rows[i].Metric, rows[i].DataPoint.Value)
+	}
+}
@@ -1,25 +1,19 @@
 package timeseries
 
-import "github.com/nakabonne/tstorage"
+import (
+	"github.com/iyashjayesh/monigo/models"
+	"github.com/nakabonne/tstorage"
+)
 
 // Label represents a metric label (key-value pair).
-type Label struct {
-	Name  string
-	Value string
-}
+type Label = models.Label
 
 // DataPoint represents a single time-series data point.
-type DataPoint struct {
-	Timestamp int64
-	Value     float64
-}
+type DataPoint = models.DataPoint
 
-// Row represents a single metric row to be inserted into storage.
-type Row struct {
-	Metric    string
-	Labels    []Label
-	DataPoint DataPoint
-}
+// Row represents a single metric row to be inserted into storage. It is an
+// alias so core collectors can build rows without importing timeseries.
+type Row = models.Row
 
 // toTStorageLabels converts monigo Labels to tstorage Labels.
 func toTStorageLabels(labels []Label) []tstorage.Label {
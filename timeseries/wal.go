@@ -0,0 +1,142 @@
+package timeseries
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/iyashjayesh/monigo/internal/logger"
+)
+
+// walFileName is the write-ahead log in DataDir, one JSON-encoded batch of
+// rows per line. tstorage ignores files in its data directory.
+const walFileName = "monigo.wal"
+
+// walEnabled makes inserts go through the write-ahead log.
+var walEnabled atomic.Bool
+
+// walMu serializes the log's append, insert and truncate steps.
+var walMu sync.Mutex
+
+// EnableWAL sets whether rows are appended to a write-ahead log in DataDir
+// before being inserted into storage. A batch is removed from the log once
+// it was inserted, so the log only keeps rows a crash (or a failed insert)
+// kept from storage; they are inserted when the storage is next opened. It
+// must be enabled before the storage is first used to replay the log.
+func EnableWAL(enabled bool) {
+	walEnabled.Store(enabled)
+}
+
+// walPath returns the path of the write-ahead log.
+func walPath() string {
+	return filepath.Join(dataDir(), walFileName)
+}
+
+// insertRows inserts rows into sto, through the write-ahead log if enabled.
+// Rows are still inserted when they can't be logged.
+func insertRows(sto Storage, rows []Row) error {
+	if !walEnabled.Load() {
+		return sto.InsertRows(rows)
+	}
+
+	walMu.Lock()
+	defer walMu.Unlock()
+
+	offset, err := appendWAL(rows)
+	if err != nil {
+		logger.Log.Warn("writing storage WAL", "error", err)
+		return sto.InsertRows(rows)
+	}
+	if err := sto.InsertRows(rows); err != nil {
+		return err
+	}
+	if err := os.Truncate(walPath(), offset); err != nil {
+		logger.Log.Warn("truncating storage WAL", "error", err)
+	}
+	return nil
+}
+
+// appendWAL appends rows to the log as one line and syncs it, returning the
+// log's size before the append.
+func appendWAL(rows []Row) (int64, error) {
+	line, err := json.Marshal(rows)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(dataDir(), os.ModePerm); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(walPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return 0, err
+	}
+	return info.Size(), f.Sync()
+}
+
+// replayWAL inserts the rows left in the log into sto and clears it. A torn
+// last line, from a crash during the append, is skipped.
+func replayWAL(sto Storage) error {
+	walMu.Lock()
+	defer walMu.Unlock()
+
+	rows, err := readWAL(walPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(rows) > 0 {
+		if err := sto.InsertRows(rows); err != nil {
+			return fmt.Errorf("replaying storage WAL: %w", err)
+		}
+		logger.Log.Info("replayed storage WAL", "rows", len(rows))
+	}
+	return os.Truncate(walPath(), 0)
+}
+
+// readWAL returns the rows of every complete batch in the log at path.
+func readWAL(path string) ([]Row, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rows []Row
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				logger.Log.Warn("skipping incomplete storage WAL batch", "bytes", len(line))
+			}
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var batch []Row
+		if err := json.Unmarshal(line, &batch); err != nil {
+			logger.Log.Warn("skipping corrupt storage WAL batch", "error", err)
+			continue
+		}
+		rows = append(rows, batch...)
+	}
+}